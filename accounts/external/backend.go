@@ -0,0 +1,214 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package external implements an account backend forwarding signing requests
+// to a standalone external signer over JSON-RPC.
+package external
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	ethereum "github.com/TeamEGEM/go-egem"
+	"github.com/TeamEGEM/go-egem/accounts"
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/event"
+	"github.com/TeamEGEM/go-egem/log"
+	"github.com/TeamEGEM/go-egem/rpc"
+	"github.com/TeamEGEM/go-egem/signer/core"
+)
+
+// ExternalBackend is an accounts.Backend exposing a single external signer as
+// its only wallet.
+type ExternalBackend struct {
+	signers []accounts.Wallet
+}
+
+// NewExternalBackend creates an account backend for the external signer
+// reachable at endpoint, which may be an IPC path or an HTTP URL.
+func NewExternalBackend(endpoint string) (*ExternalBackend, error) {
+	signer, err := NewExternalSigner(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &ExternalBackend{
+		signers: []accounts.Wallet{signer},
+	}, nil
+}
+
+// Wallets implements accounts.Backend, returning the external signer.
+func (eb *ExternalBackend) Wallets() []accounts.Wallet {
+	return eb.signers
+}
+
+// Subscribe implements accounts.Backend. The external signer never comes or
+// goes, so no events are ever fired.
+func (eb *ExternalBackend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// ExternalSigner is an accounts.Wallet backed by an external signer. Account
+// listing and transaction signing are forwarded to the signer, which asks its
+// user or rules for approval. Passwords never leave the signer, so none of the
+// passphrase based operations are supported, nor is signing raw hashes.
+type ExternalSigner struct {
+	client   *rpc.Client
+	endpoint string
+
+	cache   []accounts.Account // Accounts revealed by the signer, nil until listed
+	cacheMu sync.RWMutex
+}
+
+// NewExternalSigner connects to the external signer at endpoint.
+func NewExternalSigner(endpoint string) (*ExternalSigner, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &ExternalSigner{
+		client:   client,
+		endpoint: endpoint,
+	}, nil
+}
+
+// URL implements accounts.Wallet, returning the endpoint of the signer.
+func (api *ExternalSigner) URL() accounts.URL {
+	return accounts.URL{
+		Scheme: "extapi",
+		Path:   api.endpoint,
+	}
+}
+
+// Status implements accounts.Wallet, always reporting success as the signer is
+// only contacted on demand.
+func (api *ExternalSigner) Status() (string, error) {
+	return "ok", nil
+}
+
+// Open implements accounts.Wallet, but is a noop for external signers.
+func (api *ExternalSigner) Open(passphrase string) error {
+	return accounts.ErrNotSupported
+}
+
+// Close implements accounts.Wallet, but is a noop for external signers.
+func (api *ExternalSigner) Close() error {
+	return accounts.ErrNotSupported
+}
+
+// Accounts implements accounts.Wallet, returning the accounts the signer's user
+// allowed to be revealed. The listing is only requested once, as it needs
+// approval on the signer side.
+func (api *ExternalSigner) Accounts() []accounts.Account {
+	api.cacheMu.RLock()
+	cache := api.cache
+	api.cacheMu.RUnlock()
+	if cache != nil {
+		return cache
+	}
+	var addrs []common.Address
+	if err := api.client.Call(&addrs, "account_list"); err != nil {
+		log.Warn("Failed to list external signer accounts", "endpoint", api.endpoint, "err", err)
+		return nil
+	}
+	accs := make([]accounts.Account, 0, len(addrs))
+	for _, addr := range addrs {
+		accs = append(accs, accounts.Account{Address: addr, URL: api.URL()})
+	}
+	api.cacheMu.Lock()
+	api.cache = accs
+	api.cacheMu.Unlock()
+	return accs
+}
+
+// Contains implements accounts.Wallet, returning whether an account was revealed
+// by the signer.
+func (api *ExternalSigner) Contains(account accounts.Account) bool {
+	for _, acc := range api.Accounts() {
+		if acc.Address == account.Address && (account.URL == (accounts.URL{}) || account.URL == api.URL()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Derive implements accounts.Wallet, but is not supported by external signers.
+func (api *ExternalSigner) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// SelfDerive implements accounts.Wallet, but is a noop for external signers.
+func (api *ExternalSigner) SelfDerive(base accounts.DerivationPath, chain ethereum.ChainStateReader) {
+	log.Error("Operation SelfDerive not supported on external signers")
+}
+
+// SignHash implements accounts.Wallet, but is not supported by external signers
+// as the signer needs to see the data it signs.
+func (api *ExternalSigner) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// signTransactionResult is the response of the signer to a transaction signing
+// request.
+type signTransactionResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+// SignTx implements accounts.Wallet, requesting the signer to sign the given
+// transaction with the requested account.
+func (api *ExternalSigner) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := core.SendTxArgs{
+		From:     account.Address,
+		To:       tx.To(),
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: hexutil.Big(*tx.GasPrice()),
+		Value:    hexutil.Big(*tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+	}
+	data := hexutil.Bytes(tx.Data())
+	args.Data = &data
+
+	var res signTransactionResult
+	if err := api.client.Call(&res, "account_signTransaction", args); err != nil {
+		return nil, err
+	}
+	if res.Tx == nil {
+		return nil, fmt.Errorf("external signer returned no transaction")
+	}
+	// Make sure the signer didn't sign for a different chain
+	if chainID != nil && res.Tx.Protected() && res.Tx.ChainId().Cmp(chainID) != 0 {
+		return nil, fmt.Errorf("external signer chain id mismatch: have %v, want %v", res.Tx.ChainId(), chainID)
+	}
+	return res.Tx, nil
+}
+
+// SignHashWithPassphrase implements accounts.Wallet, but is not supported by
+// external signers as passwords are handled by the signer itself.
+func (api *ExternalSigner) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, but is not supported by
+// external signers as passwords are handled by the signer itself.
+func (api *ExternalSigner) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return nil, accounts.ErrNotSupported
+}
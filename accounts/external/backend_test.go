@@ -0,0 +1,118 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package external
+
+import (
+	"io/ioutil"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TeamEGEM/go-egem/accounts"
+	"github.com/TeamEGEM/go-egem/accounts/keystore"
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/internal/ethapi"
+	"github.com/TeamEGEM/go-egem/rpc"
+	"github.com/TeamEGEM/go-egem/signer/core"
+)
+
+// approvingUI is a signer UI approving every request with a fixed password.
+type approvingUI struct{}
+
+func (approvingUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	return core.SignTxResponse{Approved: true, Password: "pass"}, nil
+}
+
+func (approvingUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
+	return core.SignDataResponse{Approved: true, Password: "pass"}, nil
+}
+
+func (approvingUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
+	return core.ListResponse{Accounts: request.Accounts}, nil
+}
+
+func (approvingUI) ShowError(message string)                     {}
+func (approvingUI) ShowInfo(message string)                      {}
+func (approvingUI) OnApprovedTx(tx ethapi.SignTransactionResult) {}
+func (approvingUI) OnSignerStartup(info core.StartupInfo)        {}
+
+// Tests that accounts are listed and transactions signed through a signer
+// reachable over HTTP.
+func TestExternalSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks := keystore.NewKeyStore(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	am := core.NewAccountManager(filepath.Join(dir, "keystore"), true, true)
+	defer am.Close()
+
+	// Start a signer and connect a backend to it
+	handler := rpc.NewServer()
+	if err := handler.RegisterName("account", core.NewSignerAPI(1987, am, approvingUI{})); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	backend, err := NewExternalBackend(server.URL)
+	if err != nil {
+		t.Fatalf("failed to connect to signer: %v", err)
+	}
+	wallet := backend.Wallets()[0]
+	if accs := wallet.Accounts(); len(accs) != 1 || accs[0].Address != account.Address {
+		t.Fatalf("account list mismatch: have %v, want [%x]", accs, account.Address)
+	}
+	if !wallet.Contains(accounts.Account{Address: account.Address}) {
+		t.Errorf("wallet doesn't contain signer account")
+	}
+	// Sign a transaction and check that it was signed by the right key and chain
+	to := common.HexToAddress("0x0000000000000000000000000000000000000042")
+	tx := types.NewTransaction(3, to, big.NewInt(1000), 21000, big.NewInt(1), []byte{0x01})
+
+	signed, err := wallet.SignTx(accounts.Account{Address: account.Address}, tx, big.NewInt(1987))
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	from, err := types.Sender(types.NewEIP155Signer(big.NewInt(1987)), signed)
+	if err != nil {
+		t.Fatalf("failed to recover sender: %v", err)
+	}
+	if from != account.Address {
+		t.Errorf("sender mismatch: have %x, want %x", from, account.Address)
+	}
+	if signed.Nonce() != tx.Nonce() || signed.Value().Cmp(tx.Value()) != 0 || string(signed.Data()) != string(tx.Data()) {
+		t.Errorf("signed transaction differs from request")
+	}
+	// Signing for a different chain must be refused
+	if _, err := wallet.SignTx(accounts.Account{Address: account.Address}, tx, big.NewInt(1)); err == nil {
+		t.Errorf("chain id mismatch not detected")
+	}
+	// Passwords never leave the signer
+	if _, err := wallet.SignTxWithPassphrase(accounts.Account{Address: account.Address}, "pass", tx, big.NewInt(1987)); err != accounts.ErrNotSupported {
+		t.Errorf("passphrase signing error mismatch: have %v, want %v", err, accounts.ErrNotSupported)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
@@ -30,7 +31,6 @@ import (
 	"github.com/TeamEGEM/go-egem/accounts"
 	"github.com/TeamEGEM/go-egem/common"
 	"github.com/TeamEGEM/go-egem/log"
-	"gopkg.in/fatih/set.v0"
 )
 
 // Minimum amount of time between cache reloads. This limit applies if the platform does
@@ -38,6 +38,11 @@ import (
 // exist yet, the code will attempt to create a watcher at most this often.
 const minReloadInterval = 2 * time.Second
 
+// maxInPlaceUpdates is the number of account changes up to which the cache is
+// updated in place. Larger batches (e.g. the initial load of a big keystore)
+// rebuild the sorted account list at once instead.
+const maxInPlaceUpdates = 64
+
 type accountsByURL []accounts.Account
 
 func (s accountsByURL) Len() int           { return len(s) }
@@ -62,25 +67,30 @@ func (err *AmbiguousAddrError) Error() string {
 	return fmt.Sprintf("multiple keys match address (%s)", files)
 }
 
-// accountCache is a live index of all accounts in the keystore.
+// accountCache is a live index of all accounts in the keystore, along with the
+// files of the HD wallets.
 type accountCache struct {
-	keydir   string
-	watcher  *watcher
-	mu       sync.Mutex
-	all      accountsByURL
-	byAddr   map[common.Address][]accounts.Account
-	throttle *time.Timer
-	notify   chan struct{}
-	fileC    fileCache
+	keydir    string
+	hdDir     string // Folder of the HD wallets within the keystore
+	watcher   *watcher
+	mu        sync.Mutex
+	all       accountsByURL
+	byAddr    map[common.Address][]accounts.Account
+	hdWallets []string // Files of the HD wallets, sorted
+	throttle  *time.Timer
+	notify    chan struct{}
+	fileC     fileCache
 }
 
 func newAccountCache(keydir string) (*accountCache, chan struct{}) {
 	ac := &accountCache{
 		keydir: keydir,
+		hdDir:  filepath.Join(keydir, hdDirName),
 		byAddr: make(map[common.Address][]accounts.Account),
 		notify: make(chan struct{}, 1),
-		fileC:  fileCache{all: set.NewNonTS()},
+		fileC:  newFileCache(),
 	}
+	ac.fileC.loadIndex(keydir)
 	ac.watcher = newWatcher(ac)
 	return ac, ac.notify
 }
@@ -94,6 +104,31 @@ func (ac *accountCache) accounts() []accounts.Account {
 	return cpy
 }
 
+// hdWalletFiles returns the files of the HD wallets in the keystore, sorted.
+func (ac *accountCache) hdWalletFiles() []string {
+	ac.maybeReload()
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	cpy := make([]string, len(ac.hdWallets))
+	copy(cpy, ac.hdWallets)
+	return cpy
+}
+
+// addHDWallet inserts the file of a new HD wallet, without waiting for the file
+// system notifications to pick it up.
+func (ac *accountCache) addHDWallet(path string) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	i := sort.SearchStrings(ac.hdWallets, path)
+	if i < len(ac.hdWallets) && ac.hdWallets[i] == path {
+		return
+	}
+	ac.hdWallets = append(ac.hdWallets, "")
+	copy(ac.hdWallets[i+1:], ac.hdWallets[i:])
+	ac.hdWallets[i] = path
+}
+
 func (ac *accountCache) hasAddress(addr common.Address) bool {
 	ac.maybeReload()
 	ac.mu.Lock()
@@ -146,6 +181,43 @@ func (ac *accountCache) deleteByFile(path string) {
 	}
 }
 
+// update applies a batch of account changes, removing the accounts of the files
+// in removed before adding the accounts in added.
+func (ac *accountCache) update(added []accounts.Account, removed map[string]struct{}) {
+	if len(added)+len(removed) <= maxInPlaceUpdates {
+		for path := range removed {
+			ac.deleteByFile(path)
+		}
+		for _, account := range added {
+			ac.add(account)
+		}
+		return
+	}
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	all := make(accountsByURL, 0, len(ac.all)+len(added))
+	for _, account := range ac.all {
+		if _, ok := removed[account.URL.Path]; !ok {
+			all = append(all, account)
+		}
+	}
+	all = append(all, added...)
+	sort.Sort(all)
+
+	// Drop any duplicates (accounts may be added before their file is scanned)
+	// and regenerate the address lookup
+	ac.all = all[:0]
+	ac.byAddr = make(map[common.Address][]accounts.Account, len(all))
+	for i, account := range all {
+		if i > 0 && account == all[i-1] {
+			continue
+		}
+		ac.all = append(ac.all, account)
+		ac.byAddr[account.Address] = append(ac.byAddr[account.Address], account)
+	}
+}
+
 func removeAccount(slice []accounts.Account, elem accounts.Account) []accounts.Account {
 	for i := range slice {
 		if slice[i] == elem {
@@ -226,6 +298,10 @@ func (ac *accountCache) close() {
 		ac.notify = nil
 	}
 	ac.mu.Unlock()
+
+	if err := ac.fileC.saveIndex(ac.keydir); err != nil {
+		log.Debug("Failed to persist keystore index", "err", err)
+	}
 }
 
 // scanAccounts checks if any changes have occurred on the filesystem, and
@@ -237,8 +313,62 @@ func (ac *accountCache) scanAccounts() error {
 		log.Debug("Failed to reload keystore contents", "err", err)
 		return err
 	}
-	if creates.Size() == 0 && deletes.Size() == 0 && updates.Size() == 0 {
-		return nil
+	ac.applyChanges(creates, deletes, updates)
+	ac.scanHDWallets()
+
+	// All files were seen, the loaded index is not needed any more. Persist the
+	// current one instead for the next startup.
+	ac.fileC.dropIndex()
+	if err := ac.fileC.saveIndex(ac.keydir); err != nil {
+		log.Debug("Failed to persist keystore index", "err", err)
+	}
+	return nil
+}
+
+// scanHDWallets lists the HD wallet folder, updating the cached wallet files if
+// they changed. The folder only holds a handful of wallets, so it's always
+// listed as a whole.
+func (ac *accountCache) scanHDWallets() {
+	files, err := ioutil.ReadDir(ac.hdDir)
+	if err != nil && !os.IsNotExist(err) {
+		log.Debug("Failed to list HD wallets", "err", err)
+		return
+	}
+	paths := make([]string, 0, len(files))
+	for _, fi := range files {
+		if !skipKeyFile(fi) {
+			paths = append(paths, filepath.Join(ac.hdDir, fi.Name()))
+		}
+	}
+	sort.Strings(paths)
+
+	ac.mu.Lock()
+	changed := len(paths) != len(ac.hdWallets)
+	for i := 0; !changed && i < len(paths); i++ {
+		changed = paths[i] != ac.hdWallets[i]
+	}
+	ac.hdWallets = paths
+	ac.mu.Unlock()
+
+	if changed {
+		select {
+		case ac.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// scanFiles checks if any of the given files changed on the filesystem, and
+// updates the account cache accordingly.
+func (ac *accountCache) scanFiles(paths []string) {
+	creates, deletes, updates := ac.fileC.scanFiles(paths)
+	ac.applyChanges(creates, deletes, updates)
+}
+
+// applyChanges updates the account cache with the contents of the changed files.
+func (ac *accountCache) applyChanges(creates, deletes, updates []string) {
+	if len(creates) == 0 && len(deletes) == 0 && len(updates) == 0 {
+		return
 	}
 	// Create a helper method to scan the contents of the key files
 	var (
@@ -269,30 +399,41 @@ func (ac *accountCache) scanAccounts() error {
 		}
 		return nil
 	}
-	// Process all the file diffs
+	// Process all the file diffs, reading only the files not in the index
 	start := time.Now()
 
-	for _, p := range creates.List() {
-		if a := readAccount(p.(string)); a != nil {
-			ac.add(*a)
+	var (
+		added   []accounts.Account
+		removed = make(map[string]struct{})
+	)
+	load := func(path string) {
+		if addr, ok := ac.fileC.lookup(path); ok {
+			if (addr != common.Address{}) {
+				added = append(added, accounts.Account{Address: addr, URL: accounts.URL{Scheme: KeyStoreScheme, Path: path}})
+			}
+			return
 		}
-	}
-	for _, p := range deletes.List() {
-		ac.deleteByFile(p.(string))
-	}
-	for _, p := range updates.List() {
-		path := p.(string)
-		ac.deleteByFile(path)
 		if a := readAccount(path); a != nil {
-			ac.add(*a)
+			ac.fileC.setAddress(path, a.Address)
+			added = append(added, *a)
 		}
 	}
+	for _, path := range creates {
+		load(path)
+	}
+	for _, path := range deletes {
+		removed[path] = struct{}{}
+	}
+	for _, path := range updates {
+		removed[path] = struct{}{}
+		load(path)
+	}
+	ac.update(added, removed)
 	end := time.Now()
 
 	select {
 	case ac.notify <- struct{}{}:
 	default:
 	}
-	log.Trace("Handled keystore changes", "time", end.Sub(start))
-	return nil
+	log.Trace("Handled keystore changes", "added", len(added), "removed", len(removed), "time", end.Sub(start))
 }
@@ -19,6 +19,7 @@ package keystore
 import (
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -404,3 +405,216 @@ func forceCopyFile(dst, src string) error {
 	}
 	return ioutil.WriteFile(dst, data, 0644)
 }
+
+// TestCacheScanFiles tests that the cache can be updated from a set of changed
+// files, without rescanning the entire folder.
+func TestCacheScanFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eth-keystore-scan-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"aaa", "zzz"} {
+		if err := cp.CopyFile(filepath.Join(dir, name), filepath.Join(cachetestDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cache, notify := newAccountCache(dir)
+	cache.watcher.running = true // prevent unexpected reloads
+	cache.scanAccounts()
+	<-notify
+
+	// Delete aaa, replace zzz with the contents of aaa and add bbb and a hidden file
+	aaa, bbb, zzz := filepath.Join(dir, "aaa"), filepath.Join(dir, "bbb"), filepath.Join(dir, "zzz")
+	if err := os.Remove(aaa); err != nil {
+		t.Fatal(err)
+	}
+	if err := forceCopyFile(zzz, cachetestAccounts[1].URL.Path); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(zzz, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.CopyFile(bbb, cachetestAccounts[0].URL.Path); err != nil {
+		t.Fatal(err)
+	}
+	hidden := filepath.Join(dir, ".hidden")
+	if err := cp.CopyFile(hidden, cachetestAccounts[2].URL.Path); err != nil {
+		t.Fatal(err)
+	}
+	cache.scanFiles([]string{aaa, bbb, zzz, hidden})
+
+	want := []accounts.Account{
+		{Address: cachetestAccounts[0].Address, URL: accounts.URL{Scheme: KeyStoreScheme, Path: bbb}},
+		{Address: cachetestAccounts[1].Address, URL: accounts.URL{Scheme: KeyStoreScheme, Path: zzz}},
+	}
+	if list := cache.accounts(); !reflect.DeepEqual(list, want) {
+		t.Fatalf("accounts mismatch:\ngot  %v\nwant %v", list, want)
+	}
+	select {
+	case <-notify:
+	default:
+		t.Fatalf("wasn't notified of changed accounts")
+	}
+	// Rescanning unchanged files should not report any changes
+	cache.scanFiles([]string{bbb, zzz})
+	select {
+	case <-notify:
+		t.Fatalf("notified of unchanged accounts")
+	default:
+	}
+}
+
+// TestCacheIndex tests that the file index is persisted and used on the next
+// startup in place of parsing unchanged files.
+func TestCacheIndex(t *testing.T) {
+	defer func(old int) { indexMinFiles = old }(indexMinFiles)
+	indexMinFiles = 1
+
+	dir, err := ioutil.TempDir("", "eth-keystore-index-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"aaa", "zzz", "garbage"} {
+		if err := cp.CopyFile(filepath.Join(dir, name), filepath.Join(cachetestDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cache, _ := newAccountCache(dir)
+	cache.watcher.running = true // prevent unexpected reloads
+	cache.scanAccounts()
+
+	if _, err := os.Stat(filepath.Join(dir, indexFileName)); err != nil {
+		t.Fatalf("index not persisted: %v", err)
+	}
+	// Swap the contents of aaa and zzz, keeping the metadata of aaa so that only
+	// zzz is noticed as changed
+	aaa, zzz := filepath.Join(dir, "aaa"), filepath.Join(dir, "zzz")
+	info, err := os.Stat(aaa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := forceCopyFile(aaa, cachetestAccounts[2].URL.Path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(aaa, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if err := forceCopyFile(zzz, cachetestAccounts[1].URL.Path); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(zzz, future, future); err != nil {
+		t.Fatal(err)
+	}
+	cache, _ = newAccountCache(dir)
+	cache.watcher.running = true // prevent unexpected reloads
+	cache.scanAccounts()
+
+	want := []accounts.Account{
+		{Address: cachetestAccounts[1].Address, URL: accounts.URL{Scheme: KeyStoreScheme, Path: aaa}},
+		{Address: cachetestAccounts[1].Address, URL: accounts.URL{Scheme: KeyStoreScheme, Path: zzz}},
+	}
+	if list := cache.accounts(); !reflect.DeepEqual(list, want) {
+		t.Fatalf("accounts mismatch:\ngot  %v\nwant %v", list, want)
+	}
+}
+
+// createBenchKeystore creates a keystore folder with the given number of key
+// files. Only the addresses are filled in, which is all the cache reads.
+func createBenchKeystore(b *testing.B, count int) string {
+	dir, err := ioutil.TempDir("", "eth-keystore-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < count; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		blob := fmt.Sprintf(`{"address":"%x","crypto":{},"id":"","version":3}`, addr)
+		if err := ioutil.WriteFile(filepath.Join(dir, keyFileName(addr)), []byte(blob), 0600); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// Benchmarks loading a keystore of 100k accounts without a persisted index.
+func BenchmarkCacheLoad100K(b *testing.B) {
+	dir := createBenchKeystore(b, 100000)
+	defer func() {
+		b.StopTimer()
+		os.RemoveAll(dir)
+	}()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		os.Remove(filepath.Join(dir, indexFileName))
+		b.StartTimer()
+
+		cache, _ := newAccountCache(dir)
+		cache.scanAccounts()
+	}
+}
+
+// Benchmarks loading a keystore of 100k accounts with a persisted index.
+func BenchmarkCacheLoadIndexed100K(b *testing.B) {
+	dir := createBenchKeystore(b, 100000)
+	defer func() {
+		b.StopTimer()
+		os.RemoveAll(dir)
+	}()
+
+	cache, _ := newAccountCache(dir)
+	cache.scanAccounts()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache, _ := newAccountCache(dir)
+		cache.scanAccounts()
+	}
+}
+
+// Benchmarks reacting to a new key file in a keystore of 100k accounts by only
+// scanning the changed file.
+func BenchmarkCacheScanFiles100K(b *testing.B) {
+	benchmarkCacheChange(b, func(cache *accountCache, path string) {
+		cache.scanFiles([]string{path})
+	})
+}
+
+// Benchmarks reacting to a new key file in a keystore of 100k accounts by
+// rescanning the entire folder.
+func BenchmarkCacheRescan100K(b *testing.B) {
+	benchmarkCacheChange(b, func(cache *accountCache, path string) {
+		cache.scanAccounts()
+	})
+}
+
+func benchmarkCacheChange(b *testing.B, update func(cache *accountCache, path string)) {
+	dir := createBenchKeystore(b, 100000)
+	defer func() {
+		b.StopTimer()
+		os.RemoveAll(dir)
+	}()
+
+	cache, _ := newAccountCache(dir)
+	cache.scanAccounts()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		addr := common.BigToAddress(big.NewInt(int64(1000000 + i)))
+		path := filepath.Join(dir, keyFileName(addr))
+		blob := fmt.Sprintf(`{"address":"%x","crypto":{},"id":"","version":3}`, addr)
+		if err := ioutil.WriteFile(path, []byte(blob), 0600); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+
+		update(cache, path)
+	}
+}
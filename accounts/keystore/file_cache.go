@@ -17,6 +17,7 @@
 package keystore
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -24,20 +25,64 @@ import (
 	"sync"
 	"time"
 
+	"github.com/TeamEGEM/go-egem/common"
 	"github.com/TeamEGEM/go-egem/log"
-	set "gopkg.in/fatih/set.v0"
 )
 
+const (
+	// indexFileName is the name of the persisted file index within the keystore
+	// folder. Being a hidden file, it is never mistaken for a key.
+	indexFileName = ".accounts.index"
+
+	// indexVersion is the version of the persisted index format. Indexes of any
+	// other version are discarded.
+	indexVersion = 1
+)
+
+// indexMinFiles is the number of files the keystore folder needs to contain for
+// its index to be persisted. Smaller folders are parsed quickly enough anyway.
+var indexMinFiles = 1000
+
+// fileEntry is the metadata of a file in the keystore folder, along with the
+// address of the key it contains.
+type fileEntry struct {
+	Size    int64          `json:"size"`
+	ModTime int64          `json:"mtime"`   // Modification time in nanoseconds since the epoch
+	Address common.Address `json:"address"` // Zero if the file doesn't contain a valid key
+}
+
+// newFileEntry creates the metadata of a file, with its address yet unknown.
+func newFileEntry(fi os.FileInfo) fileEntry {
+	return fileEntry{Size: fi.Size(), ModTime: fi.ModTime().UnixNano()}
+}
+
+// modified returns whether the file described by e differs from the one in old.
+func (e fileEntry) modified(old fileEntry) bool {
+	return e.Size != old.Size || e.ModTime != old.ModTime
+}
+
+// fileIndex is the persisted form of the file cache, keyed by file name.
+type fileIndex struct {
+	Version int                  `json:"version"`
+	Files   map[string]fileEntry `json:"files"`
+}
+
 // fileCache is a cache of files seen during scan of keystore.
 type fileCache struct {
-	all     *set.SetNonTS // Set of all files from the keystore folder
-	lastMod time.Time     // Last time instance when a file was modified
+	all     map[string]fileEntry // Metadata of all files from the keystore folder
+	indexed map[string]fileEntry // Metadata loaded from the persisted index, until the first full scan
+	dirty   bool                 // Whether the files changed since the index was last persisted
 	mu      sync.RWMutex
 }
 
+// newFileCache creates an empty file cache.
+func newFileCache() fileCache {
+	return fileCache{all: make(map[string]fileEntry)}
+}
+
 // scan performs a new scan on the given directory, compares against the already
-// cached filenames, and returns file sets: creates, deletes, updates.
-func (fc *fileCache) scan(keyDir string) (set.Interface, set.Interface, set.Interface, error) {
+// cached files, and returns the paths created, deleted and updated since.
+func (fc *fileCache) scan(keyDir string) ([]string, []string, []string, error) {
 	t0 := time.Now()
 
 	// List all the failes from the keystore folder
@@ -50,11 +95,14 @@ func (fc *fileCache) scan(keyDir string) (set.Interface, set.Interface, set.Inte
 	fc.mu.Lock()
 	defer fc.mu.Unlock()
 
-	// Iterate all the files and gather their metadata
-	all := set.NewNonTS()
-	mods := set.NewNonTS()
-
-	var newLastMod time.Time
+	// Iterate all the files and compare their metadata with the cached one
+	var (
+		all     = make(map[string]fileEntry, len(files))
+		creates []string
+		deletes []string
+		updates []string
+		indexed int // Number of created files unchanged since indexed
+	)
 	for _, fi := range files {
 		// Skip any non-key files from the folder
 		path := filepath.Join(keyDir, fi.Name())
@@ -62,32 +110,166 @@ func (fc *fileCache) scan(keyDir string) (set.Interface, set.Interface, set.Inte
 			log.Trace("Ignoring file on account scan", "path", path)
 			continue
 		}
-		// Gather the set of all and fresly modified files
-		all.Add(path)
-
-		modified := fi.ModTime()
-		if modified.After(fc.lastMod) {
-			mods.Add(path)
+		entry := newFileEntry(fi)
+		if old, ok := fc.all[path]; !ok {
+			creates = append(creates, path)
+			if old, ok := fc.indexed[path]; ok && !entry.modified(old) {
+				indexed++
+			}
+		} else if entry.modified(old) {
+			updates = append(updates, path)
+		} else {
+			entry.Address = old.Address
 		}
-		if modified.After(newLastMod) {
-			newLastMod = modified
+		all[path] = entry
+	}
+	for path := range fc.all {
+		if _, ok := all[path]; !ok {
+			deletes = append(deletes, path)
 		}
 	}
-	t2 := time.Now()
+	fc.all = all
 
-	// Update the tracked files and return the three sets
-	deletes := set.Difference(fc.all, all)   // Deletes = previous - current
-	creates := set.Difference(all, fc.all)   // Creates = current - previous
-	updates := set.Difference(mods, creates) // Updates = modified - creates
-
-	fc.all, fc.lastMod = all, newLastMod
-	t3 := time.Now()
+	// The persisted index is stale if anything but the files it contains changed
+	if len(creates) > indexed || len(deletes) > 0 || len(updates) > 0 || indexed < len(fc.indexed) {
+		fc.dirty = true
+	}
+	t2 := time.Now()
 
 	// Report on the scanning stats and return
-	log.Debug("FS scan times", "list", t1.Sub(t0), "set", t2.Sub(t1), "diff", t3.Sub(t2))
+	log.Debug("FS scan times", "list", t1.Sub(t0), "diff", t2.Sub(t1))
 	return creates, deletes, updates, nil
 }
 
+// scanFiles checks only the given paths of the keystore folder against the
+// already cached files, and returns the paths created, deleted and updated
+// since. It is used to react on filesystem events without rescanning the
+// entire folder.
+func (fc *fileCache) scanFiles(paths []string) ([]string, []string, []string) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	var creates, deletes, updates []string
+	for _, path := range paths {
+		old, known := fc.all[path]
+
+		fi, err := os.Lstat(path)
+		if err != nil || skipKeyFile(fi) {
+			if known {
+				delete(fc.all, path)
+				deletes = append(deletes, path)
+			}
+			continue
+		}
+		entry := newFileEntry(fi)
+		switch {
+		case !known:
+			creates = append(creates, path)
+		case entry.modified(old):
+			updates = append(updates, path)
+		default:
+			continue
+		}
+		fc.all[path] = entry
+	}
+	if len(creates) > 0 || len(deletes) > 0 || len(updates) > 0 {
+		fc.dirty = true
+	}
+	return creates, deletes, updates
+}
+
+// lookup returns the address of the key in the file at path, if the persisted
+// index contains it and the file is unchanged since.
+func (fc *fileCache) lookup(path string) (common.Address, bool) {
+	fc.mu.RLock()
+	defer fc.mu.RUnlock()
+
+	indexed, ok := fc.indexed[path]
+	if !ok || indexed.modified(fc.all[path]) {
+		return common.Address{}, false
+	}
+	return indexed.Address, true
+}
+
+// setAddress records the address of the key in the file at path.
+func (fc *fileCache) setAddress(path string, addr common.Address) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if entry, ok := fc.all[path]; ok {
+		entry.Address = addr
+		fc.all[path] = entry
+	}
+}
+
+// loadIndex loads the persisted index of the keystore folder, which allows the
+// accounts of unchanged files to be known without parsing them.
+func (fc *fileCache) loadIndex(keyDir string) {
+	blob, err := ioutil.ReadFile(filepath.Join(keyDir, indexFileName))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Debug("Failed to read keystore index", "err", err)
+		}
+		return
+	}
+	var index fileIndex
+	if err := json.Unmarshal(blob, &index); err != nil {
+		log.Debug("Failed to decode keystore index", "err", err)
+		return
+	}
+	if index.Version != indexVersion {
+		log.Debug("Discarding keystore index", "version", index.Version, "want", indexVersion)
+		return
+	}
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.indexed = make(map[string]fileEntry, len(index.Files))
+	for name, entry := range index.Files {
+		fc.indexed[filepath.Join(keyDir, name)] = entry
+	}
+	log.Debug("Loaded keystore index", "files", len(fc.indexed))
+}
+
+// dropIndex releases the loaded index once all files have been scanned.
+func (fc *fileCache) dropIndex() {
+	fc.mu.Lock()
+	fc.indexed = nil
+	fc.mu.Unlock()
+}
+
+// saveIndex persists the metadata of the keystore folder, if it changed since
+// last saved and the folder is large enough for the index to pay off.
+func (fc *fileCache) saveIndex(keyDir string) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if !fc.dirty || len(fc.all) < indexMinFiles {
+		return nil
+	}
+	index := fileIndex{
+		Version: indexVersion,
+		Files:   make(map[string]fileEntry, len(fc.all)),
+	}
+	for path, entry := range fc.all {
+		index.Files[filepath.Base(path)] = entry
+	}
+	blob, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	// Write into a temporary file first, so a crash never leaves a partial index
+	path := filepath.Join(keyDir, indexFileName)
+	if err := ioutil.WriteFile(path+".tmp", blob, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	fc.dirty = false
+	return nil
+}
+
 // skipKeyFile ignores editor backups, hidden files and folders/symlinks.
 func skipKeyFile(fi os.FileInfo) bool {
 	// Skip editor backups and UNIX-style hidden files.
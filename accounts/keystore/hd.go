@@ -0,0 +1,118 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/TeamEGEM/go-egem/accounts"
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/math"
+	"github.com/TeamEGEM/go-egem/crypto"
+)
+
+// hdMasterKeySalt is the HMAC key used to derive the master key of a BIP-32
+// hierarchical deterministic wallet from its seed.
+var hdMasterKeySalt = []byte("Bitcoin seed")
+
+// errInvalidHDKey is returned in the astronomically unlikely case of a BIP-32
+// derivation step not resulting in a valid secp256k1 private key.
+var errInvalidHDKey = errors.New("invalid derived key")
+
+// hdKey is a BIP-32 extended private key: a secp256k1 private key along with the
+// chain code needed to derive its children.
+type hdKey struct {
+	key   []byte // 32 byte private key
+	chain []byte // 32 byte chain code
+}
+
+// newMasterKey derives the root extended key of a wallet from its seed.
+func newMasterKey(seed []byte) (*hdKey, error) {
+	mac := hmac.New(sha512.New, hdMasterKeySalt)
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	if k := new(big.Int).SetBytes(sum[:32]); k.Sign() == 0 || k.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, errInvalidHDKey
+	}
+	return &hdKey{key: sum[:32], chain: sum[32:]}, nil
+}
+
+// child derives the extended key at the given index below k. Indices from 2^31
+// upwards yield hardened keys, which cannot be derived from the public key.
+func (k *hdKey) child(index uint32) (*hdKey, error) {
+	data := make([]byte, 0, 37)
+	if index >= 0x80000000 {
+		data = append(data, 0)
+		data = append(data, k.key...)
+	} else {
+		priv, err := crypto.ToECDSA(k.key)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, crypto.CompressPubkey(&priv.PublicKey)...)
+	}
+	var ser [4]byte
+	binary.BigEndian.PutUint32(ser[:], index)
+	data = append(data, ser[:]...)
+
+	mac := hmac.New(sha512.New, k.chain)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	// The child key is the parent key tweaked by the left half of the hash
+	n := crypto.S256().Params().N
+
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(n) >= 0 {
+		return nil, errInvalidHDKey
+	}
+	key := tweak.Add(tweak, new(big.Int).SetBytes(k.key))
+	if key.Mod(key, n).Sign() == 0 {
+		return nil, errInvalidHDKey
+	}
+	return &hdKey{key: math.PaddedBigBytes(key, 32), chain: sum[32:]}, nil
+}
+
+// deriveKey derives the private key at the given path from a wallet seed.
+func deriveKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	key, err := newMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range path {
+		if key, err = key.child(index); err != nil {
+			return nil, err
+		}
+	}
+	return crypto.ToECDSA(key.key)
+}
+
+// deriveAddress derives the address of the account at the given path from a
+// wallet seed.
+func deriveAddress(seed []byte, path accounts.DerivationPath) (common.Address, error) {
+	key, err := deriveKey(seed, path)
+	if err != nil {
+		return common.Address{}, err
+	}
+	defer zeroKey(key)
+	return crypto.PubkeyToAddress(key.PublicKey), nil
+}
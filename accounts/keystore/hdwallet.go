@@ -0,0 +1,585 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package keystore
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"time"
+
+	ethereum "github.com/TeamEGEM/go-egem"
+	"github.com/TeamEGEM/go-egem/accounts"
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/crypto"
+	"github.com/TeamEGEM/go-egem/log"
+	"github.com/pborman/uuid"
+)
+
+const (
+	// hdDirName is the folder within the keystore holding the HD wallets. Being a
+	// folder, it is skipped when scanning the keystore for plain keys.
+	hdDirName = "hd"
+
+	// hdVersion is the version of the HD wallet file format.
+	hdVersion = 1
+
+	// hdSelfDeriveThrottling is the minimum time between two account discovery
+	// runs of an open HD wallet.
+	hdSelfDeriveThrottling = time.Second
+)
+
+// hdAccountJSON is an account pinned to an HD wallet. Its address is stored next
+// to its path, so pinned accounts can be listed without decrypting the seed.
+type hdAccountJSON struct {
+	Address string `json:"address"`
+	Path    string `json:"path"`
+}
+
+// hdWalletJSON is the on-disk format of an HD wallet, consisting of the wallet
+// seed encrypted like a version 3 key and the list of pinned accounts.
+type hdWalletJSON struct {
+	Crypto   cryptoJSON      `json:"crypto"`
+	Accounts []hdAccountJSON `json:"accounts"`
+	Id       string          `json:"id"`
+	Version  int             `json:"version"`
+}
+
+// pinned parses the accounts pinned to the wallet and their derivation paths.
+func (f *hdWalletJSON) pinned() ([]common.Address, []accounts.DerivationPath, error) {
+	var (
+		addrs = make([]common.Address, len(f.Accounts))
+		paths = make([]accounts.DerivationPath, len(f.Accounts))
+	)
+	for i, account := range f.Accounts {
+		if !common.IsHexAddress(account.Address) {
+			return nil, nil, fmt.Errorf("invalid pinned address: %q", account.Address)
+		}
+		path, err := accounts.ParseDerivationPath(account.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		addrs[i], paths[i] = common.HexToAddress(account.Address), path
+	}
+	return addrs, paths, nil
+}
+
+// hdWallet implements accounts.Wallet for a BIP-32 hierarchical deterministic
+// wallet, whose seed is stored encrypted within the keystore. Opening the wallet
+// decrypts the seed, after which accounts can be derived and used for signing.
+type hdWallet struct {
+	url      accounts.URL // Location of the wallet file within the keystore
+	keystore *KeyStore    // Keystore to notify of the wallet being opened
+
+	file hdWalletJSON // Persisted wallet, updated whenever an account is pinned
+	seed []byte       // Decrypted wallet seed, nil while the wallet is closed
+
+	accounts []accounts.Account                         // List of pinned and self-derived accounts
+	paths    map[common.Address]accounts.DerivationPath // Known derivation paths for signing operations
+
+	deriveNextPath accounts.DerivationPath   // Next derivation path for account auto-discovery
+	deriveNextAddr common.Address            // Next derived account address for auto-discovery
+	deriveChain    ethereum.ChainStateReader // Blockchain state reader to discover used account with
+	deriveReq      chan chan struct{}        // Channel to request a self-derivation on
+	deriveQuit     chan chan error           // Channel to terminate the self-deriver with
+
+	stateLock sync.RWMutex // Protects read and write access to the wallet struct fields
+}
+
+// newHDWallet creates an HD wallet from a BIP-39 mnemonic and passphrase, with
+// its first account at the default derivation path pinned. The seed is encrypted
+// with auth, but the wallet is not yet stored into the given folder.
+func newHDWallet(dir, mnemonic, bip39Passphrase, auth string, scryptN, scryptP int) (*hdWallet, error) {
+	seed, err := MnemonicToSeed(mnemonic, bip39Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(seed)
+
+	path := accounts.DefaultBaseDerivationPath
+	address, err := deriveAddress(seed, path)
+	if err != nil {
+		return nil, err
+	}
+	cryptoStruct, err := encryptData(seed, auth, scryptN, scryptP)
+	if err != nil {
+		return nil, err
+	}
+	w := &hdWallet{
+		url: accounts.URL{Scheme: KeyStoreScheme, Path: filepath.Join(dir, keyFileName(address))},
+		file: hdWalletJSON{
+			Crypto:   cryptoStruct,
+			Accounts: []hdAccountJSON{{Address: address.Hex(), Path: path.String()}},
+			Id:       uuid.NewRandom().String(),
+			Version:  hdVersion,
+		},
+	}
+	w.reset()
+	return w, nil
+}
+
+// loadHDWallet loads the HD wallet stored in the given file.
+func loadHDWallet(file string) (*hdWallet, error) {
+	blob, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	w := &hdWallet{url: accounts.URL{Scheme: KeyStoreScheme, Path: file}}
+	if err := json.Unmarshal(blob, &w.file); err != nil {
+		return nil, err
+	}
+	if w.file.Version != hdVersion {
+		return nil, fmt.Errorf("HD wallet version not supported: %v", w.file.Version)
+	}
+	if _, _, err := w.file.pinned(); err != nil {
+		return nil, err
+	}
+	w.reset()
+	return w, nil
+}
+
+// StoreMnemonic creates an HD wallet from a BIP-39 mnemonic and the optional
+// passphrase protecting it, encrypts its seed with auth and stores it in the
+// given keystore directory. The first account of the wallet is returned.
+func StoreMnemonic(dir, mnemonic, bip39Passphrase, auth string, scryptN, scryptP int) (accounts.Account, error) {
+	w, err := newHDWallet(filepath.Join(dir, hdDirName), mnemonic, bip39Passphrase, auth, scryptN, scryptP)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	if err := w.store(w.file); err != nil {
+		return accounts.Account{}, err
+	}
+	return w.accounts[0], nil
+}
+
+// store persists the given wallet contents into the wallet file.
+func (w *hdWallet) store(file hdWalletJSON) error {
+	blob, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	return writeKeyFile(w.url.Path, blob)
+}
+
+// reset drops any self-derived accounts, leaving only the pinned ones.
+//
+// Note, reset assumes the state lock is held!
+func (w *hdWallet) reset() {
+	addrs, paths, _ := w.file.pinned() // Validated when loaded
+
+	w.accounts = make([]accounts.Account, len(addrs))
+	w.paths = make(map[common.Address]accounts.DerivationPath, len(addrs))
+	for i, addr := range addrs {
+		w.accounts[i] = accounts.Account{Address: addr, URL: w.accountURL(paths[i])}
+		w.paths[addr] = paths[i]
+	}
+}
+
+// accountURL returns the URL of the account derived at the given path.
+func (w *hdWallet) accountURL(path accounts.DerivationPath) accounts.URL {
+	return accounts.URL{Scheme: w.url.Scheme, Path: fmt.Sprintf("%s/%s", w.url.Path, path)}
+}
+
+// URL implements accounts.Wallet, returning the URL of the wallet file.
+func (w *hdWallet) URL() accounts.URL {
+	return w.url // Immutable, no need for a lock
+}
+
+// Status implements accounts.Wallet, returning whether the seed of the wallet
+// is decrypted or not.
+func (w *hdWallet) Status() (string, error) {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	if w.seed == nil {
+		return "Locked", nil
+	}
+	return "Unlocked", nil
+}
+
+// Open implements accounts.Wallet, decrypting the seed of the wallet with the
+// given passphrase and starting account self-derivation.
+func (w *hdWallet) Open(passphrase string) error {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	if w.seed != nil {
+		return accounts.ErrWalletAlreadyOpen
+	}
+	seed, err := decryptData(w.file.Crypto, passphrase)
+	if err != nil {
+		return err
+	}
+	w.seed = seed
+
+	w.deriveReq = make(chan chan struct{})
+	w.deriveQuit = make(chan chan error)
+
+	go w.selfDerive()
+
+	// Notify anyone listening for wallet events that the wallet is accessible
+	if w.keystore != nil {
+		go w.keystore.updateFeed.Send(accounts.WalletEvent{Wallet: w, Kind: accounts.WalletOpened})
+	}
+	return nil
+}
+
+// Close implements accounts.Wallet, stopping self-derivation and wiping the
+// decrypted seed from memory.
+func (w *hdWallet) Close() error {
+	// Terminate the self-derivations
+	w.stateLock.RLock()
+	dQuit := w.deriveQuit
+	w.stateLock.RUnlock()
+
+	var err error
+	if dQuit != nil {
+		errc := make(chan error)
+		dQuit <- errc
+		err = <-errc
+	}
+	// Forget the seed and any accounts not pinned
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	w.deriveQuit = nil
+	w.deriveReq = nil
+
+	if w.seed != nil {
+		zeroBytes(w.seed)
+		w.seed = nil
+	}
+	w.reset()
+
+	return err
+}
+
+// Accounts implements accounts.Wallet, returning the list of accounts pinned to
+// the HD wallet. If self-derivation was enabled, the account list is
+// periodically expanded based on current chain state.
+func (w *hdWallet) Accounts() []accounts.Account {
+	// Attempt self-derivation if it's running
+	reqc := make(chan struct{}, 1)
+	select {
+	case w.deriveReq <- reqc:
+		// Self-derivation request accepted, wait for it
+		<-reqc
+	default:
+		// Self-derivation offline, throttled or busy, skip
+	}
+	// Return whatever account list we ended up with
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	cpy := make([]accounts.Account, len(w.accounts))
+	copy(cpy, w.accounts)
+	return cpy
+}
+
+// selfDerive is an account derivation loop that upon request attempts to find
+// new non-zero accounts.
+func (w *hdWallet) selfDerive() {
+	log.Debug("HD wallet self-derivation started", "url", w.url)
+	defer log.Debug("HD wallet self-derivation stopped", "url", w.url)
+
+	// Execute self-derivations until termination or error
+	var (
+		reqc chan struct{}
+		errc chan error
+		err  error
+	)
+	for errc == nil && err == nil {
+		// Wait until either derivation or termination is requested
+		select {
+		case errc = <-w.deriveQuit:
+			// Termination requested
+			continue
+		case reqc = <-w.deriveReq:
+			// Account discovery requested
+		}
+		// Derivation needs a chain and the seed, skip if either unavailable
+		w.stateLock.RLock()
+		if w.seed == nil || w.deriveChain == nil {
+			w.stateLock.RUnlock()
+			reqc <- struct{}{}
+			continue
+		}
+		// Derive the next batch of accounts
+		var (
+			accs  []accounts.Account
+			paths []accounts.DerivationPath
+
+			nextAddr = w.deriveNextAddr
+			nextPath = w.deriveNextPath
+
+			context = context.Background()
+		)
+		for empty := false; !empty; {
+			// Retrieve the next derived Ethereum account
+			if nextAddr == (common.Address{}) {
+				if nextAddr, err = deriveAddress(w.seed, nextPath); err != nil {
+					log.Warn("HD wallet account derivation failed", "url", w.url, "err", err)
+					break
+				}
+			}
+			// Check the account's status against the current chain state
+			var (
+				balance *big.Int
+				nonce   uint64
+			)
+			balance, err = w.deriveChain.BalanceAt(context, nextAddr, nil)
+			if err != nil {
+				log.Warn("HD wallet balance retrieval failed", "url", w.url, "err", err)
+				break
+			}
+			nonce, err = w.deriveChain.NonceAt(context, nextAddr, nil)
+			if err != nil {
+				log.Warn("HD wallet nonce retrieval failed", "url", w.url, "err", err)
+				break
+			}
+			// If the next account is empty, stop self-derivation, but add it nonetheless
+			if balance.Sign() == 0 && nonce == 0 {
+				empty = true
+			}
+			// We've just self-derived a new account, start tracking it locally
+			path := make(accounts.DerivationPath, len(nextPath))
+			copy(path[:], nextPath[:])
+			paths = append(paths, path)
+
+			accs = append(accs, accounts.Account{Address: nextAddr, URL: w.accountURL(path)})
+
+			// Display a log message to the user for new (or previously empty accounts)
+			if _, known := w.paths[nextAddr]; !known || (!empty && nextAddr == w.deriveNextAddr) {
+				log.Info("HD wallet discovered new account", "address", nextAddr, "path", path, "balance", balance, "nonce", nonce)
+			}
+			// Fetch the next potential account
+			if !empty {
+				nextAddr = common.Address{}
+				nextPath[len(nextPath)-1]++
+			}
+		}
+		w.stateLock.RUnlock()
+
+		// Insert any accounts successfully derived
+		w.stateLock.Lock()
+		for i := 0; i < len(accs); i++ {
+			if _, ok := w.paths[accs[i].Address]; !ok {
+				w.accounts = append(w.accounts, accs[i])
+				w.paths[accs[i].Address] = paths[i]
+			}
+		}
+		// Shift the self-derivation forward
+		w.deriveNextAddr = nextAddr
+		w.deriveNextPath = nextPath
+		w.stateLock.Unlock()
+
+		// Notify the user of termination and loop after a bit of time (to avoid trashing)
+		reqc <- struct{}{}
+		if err == nil {
+			select {
+			case errc = <-w.deriveQuit:
+				// Termination requested, abort
+			case <-time.After(hdSelfDeriveThrottling):
+				// Waited enough, willing to self-derive again
+			}
+		}
+	}
+	// In case of error, wait for termination
+	if err != nil {
+		log.Debug("HD wallet self-derivation failed", "url", w.url, "err", err)
+		errc = <-w.deriveQuit
+	}
+	errc <- err
+}
+
+// Contains implements accounts.Wallet, returning whether a particular account is
+// or is not pinned or self-derived into this wallet instance.
+func (w *hdWallet) Contains(account accounts.Account) bool {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	_, exists := w.paths[account.Address]
+	return exists
+}
+
+// Derive implements accounts.Wallet, deriving a new account at the specific
+// derivation path. If pin is set to true, the account will be added to the list
+// of tracked accounts and persisted in the wallet file.
+func (w *hdWallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	w.stateLock.RLock()
+	if w.seed == nil {
+		w.stateLock.RUnlock()
+		return accounts.Account{}, accounts.ErrWalletClosed
+	}
+	address, err := deriveAddress(w.seed, path)
+	w.stateLock.RUnlock()
+
+	// If an error occurred or no pinning was requested, return
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	account := accounts.Account{Address: address, URL: w.accountURL(path)}
+	if !pin {
+		return account, nil
+	}
+	// Pinning needs to modify the state and persist the account
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	pinned, _, _ := w.file.pinned()
+	for _, addr := range pinned {
+		if addr == address {
+			return account, nil
+		}
+	}
+	file := w.file
+	file.Accounts = append(append([]hdAccountJSON{}, w.file.Accounts...), hdAccountJSON{Address: address.Hex(), Path: path.String()})
+	if err := w.store(file); err != nil {
+		return accounts.Account{}, err
+	}
+	w.file = file
+
+	if _, ok := w.paths[address]; !ok {
+		w.accounts = append(w.accounts, account)
+		w.paths[address] = path
+	}
+	return account, nil
+}
+
+// SelfDerive implements accounts.Wallet, trying to discover accounts that the
+// user used previously (based on the chain state), but ones that he/she did not
+// explicitly pin to the wallet manually. To avoid chain head monitoring, self
+// derivation only runs during account listing (and even then throttled).
+func (w *hdWallet) SelfDerive(base accounts.DerivationPath, chain ethereum.ChainStateReader) {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	w.deriveNextPath = make(accounts.DerivationPath, len(base))
+	copy(w.deriveNextPath[:], base[:])
+
+	w.deriveNextAddr = common.Address{}
+	w.deriveChain = chain
+}
+
+// deriveAccountKey derives the private key of a known account from the seed.
+//
+// Note, deriveAccountKey assumes the state lock is held!
+func (w *hdWallet) deriveAccountKey(seed []byte, account accounts.Account) (*ecdsa.PrivateKey, error) {
+	path, ok := w.paths[account.Address]
+	if !ok {
+		return nil, accounts.ErrUnknownAccount
+	}
+	return deriveKey(seed, path)
+}
+
+// SignHash implements accounts.Wallet, signing the given hash with the key of
+// the requested account, derived from the seed of the open wallet.
+func (w *hdWallet) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	if w.seed == nil {
+		return nil, accounts.ErrWalletClosed
+	}
+	key, err := w.deriveAccountKey(w.seed, account)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+	return crypto.Sign(hash, key)
+}
+
+// SignTx implements accounts.Wallet, signing the given transaction with the key
+// of the requested account, derived from the seed of the open wallet.
+func (w *hdWallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	if w.seed == nil {
+		return nil, accounts.ErrWalletClosed
+	}
+	key, err := w.deriveAccountKey(w.seed, account)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+
+	// Depending on the presence of the chain ID, sign with EIP155 or homestead
+	if chainID != nil {
+		return types.SignTx(tx, types.NewEIP155Signer(chainID), key)
+	}
+	return types.SignTx(tx, types.HomesteadSigner{}, key)
+}
+
+// SignHashWithPassphrase implements accounts.Wallet, attempting to sign the
+// given hash with the given account, decrypting the seed with the passphrase
+// for this single operation.
+func (w *hdWallet) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	seed, err := decryptData(w.file.Crypto, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(seed)
+
+	key, err := w.deriveAccountKey(seed, account)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+	return crypto.Sign(hash, key)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, attempting to sign the given
+// transaction with the given account, decrypting the seed with the passphrase
+// for this single operation.
+func (w *hdWallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	seed, err := decryptData(w.file.Crypto, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(seed)
+
+	key, err := w.deriveAccountKey(seed, account)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+
+	// Depending on the presence of the chain ID, sign with EIP155 or homestead
+	if chainID != nil {
+		return types.SignTx(tx, types.NewEIP155Signer(chainID), key)
+	}
+	return types.SignTx(tx, types.HomesteadSigner{}, key)
+}
+
+// zeroBytes zeroes a byte slice in memory.
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package keystore
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TeamEGEM/go-egem/accounts"
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/crypto"
+)
+
+const testMnemonic = "test test test test test test test test test test test junk"
+
+// Tests that keys are derived according to BIP-32/BIP-44, checked against the
+// addresses other Ethereum wallets generate for well known mnemonics.
+func TestHDDerivation(t *testing.T) {
+	tests := []struct {
+		mnemonic string
+		path     string
+		address  common.Address
+	}{
+		{mnemonicTests[0].mnemonic, "m/44'/60'/0'/0/0", common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")},
+		{testMnemonic, "m/44'/60'/0'/0/0", common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")},
+		{testMnemonic, "m/44'/60'/0'/0/1", common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")},
+	}
+	for i, tt := range tests {
+		seed, err := MnemonicToSeed(tt.mnemonic, "")
+		if err != nil {
+			t.Fatalf("test %d: failed to create seed: %v", i, err)
+		}
+		path, err := accounts.ParseDerivationPath(tt.path)
+		if err != nil {
+			t.Fatalf("test %d: failed to parse path: %v", i, err)
+		}
+		address, err := deriveAddress(seed, path)
+		if err != nil {
+			t.Fatalf("test %d: failed to derive address: %v", i, err)
+		}
+		if address != tt.address {
+			t.Errorf("test %d: address mismatch: have %x, want %x", i, address, tt.address)
+		}
+	}
+}
+
+// Tests the life cycle of an HD wallet: importing, opening, deriving, signing
+// and reloading from disk.
+func TestHDWallet(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	account, err := ks.ImportMnemonic(testMnemonic, "", "foo")
+	if err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	if _, err := ks.ImportMnemonic(testMnemonic, "", "bar"); err == nil {
+		t.Errorf("duplicate mnemonic import succeeded")
+	}
+	seed, _ := MnemonicToSeed(testMnemonic, "")
+	if want, _ := deriveAddress(seed, accounts.DefaultBaseDerivationPath); account.Address != want {
+		t.Errorf("first account mismatch: have %x, want %x", account.Address, want)
+	}
+	wallets := ks.Wallets()
+	if len(wallets) != 1 {
+		t.Fatalf("wallet count mismatch: have %d, want 1", len(wallets))
+	}
+	wallet := wallets[0]
+	if !strings.HasPrefix(wallet.URL().Path, filepath.Join(dir, hdDirName)) {
+		t.Errorf("wallet file %s not in HD folder", wallet.URL())
+	}
+	if accs := wallet.Accounts(); len(accs) != 1 || accs[0] != account {
+		t.Errorf("wallet accounts mismatch: have %v, want %v", accs, account)
+	}
+	// Derivation and signing need the wallet to be open
+	path, _ := accounts.ParseDerivationPath("m/44'/60'/0'/0/1")
+	if _, err := wallet.Derive(path, true); err != accounts.ErrWalletClosed {
+		t.Errorf("closed wallet derivation error mismatch: have %v, want %v", err, accounts.ErrWalletClosed)
+	}
+	if _, err := wallet.SignHash(account, testSigData); err != accounts.ErrWalletClosed {
+		t.Errorf("closed wallet signing error mismatch: have %v, want %v", err, accounts.ErrWalletClosed)
+	}
+	if err := wallet.Open("bar"); err != ErrDecrypt {
+		t.Errorf("wrong passphrase error mismatch: have %v, want %v", err, ErrDecrypt)
+	}
+	if err := wallet.Open("foo"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	derived, err := wallet.Derive(path, true)
+	if err != nil {
+		t.Fatalf("failed to derive account: %v", err)
+	}
+	if want := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"); derived.Address != want {
+		t.Errorf("derived address mismatch: have %x, want %x", derived.Address, want)
+	}
+	for _, acc := range []accounts.Account{account, derived} {
+		sig, err := wallet.SignHash(acc, testSigData)
+		if err != nil {
+			t.Fatalf("failed to sign with %x: %v", acc.Address, err)
+		}
+		if signer := recoverSigner(t, sig); signer != acc.Address {
+			t.Errorf("signer mismatch: have %x, want %x", signer, acc.Address)
+		}
+	}
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	signed, err := wallet.SignTx(derived, tx, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if sender, _ := types.Sender(types.NewEIP155Signer(big.NewInt(1)), signed); sender != derived.Address {
+		t.Errorf("transaction sender mismatch: have %x, want %x", sender, derived.Address)
+	}
+	// Closing the wallet keeps pinned accounts, signing needs the passphrase
+	if err := wallet.Close(); err != nil {
+		t.Fatalf("failed to close wallet: %v", err)
+	}
+	if _, err := wallet.SignHash(derived, testSigData); err != accounts.ErrWalletClosed {
+		t.Errorf("closed wallet signing error mismatch: have %v, want %v", err, accounts.ErrWalletClosed)
+	}
+	if _, err := wallet.SignHashWithPassphrase(derived, "bar", testSigData); err != ErrDecrypt {
+		t.Errorf("wrong passphrase error mismatch: have %v, want %v", err, ErrDecrypt)
+	}
+	sig, err := wallet.SignHashWithPassphrase(derived, "foo", testSigData)
+	if err != nil {
+		t.Fatalf("failed to sign with passphrase: %v", err)
+	}
+	if signer := recoverSigner(t, sig); signer != derived.Address {
+		t.Errorf("signer mismatch: have %x, want %x", signer, derived.Address)
+	}
+	// Pinned accounts must survive reloading the keystore
+	wallets = NewKeyStore(dir, veryLightScryptN, veryLightScryptP).Wallets()
+	if len(wallets) != 1 {
+		t.Fatalf("reloaded wallet count mismatch: have %d, want 1", len(wallets))
+	}
+	if accs := wallets[0].Accounts(); len(accs) != 2 || accs[0] != account || accs[1] != derived {
+		t.Errorf("reloaded accounts mismatch: have %v, want %v", accs, []accounts.Account{account, derived})
+	}
+}
+
+// Tests that self-derivation discovers all used accounts and the first empty one.
+func TestHDWalletSelfDerive(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	if _, err := ks.ImportMnemonic(testMnemonic, "", "foo"); err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	wallet := ks.Wallets()[0]
+	if err := wallet.Open("foo"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	defer wallet.Close()
+
+	base, _ := accounts.ParseDerivationPath("m/44'/60'/0'/0/0")
+	chain := &testChainState{nonces: map[common.Address]uint64{
+		common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"): 1,
+		common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"): 3,
+	}}
+	wallet.SelfDerive(base, chain)
+
+	// Self-derivation runs in the background, retry listing until it happened
+	var accs []accounts.Account
+	for i := 0; i < 100; i++ {
+		if accs = wallet.Accounts(); len(accs) == 4 { // pinned default account plus three self-derived ones
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(accs) != 4 {
+		t.Fatalf("account count mismatch: have %d, want 4", len(accs))
+	}
+	for i, want := range []string{"m/44'/60'/0'/0/0", "m/44'/60'/0'/0/1", "m/44'/60'/0'/0/2"} {
+		if !strings.HasSuffix(accs[i+1].URL.Path, want) {
+			t.Errorf("account %d: path mismatch: have %s, want %s", i+1, accs[i+1].URL, want)
+		}
+	}
+}
+
+// testChainState is a mock chain state reader reporting the given nonces and
+// no balance for any account.
+type testChainState struct {
+	nonces map[common.Address]uint64
+}
+
+func (c *testChainState) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return new(big.Int), nil
+}
+
+func (c *testChainState) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *testChainState) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *testChainState) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return c.nonces[account], nil
+}
+
+func recoverSigner(t *testing.T, sig []byte) common.Address {
+	pub, err := crypto.SigToPub(testSigData, sig)
+	if err != nil {
+		t.Fatalf("failed to recover signer: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pub)
+}
+
+// Tests that HD wallets moved into or out of the keystore folder by other
+// processes are picked up by the watcher.
+func TestHDWalletWatch(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	if _, err := ks.ImportMnemonic(testMnemonic, "", "foo"); err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	wallets := ks.Wallets()
+	if len(wallets) != 1 {
+		t.Fatalf("wallet count mismatch: have %d, want 1", len(wallets))
+	}
+	// Renaming the wallet file must replace the wallet, removing it drop it
+	moved := filepath.Join(dir, hdDirName, "moved")
+	if err := os.Rename(wallets[0].URL().Path, moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitForHDWallets(ks, []string{moved}); err != nil {
+		t.Fatalf("renamed wallet not picked up: %v", err)
+	}
+	if err := os.Remove(moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitForHDWallets(ks, []string{}); err != nil {
+		t.Fatalf("removed wallet not dropped: %v", err)
+	}
+}
+
+// waitForHDWallets waits until the keystore lists exactly the HD wallets stored
+// in the given files.
+func waitForHDWallets(ks *KeyStore, want []string) error {
+	var have []string
+	for d := 200 * time.Millisecond; d < 8*time.Second; d *= 2 {
+		have = []string{}
+		for _, wallet := range ks.Wallets() {
+			have = append(have, wallet.URL().Path)
+		}
+		if reflect.DeepEqual(have, want) {
+			return nil
+		}
+		time.Sleep(d)
+	}
+	return fmt.Errorf("have %v, want %v", have, want)
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/TeamEGEM/go-egem/core/types"
 	"github.com/TeamEGEM/go-egem/crypto"
 	"github.com/TeamEGEM/go-egem/event"
+	"github.com/TeamEGEM/go-egem/log"
 )
 
 var (
@@ -63,6 +64,7 @@ type KeyStore struct {
 	unlocked map[common.Address]*unlocked // Currently unlocked account (decrypted private keys)
 
 	wallets     []accounts.Wallet       // Wallet wrappers around the individual key files
+	hdWallets   []*hdWallet             // HD wallets from the keystore's HD folder, sorted by URL
 	updateFeed  event.Feed              // Event feed to notify wallet additions/removals
 	updateScope event.SubscriptionScope // Subscription scope tracking current live listeners
 	updating    bool                    // Whether the event notification loop is running
@@ -113,10 +115,11 @@ func (ks *KeyStore) init(keydir string) {
 	for i := 0; i < len(accs); i++ {
 		ks.wallets[i] = &keystoreWallet{account: accs[i], keystore: ks}
 	}
+	ks.refreshHDWallets(ks.cache.hdWalletFiles())
 }
 
-// Wallets implements accounts.Backend, returning all single-key wallets from the
-// keystore directory.
+// Wallets implements accounts.Backend, returning all single-key and HD wallets
+// from the keystore directory.
 func (ks *KeyStore) Wallets() []accounts.Wallet {
 	// Make sure the list of wallets is in sync with the account cache
 	ks.refreshWallets()
@@ -124,8 +127,23 @@ func (ks *KeyStore) Wallets() []accounts.Wallet {
 	ks.mu.RLock()
 	defer ks.mu.RUnlock()
 
-	cpy := make([]accounts.Wallet, len(ks.wallets))
-	copy(cpy, ks.wallets)
+	// Both wallet lists are sorted by URL, merge them
+	var (
+		cpy       = make([]accounts.Wallet, 0, len(ks.wallets)+len(ks.hdWallets))
+		keys      = ks.wallets
+		hdwallets = ks.hdWallets
+	)
+	for len(keys) > 0 && len(hdwallets) > 0 {
+		if keys[0].URL().Cmp(hdwallets[0].url) < 0 {
+			cpy, keys = append(cpy, keys[0]), keys[1:]
+		} else {
+			cpy, hdwallets = append(cpy, hdwallets[0]), hdwallets[1:]
+		}
+	}
+	cpy = append(cpy, keys...)
+	for _, wallet := range hdwallets {
+		cpy = append(cpy, wallet)
+	}
 	return cpy
 }
 
@@ -135,6 +153,7 @@ func (ks *KeyStore) refreshWallets() {
 	// Retrieve the current list of accounts
 	ks.mu.Lock()
 	accs := ks.cache.accounts()
+	hdFiles := ks.cache.hdWalletFiles()
 
 	// Transform the current list of wallets into the new one
 	wallets := make([]accounts.Wallet, 0, len(accs))
@@ -166,6 +185,7 @@ func (ks *KeyStore) refreshWallets() {
 		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletDropped})
 	}
 	ks.wallets = wallets
+	events = append(events, ks.refreshHDWallets(hdFiles)...)
 	ks.mu.Unlock()
 
 	// Fire all wallet events and return
@@ -174,6 +194,46 @@ func (ks *KeyStore) refreshWallets() {
 	}
 }
 
+// refreshHDWallets updates the list of HD wallets to the given sorted wallet
+// files, loading the new ones and returning the events of any wallets arrived or
+// dropped since last refresh.
+//
+// Note, refreshHDWallets assumes the keystore lock is held!
+func (ks *KeyStore) refreshHDWallets(files []string) []accounts.WalletEvent {
+	var (
+		wallets = make([]*hdWallet, 0, len(files))
+		events  []accounts.WalletEvent
+	)
+	for _, path := range files {
+		// Drop wallets while they were in front of the next file
+		for len(ks.hdWallets) > 0 && ks.hdWallets[0].url.Path < path {
+			events = append(events, accounts.WalletEvent{Wallet: ks.hdWallets[0], Kind: accounts.WalletDropped})
+			ks.hdWallets = ks.hdWallets[1:]
+		}
+		// If the file is already loaded, keep its wallet
+		if len(ks.hdWallets) > 0 && ks.hdWallets[0].url.Path == path {
+			wallets = append(wallets, ks.hdWallets[0])
+			ks.hdWallets = ks.hdWallets[1:]
+			continue
+		}
+		wallet, err := loadHDWallet(path)
+		if err != nil {
+			log.Debug("Failed to load HD wallet", "path", path, "err", err)
+			continue
+		}
+		wallet.keystore = ks
+
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletArrived})
+		wallets = append(wallets, wallet)
+	}
+	// Drop any leftover wallets and set the new batch
+	for _, wallet := range ks.hdWallets {
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletDropped})
+	}
+	ks.hdWallets = wallets
+	return events
+}
+
 // Subscribe implements accounts.Backend, creating an async subscription to
 // receive notifications on the addition or removal of keystore wallets.
 func (ks *KeyStore) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
@@ -417,18 +477,52 @@ func (ks *KeyStore) NewAccount(passphrase string) (accounts.Account, error) {
 	return account, nil
 }
 
+// ImportMnemonic creates an HD wallet from the given BIP-39 mnemonic and its
+// optional passphrase, storing the wallet seed into the key directory encrypted
+// with passphrase. The first account of the wallet is returned.
+func (ks *KeyStore) ImportMnemonic(mnemonic, bip39Passphrase, passphrase string) (accounts.Account, error) {
+	N, P := ks.scryptParams()
+	wallet, err := newHDWallet(ks.storage.JoinPath(hdDirName), mnemonic, bip39Passphrase, passphrase, N, P)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	account := wallet.accounts[0]
+
+	ks.refreshWallets()
+	ks.mu.RLock()
+	for _, existing := range ks.hdWallets {
+		if existing.Contains(account) {
+			ks.mu.RUnlock()
+			return accounts.Account{}, fmt.Errorf("wallet already exists")
+		}
+	}
+	ks.mu.RUnlock()
+
+	if err := wallet.store(wallet.file); err != nil {
+		return accounts.Account{}, err
+	}
+	// Add the wallet to the cache immediately rather
+	// than waiting for file system notifications to pick it up.
+	ks.cache.addHDWallet(wallet.url.Path)
+	ks.refreshWallets()
+	return account, nil
+}
+
+// scryptParams returns the scrypt parameters to encrypt new secrets with.
+func (ks *KeyStore) scryptParams() (int, int) {
+	if store, ok := ks.storage.(*keyStorePassphrase); ok {
+		return store.scryptN, store.scryptP
+	}
+	return StandardScryptN, StandardScryptP
+}
+
 // Export exports as a JSON key, encrypted with newPassphrase.
 func (ks *KeyStore) Export(a accounts.Account, passphrase, newPassphrase string) (keyJSON []byte, err error) {
 	_, key, err := ks.getDecryptedKey(a, passphrase)
 	if err != nil {
 		return nil, err
 	}
-	var N, P int
-	if store, ok := ks.storage.(*keyStorePassphrase); ok {
-		N, P = store.scryptN, store.scryptP
-	} else {
-		N, P = StandardScryptN, StandardScryptP
-	}
+	N, P := ks.scryptParams()
 	return EncryptKey(key, newPassphrase, N, P)
 }
 
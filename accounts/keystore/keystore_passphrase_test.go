@@ -17,6 +17,7 @@
 package keystore
 
 import (
+	"bytes"
 	"io/ioutil"
 	"testing"
 
@@ -58,3 +59,24 @@ func TestKeyEncryptDecrypt(t *testing.T) {
 		}
 	}
 }
+
+// Tests that arbitrary data can be encrypted and only decrypted with the right
+// password.
+func TestDataEncryptDecrypt(t *testing.T) {
+	data := []byte("master seed of the signer")
+
+	blob, err := EncryptDataV3(data, "pass", veryLightScryptN, veryLightScryptP)
+	if err != nil {
+		t.Fatalf("failed to encrypt data: %v", err)
+	}
+	if _, err := DecryptDataV3(blob, "bad"); err != ErrDecrypt {
+		t.Errorf("bad password error mismatch: have %v, want %v", err, ErrDecrypt)
+	}
+	plain, err := DecryptDataV3(blob, "pass")
+	if err != nil {
+		t.Fatalf("failed to decrypt data: %v", err)
+	}
+	if !bytes.Equal(plain, data) {
+		t.Errorf("decrypted data mismatch: have %q, want %q", plain, data)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package keystore
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/TeamEGEM/go-egem/common/math"
+	"github.com/TeamEGEM/go-egem/crypto/randentropy"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// ErrInvalidMnemonic is returned if a mnemonic is not a valid BIP-39 sentence:
+// it has an unsupported number of words or its checksum does not match.
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+const (
+	// mnemonicSeedRounds is the number of PBKDF2 iterations used to stretch a
+	// mnemonic into a wallet seed, as defined by BIP-39.
+	mnemonicSeedRounds = 2048
+
+	// mnemonicSeedLength is the length of the wallet seed in bytes.
+	mnemonicSeedLength = 64
+)
+
+var (
+	mnemonicWords   = strings.Split(englishWords, "\n") // Words of the mnemonic alphabet
+	mnemonicIndices = make(map[string]int)              // Index of each word within the alphabet
+)
+
+func init() {
+	for i, word := range mnemonicWords {
+		mnemonicIndices[word] = i
+	}
+}
+
+// NewMnemonic generates a random BIP-39 mnemonic from the given number of
+// entropy bits, which must be a multiple of 32 between 128 and 256. Every 32
+// bits of entropy result in 3 words.
+func NewMnemonic(bits int) (string, error) {
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return "", fmt.Errorf("invalid mnemonic entropy size: %d bits", bits)
+	}
+	return entropyToMnemonic(randentropy.GetEntropyCSPRNG(bits / 8)), nil
+}
+
+// ValidateMnemonic checks whether the given sentence is a BIP-39 mnemonic made of
+// words from the English wordlist and with a matching checksum.
+func ValidateMnemonic(mnemonic string) error {
+	_, err := mnemonicToEntropy(mnemonic)
+	return err
+}
+
+// MnemonicToSeed validates a BIP-39 mnemonic and stretches it together with the
+// optional passphrase into the seed of a hierarchical deterministic wallet.
+//
+// Note, BIP-39 mandates the passphrase to be in Unicode NFKD form, which is the
+// caller's responsibility for anything beyond ASCII.
+func MnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	sentence := strings.Join(strings.Fields(mnemonic), " ")
+	return pbkdf2.Key([]byte(sentence), []byte("mnemonic"+passphrase), mnemonicSeedRounds, mnemonicSeedLength, sha512.New), nil
+}
+
+// entropyToMnemonic appends the checksum to the given entropy and encodes the
+// result 11 bits at a time into words.
+func entropyToMnemonic(entropy []byte) string {
+	var (
+		hash     = sha256.Sum256(entropy)
+		checksum = uint(len(entropy) / 4) // One bit per 32 bits of entropy
+		data     = new(big.Int).SetBytes(entropy)
+	)
+	data.Lsh(data, checksum)
+	data.Or(data, big.NewInt(int64(hash[0]>>(8-checksum))))
+
+	var (
+		words = make([]string, (len(entropy)*8+int(checksum))/11)
+		mask  = big.NewInt(2047)
+		index = new(big.Int)
+	)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = mnemonicWords[index.And(data, mask).Int64()]
+		data.Rsh(data, 11)
+	}
+	return strings.Join(words, " ")
+}
+
+// mnemonicToEntropy decodes the words of a mnemonic back into the entropy it
+// was generated from, verifying its checksum.
+func mnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, ErrInvalidMnemonic
+	}
+	data := new(big.Int)
+	for _, word := range words {
+		index, ok := mnemonicIndices[word]
+		if !ok {
+			return nil, fmt.Errorf("invalid mnemonic word: %q", word)
+		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(index)))
+	}
+	// Split off the checksum and ensure it matches the entropy
+	checksum := uint(len(words) / 3)
+	sum := new(big.Int).And(data, big.NewInt(1<<checksum-1)).Int64()
+	data.Rsh(data, checksum)
+
+	entropy := math.PaddedBigBytes(data, len(words)*4/3)
+	if hash := sha256.Sum256(entropy); int64(hash[0]>>(8-checksum)) != sum {
+		return nil, ErrInvalidMnemonic
+	}
+	return entropy, nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package keystore
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// Test vectors from https://github.com/trezor/python-mnemonic/blob/master/vectors.json
+var mnemonicTests = []struct {
+	entropy  string
+	mnemonic string
+}{
+	{"00000000000000000000000000000000", "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"},
+	{"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f", "legal winner thank year wave sausage worth useful legal winner thank yellow"},
+	{"80808080808080808080808080808080", "letter advice cage absurd amount doctor acoustic avoid letter advice cage above"},
+	{"ffffffffffffffffffffffffffffffff", "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong"},
+	{"9e885d952ad362caeb4efe34a8e91bd2", "ozone drill grab fiber curtain grace pudding thank cruise elder eight picnic"},
+	{"c0ba5a8e914111210f2bd131f3d5e08d", "scheme spot photo card baby mountain device kick cradle pact join borrow"},
+	{"f30f8c1da665478f49b001d94c5fc452", "vessel ladder alter error federal sibling chat ability sun glass valve picture"},
+	{"18ab19a9f54a9274f03e5209a2ac8a91", "board flee heavy tunnel powder denial science ski answer betray cargo cat"},
+	{"6610b25967cdcca9d59875f5cb50b0ea75433311869e930b", "gravity machine north sort system female filter attitude volume fold club stay feature office ecology stable narrow fog"},
+	{"68a79eaca2324873eacc50cb9c6eca8cc68ea5d936f98787c60c7ebc74e6ce7c", "hamster diagram private dutch cause delay private meat slide toddler razor book happy fancy gospel tennis maple dilemma loan word shrug inflict delay length"},
+	{"9f6a2878b2520799a44ef18bc7df394e7061a224d2c33cd015b157d746869863", "panda eyebrow bullet gorilla call smoke muffin taste mesh discover soft ostrich alcohol speed nation flash devote level hobby quick inner drive ghost inside"},
+	{"15da872c95a13dd738fbf50e427583ad61f18fd99f628c417a61cf8343c90419", "beyond stage sleep clip because twist token leaf atom beauty genius food business side grid unable middle armed observe pair crouch tonight away coconut"},
+}
+
+func TestMnemonicEncoding(t *testing.T) {
+	if len(mnemonicWords) != 2048 || len(mnemonicIndices) != 2048 {
+		t.Fatalf("wordlist size mismatch: have %d words, %d unique", len(mnemonicWords), len(mnemonicIndices))
+	}
+	for i, tt := range mnemonicTests {
+		entropy, _ := hex.DecodeString(tt.entropy)
+		if mnemonic := entropyToMnemonic(entropy); mnemonic != tt.mnemonic {
+			t.Errorf("test %d: mnemonic mismatch: have %q, want %q", i, mnemonic, tt.mnemonic)
+		}
+		decoded, err := mnemonicToEntropy(tt.mnemonic)
+		if err != nil {
+			t.Errorf("test %d: failed to decode mnemonic: %v", i, err)
+			continue
+		}
+		if hex.EncodeToString(decoded) != tt.entropy {
+			t.Errorf("test %d: entropy mismatch: have %x, want %s", i, decoded, tt.entropy)
+		}
+	}
+}
+
+func TestMnemonicToSeed(t *testing.T) {
+	seed, err := MnemonicToSeed(mnemonicTests[0].mnemonic, "TREZOR")
+	if err != nil {
+		t.Fatalf("failed to create seed: %v", err)
+	}
+	want := "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04"
+	if hex.EncodeToString(seed) != want {
+		t.Errorf("seed mismatch: have %x, want %s", seed, want)
+	}
+	// Extra whitespace must not change the seed
+	spaced, err := MnemonicToSeed("  "+strings.Replace(mnemonicTests[0].mnemonic, " ", "\n ", -1)+"\t", "TREZOR")
+	if err != nil {
+		t.Fatalf("failed to create seed from spaced mnemonic: %v", err)
+	}
+	if hex.EncodeToString(spaced) != want {
+		t.Errorf("spaced seed mismatch: have %x, want %s", spaced, want)
+	}
+}
+
+func TestMnemonicValidation(t *testing.T) {
+	invalid := []string{
+		"",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",         // too short
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", // bad checksum
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abut",    // unknown word
+		"Abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",   // not in the wordlist
+	}
+	for i, mnemonic := range invalid {
+		if _, err := MnemonicToSeed(mnemonic, ""); err == nil {
+			t.Errorf("test %d: invalid mnemonic accepted: %q", i, mnemonic)
+		}
+	}
+}
+
+func TestNewMnemonic(t *testing.T) {
+	for _, bits := range []int{128, 160, 192, 224, 256} {
+		mnemonic, err := NewMnemonic(bits)
+		if err != nil {
+			t.Fatalf("%d bits: failed to generate mnemonic: %v", bits, err)
+		}
+		if words := len(strings.Fields(mnemonic)); words != bits/32*3 {
+			t.Errorf("%d bits: word count mismatch: have %d, want %d", bits, words, bits/32*3)
+		}
+		if _, err := MnemonicToSeed(mnemonic, ""); err != nil {
+			t.Errorf("%d bits: generated mnemonic invalid: %v", bits, err)
+		}
+	}
+	for _, bits := range []int{0, 96, 129, 288} {
+		if _, err := NewMnemonic(bits); err == nil {
+			t.Errorf("%d bits: invalid entropy size accepted", bits)
+		}
+	}
+}
@@ -19,12 +19,23 @@
 package keystore
 
 import (
+	"path/filepath"
 	"time"
 
 	"github.com/TeamEGEM/go-egem/log"
 	"github.com/rjeczalik/notify"
 )
 
+const (
+	// maxPendingEvents is the number of changed files up to which only those are
+	// rescanned. If more files change at once, the entire folder is rescanned.
+	maxPendingEvents = 4096
+
+	// fullRescanInterval is the interval at which the entire keystore folder is
+	// rescanned, catching up on any events dropped by the filesystem notifier.
+	fullRescanInterval = 5 * time.Minute
+)
+
 type watcher struct {
 	ac       *accountCache
 	starting bool
@@ -36,7 +47,7 @@ type watcher struct {
 func newWatcher(ac *accountCache) *watcher {
 	return &watcher{
 		ac:   ac,
-		ev:   make(chan notify.EventInfo, 10),
+		ev:   make(chan notify.EventInfo, 1024),
 		quit: make(chan struct{}),
 	}
 }
@@ -73,36 +84,87 @@ func (w *watcher) loop() {
 	logger.Trace("Started watching keystore folder")
 	defer logger.Trace("Stopped watching keystore folder")
 
+	// Watch the HD wallet folder too if it already exists, otherwise it gets
+	// picked up once created. Relist it as it may have changed in between.
+	w.watchHDWallets(logger)
+	w.ac.scanHDWallets()
+
 	w.ac.mu.Lock()
 	w.running = true
 	w.ac.mu.Unlock()
 
-	// Wait for file system events and reload.
+	// Wait for file system events and reload the changed files.
 	// When an event occurs, the reload call is delayed a bit so that
 	// multiple events arriving quickly only cause a single reload.
 	var (
 		debounceDuration = 500 * time.Millisecond
 		rescanTriggered  = false
 		debounce         = time.NewTimer(0)
+		pending          = make(map[string]struct{})
+		fullRescan       = false
+		hdRescan         = false
+		periodic         = time.NewTicker(fullRescanInterval)
 	)
 	// Ignore initial trigger
 	if !debounce.Stop() {
 		<-debounce.C
 	}
 	defer debounce.Stop()
+	defer periodic.Stop()
 	for {
 		select {
 		case <-w.quit:
 			return
-		case <-w.ev:
+		case ev := <-w.ev:
+			// Track the changed file, or the whole folder if too much changed.
+			// Changes in or of the HD wallet folder only relist that folder.
+			path := filepath.Clean(ev.Path())
+			dir, name := filepath.Dir(path), filepath.Base(path)
+			switch {
+			case path == w.ac.keydir || len(pending) >= maxPendingEvents:
+				fullRescan = true
+			case dir == w.ac.keydir && name != hdDirName:
+				pending[path] = struct{}{}
+			case path == w.ac.hdDir:
+				if ev.Event() == notify.Create {
+					w.watchHDWallets(logger)
+				}
+				hdRescan = true
+			case dir == w.ac.hdDir:
+				hdRescan = true
+			default:
+				pending[filepath.Join(w.ac.keydir, name)] = struct{}{}
+			}
 			// Trigger the scan (with delay), if not already triggered
 			if !rescanTriggered {
 				debounce.Reset(debounceDuration)
 				rescanTriggered = true
 			}
 		case <-debounce.C:
+			if fullRescan {
+				w.ac.scanAccounts()
+			} else {
+				paths := make([]string, 0, len(pending))
+				for path := range pending {
+					paths = append(paths, path)
+				}
+				w.ac.scanFiles(paths)
+				if hdRescan {
+					w.ac.scanHDWallets()
+				}
+			}
+			pending = make(map[string]struct{})
+			rescanTriggered, fullRescan, hdRescan = false, false, false
+		case <-periodic.C:
 			w.ac.scanAccounts()
-			rescanTriggered = false
 		}
 	}
 }
+
+// watchHDWallets adds the HD wallet folder to the watched paths. The keystore
+// folder watch is not recursive, so changes in there are not reported otherwise.
+func (w *watcher) watchHDWallets(logger log.Logger) {
+	if err := notify.Watch(w.ac.hdDir, w.ev, notify.All); err != nil {
+		logger.Trace("Failed to watch HD wallet folder", "err", err)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/TeamEGEM/go-egem/console"
+	"github.com/TeamEGEM/go-egem/internal/ethapi"
+	"github.com/TeamEGEM/go-egem/signer/core"
+)
+
+// commandlineUI is an interactive SignerUI asking for approval of every request
+// on the terminal. Requests are presented one at a time.
+type commandlineUI struct {
+	prompter console.UserPrompter
+	mu       sync.Mutex
+}
+
+// newCommandlineUI creates a SignerUI prompting on stdin.
+func newCommandlineUI() *commandlineUI {
+	return &commandlineUI{prompter: console.Stdin}
+}
+
+// confirm asks the user a yes/no question, defaulting to no on any failure.
+func (ui *commandlineUI) confirm(prompt string) bool {
+	ok, err := ui.prompter.PromptConfirm(prompt)
+	return err == nil && ok
+}
+
+// ApproveTx prompts the user for confirmation to sign a transaction.
+func (ui *commandlineUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	fmt.Printf("-------- Transaction request -------------\n")
+	fmt.Print(request.Transaction.String())
+	fmt.Printf("------------------------------------------\n")
+	if !ui.confirm("Approve?") {
+		return core.SignTxResponse{Approved: false}, nil
+	}
+	password, err := ui.prompter.PromptPassword(fmt.Sprintf("Password for %s: ", request.Transaction.From.Hex()))
+	if err != nil {
+		return core.SignTxResponse{Approved: false}, err
+	}
+	return core.SignTxResponse{Approved: true, Password: password}, nil
+}
+
+// ApproveSignData prompts the user for confirmation to sign data.
+func (ui *commandlineUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	fmt.Printf("-------- Sign data request ---------------\n")
+	fmt.Printf("account:  %s\n", request.Address.Hex())
+	fmt.Printf("message:  %q\n", request.Message)
+	fmt.Printf("raw data: %v\n", request.Rawdata)
+	fmt.Printf("hash:     %v\n", request.Hash)
+	fmt.Printf("------------------------------------------\n")
+	if !ui.confirm("Approve?") {
+		return core.SignDataResponse{Approved: false}, nil
+	}
+	password, err := ui.prompter.PromptPassword(fmt.Sprintf("Password for %s: ", request.Address.Hex()))
+	if err != nil {
+		return core.SignDataResponse{Approved: false}, err
+	}
+	return core.SignDataResponse{Approved: true, Password: password}, nil
+}
+
+// ApproveListing prompts the user for confirmation to list accounts, letting
+// them select which of the accounts the caller gets to see.
+func (ui *commandlineUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	fmt.Printf("-------- List account request ------------\n")
+	fmt.Printf("A request has been made to list all accounts.\n")
+	fmt.Printf("You can select which accounts the caller can see\n")
+	for _, account := range request.Accounts {
+		fmt.Printf("  %s\n", account.Address.Hex())
+		fmt.Printf("    URL: %s\n", account.URL)
+	}
+	fmt.Printf("------------------------------------------\n")
+	if !ui.confirm("Approve?") {
+		return core.ListResponse{}, nil
+	}
+	// An empty but non-nil selection approves the request without revealing anything
+	selected := make([]core.Account, 0, len(request.Accounts))
+	for _, account := range request.Accounts {
+		if ui.confirm(fmt.Sprintf("Show %s?", account.Address.Hex())) {
+			selected = append(selected, account)
+		}
+	}
+	return core.ListResponse{Accounts: selected}, nil
+}
+
+// ShowError displays an error message to the user.
+func (ui *commandlineUI) ShowError(message string) {
+	fmt.Printf("ERROR: %v\n", message)
+}
+
+// ShowInfo displays an informational message to the user.
+func (ui *commandlineUI) ShowInfo(message string) {
+	fmt.Printf("Info: %v\n", message)
+}
+
+// OnApprovedTx notifies the UI about a transaction having been successfully signed.
+func (ui *commandlineUI) OnApprovedTx(tx ethapi.SignTransactionResult) {
+	fmt.Printf("Transaction signed:\n %v\n", tx.Tx.Hash().Hex())
+}
+
+// OnSignerStartup is invoked once the signer is up and reachable.
+func (ui *commandlineUI) OnSignerStartup(info core.StartupInfo) {
+	fmt.Printf("------- Signer info -------\n")
+	for k, v := range info.Info {
+		fmt.Printf("* %v : %v\n", k, v)
+	}
+}
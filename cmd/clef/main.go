@@ -0,0 +1,367 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// clef is a standalone signer, holding the keys outside of the node and signing
+// the requests it receives after approval by the user or a rule file.
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/TeamEGEM/go-egem/accounts/keystore"
+	"github.com/TeamEGEM/go-egem/cmd/utils"
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/console"
+	"github.com/TeamEGEM/go-egem/crypto"
+	"github.com/TeamEGEM/go-egem/log"
+	"github.com/TeamEGEM/go-egem/node"
+	"github.com/TeamEGEM/go-egem/params"
+	"github.com/TeamEGEM/go-egem/rpc"
+	"github.com/TeamEGEM/go-egem/signer/core"
+	"github.com/TeamEGEM/go-egem/signer/rules"
+	"github.com/TeamEGEM/go-egem/signer/storage"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// Git SHA1 commit hash of the release (set via linker flags)
+var gitCommit = ""
+
+const (
+	// masterSeedFile is the name of the file within the config directory holding
+	// the master seed, encrypted with the user's password.
+	masterSeedFile = "masterseed.json"
+
+	// credentialsFile is the name of the file within the config directory holding
+	// the account passwords, encrypted with a key derived from the master seed.
+	credentialsFile = "credentials.json"
+)
+
+var (
+	logLevelFlag = cli.IntFlag{
+		Name:  "loglevel",
+		Value: 4,
+		Usage: "log level to emit to the screen",
+	}
+	keystoreFlag = cli.StringFlag{
+		Name:  "keystore",
+		Value: filepath.Join(node.DefaultDataDir(), "keystore"),
+		Usage: "Directory for the keystore",
+	}
+	chainIdFlag = cli.Int64Flag{
+		Name:  "chainid",
+		Value: params.MainnetChainConfig.ChainId.Int64(),
+		Usage: "Chain id to use for signing",
+	}
+	rpcPortFlag = cli.IntFlag{
+		Name:  "rpcport",
+		Usage: "HTTP-RPC server listening port",
+		Value: 8550,
+	}
+	ipcPathFlag = cli.StringFlag{
+		Name:  "ipcpath",
+		Usage: "Filename for IPC socket/pipe",
+		Value: node.DefaultIPCEndpoint("clef"),
+	}
+	ruleFlag = cli.StringFlag{
+		Name:  "rules",
+		Usage: "Javascript file with rules to approve requests automatically",
+	}
+	configdirFlag = cli.StringFlag{
+		Name:  "configdir",
+		Value: filepath.Join(node.DefaultDataDir(), "clef"),
+		Usage: "Directory for the master seed and the encrypted credential store",
+	}
+	auditLogFlag = cli.StringFlag{
+		Name:  "auditlog",
+		Usage: "File used to emit audit logs. Set to \"\" to disable",
+		Value: "audit.log",
+	}
+)
+
+var (
+	initCommand = cli.Command{
+		Action:    utils.MigrateFlags(initializeSecrets),
+		Name:      "init",
+		Usage:     "Initialize the master seed protecting the credential store",
+		ArgsUsage: "",
+		Flags: []cli.Flag{
+			logLevelFlag,
+			configdirFlag,
+			utils.LightKDFFlag,
+		},
+		Description: `
+The init command generates a random master seed and stores it in the config
+directory, encrypted with a password. The credential store, holding the account
+passwords used for requests approved by the rules, is encrypted with a key derived
+from the master seed.`,
+	}
+	setCredentialCommand = cli.Command{
+		Action:    utils.MigrateFlags(setCredential),
+		Name:      "setpw",
+		Usage:     "Store the password of an account in the credential store",
+		ArgsUsage: "<address>",
+		Flags: []cli.Flag{
+			logLevelFlag,
+			configdirFlag,
+		},
+		Description: `
+The setpw command stores the password of the given account in the encrypted
+credential store, to sign the requests approved by the rules with. Unlocking the
+store requires the password of the master seed created by init.`,
+	}
+)
+
+var app = cli.NewApp()
+
+func init() {
+	app.Name = "clef"
+	app.Usage = "Manage accounts and sign requests outside of the node"
+	app.Version = params.Version
+	if len(gitCommit) >= 8 {
+		app.Version += "-" + gitCommit[:8]
+	}
+	app.Flags = []cli.Flag{
+		logLevelFlag,
+		keystoreFlag,
+		chainIdFlag,
+		utils.LightKDFFlag,
+		utils.NoUSBFlag,
+		utils.RPCEnabledFlag,
+		utils.RPCListenAddrFlag,
+		rpcPortFlag,
+		utils.RPCCORSDomainFlag,
+		utils.RPCVirtualHostsFlag,
+		utils.IPCDisabledFlag,
+		ipcPathFlag,
+		ruleFlag,
+		configdirFlag,
+		auditLogFlag,
+	}
+	app.Action = signer
+	app.Commands = []cli.Command{
+		initCommand,
+		setCredentialCommand,
+	}
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func signer(c *cli.Context) error {
+	if args := c.Args(); len(args) > 0 {
+		return fmt.Errorf("invalid command: %q", args[0])
+	}
+	// Log to stderr, leaving stdout to the interactive UI
+	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(c.Int(logLevelFlag.Name)), log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
+
+	var ui core.SignerUI = newCommandlineUI()
+	if c.IsSet(ruleFlag.Name) {
+		ruleset, err := loadRules(c, ui)
+		if err != nil {
+			utils.Fatalf("Failed to load rules: %v", err)
+		}
+		ui = ruleset
+	}
+	am := core.NewAccountManager(c.String(keystoreFlag.Name), c.Bool(utils.NoUSBFlag.Name), c.Bool(utils.LightKDFFlag.Name))
+	defer am.Close()
+
+	var api core.ExternalAPI = core.NewSignerAPI(c.Int64(chainIdFlag.Name), am, ui)
+	if logfile := c.String(auditLogFlag.Name); logfile != "" {
+		audit, err := core.NewAuditLogger(logfile, api)
+		if err != nil {
+			utils.Fatalf("Failed to open audit log: %v", err)
+		}
+		log.Info("Audit logs configured", "file", logfile)
+		api = audit
+	}
+	// Register the signing API in the account namespace
+	handler := rpc.NewServer()
+	if err := handler.RegisterName("account", api); err != nil {
+		utils.Fatalf("Could not register API: %v", err)
+	}
+	info := map[string]interface{}{
+		"chainid": c.Int64(chainIdFlag.Name),
+	}
+	if c.Bool(utils.RPCEnabledFlag.Name) {
+		endpoint := fmt.Sprintf("%s:%d", c.String(utils.RPCListenAddrFlag.Name), c.Int(rpcPortFlag.Name))
+		listener, err := net.Listen("tcp", endpoint)
+		if err != nil {
+			utils.Fatalf("Could not start HTTP listener: %v", err)
+		}
+		defer listener.Close()
+
+		cors := splitAndTrim(c.String(utils.RPCCORSDomainFlag.Name))
+		vhosts := splitAndTrim(c.String(utils.RPCVirtualHostsFlag.Name))
+		go rpc.NewHTTPServer(cors, vhosts, handler).Serve(listener)
+
+		log.Info("HTTP endpoint opened", "url", fmt.Sprintf("http://%s", endpoint))
+		info["extapi_http"] = fmt.Sprintf("http://%s", endpoint)
+	}
+	if !c.Bool(utils.IPCDisabledFlag.Name) {
+		endpoint := c.String(ipcPathFlag.Name)
+		listener, err := rpc.CreateIPCListener(endpoint)
+		if err != nil {
+			utils.Fatalf("Could not start IPC listener: %v", err)
+		}
+		defer listener.Close()
+		go handler.ServeListener(listener)
+
+		log.Info("IPC endpoint opened", "url", endpoint)
+		info["extapi_ipc"] = endpoint
+	}
+	ui.OnSignerStartup(core.StartupInfo{Info: info})
+
+	abortChan := make(chan os.Signal, 1)
+	signal.Notify(abortChan, os.Interrupt)
+
+	sig := <-abortChan
+	log.Info("Exiting...", "signal", sig)
+	return nil
+}
+
+// loadRules creates a rule based UI from the rule file, deferring to next for
+// undecided requests.
+func loadRules(c *cli.Context, next core.SignerUI) (core.SignerUI, error) {
+	script, err := ioutil.ReadFile(c.String(ruleFlag.Name))
+	if err != nil {
+		return nil, err
+	}
+	// Unlock the credential store if set up, otherwise approved requests can only
+	// be signed after asking the user for the password
+	var credentials storage.Storage = storage.NewEphemeralStorage()
+
+	configDir := c.String(configdirFlag.Name)
+	if _, err := os.Stat(filepath.Join(configDir, masterSeedFile)); err == nil {
+		if credentials, err = openCredentials(configDir); err != nil {
+			return nil, err
+		}
+	} else {
+		log.Warn("No master seed, rules can't unlock accounts", "configdir", configDir, "hint", "clef init")
+	}
+	ruleset := rules.NewRuleEvaluator(next, credentials)
+	if err := ruleset.Init(string(script)); err != nil {
+		return nil, err
+	}
+	log.Info("Rules loaded", "file", c.String(ruleFlag.Name))
+	return ruleset, nil
+}
+
+// initializeSecrets generates the master seed and stores it in the config
+// directory, encrypted with a password from the user.
+func initializeSecrets(c *cli.Context) error {
+	configDir := c.String(configdirFlag.Name)
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return err
+	}
+	location := filepath.Join(configDir, masterSeedFile)
+	if _, err := os.Stat(location); err == nil {
+		return fmt.Errorf("master seed %s already exists", location)
+	}
+	seed := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, seed); err != nil {
+		return err
+	}
+	scryptN, scryptP := keystore.StandardScryptN, keystore.StandardScryptP
+	if c.Bool(utils.LightKDFFlag.Name) {
+		scryptN, scryptP = keystore.LightScryptN, keystore.LightScryptP
+	}
+	password := promptPassword("Password for the master seed: ", true)
+	blob, err := keystore.EncryptDataV3(seed, password, scryptN, scryptP)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(location, blob, 0400); err != nil {
+		return err
+	}
+	fmt.Printf("Master seed stored in %s\n", location)
+	return nil
+}
+
+// setCredential stores the password of an account in the credential store.
+func setCredential(c *cli.Context) error {
+	if len(c.Args()) != 1 || !common.IsHexAddress(c.Args().First()) {
+		return errors.New("a single account address is required")
+	}
+	address := common.HexToAddress(c.Args().First())
+
+	credentials, err := openCredentials(c.String(configdirFlag.Name))
+	if err != nil {
+		return err
+	}
+	password := promptPassword(fmt.Sprintf("Password for %s: ", address.Hex()), true)
+	if err := credentials.Put(address.Hex(), password); err != nil {
+		return err
+	}
+	fmt.Printf("Credentials stored for %s\n", address.Hex())
+	return nil
+}
+
+// openCredentials decrypts the master seed in the config directory with a
+// password from the user and opens the credential store protected by it.
+func openCredentials(configDir string) (storage.Storage, error) {
+	blob, err := ioutil.ReadFile(filepath.Join(configDir, masterSeedFile))
+	if err != nil {
+		return nil, err
+	}
+	seed, err := keystore.DecryptDataV3(blob, promptPassword("Password for the master seed: ", false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt master seed: %v", err)
+	}
+	key := crypto.Keccak256([]byte("credentials"), seed)
+	return storage.NewAESEncryptedStorage(filepath.Join(configDir, credentialsFile), key), nil
+}
+
+// promptPassword asks the user for a password, optionally twice to rule out
+// typos.
+func promptPassword(prompt string, confirmation bool) string {
+	password, err := console.Stdin.PromptPassword(prompt)
+	if err != nil {
+		utils.Fatalf("Failed to read password: %v", err)
+	}
+	if confirmation {
+		confirm, err := console.Stdin.PromptPassword("Repeat password: ")
+		if err != nil {
+			utils.Fatalf("Failed to read password confirmation: %v", err)
+		}
+		if password != confirm {
+			utils.Fatalf("Passwords do not match")
+		}
+	}
+	return password
+}
+
+// splitAndTrim splits input separated by a comma and trims excessive white
+// space from the substrings.
+func splitAndTrim(input string) []string {
+	result := strings.Split(input, ",")
+	for i, r := range result {
+		result[i] = strings.TrimSpace(r)
+	}
+	return result
+}
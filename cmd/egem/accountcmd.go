@@ -110,6 +110,8 @@ Print a short summary of all accounts`,
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					utils.MnemonicFlag,
+					utils.MnemonicPassphraseFlag,
 				},
 				Description: `
     geth account new
@@ -124,6 +126,13 @@ For non-interactive use the passphrase can be specified with the --password flag
 
 Note, this is meant to be used for testing only, it is a bad idea to save your
 password to file or expose in any other way.
+
+With the --mnemonic flag a hierarchical deterministic wallet is created instead,
+seeded from a newly generated 24 word BIP-39 mnemonic. The mnemonic is printed
+once and is the only backup of all accounts derived from the wallet, write it
+down and keep it safe. The wallet seed is stored encrypted with the passphrase.
+With --mnemonic.passphrase you are additionally prompted for a BIP-39 passphrase,
+which is needed along with the mnemonic to restore the wallet.
 `,
 			},
 			{
@@ -186,6 +195,36 @@ Note:
 As you can directly copy your encrypted accounts to another ethereum instance,
 this import mechanism is not needed when you transfer an account between
 nodes.
+`,
+			},
+			{
+				Name:   "import-mnemonic",
+				Usage:  "Import a BIP-39 mnemonic into a new HD wallet",
+				Action: utils.MigrateFlags(accountImportMnemonic),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+					utils.MnemonicPassphraseFlag,
+				},
+				ArgsUsage: "[<mnemonicFile>]",
+				Description: `
+    geth account import-mnemonic [<mnemonicfile>]
+
+Restores a hierarchical deterministic wallet from a BIP-39 mnemonic and prints
+the address of its first account. The mnemonic is read from <mnemonicfile> if
+given, otherwise you are prompted for it.
+
+If the mnemonic is protected by a BIP-39 passphrase, use --mnemonic.passphrase
+to be prompted for it.
+
+The wallet seed is saved in encrypted format, you are prompted for a passphrase.
+Accounts of the wallet are derived once it is opened with this passphrase.
+
+For non-interactive use the passphrase can be specified with the -password flag:
+
+    geth account import-mnemonic [options] <mnemonicfile>
 `,
 			},
 		},
@@ -307,6 +346,19 @@ func accountCreate(ctx *cli.Context) error {
 
 	password := getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
 
+	if ctx.Bool(utils.MnemonicFlag.Name) {
+		mnemonic, err := keystore.NewMnemonic(256)
+		if err != nil {
+			utils.Fatalf("Failed to generate mnemonic: %v", err)
+		}
+		account, err := keystore.StoreMnemonic(keydir, mnemonic, getMnemonicPassphrase(ctx, true), password, scryptN, scryptP)
+		if err != nil {
+			utils.Fatalf("Failed to create wallet: %v", err)
+		}
+		fmt.Printf("Address: {%x}\n", account.Address)
+		fmt.Printf("\nWrite down the mnemonic below, it is the only way to restore your wallet:\n\n%s\n\n", mnemonic)
+		return nil
+	}
 	address, err := keystore.StoreKey(keydir, password, scryptN, scryptP)
 
 	if err != nil {
@@ -357,6 +409,28 @@ func importWallet(ctx *cli.Context) error {
 	return nil
 }
 
+// getMnemonicPassphrase retrieves the optional BIP-39 passphrase protecting a
+// mnemonic, prompting the user for it if requested by the CLI flags.
+func getMnemonicPassphrase(ctx *cli.Context, confirmation bool) string {
+	if !ctx.Bool(utils.MnemonicPassphraseFlag.Name) {
+		return ""
+	}
+	passphrase, err := console.Stdin.PromptPassword("Mnemonic passphrase: ")
+	if err != nil {
+		utils.Fatalf("Failed to read mnemonic passphrase: %v", err)
+	}
+	if confirmation {
+		confirm, err := console.Stdin.PromptPassword("Repeat mnemonic passphrase: ")
+		if err != nil {
+			utils.Fatalf("Failed to read mnemonic passphrase confirmation: %v", err)
+		}
+		if passphrase != confirm {
+			utils.Fatalf("Mnemonic passphrases do not match")
+		}
+	}
+	return passphrase
+}
+
 func accountImport(ctx *cli.Context) error {
 	keyfile := ctx.Args().First()
 	if len(keyfile) == 0 {
@@ -377,3 +451,35 @@ func accountImport(ctx *cli.Context) error {
 	fmt.Printf("Address: {%x}\n", acct.Address)
 	return nil
 }
+
+func accountImportMnemonic(ctx *cli.Context) error {
+	var mnemonic string
+	if file := ctx.Args().First(); file != "" {
+		blob, err := ioutil.ReadFile(file)
+		if err != nil {
+			utils.Fatalf("Failed to read the mnemonic: %v", err)
+		}
+		mnemonic = string(blob)
+	} else {
+		input, err := console.Stdin.PromptPassword("Mnemonic: ")
+		if err != nil {
+			utils.Fatalf("Failed to read the mnemonic: %v", err)
+		}
+		mnemonic = input
+	}
+	if err := keystore.ValidateMnemonic(mnemonic); err != nil {
+		utils.Fatalf("Could not import the mnemonic: %v", err)
+	}
+	bip39Passphrase := getMnemonicPassphrase(ctx, false)
+
+	stack, _ := makeConfigNode(ctx)
+	passphrase := getPassPhrase("Your new wallet is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	acct, err := ks.ImportMnemonic(mnemonic, bip39Passphrase, passphrase)
+	if err != nil {
+		utils.Fatalf("Could not create the wallet: %v", err)
+	}
+	fmt.Printf("Address: {%x}\n", acct.Address)
+	return nil
+}
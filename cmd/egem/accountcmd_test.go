@@ -90,6 +90,47 @@ Fatal: Passphrases do not match
 `)
 }
 
+func TestAccountNewMnemonic(t *testing.T) {
+	geth := runGeth(t, "account", "new", "--lightkdf", "--mnemonic")
+	defer geth.ExpectExit()
+	geth.Expect(`
+Your new account is locked with a password. Please give a password. Do not forget this password.
+!! Unsupported terminal, password will be echoed.
+Passphrase: {{.InputLine "foobar"}}
+Repeat passphrase: {{.InputLine "foobar"}}
+`)
+	geth.ExpectRegexp(`Address: \{[0-9a-f]{40}\}\n\nWrite down the mnemonic below, it is the only way to restore your wallet:\n\n([a-z]+ ){23}[a-z]+\n`)
+
+	files, err := ioutil.ReadDir(filepath.Join(geth.Datadir, "keystore", "hd"))
+	if len(files) != 1 {
+		t.Errorf("expected one wallet file in HD directory, found %d files (error: %v)", len(files), err)
+	}
+}
+
+func TestAccountImportMnemonic(t *testing.T) {
+	geth := runGeth(t, "account", "import-mnemonic", "--lightkdf", "--mnemonic.passphrase")
+	defer geth.ExpectExit()
+	geth.Expect(`
+!! Unsupported terminal, password will be echoed.
+Mnemonic: {{.InputLine "test test test test test test test test test test test junk"}}
+Mnemonic passphrase: {{.InputLine "TREZOR"}}
+Your new wallet is locked with a password. Please give a password. Do not forget this password.
+Passphrase: {{.InputLine "foobar"}}
+Repeat passphrase: {{.InputLine "foobar"}}
+Address: {c362d517d4a6443187c4a3c7b1ad570cb55f88cb}
+`)
+}
+
+func TestAccountImportMnemonicInvalid(t *testing.T) {
+	geth := runGeth(t, "account", "import-mnemonic", "--lightkdf")
+	defer geth.ExpectExit()
+	geth.Expect(`
+!! Unsupported terminal, password will be echoed.
+Mnemonic: {{.InputLine "test test test test test test test test test test test test"}}
+Fatal: Could not import the mnemonic: invalid mnemonic
+`)
+}
+
 func TestAccountUpdate(t *testing.T) {
 	datadir := tmpDatadirWithKeystore(t)
 	geth := runGeth(t, "account", "update",
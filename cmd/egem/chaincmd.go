@@ -19,10 +19,13 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"os"
+	"os/signal"
 	"runtime"
 	"strconv"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/TeamEGEM/go-egem/cmd/utils"
@@ -31,12 +34,14 @@ import (
 	"github.com/TeamEGEM/go-egem/core"
 	"github.com/TeamEGEM/go-egem/core/state"
 	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/eth"
 	"github.com/TeamEGEM/go-egem/eth/downloader"
 	"github.com/TeamEGEM/go-egem/ethdb"
 	"github.com/TeamEGEM/go-egem/event"
 	"github.com/TeamEGEM/go-egem/log"
+	"github.com/TeamEGEM/go-egem/params"
 	"github.com/TeamEGEM/go-egem/trie"
-	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/olekukonko/tablewriter"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -141,6 +146,108 @@ Remove blockchain and state databases`,
 The arguments are interpreted as block numbers or hashes.
 Use "ethereum dump 0" to dump the genesis block.`,
 	}
+	supplyCommand = cli.Command{
+		Action:    utils.MigrateFlags(supply),
+		Name:      "supply",
+		Usage:     "Show the coin supply at a specific block",
+		ArgsUsage: "[<blockHash> | <blockNum>]",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			utils.TestnetFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The supply command reports the circulating coin supply at the given block (or
+the current head if omitted), split up into the genesis allocation, the block
+rewards, the uncle rewards and the dev-fund payouts. Blocks covered by the
+issuance index are reported instantly, others are summed up from the chain.`,
+	}
+	checkConfigCommand = cli.Command{
+		Action:    utils.MigrateFlags(checkConfig),
+		Name:      "checkconfig",
+		Usage:     "Validate the chain configuration and show its reward schedule",
+		ArgsUsage: "[<genesisPath>]",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.TestnetFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The checkconfig command validates the chain configuration of the given genesis
+file, or of the local database if omitted, and prints the effective reward
+schedule and difficulty forks. It fails if the reward eras are not ordered by
+block height, pay dev rewards to the zero address or increase the rewards over
+the previous era.`,
+	}
+	pruneStateCommand = cli.Command{
+		Action:    utils.MigrateFlags(pruneState),
+		Name:      "prune-state",
+		Usage:     "Delete historical state from the database",
+		ArgsUsage: " ",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			utils.StateRetentionFlag,
+			utils.TestnetFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The prune-state command deletes all the state trie nodes and contract codes from
+the database which are not reachable from the most recent persisted states (as
+set by --prune.recent) or the genesis state. The node must not be running.
+
+Pruning can be interrupted at any time; running the command again resumes the
+sweep where the previous run left off.`,
+	}
+	migrateAncientCommand = cli.Command{
+		Action:    utils.MigrateFlags(migrateAncient),
+		Name:      "migrate-ancient",
+		Usage:     "Move the immutable chain segment into the ancient store",
+		ArgsUsage: " ",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.AncientFlag,
+			utils.CacheFlag,
+			utils.FreezerThresholdFlag,
+			utils.TestnetFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The migrate-ancient command moves all the canonical headers, bodies, receipts and
+total difficulties older than --freezer.threshold blocks from the key-value store
+into the flat files of the ancient store. The node must not be running.
+
+A running node migrates an existing database in the background too; this command
+does the same in one go, followed by a compaction to release the disk space.`,
+	}
+	dbCommand = cli.Command{
+		Name:      "db",
+		Usage:     "Low level database operations",
+		ArgsUsage: "",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Name:      "inspect",
+				Usage:     "Inspect the storage size of each type of chain data",
+				ArgsUsage: " ",
+				Action:    utils.MigrateFlags(inspectDB),
+				Category:  "BLOCKCHAIN COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.CacheFlag,
+					utils.LightModeFlag,
+					utils.TestnetFlag,
+				},
+				Description: `
+The inspect command walks the entire chain database and prints the number of
+entries and their total size for every type of chain data, along with the tables
+of the ancient store. Entries not belonging to any known type are reported as
+unaccounted, listing their keys. The node must not be running.`,
+			},
+		},
+	}
 )
 
 // initGenesis will initialise the given JSON format genesis file and writes it as
@@ -161,6 +268,11 @@ func initGenesis(ctx *cli.Context) error {
 	if err := json.NewDecoder(file).Decode(genesis); err != nil {
 		utils.Fatalf("invalid genesis file: %v", err)
 	}
+	if genesis.Config != nil {
+		if err := genesis.Config.Validate(); err != nil {
+			utils.Fatalf("invalid chain config: %v", err)
+		}
+	}
 	// Open an initialise both full and light databases
 	stack := makeFullNode(ctx)
 	for _, name := range []string{"chaindata", "lightchaindata"} {
@@ -218,19 +330,7 @@ func importChain(ctx *cli.Context) error {
 	fmt.Printf("Import done in %v.\n\n", time.Since(start))
 
 	// Output pre-compaction stats mostly to see the import trashing
-	db := chainDb.(*ethdb.LDBDatabase)
-
-	stats, err := db.LDB().GetProperty("leveldb.stats")
-	if err != nil {
-		utils.Fatalf("Failed to read database stats: %v", err)
-	}
-	fmt.Println(stats)
-
-	ioStats, err := db.LDB().GetProperty("leveldb.iostats")
-	if err != nil {
-		utils.Fatalf("Failed to read database iostats: %v", err)
-	}
-	fmt.Println(ioStats)
+	printDatabaseStats(chainDb)
 
 	fmt.Printf("Trie cache misses:  %d\n", trie.CacheMisses())
 	fmt.Printf("Trie cache unloads: %d\n\n", trie.CacheUnloads())
@@ -251,24 +351,31 @@ func importChain(ctx *cli.Context) error {
 	// Compact the entire database to more accurately measure disk io and print the stats
 	start = time.Now()
 	fmt.Println("Compacting entire database...")
-	if err = db.LDB().CompactRange(util.Range{}); err != nil {
+	if err := chainDb.Compact(nil, nil); err != nil {
 		utils.Fatalf("Compaction failed: %v", err)
 	}
 	fmt.Printf("Compaction done in %v.\n\n", time.Since(start))
 
-	stats, err = db.LDB().GetProperty("leveldb.stats")
+	printDatabaseStats(chainDb)
+	return nil
+}
+
+// printDatabaseStats prints the general statistics of the chain database, and
+// the LevelDB io statistics if it's backed by LevelDB.
+func printDatabaseStats(db ethdb.Database) {
+	stats, err := db.Stat("")
 	if err != nil {
 		utils.Fatalf("Failed to read database stats: %v", err)
 	}
 	fmt.Println(stats)
 
-	ioStats, err = db.LDB().GetProperty("leveldb.iostats")
-	if err != nil {
-		utils.Fatalf("Failed to read database iostats: %v", err)
+	if _, ok := db.(*ethdb.LDBDatabase); ok {
+		ioStats, err := db.Stat("leveldb.iostats")
+		if err != nil {
+			utils.Fatalf("Failed to read database iostats: %v", err)
+		}
+		fmt.Println(ioStats)
 	}
-	fmt.Println(ioStats)
-
-	return nil
 }
 
 func exportChain(ctx *cli.Context) error {
@@ -325,7 +432,7 @@ func copyDb(ctx *cli.Context) error {
 		return err
 	}
 	peer := downloader.NewFakePeer("local", db, hc, dl)
-	if err = dl.RegisterPeer("local", 63, peer); err != nil {
+	if err = dl.RegisterPeer("local", 64, peer); err != nil {
 		return err
 	}
 	// Synchronise with the simulated peer
@@ -343,7 +450,7 @@ func copyDb(ctx *cli.Context) error {
 	// Compact the entire database to remove any sync overhead
 	start = time.Now()
 	fmt.Println("Compacting entire database...")
-	if err = chainDb.(*ethdb.LDBDatabase).LDB().CompactRange(util.Range{}); err != nil {
+	if err = chainDb.Compact(nil, nil); err != nil {
 		utils.Fatalf("Compaction failed: %v", err)
 	}
 	fmt.Printf("Compaction done in %v.\n\n", time.Since(start))
@@ -406,8 +513,258 @@ func dump(ctx *cli.Context) error {
 	return nil
 }
 
+// supply prints the cumulative coin issuance up to a given block.
+func supply(ctx *cli.Context) error {
+	if len(ctx.Args()) > 1 {
+		utils.Fatalf("This command requires at most one argument.")
+	}
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	if chain.Config().Ethash == nil {
+		utils.Fatalf("Chain has no ethash reward schedule")
+	}
+	block := chain.CurrentBlock()
+	if arg := ctx.Args().First(); arg != "" {
+		if hashish(arg) {
+			block = chain.GetBlockByHash(common.HexToHash(arg))
+		} else {
+			num, _ := strconv.ParseUint(arg, 10, 64)
+			block = chain.GetBlockByNumber(num)
+		}
+	}
+	if block == nil {
+		utils.Fatalf("block not found")
+	}
+	if canonical := chain.GetBlockByNumber(block.NumberU64()); canonical == nil || canonical.Hash() != block.Hash() {
+		utils.Fatalf("block %x is not canonical", block.Hash())
+	}
+	genesisState, err := state.New(chain.Genesis().Root(), state.NewDatabase(chainDb))
+	if err != nil {
+		utils.Fatalf("could not open genesis state: %v", err)
+	}
+	genesis, err := genesisState.TotalBalance()
+	if err != nil {
+		utils.Fatalf("could not sum genesis allocation: %v", err)
+	}
+	issuance, err := eth.GetCumulativeIssuance(chainDb, chain.Config(), block.NumberU64())
+	if err != nil {
+		utils.Fatalf("could not compute issuance: %v", err)
+	}
+	issued := issuance.Total()
+
+	fmt.Printf("Block:             #%d [%x]\n", block.NumberU64(), block.Hash())
+	fmt.Printf("Genesis:           %v\n", genesis)
+	fmt.Printf("Block rewards:     %v\n", issuance.Miner)
+	fmt.Printf("Uncle inclusion:   %v\n", issuance.UncleInclusion)
+	fmt.Printf("Uncle rewards:     %v\n", issuance.Uncles)
+	fmt.Printf("Dev funds:         %v\n", issuance.DevFunds)
+	fmt.Printf("Issued:            %v\n", issued)
+	fmt.Printf("Total supply:      %v\n", new(big.Int).Add(genesis, issued))
+	return nil
+}
+
+// checkConfig validates the chain configuration of a genesis file or of the local
+// database and prints the effective reward schedule.
+func checkConfig(ctx *cli.Context) error {
+	if len(ctx.Args()) > 1 {
+		utils.Fatalf("This command requires at most one argument.")
+	}
+	var config *params.ChainConfig
+	if genesisPath := ctx.Args().First(); genesisPath != "" {
+		file, err := os.Open(genesisPath)
+		if err != nil {
+			utils.Fatalf("Failed to read genesis file: %v", err)
+		}
+		defer file.Close()
+
+		genesis := new(core.Genesis)
+		if err := json.NewDecoder(file).Decode(genesis); err != nil {
+			utils.Fatalf("invalid genesis file: %v", err)
+		}
+		if config = genesis.Config; config == nil {
+			utils.Fatalf("Genesis file has no chain config")
+		}
+	} else {
+		stack := makeFullNode(ctx)
+		chainDb := utils.MakeChainDatabase(ctx, stack)
+		defer chainDb.Close()
+
+		// Use the stored config if the database was initialized, the network default otherwise
+		if stored := core.GetCanonicalHash(chainDb, 0); stored != (common.Hash{}) {
+			config, _ = core.GetChainConfig(chainDb, stored)
+		}
+		if config == nil {
+			if genesis := utils.MakeGenesis(ctx); genesis != nil {
+				config = genesis.Config
+			} else {
+				config = params.MainnetChainConfig
+			}
+		}
+	}
+	fmt.Printf("Chain config: %v\n", config)
+
+	if config.Ethash != nil {
+		fmt.Println("\nReward schedule:")
+		for i, era := range config.Ethash.RewardEras() {
+			fmt.Printf("  Era %d from block #%v\n", i, era.Block)
+			fmt.Printf("    Block reward:     %v\n", era.BlockReward)
+			if era.Uncles.InclusionDivisor != nil {
+				fmt.Printf("    Uncle inclusion:  1/%v of the reward per uncle\n", era.Uncles.InclusionDivisor)
+			} else {
+				fmt.Printf("    Uncle inclusion:  none\n")
+			}
+			fmt.Printf("    Uncle rewards:    %v\n", era.Uncles.RewardUncles)
+			for _, dev := range era.DevRewards {
+				fmt.Printf("    Dev reward:       %v to %x\n", dev.Amount, dev.Address)
+			}
+		}
+		if len(config.Ethash.Difficulty) > 0 {
+			fmt.Println("\nDifficulty forks:")
+			for _, fork := range config.Ethash.Difficulty {
+				fmt.Printf("  Block #%v: %s (target %ds, window %d, half-life %ds)\n", fork.Block, fork.Algorithm, fork.TargetTime, fork.Window, fork.HalfLife)
+			}
+		}
+	}
+	if err := config.Validate(); err != nil {
+		utils.Fatalf("Invalid chain config: %v", err)
+	}
+	fmt.Println("\nChain config is valid")
+	return nil
+}
+
+// pruneState deletes all the historical state from an offline database, apart
+// from the most recent persisted states and the genesis state.
+func pruneState(ctx *cli.Context) error {
+	recent := ctx.Uint64(utils.StateRetentionFlag.Name)
+	if recent == 0 {
+		utils.Fatalf("--%s must retain at least one state", utils.StateRetentionFlag.Name)
+	}
+	stack := makeFullNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	if state.PruneInterrupted(chainDb) {
+		log.Info("Found interrupted state pruning, resuming")
+	}
+	// Abort the pruning gracefully on user interrupt
+	abort := make(chan struct{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during pruning, stopping at next batch")
+			close(abort)
+		}
+	}()
+	start := time.Now()
+	if err := core.PruneState(chainDb, recent, abort); err != nil {
+		utils.Fatalf("State pruning failed: %v", err)
+	}
+	fmt.Printf("State pruning done in %v.\n", time.Since(start))
+
+	// Compact the database to actually release the freed disk space
+	start = time.Now()
+	fmt.Println("Compacting entire database...")
+	if err := chainDb.Compact(nil, nil); err != nil {
+		utils.Fatalf("Compaction failed: %v", err)
+	}
+	fmt.Printf("Compaction done in %v.\n", time.Since(start))
+	return nil
+}
+
+func migrateAncient(ctx *cli.Context) error {
+	threshold := ctx.Uint64(utils.FreezerThresholdFlag.Name)
+	if threshold < core.MinFreezerThreshold {
+		utils.Fatalf("--%s must be at least %d", utils.FreezerThresholdFlag.Name, core.MinFreezerThreshold)
+	}
+	stack := makeFullNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	// Abort the migration gracefully on user interrupt
+	abort := make(chan struct{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during migration, stopping at next batch")
+			close(abort)
+		}
+	}()
+	start := time.Now()
+	frozen, err := core.FreezeAncients(chainDb, threshold, abort)
+	if err != nil {
+		utils.Fatalf("Ancient migration failed: %v", err)
+	}
+	fmt.Printf("Moved %d blocks into the ancient store in %v.\n", frozen, time.Since(start))
+
+	// Compact the database to actually release the freed disk space
+	if frozen > 0 {
+		start = time.Now()
+		fmt.Println("Compacting entire database...")
+		if err := chainDb.Compact(nil, nil); err != nil {
+			utils.Fatalf("Compaction failed: %v", err)
+		}
+		fmt.Printf("Compaction done in %v.\n", time.Since(start))
+	}
+	return nil
+}
+
 // hashish returns true for strings that look like hashes.
 func hashish(x string) bool {
 	_, err := strconv.Atoi(x)
 	return err != nil
 }
+
+func inspectDB(ctx *cli.Context) error {
+	stack := makeFullNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	// Abort the inspection gracefully on user interrupt
+	abort := make(chan struct{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during inspection, stopping")
+			close(abort)
+		}
+	}()
+	result, err := core.InspectDatabase(chainDb, abort)
+	if err != nil {
+		utils.Fatalf("Database inspection failed: %v", err)
+	}
+	var (
+		rows  [][]string
+		count uint64
+		total common.StorageSize
+	)
+	for _, stat := range append(result.Categories, &result.Unaccounted) {
+		rows = append(rows, []string{"Key-Value store", stat.Name, strconv.FormatUint(stat.Count, 10), stat.Size.String()})
+		count, total = count+stat.Count, total+stat.Size
+	}
+	for _, stat := range result.Ancients {
+		rows = append(rows, []string{"Ancient store", stat.Name, strconv.FormatUint(stat.Count, 10), stat.Size.String()})
+		total += stat.Size
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeader([]string{"Database", "Category", "Items", "Size"})
+	table.SetFooter([]string{"", "Total", strconv.FormatUint(count, 10), total.String()})
+	table.AppendBulk(rows)
+	table.Render()
+
+	if len(result.UnaccountedKeys) > 0 {
+		fmt.Printf("Unaccounted keys (%d of %d):\n", len(result.UnaccountedKeys), result.Unaccounted.Count)
+		for _, key := range result.UnaccountedKeys {
+			fmt.Printf("  %#x\n", key)
+		}
+	}
+	return nil
+}
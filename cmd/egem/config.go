@@ -30,6 +30,7 @@ import (
 	"github.com/TeamEGEM/go-egem/cmd/utils"
 	"github.com/TeamEGEM/go-egem/dashboard"
 	"github.com/TeamEGEM/go-egem/eth"
+	"github.com/TeamEGEM/go-egem/graphql"
 	"github.com/TeamEGEM/go-egem/node"
 	"github.com/TeamEGEM/go-egem/params"
 	whisper "github.com/TeamEGEM/go-egem/whisper/whisperv5"
@@ -80,6 +81,7 @@ type gethConfig struct {
 	Node      node.Config
 	Ethstats  ethstatsConfig
 	Dashboard dashboard.Config
+	GraphQL   graphql.Config
 }
 
 func loadConfig(file string, cfg *gethConfig) error {
@@ -101,8 +103,8 @@ func defaultNodeConfig() node.Config {
 	cfg := node.DefaultConfig
 	cfg.Name = clientIdentifier
 	cfg.Version = params.VersionWithCommit(gitCommit)
-	cfg.HTTPModules = append(cfg.HTTPModules, "eth", "shh")
-	cfg.WSModules = append(cfg.WSModules, "eth", "shh")
+	cfg.HTTPModules = append(cfg.HTTPModules, "eth", "egem", "shh")
+	cfg.WSModules = append(cfg.WSModules, "eth", "egem", "shh")
 	cfg.IPCPath = "egem.ipc"
 	return cfg
 }
@@ -114,6 +116,7 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 		Shh:       whisper.DefaultConfig,
 		Node:      defaultNodeConfig(),
 		Dashboard: dashboard.DefaultConfig,
+		GraphQL:   graphql.DefaultConfig,
 	}
 
 	// Load config file.
@@ -136,6 +139,7 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 
 	utils.SetShhConfig(ctx, stack, &cfg.Shh)
 	utils.SetDashboardConfig(ctx, &cfg.Dashboard)
+	utils.SetGraphQLConfig(ctx, &cfg.GraphQL)
 
 	return stack, cfg
 }
@@ -171,6 +175,10 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 		utils.RegisterShhService(stack, &cfg.Shh)
 	}
 
+	// Add the GraphQL server if requested.
+	if ctx.GlobalBool(utils.GraphQLEnabledFlag.Name) {
+		utils.RegisterGraphQLService(stack, &cfg.GraphQL)
+	}
 	// Add the Ethereum Stats daemon if requested.
 	if cfg.Ethstats.URL != "" {
 		utils.RegisterEthStatsService(stack, cfg.Ethstats.URL)
@@ -0,0 +1,112 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/TeamEGEM/go-egem/cmd/utils"
+	"github.com/TeamEGEM/go-egem/eth"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	devfundFromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "First block to audit",
+	}
+	devfundToFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "Last block to audit (default = current head)",
+	}
+	devfundCommand = cli.Command{
+		Name:      "devfund",
+		Usage:     "Audit the dev-fund payouts",
+		ArgsUsage: "",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+Inspect the dev-fund payouts of the ethash reward schedule.`,
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(devfundReport),
+				Name:      "report",
+				Usage:     "Report the dev-fund credits of a block range",
+				ArgsUsage: " ",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.TestnetFlag,
+					devfundFromFlag,
+					devfundToFlag,
+				},
+				Description: `
+    egem devfund report --from <block> --to <block>
+
+Walks the canonical chain over the given range and reports the credits paid to
+every dev-fund address per reward era. The credits are checked against the
+balance changes of the addresses over the range, which requires the states of
+the blocks around the range to be available (e.g. an archive node). Balance
+changes caused by transactions and mining are accounted for, the remaining
+difference is reported as unexplained.`,
+			},
+		},
+	}
+)
+
+// devfundReport audits the dev-fund payouts of a block range.
+func devfundReport(ctx *cli.Context) error {
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	to := chain.CurrentBlock().NumberU64()
+	if ctx.IsSet(devfundToFlag.Name) {
+		to = ctx.Uint64(devfundToFlag.Name)
+	}
+	report, err := eth.AuditDevFunds(chain, ctx.Uint64(devfundFromFlag.Name), to)
+	if err != nil {
+		utils.Fatalf("Dev-fund audit failed: %v", err)
+	}
+	fmt.Printf("Dev-fund payouts of blocks #%d - #%d\n", report.From, report.To)
+	for _, era := range report.Eras {
+		fmt.Printf("\nEra %d (blocks #%d - #%d):\n", era.Era, era.From, era.To)
+		for _, credit := range era.Credits {
+			fmt.Printf("  %x  %8d blocks  %v\n", credit.Address, credit.Blocks, (*big.Int)(credit.Amount))
+		}
+	}
+	fmt.Println("\nBalance reconciliation:")
+	for _, account := range report.Accounts {
+		fmt.Printf("  %x\n", account.Address)
+		fmt.Printf("    Credited:     %v\n", (*big.Int)(account.Credited))
+		fmt.Printf("    Received:     %v\n", (*big.Int)(account.Received))
+		fmt.Printf("    Sent:         %v\n", (*big.Int)(account.Sent))
+		fmt.Printf("    Mined:        %v\n", (*big.Int)(account.Mined))
+		if account.Unexplained == nil {
+			fmt.Printf("    Balances:     state unavailable\n")
+			continue
+		}
+		fmt.Printf("    Balance:      %v -> %v\n", (*big.Int)(account.Before), (*big.Int)(account.After))
+		fmt.Printf("    Unexplained:  %v\n", (*big.Int)(account.Unexplained))
+		if account.Consistent {
+			fmt.Printf("    Status:       OK\n")
+		} else {
+			fmt.Printf("    Status:       MISMATCH\n")
+		}
+	}
+	return nil
+}
@@ -19,6 +19,7 @@ package utils
 
 import (
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -45,9 +46,11 @@ import (
 	"github.com/TeamEGEM/go-egem/eth/gasprice"
 	"github.com/TeamEGEM/go-egem/ethdb"
 	"github.com/TeamEGEM/go-egem/ethstats"
+	"github.com/TeamEGEM/go-egem/graphql"
 	"github.com/TeamEGEM/go-egem/les"
 	"github.com/TeamEGEM/go-egem/log"
 	"github.com/TeamEGEM/go-egem/metrics"
+	"github.com/TeamEGEM/go-egem/metrics/exp"
 	"github.com/TeamEGEM/go-egem/node"
 	"github.com/TeamEGEM/go-egem/p2p"
 	"github.com/TeamEGEM/go-egem/p2p/discover"
@@ -118,6 +121,10 @@ var (
 		Usage: "Data directory for the databases and keystore",
 		Value: DirectoryString{node.DefaultDataDir()},
 	}
+	AncientFlag = DirectoryFlag{
+		Name:  "datadir.ancient",
+		Usage: "Directory for the ancient chain data (default = inside the chaindata)",
+	}
 	KeyStoreDirFlag = DirectoryFlag{
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
@@ -126,6 +133,10 @@ var (
 		Name:  "nousb",
 		Usage: "Disables monitoring for and managing USB hardware wallets",
 	}
+	ExternalSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "External signer (IPC path or HTTP url) to forward transaction signing to",
+	}
 	NetworkIdFlag = cli.Uint64Flag{
 		Name:  "networkid",
 		Usage: "Network identifier (integer, 1=Frontier, 2=Morden (disused), 3=Ropsten, 4=Rinkeby)",
@@ -167,7 +178,7 @@ var (
 	defaultSyncMode = eth.DefaultConfig.SyncMode
 	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
-		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
+		Usage: `Blockchain sync mode ("fast", "full", "snap", or "light")`,
 		Value: &defaultSyncMode,
 	}
 	GCModeFlag = cli.StringFlag{
@@ -175,6 +186,20 @@ var (
 		Usage: `Blockchain garbage collection mode ("full", "archive")`,
 		Value: "full",
 	}
+	StatePruningFlag = cli.BoolFlag{
+		Name:  "prune.online",
+		Usage: "Periodically delete historical state from disk while running (full gcmode only)",
+	}
+	StateRetentionFlag = cli.Uint64Flag{
+		Name:  "prune.recent",
+		Usage: "Number of recent persisted states to retain when pruning",
+		Value: eth.DefaultConfig.StateRetention,
+	}
+	FreezerThresholdFlag = cli.Uint64Flag{
+		Name:  "freezer.threshold",
+		Usage: "Number of recent blocks kept out of the ancient store (0 = no freezing)",
+		Value: eth.DefaultConfig.FreezerThreshold,
+	}
 	LightServFlag = cli.IntFlag{
 		Name:  "lightserv",
 		Usage: "Maximum percentage of time allowed for serving LES requests (0-90)",
@@ -254,6 +279,16 @@ var (
 		Usage: "Time interval to regenerate the local transaction journal",
 		Value: core.DefaultTxPoolConfig.Rejournal,
 	}
+	TxPoolMempoolFlag = cli.StringFlag{
+		Name:  "txpool.mempool",
+		Usage: "Disk snapshot of remote transactions to survive node restarts (empty = disabled)",
+		Value: core.DefaultTxPoolConfig.Mempool,
+	}
+	TxPoolMempoolSizeFlag = cli.Uint64Flag{
+		Name:  "txpool.mempoolsize",
+		Usage: "Maximum number of transactions to store in the mempool snapshot",
+		Value: core.DefaultTxPoolConfig.MempoolSize,
+	}
 	TxPoolPriceLimitFlag = cli.Uint64Flag{
 		Name:  "txpool.pricelimit",
 		Usage: "Minimum gas price limit to enforce for acceptance into the pool",
@@ -339,6 +374,19 @@ var (
 		Name:  "extradata",
 		Usage: "Block extra data set by the miner (default = client version)",
 	}
+	MinerNotifyFlag = cli.StringFlag{
+		Name:  "miner.notify",
+		Usage: "Comma separated HTTP URL list to post new work packages to (requires --mine)",
+	}
+	StratumAddrFlag = cli.StringFlag{
+		Name:  "stratum.addr",
+		Usage: "Stratum mining server listening address, serving the sealing work to pooled miners (requires --mine)",
+	}
+	StratumDifficultyFlag = cli.Uint64Flag{
+		Name:  "stratum.difficulty",
+		Usage: "Default Stratum share difficulty in hashes",
+		Value: eth.DefaultConfig.Stratum.Difficulty,
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -350,6 +398,14 @@ var (
 		Usage: "Password file to use for non-interactive password input",
 		Value: "",
 	}
+	MnemonicFlag = cli.BoolFlag{
+		Name:  "mnemonic",
+		Usage: "Create an HD wallet from a new BIP-39 mnemonic instead of a single key",
+	}
+	MnemonicPassphraseFlag = cli.BoolFlag{
+		Name:  "mnemonic.passphrase",
+		Usage: "Prompt for an optional BIP-39 passphrase protecting the mnemonic",
+	}
 
 	VMEnableDebugFlag = cli.BoolFlag{
 		Name:  "vmdebug",
@@ -364,6 +420,16 @@ var (
 		Name:  metrics.MetricsEnabledFlag,
 		Usage: "Enable metrics collection and reporting",
 	}
+	MetricsHTTPFlag = cli.StringFlag{
+		Name:  "metrics.addr",
+		Usage: "Enable stand-alone metrics HTTP server listening interface",
+		Value: "",
+	}
+	MetricsPortFlag = cli.IntFlag{
+		Name:  "metrics.port",
+		Usage: "Metrics HTTP server listening port",
+		Value: 6061,
+	}
 	FakePoWFlag = cli.BoolFlag{
 		Name:  "fakepow",
 		Usage: "Disables proof-of-work verification",
@@ -402,6 +468,30 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: "",
 	}
+	GraphQLEnabledFlag = cli.BoolFlag{
+		Name:  "graphql",
+		Usage: "Enable the GraphQL server",
+	}
+	GraphQLListenAddrFlag = cli.StringFlag{
+		Name:  "graphql.addr",
+		Usage: "GraphQL server listening interface",
+		Value: graphql.DefaultConfig.Host,
+	}
+	GraphQLPortFlag = cli.IntFlag{
+		Name:  "graphql.port",
+		Usage: "GraphQL server listening port",
+		Value: graphql.DefaultConfig.Port,
+	}
+	GraphQLCORSDomainFlag = cli.StringFlag{
+		Name:  "graphql.corsdomain",
+		Usage: "Comma separated list of domains from which to accept cross origin requests (browser enforced)",
+		Value: "",
+	}
+	GraphQLVirtualHostsFlag = cli.StringFlag{
+		Name:  "graphql.vhosts",
+		Usage: "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.",
+		Value: strings.Join(graphql.DefaultConfig.VirtualHosts, ","),
+	}
 	IPCDisabledFlag = cli.BoolFlag{
 		Name:  "ipcdisable",
 		Usage: "Disable the IPC-RPC server",
@@ -896,6 +986,9 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(NoUSBFlag.Name) {
 		cfg.NoUSB = ctx.GlobalBool(NoUSBFlag.Name)
 	}
+	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
+		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
+	}
 }
 
 func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
@@ -917,6 +1010,12 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.GlobalDuration(TxPoolRejournalFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolMempoolFlag.Name) {
+		cfg.Mempool = ctx.GlobalString(TxPoolMempoolFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolMempoolSizeFlag.Name) {
+		cfg.MempoolSize = ctx.GlobalUint64(TxPoolMempoolSizeFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.GlobalUint64(TxPoolPriceLimitFlag.Name)
 	}
@@ -1046,11 +1145,33 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	}
 	cfg.DatabaseHandles = makeDatabaseHandles()
 
+	if ctx.GlobalIsSet(AncientFlag.Name) {
+		cfg.DatabaseFreezer = ctx.GlobalString(AncientFlag.Name)
+	}
+	if ctx.GlobalIsSet(FreezerThresholdFlag.Name) {
+		cfg.FreezerThreshold = ctx.GlobalUint64(FreezerThresholdFlag.Name)
+	}
+	if cfg.FreezerThreshold > 0 && cfg.FreezerThreshold < core.MinFreezerThreshold {
+		Fatalf("--%s must be 0 or at least %d", FreezerThresholdFlag.Name, core.MinFreezerThreshold)
+	}
+
 	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
 	}
 	cfg.NoPruning = ctx.GlobalString(GCModeFlag.Name) == "archive"
 
+	if ctx.GlobalBool(StatePruningFlag.Name) {
+		if cfg.NoPruning {
+			Fatalf("--%s is incompatible with --%s=archive", StatePruningFlag.Name, GCModeFlag.Name)
+		}
+		cfg.StatePruning = true
+	}
+	if ctx.GlobalIsSet(StateRetentionFlag.Name) {
+		cfg.StateRetention = ctx.GlobalUint64(StateRetentionFlag.Name)
+	}
+	if cfg.StateRetention == 0 {
+		Fatalf("--%s must retain at least one state", StateRetentionFlag.Name)
+	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
@@ -1066,6 +1187,15 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(GasPriceFlag.Name) {
 		cfg.GasPrice = GlobalBig(ctx, GasPriceFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerNotifyFlag.Name) {
+		cfg.MinerNotify = strings.Split(ctx.GlobalString(MinerNotifyFlag.Name), ",")
+	}
+	if ctx.GlobalIsSet(StratumAddrFlag.Name) {
+		cfg.Stratum.Addr = ctx.GlobalString(StratumAddrFlag.Name)
+	}
+	if ctx.GlobalIsSet(StratumDifficultyFlag.Name) {
+		cfg.Stratum.Difficulty = ctx.GlobalUint64(StratumDifficultyFlag.Name)
+	}
 	if ctx.GlobalIsSet(VMEnableDebugFlag.Name) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
@@ -1120,6 +1250,22 @@ func SetDashboardConfig(ctx *cli.Context, cfg *dashboard.Config) {
 	cfg.Refresh = ctx.GlobalDuration(DashboardRefreshFlag.Name)
 }
 
+// SetGraphQLConfig applies GraphQL related command line flags to the config.
+func SetGraphQLConfig(ctx *cli.Context, cfg *graphql.Config) {
+	if ctx.GlobalIsSet(GraphQLListenAddrFlag.Name) {
+		cfg.Host = ctx.GlobalString(GraphQLListenAddrFlag.Name)
+	}
+	if ctx.GlobalIsSet(GraphQLPortFlag.Name) {
+		cfg.Port = ctx.GlobalInt(GraphQLPortFlag.Name)
+	}
+	if ctx.GlobalIsSet(GraphQLCORSDomainFlag.Name) {
+		cfg.Cors = splitAndTrim(ctx.GlobalString(GraphQLCORSDomainFlag.Name))
+	}
+	if ctx.GlobalIsSet(GraphQLVirtualHostsFlag.Name) {
+		cfg.VirtualHosts = splitAndTrim(ctx.GlobalString(GraphQLVirtualHostsFlag.Name))
+	}
+}
+
 // RegisterEthService adds an Ethereum client to the stack.
 func RegisterEthService(stack *node.Node, cfg *eth.Config) {
 	var err error
@@ -1149,6 +1295,25 @@ func RegisterDashboardService(stack *node.Node, cfg *dashboard.Config, commit st
 	})
 }
 
+// RegisterGraphQLService adds a GraphQL server on top of the Ethereum or light
+// client service of the stack.
+func RegisterGraphQLService(stack *node.Node, cfg *graphql.Config) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		// Retrieve either the eth or the les service
+		var ethServ *eth.Ethereum
+		if err := ctx.Service(&ethServ); err == nil {
+			return graphql.New(cfg, ethServ.ApiBackend)
+		}
+		var lesServ *les.LightEthereum
+		if err := ctx.Service(&lesServ); err == nil {
+			return graphql.New(cfg, lesServ.ApiBackend)
+		}
+		return nil, errors.New("no Ethereum service")
+	}); err != nil {
+		Fatalf("Failed to register the GraphQL service: %v", err)
+	}
+}
+
 // RegisterShhService configures Whisper and adds it to the given node.
 func RegisterShhService(stack *node.Node, cfg *whisper.Config) {
 	if err := stack.Register(func(n *node.ServiceContext) (node.Service, error) {
@@ -1175,6 +1340,19 @@ func RegisterEthStatsService(stack *node.Node, url string) {
 	}
 }
 
+// SetupMetrics starts the stand-alone metrics HTTP server if it was requested,
+// serving the collected metrics in expvar and Prometheus formats.
+func SetupMetrics(ctx *cli.Context) {
+	if !ctx.GlobalIsSet(MetricsHTTPFlag.Name) {
+		return
+	}
+	if !metrics.Enabled {
+		log.Warn("Metrics HTTP server requested without metrics collection", "flag", "--"+MetricsEnabledFlag.Name)
+	}
+	address := fmt.Sprintf("%s:%d", ctx.GlobalString(MetricsHTTPFlag.Name), ctx.GlobalInt(MetricsPortFlag.Name))
+	exp.Setup(address)
+}
+
 // SetupNetwork configures the system for either the main net or some test network.
 func SetupNetwork(ctx *cli.Context) {
 	// TODO(fjl): move target gas limit into config
@@ -1187,11 +1365,14 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node) ethdb.Database {
 		cache   = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheDatabaseFlag.Name) / 100
 		handles = makeDatabaseHandles()
 	)
-	name := "chaindata"
+	name, freezer := "chaindata", eth.DefaultConfig.DatabaseFreezer
+	if ctx.GlobalIsSet(AncientFlag.Name) {
+		freezer = ctx.GlobalString(AncientFlag.Name)
+	}
 	if ctx.GlobalBool(LightModeFlag.Name) {
-		name = "lightchaindata"
+		name, freezer = "lightchaindata", ""
 	}
-	chainDb, err := stack.OpenDatabase(name, cache, handles)
+	chainDb, err := stack.OpenDatabaseWithFreezer(name, cache, handles, freezer)
 	if err != nil {
 		Fatalf("Could not open database: %v", err)
 	}
@@ -250,6 +250,43 @@ func BenchmarkUnmarshalUint64(b *testing.B) {
 	}
 }
 
+func TestUnmarshalGraphQL(t *testing.T) {
+	tests := []struct {
+		input   interface{}
+		want    uint64
+		wantErr bool
+	}{
+		{input: "0x2a", want: 42},
+		{input: "42", want: 42},
+		{input: int32(42), want: 42},
+		{input: "18446744073709551615", want: 18446744073709551615},
+		{input: int32(-1), wantErr: true},
+		{input: "-1", wantErr: true},
+		{input: "0xx", wantErr: true},
+		{input: 42.0, wantErr: true},
+	}
+	for _, test := range tests {
+		var u Uint64
+		err := u.UnmarshalGraphQL(test.input)
+		if (err != nil) != test.wantErr {
+			t.Errorf("input %v: error mismatch: have %v, want error %v", test.input, err, test.wantErr)
+			continue
+		}
+		if err == nil && uint64(u) != test.want {
+			t.Errorf("input %v: Uint64 mismatch: have %d, want %d", test.input, u, test.want)
+		}
+		var b Big
+		err = b.UnmarshalGraphQL(test.input)
+		if (err != nil) != test.wantErr {
+			t.Errorf("input %v: error mismatch: have %v, want error %v", test.input, err, test.wantErr)
+			continue
+		}
+		if err == nil && b.ToInt().Uint64() != test.want {
+			t.Errorf("input %v: Big mismatch: have %v, want %d", test.input, b.ToInt(), test.want)
+		}
+	}
+}
+
 func TestMarshalUint64(t *testing.T) {
 	for _, test := range encodeUint64Tests {
 		in := test.input.(uint64)
@@ -42,45 +42,6 @@ var (
 	ByzantiumBlockReward   					*big.Int = big.NewInt(3e+18) // Not used will be removed in furture EGEM update.
 )
 
-//  EGEM Variables
-var (
-	egem0BlockReward                *big.Int = big.NewInt(8e+18)              //  8 EGEM Block reward in wei for successfully mining a block.     (ERA0)
-	egem1BlockReward                *big.Int = big.NewInt(4e+18)              //  4 EGEM Block reward in wei for successfully mining a block.     (ERA1)
-	egem2BlockReward                *big.Int = big.NewInt(2e+18)              //  2 EGEM Block reward in wei for successfully mining a block.     (ERA2)
-	egem3BlockReward                *big.Int = big.NewInt(1e+18)              //  1 EGEM Block reward in wei for successfully mining a block.     (ERA3)
-	egem4BlockReward                *big.Int = big.NewInt(500000000000000000) //  0.5 EGEM Block reward in wei for successfully mining a block.   (ERA4)
-	egem5BlockReward                *big.Int = big.NewInt(250000000000000000) //  0.25 EGEM Block reward in wei for successfully mining a block.  (ERA5)
-	egem6BlockReward                *big.Int = big.NewInt(125000000000000000) //  0.125 EGEM Block reward in wei for successfully mining a block. (ERA6)
-	egem0DevReward                  *big.Int = big.NewInt(250000000000000000) //  Era0 1  EGEM per block.
-  egem1DevReward								  *big.Int = big.NewInt(187500000000000000) //  Era1 0.75 EGEM per block.
-	egem2DevReward                  *big.Int = big.NewInt(125000000000000000) //  Era2 0.5 EGEM per block.
-	egem3DevReward								  *big.Int = big.NewInt(62500000000000000)  //  Era3 0.25 EGEM per block
-	egem4DevReward                  *big.Int = big.NewInt(25000000000000000)  //  Era4 0.1 EGEM per block.
-	egem5DevReward                  *big.Int = big.NewInt(12500000000000000)  //  Era5 0.05 EGEM per block.
-	egem6DevReward                  *big.Int = big.NewInt(6250000000000000)   //  Era6 0.025 EGEM per block.
-	egemRewardSwitchBlockEra0       *big.Int = big.NewInt(5000)               //  5K Block transition
-	egemRewardSwitchBlockEra1			  *big.Int = big.NewInt(2500000)            //  2.5M block transition
-	egemRewardSwitchBlockEra2       *big.Int = big.NewInt(5000000)            //  5M block transition
-	egemRewardSwitchBlockEra3       *big.Int = big.NewInt(7500000)            //  7.5M block transition
-	egemRewardSwitchBlockEra4       *big.Int = big.NewInt(10000000)           //  10M block transtiton
-	egemRewardSwitchBlockEra5       *big.Int = big.NewInt(12500000)           //  12.5M block transtiton
-	egemRewardSwitchBlockEra6       *big.Int = big.NewInt(15000000)           //  15M block transtiton
-	devFund0 												= common.HexToAddress("0x3fa6576610cac6c68e88ee68de07b104c9524fda") // invalid as of block 350k
-	devFund1 												= common.HexToAddress("0xfc0f0a5F06cB00c9EB435127142ac79ac6F48B94") // invalid as of block 350k
-	devFund2												= common.HexToAddress("0x0666bf13ab1902de7dee4f8193c819118d7e21a6") // invalid as of block 350k
-	devFund3 												= common.HexToAddress("0xcEf0890408b4FC0DC025c8F581c77383529D38B6") // invalid as of block 350k
-)
-
-// Fork Variables
-// Moves funds to multisigs.
-var (
-	egemSwitchBlock       					*big.Int = big.NewInt(350000)           //  350k block transtiton
-	devFund0F												= common.HexToAddress("0x1140e31A4A7ae014E55f6c235af027C5CFABCA17") // riddlez
-	devFund1F												= common.HexToAddress("0x63e9ceFD428D37430205c0ab8fa2a34A21F911Ac") // beast/tbates
-	devFund2F												= common.HexToAddress("0x2025ed239a8dec4de0034a252d5c5e385b73fcd0") // osoese
-	devFund3F												= common.HexToAddress("0xe485aA04bb231f331B85BF64614737c6495CC4b3") // jal
-)
-
 // Various error messages to mark blocks invalid. These should be private to
 // prevent engine specific errors from being referenced in the remainder of the
 // codebase, inherently breaking if the engine is swapped out. Please put common
@@ -95,6 +56,10 @@ var (
 	errInvalidDifficulty = errors.New("non-positive difficulty")
 	errInvalidMixDigest  = errors.New("invalid mix digest")
 	errInvalidPoW        = errors.New("invalid proof-of-work")
+
+	// errNeedAncestors is returned by CalcDifficulty if the difficulty algorithm
+	// scheduled for the block averages over ancestors the caller can't provide.
+	errNeedAncestors = errors.New("difficulty algorithm needs the ancestor headers")
 )
 
 // Author implements consensus.Engine, returning the header's coinbase as the
@@ -142,6 +107,11 @@ func (ethash *Ethash) VerifyHeaders(chain consensus.ChainReader, headers []*type
 		workers = len(headers)
 	}
 
+	// Windowed difficulty algorithms need access to the ancestors in the batch
+	verifier := chain
+	if chain.Config().Ethash != nil && len(chain.Config().Ethash.Difficulty) > 0 {
+		verifier = newBatchHeaderReader(chain, headers)
+	}
 	// Create a task channel and spawn the verifiers
 	var (
 		inputs = make(chan int)
@@ -152,7 +122,7 @@ func (ethash *Ethash) VerifyHeaders(chain consensus.ChainReader, headers []*type
 	for i := 0; i < workers; i++ {
 		go func() {
 			for index := range inputs {
-				errors[index] = ethash.verifyHeaderWorker(chain, headers, seals, index)
+				errors[index] = ethash.verifyHeaderWorker(chain, verifier, headers, seals, index)
 				done <- index
 			}
 		}()
@@ -188,7 +158,7 @@ func (ethash *Ethash) VerifyHeaders(chain consensus.ChainReader, headers []*type
 	return abort, errorsOut
 }
 
-func (ethash *Ethash) verifyHeaderWorker(chain consensus.ChainReader, headers []*types.Header, seals []bool, index int) error {
+func (ethash *Ethash) verifyHeaderWorker(chain, verifier consensus.ChainReader, headers []*types.Header, seals []bool, index int) error {
 	var parent *types.Header
 	if index == 0 {
 		parent = chain.GetHeader(headers[0].ParentHash, headers[0].Number.Uint64()-1)
@@ -201,7 +171,7 @@ func (ethash *Ethash) verifyHeaderWorker(chain consensus.ChainReader, headers []
 	if chain.GetHeader(headers[index].Hash(), headers[index].Number.Uint64()) != nil {
 		return nil // known block
 	}
-	return ethash.verifyHeader(chain, headers[index], parent, false, seals[index])
+	return ethash.verifyHeader(verifier, headers[index], parent, false, seals[index])
 }
 
 // VerifyUncles verifies that the given block's uncles conform to the consensus
@@ -327,22 +297,30 @@ func (ethash *Ethash) verifyHeader(chain consensus.ChainReader, header, parent *
 // the difficulty that a new block should have when created at time
 // given the parent block's time and difficulty.
 func (ethash *Ethash) CalcDifficulty(chain consensus.ChainReader, time uint64, parent *types.Header) *big.Int {
-	return CalcDifficulty(chain.Config(), time, parent)
+	return calcDifficulty(chain, chain.Config(), time, parent)
 }
 
 // CalcDifficulty is the difficulty adjustment algorithm. It returns
 // the difficulty that a new block should have when created at time
 // given the parent block's time and difficulty.
-func CalcDifficulty(config *params.ChainConfig, time uint64, parent *types.Header) *big.Int {
+//
+// Algorithms averaging over the ancestors of a block can't be calculated from
+// the parent alone, so an error is returned if one is scheduled for the child
+// of parent. Use the engine's CalcDifficulty with a chain reader instead.
+func CalcDifficulty(config *params.ChainConfig, time uint64, parent *types.Header) (*big.Int, error) {
 	next := new(big.Int).Add(parent.Number, big1)
-	switch {
-	case config.IsByzantium(next):
-		return calcDifficultyEGEM(time, parent)
-	case config.IsHomestead(next):
-		return calcDifficultyEGEM(time, parent)
-	default:
-		return calcDifficultyEGEM(time, parent)
+
+	algo := NewDifficultyAlgorithm(config.Ethash.DifficultyFork(next))
+	if _, ok := algo.(*lwmaDifficulty); ok {
+		return nil, errNeedAncestors
 	}
+	return algo.CalcDifficulty(nil, time, parent), nil
+}
+
+// calcDifficulty runs the difficulty algorithm scheduled for the child of parent.
+func calcDifficulty(chain headerReader, config *params.ChainConfig, time uint64, parent *types.Header) *big.Int {
+	next := new(big.Int).Add(parent.Number, big1)
+	return NewDifficultyAlgorithm(config.Ethash.DifficultyFork(next)).CalcDifficulty(chain, time, parent)
 }
 
 // Some weird constants to avoid constant memory allocs for them.
@@ -427,6 +405,42 @@ func (ethash *Ethash) VerifySeal(chain consensus.ChainReader, header *types.Head
 	return nil
 }
 
+// VerifyShare checks whether the nonce of a header satisfies the proof-of-work
+// at a share difficulty, which is usually lower than the block difficulty. The
+// mix digest in the header is ignored; the recomputed one is returned instead,
+// together with whether the nonce also seals the block itself.
+func (ethash *Ethash) VerifyShare(header *types.Header, difficulty *big.Int) (common.Hash, bool, error) {
+	// If we're running a fake PoW, accept any share as a valid block
+	if ethash.config.PowMode == ModeFake || ethash.config.PowMode == ModeFullFake {
+		if ethash.fakeFail == header.Number.Uint64() {
+			return common.Hash{}, false, errInvalidPoW
+		}
+		return header.MixDigest, true, nil
+	}
+	// If we're running a shared PoW, delegate verification to it
+	if ethash.shared != nil {
+		return ethash.shared.VerifyShare(header, difficulty)
+	}
+	if difficulty.Sign() <= 0 || header.Difficulty.Sign() <= 0 {
+		return common.Hash{}, false, errInvalidDifficulty
+	}
+	number := header.Number.Uint64()
+
+	cache := ethash.cache(number)
+	size := datasetSize(number)
+	if ethash.config.PowMode == ModeTest {
+		size = 32 * 1024
+	}
+	digest, result := hashimotoLight(size, cache.cache, header.HashNoNonce().Bytes(), header.Nonce.Uint64())
+	runtime.KeepAlive(cache)
+
+	value := new(big.Int).SetBytes(result)
+	if value.Cmp(new(big.Int).Div(maxUint256, difficulty)) > 0 {
+		return common.BytesToHash(digest), false, errInvalidPoW
+	}
+	return common.BytesToHash(digest), value.Cmp(new(big.Int).Div(maxUint256, header.Difficulty)) <= 0, nil
+}
+
 // Prepare implements consensus.Engine, initializing the difficulty field of a
 // header to conform to the ethash protocol. The changes are done inline.
 func (ethash *Ethash) Prepare(chain consensus.ChainReader, header *types.Header) error {
@@ -441,375 +455,29 @@ func (ethash *Ethash) Prepare(chain consensus.ChainReader, header *types.Header)
 // Finalize implements consensus.Engine, accumulating the block and uncle rewards,
 // setting the final state and assembling the block.
 func (ethash *Ethash) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
-	if (header.Number.Cmp(egemSwitchBlock) == 1) {
-
-		// Accumulate any block and uncle rewards and commit the final state root
-		accumulateRewards2(chain.Config(), state, header, uncles)
-		header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
-
-		// Header seems complete, assemble into a block and return
-		return types.NewBlock(header, txs, uncles, receipts), nil
+	// Accumulate any block and uncle rewards and commit the final state root
+	accumulateRewards(chain.Config(), state, header, uncles)
+	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
 
-	} else {
-
-		// Accumulate any block and uncle rewards and commit the final state root
-		accumulateRewards(chain.Config(), state, header, uncles)
-		header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
-
-		// Header seems complete, assemble into a block and return
-		return types.NewBlock(header, txs, uncles, receipts), nil
-
-	}
+	// Header seems complete, assemble into a block and return
+	return types.NewBlock(header, txs, uncles, receipts), nil
 }
 
-// Some weird constants to avoid constant memory allocs for them.
-var (
-	big8  = big.NewInt(8)
-	big32 = big.NewInt(32)
-)
-
 // AccumulateRewards credits the coinbase of the given block with the mining
-// reward. The total reward consists of the static block reward and rewards for
-// included uncles. The coinbase of each uncle block is also rewarded.
+// reward. The total reward consists of the static block reward of the active
+// reward era and the bonus for included uncles. Depending on the era's uncle
+// policy, the coinbase of each uncle block is also rewarded. Finally the era's
+// dev-fund payouts are credited.
 func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header *types.Header, uncles []*types.Header) {
+	rewards := CalcBlockRewards(config, header, uncles)
 
-	// Select the correct block reward based on chain progression
-	block0Reward := egem0BlockReward
-	block1Reward := egem1BlockReward
-	block2Reward := egem2BlockReward
-	block3Reward := egem3BlockReward
-	block4Reward := egem4BlockReward
-	block5Reward := egem5BlockReward
-	block6Reward := egem6BlockReward
-	d0Reward := egem0DevReward
-	d1Reward := egem1DevReward
-	d2Reward := egem2DevReward
-  d3Reward := egem3DevReward
-	d4Reward := egem4DevReward
-	d5Reward := egem5DevReward
-	d6Reward := egem6DevReward
-
-	// Accumulate the rewards for the miner and any included uncles
-	if (header.Number.Cmp(egemRewardSwitchBlockEra6) == 1) {
-			reward := new(big.Int).Set(block6Reward)
-			r := new(big.Int)
-			for _, uncle := range uncles {
-					r.Add(uncle.Number, big8)
-					r.Sub(r, header.Number)
-					r.Mul(r, reward)
-					r.Div(r, big8)
-
-					r.Div(reward, big32)
-					reward.Add(reward, r)
-			}
-		//fmt.Println("Miner Block Reward:", reward, "in Wei.", "|", "Dev Block Fee:", d6Reward, "in Wei.")
-		state.AddBalance(header.Coinbase, reward)
-		state.AddBalance(devFund0, d6Reward)
-		state.AddBalance(devFund1, d6Reward)
-		state.AddBalance(devFund2, d6Reward)
-		state.AddBalance(devFund3, d6Reward)
-
-	} else if (header.Number.Cmp(egemRewardSwitchBlockEra5) == 1) {
-			reward := new(big.Int).Set(block5Reward)
-			r := new(big.Int)
-			for _, uncle := range uncles {
-					r.Add(uncle.Number, big8)
-					r.Sub(r, header.Number)
-					r.Mul(r, reward)
-					r.Div(r, big8)
-
-					r.Div(reward, big32)
-					reward.Add(reward, r)
-			}
-		//fmt.Println("Miner Block Reward:", reward, "in Wei.", "|", "Dev Block Fee:", d5Reward, "in Wei.")
-		state.AddBalance(header.Coinbase, reward)
-		state.AddBalance(devFund0, d5Reward)
-		state.AddBalance(devFund1, d5Reward)
-		state.AddBalance(devFund2, d5Reward)
-		state.AddBalance(devFund3, d5Reward)
-
-	} else if (header.Number.Cmp(egemRewardSwitchBlockEra4) == 1) {
-			reward := new(big.Int).Set(block4Reward)
-			r := new(big.Int)
-			for _, uncle := range uncles {
-					r.Add(uncle.Number, big8)
-					r.Sub(r, header.Number)
-					r.Mul(r, reward)
-					r.Div(r, big8)
-
-					r.Div(reward, big32)
-					reward.Add(reward, r)
-			}
-		//fmt.Println("Miner Block Reward:", reward, "in Wei.", "|", "Dev Block Fee:", d4Reward, "in Wei.")
-		state.AddBalance(header.Coinbase, reward)
-		state.AddBalance(devFund0, d4Reward)
-		state.AddBalance(devFund1, d4Reward)
-		state.AddBalance(devFund2, d4Reward)
-		state.AddBalance(devFund3, d4Reward)
-
-	} else if (header.Number.Cmp(egemRewardSwitchBlockEra3) == 1) {
-			reward := new(big.Int).Set(block3Reward)
-			r := new(big.Int)
-			for _, uncle := range uncles {
-					r.Add(uncle.Number, big8)
-					r.Sub(r, header.Number)
-					r.Mul(r, reward)
-					r.Div(r, big8)
-
-					r.Div(reward, big32)
-					reward.Add(reward, r)
-			}
-		//fmt.Println("Miner Block Reward:", reward, "in Wei.", "|", "Dev Block Fee:", d3Reward, "in Wei.")
-		state.AddBalance(header.Coinbase, reward)
-		state.AddBalance(devFund0, d3Reward)
-		state.AddBalance(devFund1, d3Reward)
-		state.AddBalance(devFund2, d3Reward)
-		state.AddBalance(devFund3, d3Reward)
-
-	} else if (header.Number.Cmp(egemRewardSwitchBlockEra2) == 1) {
-			reward := new(big.Int).Set(block2Reward)
-			r := new(big.Int)
-			for _, uncle := range uncles {
-					r.Add(uncle.Number, big8)
-					r.Sub(r, header.Number)
-					r.Mul(r, reward)
-					r.Div(r, big8)
-
-					r.Div(reward, big32)
-					reward.Add(reward, r)
-			}
-		//fmt.Println("Miner Block Reward:", reward, "in Wei.", "|", "Dev Block Fee:", d2Reward, "in Wei.")
-		state.AddBalance(header.Coinbase, reward)
-		state.AddBalance(devFund0, d2Reward)
-		state.AddBalance(devFund1, d2Reward)
-		state.AddBalance(devFund2, d2Reward)
-		state.AddBalance(devFund3, d2Reward)
-
-	} else if (header.Number.Cmp(egemRewardSwitchBlockEra1) == 1) {
-			reward := new(big.Int).Set(block1Reward)
-			r := new(big.Int)
-			for _, uncle := range uncles {
-					r.Add(uncle.Number, big8)
-					r.Sub(r, header.Number)
-					r.Mul(r, reward)
-					r.Div(r, big8)
-
-					r.Div(reward, big32)
-					reward.Add(reward, r)
-			}
-		//fmt.Println("Miner Block Reward:", reward, "in Wei.", "|", "Dev Block Fee:", d1Reward, "in Wei.")
-		state.AddBalance(header.Coinbase, reward)
-		state.AddBalance(devFund0, d1Reward)
-		state.AddBalance(devFund1, d1Reward)
-		state.AddBalance(devFund2, d1Reward)
-		state.AddBalance(devFund3, d1Reward)
-
-	} else if (header.Number.Cmp(egemRewardSwitchBlockEra0) == 1) {
-			reward := new(big.Int).Set(block0Reward)
-			r := new(big.Int)
-			for _, uncle := range uncles {
-					r.Add(uncle.Number, big8)
-					r.Sub(r, header.Number)
-					r.Mul(r, reward)
-					r.Div(r, big8)
-
-					r.Div(reward, big32)
-					reward.Add(reward, r)
-			}
-		//fmt.Println("Miner Block Reward:", reward, "in Wei.", "|", "Dev Block Fee:", d0Reward, "in Wei.")
-		state.AddBalance(header.Coinbase, reward)
-		state.AddBalance(devFund0, d0Reward)
-		state.AddBalance(devFund1, d0Reward)
-		state.AddBalance(devFund2, d0Reward)
-		state.AddBalance(devFund3, d0Reward)
-
-	} else {
-		reward := new(big.Int).Set(block0Reward)
-		r := new(big.Int)
-		for _, uncle := range uncles {
-					r.Add(uncle.Number, big8)
-					r.Sub(r, header.Number)
-					r.Mul(r, reward)
-					r.Div(r, big8)
-
-					r.Div(reward, big32)
-					reward.Add(reward, r)
-			}
-		//fmt.Println("Miner Block Reward:", reward, "in Wei.")
-		state.AddBalance(header.Coinbase, reward)
+	state.AddBalance(header.Coinbase, new(big.Int).Add(rewards.Miner, rewards.UncleBonus))
+	for i, uncle := range uncles {
+		if rewards.Uncles[i].Sign() > 0 {
+			state.AddBalance(uncle.Coinbase, rewards.Uncles[i])
+		}
 	}
-
-}
-
-// AccumulateRewards credits the coinbase of the given block with the mining
-// reward. The total reward consists of the static block reward and rewards for
-// included uncles. The coinbase of each uncle block is also rewarded.
-func accumulateRewards2(config *params.ChainConfig, state *state.StateDB, header *types.Header, uncles []*types.Header) {
-
-	// Select the correct block reward based on chain progression
-	block0Reward := egem0BlockReward
-	block1Reward := egem1BlockReward
-	block2Reward := egem2BlockReward
-	block3Reward := egem3BlockReward
-	block4Reward := egem4BlockReward
-	block5Reward := egem5BlockReward
-	block6Reward := egem6BlockReward
-	d0Reward := egem0DevReward
-	d1Reward := egem1DevReward
-	d2Reward := egem2DevReward
-  d3Reward := egem3DevReward
-	d4Reward := egem4DevReward
-	d5Reward := egem5DevReward
-	d6Reward := egem6DevReward
-
-	// Accumulate the rewards for the miner and any included uncles
-	if (header.Number.Cmp(egemRewardSwitchBlockEra6) == 1) {
-			reward := new(big.Int).Set(block6Reward)
-			r := new(big.Int)
-			for _, uncle := range uncles {
-					r.Add(uncle.Number, big8)
-					r.Sub(r, header.Number)
-					r.Mul(r, reward)
-					r.Div(r, big8)
-
-					r.Div(reward, big32)
-					reward.Add(reward, r)
-			}
-		//fmt.Println("Miner Block Reward:", reward, "in Wei.", "|", "Dev Block Fee:", d6Reward, "in Wei.")
-		state.AddBalance(header.Coinbase, reward)
-		state.AddBalance(devFund0F, d6Reward)
-		state.AddBalance(devFund1F, d6Reward)
-		state.AddBalance(devFund2F, d6Reward)
-		state.AddBalance(devFund3F, d6Reward)
-
-	} else if (header.Number.Cmp(egemRewardSwitchBlockEra5) == 1) {
-			reward := new(big.Int).Set(block5Reward)
-			r := new(big.Int)
-			for _, uncle := range uncles {
-					r.Add(uncle.Number, big8)
-					r.Sub(r, header.Number)
-					r.Mul(r, reward)
-					r.Div(r, big8)
-
-					r.Div(reward, big32)
-					reward.Add(reward, r)
-			}
-		//fmt.Println("Miner Block Reward:", reward, "in Wei.", "|", "Dev Block Fee:", d5Reward, "in Wei.")
-		state.AddBalance(header.Coinbase, reward)
-		state.AddBalance(devFund0F, d5Reward)
-		state.AddBalance(devFund1F, d5Reward)
-		state.AddBalance(devFund2F, d5Reward)
-		state.AddBalance(devFund3F, d5Reward)
-
-	} else if (header.Number.Cmp(egemRewardSwitchBlockEra4) == 1) {
-			reward := new(big.Int).Set(block4Reward)
-			r := new(big.Int)
-			for _, uncle := range uncles {
-					r.Add(uncle.Number, big8)
-					r.Sub(r, header.Number)
-					r.Mul(r, reward)
-					r.Div(r, big8)
-
-					r.Div(reward, big32)
-					reward.Add(reward, r)
-			}
-		//fmt.Println("Miner Block Reward:", reward, "in Wei.", "|", "Dev Block Fee:", d4Reward, "in Wei.")
-		state.AddBalance(header.Coinbase, reward)
-		state.AddBalance(devFund0F, d4Reward)
-		state.AddBalance(devFund1F, d4Reward)
-		state.AddBalance(devFund2F, d4Reward)
-		state.AddBalance(devFund3F, d4Reward)
-
-	} else if (header.Number.Cmp(egemRewardSwitchBlockEra3) == 1) {
-			reward := new(big.Int).Set(block3Reward)
-			r := new(big.Int)
-			for _, uncle := range uncles {
-					r.Add(uncle.Number, big8)
-					r.Sub(r, header.Number)
-					r.Mul(r, reward)
-					r.Div(r, big8)
-
-					r.Div(reward, big32)
-					reward.Add(reward, r)
-			}
-		//fmt.Println("Miner Block Reward:", reward, "in Wei.", "|", "Dev Block Fee:", d3Reward, "in Wei.")
-		state.AddBalance(header.Coinbase, reward)
-		state.AddBalance(devFund0F, d3Reward)
-		state.AddBalance(devFund1F, d3Reward)
-		state.AddBalance(devFund2F, d3Reward)
-		state.AddBalance(devFund3F, d3Reward)
-
-	} else if (header.Number.Cmp(egemRewardSwitchBlockEra2) == 1) {
-			reward := new(big.Int).Set(block2Reward)
-			r := new(big.Int)
-			for _, uncle := range uncles {
-					r.Add(uncle.Number, big8)
-					r.Sub(r, header.Number)
-					r.Mul(r, reward)
-					r.Div(r, big8)
-
-					r.Div(reward, big32)
-					reward.Add(reward, r)
-			}
-		//fmt.Println("Miner Block Reward:", reward, "in Wei.", "|", "Dev Block Fee:", d2Reward, "in Wei.")
-		state.AddBalance(header.Coinbase, reward)
-		state.AddBalance(devFund0F, d2Reward)
-		state.AddBalance(devFund1F, d2Reward)
-		state.AddBalance(devFund2F, d2Reward)
-		state.AddBalance(devFund3F, d2Reward)
-
-	} else if (header.Number.Cmp(egemRewardSwitchBlockEra1) == 1) {
-			reward := new(big.Int).Set(block1Reward)
-			r := new(big.Int)
-			for _, uncle := range uncles {
-					r.Add(uncle.Number, big8)
-					r.Sub(r, header.Number)
-					r.Mul(r, reward)
-					r.Div(r, big8)
-
-					r.Div(reward, big32)
-					reward.Add(reward, r)
-			}
-		//fmt.Println("Miner Block Reward:", reward, "in Wei.", "|", "Dev Block Fee:", d1Reward, "in Wei.")
-		state.AddBalance(header.Coinbase, reward)
-		state.AddBalance(devFund0F, d1Reward)
-		state.AddBalance(devFund1F, d1Reward)
-		state.AddBalance(devFund2F, d1Reward)
-		state.AddBalance(devFund3F, d1Reward)
-
-	} else if (header.Number.Cmp(egemRewardSwitchBlockEra0) == 1) {
-			reward := new(big.Int).Set(block0Reward)
-			r := new(big.Int)
-			for _, uncle := range uncles {
-					r.Add(uncle.Number, big8)
-					r.Sub(r, header.Number)
-					r.Mul(r, reward)
-					r.Div(r, big8)
-
-					r.Div(reward, big32)
-					reward.Add(reward, r)
-			}
-		//fmt.Println("Miner Block Reward:", reward, "in Wei.", "|", "Dev Block Fee:", d0Reward, "in Wei.")
-		state.AddBalance(header.Coinbase, reward)
-		state.AddBalance(devFund0F, d0Reward)
-		state.AddBalance(devFund1F, d0Reward)
-		state.AddBalance(devFund2F, d0Reward)
-		state.AddBalance(devFund3F, d0Reward)
-
-	} else {
-		reward := new(big.Int).Set(block0Reward)
-		r := new(big.Int)
-		for _, uncle := range uncles {
-					r.Add(uncle.Number, big8)
-					r.Sub(r, header.Number)
-					r.Mul(r, reward)
-					r.Div(r, big8)
-
-					r.Div(reward, big32)
-					reward.Add(reward, r)
-			}
-		//fmt.Println("Miner Block Reward:", reward, "in Wei.")
-		state.AddBalance(header.Coinbase, reward)
+	for _, dev := range rewards.Dev {
+		state.AddBalance(dev.Address, dev.Amount)
 	}
-
 }
@@ -23,8 +23,11 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/TeamEGEM/go-egem/common"
 	"github.com/TeamEGEM/go-egem/common/math"
+	"github.com/TeamEGEM/go-egem/core/state"
 	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/ethdb"
 	"github.com/TeamEGEM/go-egem/params"
 )
 
@@ -74,13 +77,62 @@ func TestCalcDifficulty(t *testing.T) {
 
 	for name, test := range tests {
 		number := new(big.Int).Sub(test.CurrentBlocknumber, big.NewInt(1))
-		diff := CalcDifficulty(config, test.CurrentTimestamp, &types.Header{
+		diff, err := CalcDifficulty(config, test.CurrentTimestamp, &types.Header{
 			Number:     number,
 			Time:       new(big.Int).SetUint64(test.ParentTimestamp),
 			Difficulty: test.ParentDifficulty,
 		})
+		if err != nil {
+			t.Fatal(name, err)
+		}
 		if diff.Cmp(test.CurrentDifficulty) != 0 {
 			t.Error(name, "failed. Expected", test.CurrentDifficulty, "and calculated", diff)
 		}
 	}
 }
+
+// Tests that the default reward schedule credits exactly the amounts paid by
+// the EGEM main network at its era boundaries.
+func TestAccumulateRewards(t *testing.T) {
+	var (
+		coinbase = common.HexToAddress("0x00000000000000000000000000000000000000c0")
+		uncle    = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+		devFund0 = common.HexToAddress("0x3fa6576610cac6c68e88ee68de07b104c9524fda")
+		devFundF = common.HexToAddress("0xe485aA04bb231f331B85BF64614737c6495CC4b3")
+	)
+	tests := []struct {
+		number  int64
+		uncles  int
+		miner   string
+		fund0   string
+		fundF   string
+		uncleCB string
+	}{
+		{5000, 0, "8000000000000000000", "0", "0", "0"},
+		{5001, 0, "8000000000000000000", "250000000000000000", "0", "0"},
+		{350000, 1, "8250000000000000000", "250000000000000000", "0", "0"},
+		{350001, 0, "8000000000000000000", "0", "250000000000000000", "0"},
+		{2500001, 0, "4000000000000000000", "0", "187500000000000000", "0"},
+		{15000001, 2, "132934570312500000", "0", "6250000000000000", "0"},
+	}
+	for i, tt := range tests {
+		db, _ := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+		header := &types.Header{Number: big.NewInt(tt.number), Coinbase: coinbase}
+
+		var uncles []*types.Header
+		for j := 0; j < tt.uncles; j++ {
+			uncles = append(uncles, &types.Header{Number: big.NewInt(tt.number - 1), Coinbase: uncle})
+		}
+		accumulateRewards(params.MainnetChainConfig, statedb, header, uncles)
+
+		for _, check := range []struct {
+			addr common.Address
+			want string
+		}{{coinbase, tt.miner}, {devFund0, tt.fund0}, {devFundF, tt.fundF}, {uncle, tt.uncleCB}} {
+			if have := statedb.GetBalance(check.addr); have.String() != check.want {
+				t.Errorf("test %d: balance mismatch for %x: have %v, want %v", i, check.addr, have, check.want)
+			}
+		}
+	}
+}
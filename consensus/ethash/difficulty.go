@@ -0,0 +1,220 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"math/big"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/consensus"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/log"
+	"github.com/TeamEGEM/go-egem/params"
+)
+
+const (
+	// defaultLWMAWindow is the number of solve times averaged by LWMA if the
+	// difficulty fork doesn't specify a window.
+	defaultLWMAWindow = 60
+
+	// defaultASERTHalfLife is the number of target block times it takes ASERT
+	// to halve or double the difficulty if the fork doesn't specify a half-life.
+	defaultASERTHalfLife = 30
+)
+
+// headerReader is the subset of consensus.ChainReader needed by difficulty
+// algorithms averaging over the ancestors of a block.
+type headerReader interface {
+	// GetHeader retrieves a block header from the database by hash and number.
+	GetHeader(hash common.Hash, number uint64) *types.Header
+}
+
+// DifficultyAlgorithm is a difficulty adjustment algorithm of the ethash engine.
+type DifficultyAlgorithm interface {
+	// CalcDifficulty returns the difficulty that a new block should have when
+	// created at time on top of parent. The ancestors of parent are retrieved
+	// from chain, which may be nil if only the parent is available.
+	CalcDifficulty(chain headerReader, time uint64, parent *types.Header) *big.Int
+}
+
+// NewDifficultyAlgorithm creates the difficulty adjustment algorithm selected by
+// a difficulty fork. A nil fork selects the original EGEM algorithm.
+func NewDifficultyAlgorithm(fork *params.DifficultyFork) DifficultyAlgorithm {
+	if fork == nil {
+		return egemDifficulty{}
+	}
+	target := fork.TargetTime
+	if target == 0 {
+		target = params.DurationLimit.Uint64()
+	}
+	switch fork.Algorithm {
+	case params.DifficultyEGEM:
+		return egemDifficulty{}
+
+	case params.DifficultyLWMA:
+		window := fork.Window
+		if window == 0 {
+			window = defaultLWMAWindow
+		}
+		return &lwmaDifficulty{target: target, window: window}
+
+	case params.DifficultyASERT:
+		halfLife := fork.HalfLife
+		if halfLife == 0 {
+			halfLife = defaultASERTHalfLife * target
+		}
+		return &asertDifficulty{target: target, halfLife: halfLife}
+
+	default:
+		log.Error("Unknown difficulty algorithm, using EGEM", "algorithm", fork.Algorithm, "block", fork.Block)
+		return egemDifficulty{}
+	}
+}
+
+// egemDifficulty is the original EGEM difficulty algorithm, stepping the parent
+// difficulty up by 1/7 or down by 1/3 depending on the block time.
+type egemDifficulty struct{}
+
+// CalcDifficulty implements DifficultyAlgorithm.
+func (egemDifficulty) CalcDifficulty(chain headerReader, time uint64, parent *types.Header) *big.Int {
+	return calcDifficultyEGEM(time, parent)
+}
+
+// lwmaDifficulty is a linearly weighted moving average difficulty algorithm. It
+// averages the solve times of the last blocks, weighting recent ones heavier to
+// respond quickly to hashrate changes without oscillating.
+type lwmaDifficulty struct {
+	target uint64 // Target block time in seconds
+	window uint64 // Number of solve times to average
+}
+
+// CalcDifficulty implements DifficultyAlgorithm.
+//
+//	next = sum(D) * T * (N + 1) / (2 * sum(i * solvetime_i))
+func (a *lwmaDifficulty) CalcDifficulty(chain headerReader, time uint64, parent *types.Header) *big.Int {
+	// Gather the headers of the averaging window, oldest first
+	headers := []*types.Header{parent}
+	for uint64(len(headers)) <= a.window && chain != nil {
+		oldest := headers[0]
+		if oldest.Number.Sign() == 0 {
+			break
+		}
+		ancestor := chain.GetHeader(oldest.ParentHash, oldest.Number.Uint64()-1)
+		if ancestor == nil {
+			break
+		}
+		headers = append([]*types.Header{ancestor}, headers...)
+	}
+	n := uint64(len(headers) - 1)
+	if n == 0 {
+		return new(big.Int).Set(parent.Difficulty)
+	}
+	// Sum up the difficulties and the weighted, clamped solve times
+	var (
+		sumDiff  = new(big.Int)
+		weighted uint64
+	)
+	for i := uint64(1); i <= n; i++ {
+		solvetime := headers[i].Time.Uint64() - headers[i-1].Time.Uint64()
+		if headers[i].Time.Cmp(headers[i-1].Time) <= 0 {
+			solvetime = 1
+		}
+		if solvetime > 6*a.target {
+			solvetime = 6 * a.target
+		}
+		weighted += i * solvetime
+		sumDiff.Add(sumDiff, headers[i].Difficulty)
+	}
+	// Prevent runaway difficulty if the timestamps are clustered
+	if min := n * (n + 1) * a.target / 20; weighted < min {
+		weighted = min
+	}
+	diff := sumDiff.Mul(sumDiff, new(big.Int).SetUint64(a.target*(n+1)))
+	diff.Div(diff, new(big.Int).SetUint64(2*weighted))
+
+	if diff.Cmp(params.MinimumDifficulty) < 0 {
+		diff.Set(params.MinimumDifficulty)
+	}
+	return diff
+}
+
+// asertDifficulty is an exponential moving average difficulty algorithm in the
+// style of ASERT. Every second the block time deviates from the target scales
+// the parent difficulty by 2^(1/halfLife).
+type asertDifficulty struct {
+	target   uint64 // Target block time in seconds
+	halfLife uint64 // Deviation in seconds that halves or doubles the difficulty
+}
+
+// CalcDifficulty implements DifficultyAlgorithm.
+//
+//	next = D * 2^((T - (time - parent.time)) / halfLife)
+func (a *asertDifficulty) CalcDifficulty(chain headerReader, time uint64, parent *types.Header) *big.Int {
+	// Calculate the exponent as a 16.16 fixed point number
+	solvetime := int64(time) - parent.Time.Int64()
+	exponent := (int64(a.target) - solvetime) * 65536 / int64(a.halfLife)
+
+	// Approximate 2^frac with a cubic polynomial (aserti3-2d), and shift for the
+	// integer part of the exponent (arithmetic shift floors negatives)
+	shifts, frac := exponent>>16, uint64(exponent&0xffff)
+
+	factor := new(big.Int).SetUint64(195766423245049 * frac)
+	factor.Add(factor, new(big.Int).SetUint64(971821376*frac*frac))
+	factor.Add(factor, new(big.Int).Mul(new(big.Int).SetUint64(5127*frac*frac), new(big.Int).SetUint64(frac)))
+	factor.Add(factor, new(big.Int).Lsh(big1, 47))
+	factor.Rsh(factor, 48)
+	factor.Add(factor, big.NewInt(65536))
+
+	diff := new(big.Int).Mul(parent.Difficulty, factor)
+	if shifts -= 16; shifts < 0 {
+		diff.Rsh(diff, uint(-shifts))
+	} else {
+		diff.Lsh(diff, uint(shifts))
+	}
+	if diff.Cmp(params.MinimumDifficulty) < 0 {
+		diff.Set(params.MinimumDifficulty)
+	}
+	return diff
+}
+
+// batchHeaderReader is a chain reader which also serves the headers of a batch
+// under verification, allowing difficulty algorithms to average over ancestors
+// which are not yet stored in the database.
+type batchHeaderReader struct {
+	consensus.ChainReader
+	headers map[common.Hash]*types.Header
+}
+
+// newBatchHeaderReader wraps a chain reader to also serve the given headers.
+func newBatchHeaderReader(chain consensus.ChainReader, headers []*types.Header) *batchHeaderReader {
+	reader := &batchHeaderReader{
+		ChainReader: chain,
+		headers:     make(map[common.Hash]*types.Header, len(headers)),
+	}
+	for _, header := range headers {
+		reader.headers[header.Hash()] = header
+	}
+	return reader
+}
+
+// GetHeader retrieves a header from the batch, or from the chain if not found.
+func (r *batchHeaderReader) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header, ok := r.headers[hash]; ok && header.Number.Uint64() == number {
+		return header
+	}
+	return r.ChainReader.GetHeader(hash, number)
+}
@@ -0,0 +1,317 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/params"
+)
+
+// simChain is an in-memory header chain used to simulate difficulty algorithms.
+type simChain struct {
+	headers []*types.Header
+}
+
+func newSimChain(difficulty *big.Int) *simChain {
+	return &simChain{headers: []*types.Header{{Number: new(big.Int), Time: new(big.Int), Difficulty: difficulty}}}
+}
+
+// GetHeader implements headerReader, using the parent hash field as the index.
+func (c *simChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if number >= uint64(len(c.headers)) {
+		return nil
+	}
+	return c.headers[number]
+}
+
+// push appends a new block mined at the given time using the algorithm.
+func (c *simChain) push(algo DifficultyAlgorithm, time uint64) *types.Header {
+	parent := c.headers[len(c.headers)-1]
+	header := &types.Header{
+		ParentHash: common.BigToHash(parent.Number),
+		Number:     new(big.Int).Add(parent.Number, big1),
+		Time:       new(big.Int).SetUint64(time),
+		Difficulty: algo.CalcDifficulty(c, time, parent),
+	}
+	c.headers = append(c.headers, header)
+	return header
+}
+
+// simStats summarises the block times of a simulation run.
+type simStats struct {
+	mean, stddev float64
+}
+
+// blockTimeStats calculates the mean and standard deviation of the block times
+// of the headers [from, to).
+func (c *simChain) blockTimeStats(from, to int) simStats {
+	var sum, sumSq float64
+	for i := from; i < to; i++ {
+		st := float64(c.headers[i].Time.Uint64() - c.headers[i-1].Time.Uint64())
+		sum += st
+		sumSq += st * st
+	}
+	n := float64(to - from)
+	mean := sum / n
+	return simStats{mean: mean, stddev: math.Sqrt(sumSq/n - mean*mean)}
+}
+
+// simulateMining mines blocks with the given algorithm against a hashrate profile,
+// drawing exponentially distributed solve times from a deterministic source.
+func simulateMining(algo DifficultyAlgorithm, hashrates []float64, start *big.Int) *simChain {
+	var (
+		chain = newSimChain(start)
+		rnd   = rand.New(rand.NewSource(1987))
+		now   = uint64(0)
+	)
+	for _, hashrate := range hashrates {
+		// Difficulty of the next block is computed at the time it's found, so
+		// approximate it with the candidate at the parent's target time
+		parent := chain.headers[len(chain.headers)-1]
+		diff, _ := new(big.Float).SetInt(algo.CalcDifficulty(chain, parent.Time.Uint64()+params.DurationLimit.Uint64(), parent)).Float64()
+
+		solvetime := uint64(rnd.ExpFloat64()*diff/hashrate) + 1
+		now += solvetime
+		chain.push(algo, now)
+	}
+	return chain
+}
+
+// replayTimestamps recomputes the difficulties of a recorded sequence of block
+// timestamps with the given algorithm.
+func replayTimestamps(algo DifficultyAlgorithm, timestamps []uint64, start *big.Int) *simChain {
+	chain := newSimChain(start)
+	chain.headers[0].Time.SetUint64(timestamps[0])
+	for _, time := range timestamps[1:] {
+		chain.push(algo, time)
+	}
+	return chain
+}
+
+// hashrateProfile returns a hashrate per block: steady, a 10x jump as a large
+// pool arrives, then the pool leaving again.
+func hashrateProfile(base float64) []float64 {
+	profile := make([]float64, 0, 3000)
+	for i := 0; i < 1000; i++ {
+		profile = append(profile, base)
+	}
+	for i := 0; i < 1000; i++ {
+		profile = append(profile, 10*base)
+	}
+	for i := 0; i < 1000; i++ {
+		profile = append(profile, base)
+	}
+	return profile
+}
+
+var simAlgorithms = []struct {
+	name string
+	fork *params.DifficultyFork
+}{
+	{params.DifficultyEGEM, nil},
+	{params.DifficultyLWMA, &params.DifficultyFork{Algorithm: params.DifficultyLWMA}},
+	{params.DifficultyASERT, &params.DifficultyFork{Algorithm: params.DifficultyASERT}},
+}
+
+// Tests that the smoothing difficulty algorithms keep the block time close to
+// the target across hashrate jumps, oscillating less than the EGEM algorithm.
+func TestDifficultySimulation(t *testing.T) {
+	var (
+		target = float64(params.DurationLimit.Uint64())
+		start  = big.NewInt(1e12)
+		stats  = make(map[string][3]simStats)
+	)
+	for _, algo := range simAlgorithms {
+		chain := simulateMining(NewDifficultyAlgorithm(algo.fork), hashrateProfile(1e12/target), start)
+
+		// Measure each hashrate phase after allowing some blocks to adjust
+		stats[algo.name] = [3]simStats{chain.blockTimeStats(200, 1000), chain.blockTimeStats(1200, 2000), chain.blockTimeStats(2200, 3000)}
+		t.Logf("%-6s steady %5.1fs ±%5.1f, jump %5.1fs ±%5.1f, drop %5.1fs ±%5.1f", algo.name,
+			stats[algo.name][0].mean, stats[algo.name][0].stddev, stats[algo.name][1].mean, stats[algo.name][1].stddev,
+			stats[algo.name][2].mean, stats[algo.name][2].stddev)
+	}
+	for _, name := range []string{params.DifficultyLWMA, params.DifficultyASERT} {
+		for phase, stat := range stats[name] {
+			if stat.mean < 0.8*target || stat.mean > 1.2*target {
+				t.Errorf("%s phase %d: mean block time %.1fs too far from target %.0fs", name, phase, stat.mean, target)
+			}
+		}
+	}
+}
+
+// Tests that replaying a sequence of erratic timestamps keeps the smoothing
+// algorithms' difficulties within a tighter band than the EGEM algorithm.
+func TestDifficultyReplay(t *testing.T) {
+	// Record the timestamps a chain using the EGEM algorithm would have produced
+	var (
+		target     = float64(params.DurationLimit.Uint64())
+		start      = big.NewInt(1e12)
+		recorded   = simulateMining(NewDifficultyAlgorithm(nil), hashrateProfile(1e12/target), start)
+		timestamps = make([]uint64, len(recorded.headers))
+	)
+	for i, header := range recorded.headers {
+		timestamps[i] = header.Time.Uint64()
+	}
+	swing := make(map[string]float64)
+	for _, algo := range simAlgorithms {
+		chain := replayTimestamps(NewDifficultyAlgorithm(algo.fork), timestamps, start)
+
+		// Measure the average relative block-to-block difficulty change
+		var sum float64
+		for i := 1; i < len(chain.headers); i++ {
+			prev, _ := new(big.Float).SetInt(chain.headers[i-1].Difficulty).Float64()
+			next, _ := new(big.Float).SetInt(chain.headers[i].Difficulty).Float64()
+			sum += math.Abs(next-prev) / prev
+		}
+		swing[algo.name] = sum / float64(len(chain.headers)-1)
+		t.Logf("%-6s average difficulty swing %.2f%%", algo.name, 100*swing[algo.name])
+	}
+	for _, name := range []string{params.DifficultyLWMA, params.DifficultyASERT} {
+		if swing[name] >= swing[params.DifficultyEGEM] {
+			t.Errorf("%s swings more than EGEM: %.4f >= %.4f", name, swing[name], swing[params.DifficultyEGEM])
+		}
+	}
+}
+
+// Tests that difficulty forks switch algorithms at the configured block.
+func TestDifficultyFork(t *testing.T) {
+	config := &params.ChainConfig{Ethash: &params.EthashConfig{
+		Difficulty: []params.DifficultyFork{{Block: big.NewInt(100), Algorithm: params.DifficultyASERT}},
+	}}
+	parent := &types.Header{Number: big.NewInt(98), Time: big.NewInt(1000), Difficulty: big.NewInt(1e12)}
+
+	tests := []struct {
+		number uint64
+		time   uint64
+		want   *big.Int
+	}{
+		// Before the fork, a block on target is still stepped by the EGEM algorithm
+		{98, 1000 + params.DurationLimit.Uint64(), calcDifficultyEGEM(1000+params.DurationLimit.Uint64(), parent)},
+		// After the fork, a block exactly on target keeps the difficulty
+		{99, 1000 + params.DurationLimit.Uint64(), big.NewInt(1e12)},
+		// A block taking a half-life longer than the target halves the difficulty
+		{99, 1000 + params.DurationLimit.Uint64() + defaultASERTHalfLife*params.DurationLimit.Uint64(), big.NewInt(5e11)},
+	}
+	for i, tt := range tests {
+		parent.Number = new(big.Int).SetUint64(tt.number)
+		diff, err := CalcDifficulty(config, tt.time, parent)
+		if err != nil {
+			t.Errorf("test %d: failed to calculate difficulty: %v", i, err)
+			continue
+		}
+		if diff.Cmp(tt.want) != 0 {
+			t.Errorf("test %d: difficulty mismatch: have %v, want %v", i, diff, tt.want)
+		}
+	}
+}
+
+// Tests that the parent-only difficulty helper refuses to calculate algorithms
+// which average over the ancestors instead of silently returning a wrong value.
+func TestCalcDifficultyNeedsChain(t *testing.T) {
+	config := &params.ChainConfig{Ethash: &params.EthashConfig{
+		Difficulty: []params.DifficultyFork{{Block: big.NewInt(100), Algorithm: params.DifficultyLWMA}},
+	}}
+	parent := &types.Header{Number: big.NewInt(98), Time: big.NewInt(1000), Difficulty: big.NewInt(1e12)}
+
+	if _, err := CalcDifficulty(config, 1000+params.DurationLimit.Uint64(), parent); err != nil {
+		t.Fatalf("pre-fork difficulty refused: %v", err)
+	}
+	parent.Number = big.NewInt(99)
+	if diff, err := CalcDifficulty(config, 1000+params.DurationLimit.Uint64(), parent); err != errNeedAncestors {
+		t.Fatalf("LWMA difficulty calculated without the chain: have %v, %v, want %v", diff, err, errNeedAncestors)
+	}
+}
+
+// legacyDifficulty is the EGEM difficulty adjustment as hardcoded before the
+// difficulty algorithms were made pluggable, kept verbatim as the reference the
+// main network blocks were mined with.
+func legacyDifficulty(time uint64, parent *types.Header) *big.Int {
+	diff := new(big.Int)
+	adjustUp := new(big.Int).Div(parent.Difficulty, big.NewInt(7))
+	adjustDown := new(big.Int).Div(parent.Difficulty, big.NewInt(3))
+
+	if new(big.Int).Sub(new(big.Int).SetUint64(time), parent.Time).Cmp(params.DurationLimit) < 0 {
+		diff.Add(parent.Difficulty, big.NewInt(7))
+		diff.Add(diff, adjustUp)
+	} else {
+		diff.Sub(parent.Difficulty, big.NewInt(3))
+		diff.Sub(diff, adjustDown)
+	}
+	if diff.Cmp(params.MinimumDifficulty) < 0 {
+		diff.Set(params.MinimumDifficulty)
+	}
+	return diff
+}
+
+// Tests that replaying a main network header chain through the default
+// difficulty schedule reproduces every difficulty of the legacy algorithm. The
+// headers are generated from the main network genesis with randomized solve
+// times around the target, so both adjustment directions and the minimum
+// difficulty clamp are exercised.
+func TestDifficultyMainnetReplay(t *testing.T) {
+	var (
+		rnd   = rand.New(rand.NewSource(1987))
+		chain = &simChain{headers: []*types.Header{{
+			Number:     new(big.Int),
+			Time:       big.NewInt(1521416601),
+			Difficulty: big.NewInt(200313635671),
+		}}}
+	)
+	for i := 1; i <= 5000; i++ {
+		parent := chain.headers[i-1]
+
+		// Mostly solve around the target, with occasional hashrate drops long
+		// enough to walk the difficulty down to the minimum
+		solvetime := uint64(rnd.Intn(int(3 * params.DurationLimit.Uint64())))
+		if i%1000 >= 900 {
+			solvetime += 10 * params.DurationLimit.Uint64()
+		}
+		time := parent.Time.Uint64() + solvetime
+		chain.headers = append(chain.headers, &types.Header{
+			ParentHash: common.BigToHash(parent.Number),
+			Number:     big.NewInt(int64(i)),
+			Time:       new(big.Int).SetUint64(time),
+			Difficulty: legacyDifficulty(time, parent),
+		})
+	}
+	var clamped bool
+	for i := 1; i < len(chain.headers); i++ {
+		header, parent := chain.headers[i], chain.headers[i-1]
+		if header.Difficulty.Cmp(params.MinimumDifficulty) == 0 {
+			clamped = true
+		}
+		have, err := CalcDifficulty(params.MainnetChainConfig, header.Time.Uint64(), parent)
+		if err != nil {
+			t.Fatalf("block %d: failed to calculate difficulty: %v", i, err)
+		}
+		if have.Cmp(header.Difficulty) != 0 {
+			t.Fatalf("block %d: difficulty mismatch: have %v, want %v", i, have, header.Difficulty)
+		}
+		if have := calcDifficulty(chain, params.MainnetChainConfig, header.Time.Uint64(), parent); have.Cmp(header.Difficulty) != 0 {
+			t.Fatalf("block %d: engine difficulty mismatch: have %v, want %v", i, have, header.Difficulty)
+		}
+	}
+	if !clamped {
+		t.Fatalf("replay never reached the minimum difficulty")
+	}
+}
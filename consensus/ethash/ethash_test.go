@@ -43,6 +43,38 @@ func TestTestMode(t *testing.T) {
 	}
 }
 
+// Tests that shares are verified against the share difficulty, reporting whether
+// they also seal the block.
+func TestVerifyShare(t *testing.T) {
+	head := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)}
+
+	ethash := NewTester()
+	block, err := ethash.Seal(nil, types.NewBlockWithHeader(head), nil)
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	head.Nonce = types.EncodeNonce(block.Nonce())
+
+	digest, sealed, err := ethash.VerifyShare(head, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("unexpected share verification error: %v", err)
+	}
+	if !sealed {
+		t.Errorf("block solution not reported as sealing")
+	}
+	if digest != block.MixDigest() {
+		t.Errorf("mix digest mismatch: have %x, want %x", digest, block.MixDigest())
+	}
+	// Raise the block difficulty out of reach, the share should remain valid
+	head = &types.Header{Number: big.NewInt(1), Difficulty: new(big.Int).Lsh(big.NewInt(1), 64)}
+	if _, sealed, err := ethash.VerifyShare(head, big.NewInt(1)); err != nil || sealed {
+		t.Errorf("share verification mismatch: sealed %v, err %v", sealed, err)
+	}
+	if _, _, err := ethash.VerifyShare(head, head.Difficulty); err != errInvalidPoW {
+		t.Errorf("share above difficulty error mismatch: have %v, want %v", err, errInvalidPoW)
+	}
+}
+
 // This test checks that cache lru logic doesn't crash under load.
 // It reproduces https://github.com/TeamEGEM/go-egem/issues/14943
 func TestCacheFileEvict(t *testing.T) {
@@ -0,0 +1,126 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"math/big"
+
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/params"
+)
+
+// Some weird constants to avoid constant memory allocs for them.
+var (
+	big8 = big.NewInt(8)
+)
+
+// BlockRewards is the breakdown of the balances credited by Finalize when a
+// block is sealed.
+type BlockRewards struct {
+	Era        *params.RewardEra  // Reward era the block belongs to (nil = no rewards)
+	Miner      *big.Int           // Static block reward credited to the coinbase
+	UncleBonus *big.Int           // Uncle inclusion bonus credited to the coinbase
+	Uncles     []*big.Int         // Rewards credited to each uncle's coinbase
+	Dev        []params.DevReward // Dev-fund payouts
+}
+
+// Total returns the sum of all the credits in the breakdown, i.e. the amount of
+// new coins issued by the block.
+func (r *BlockRewards) Total() *big.Int {
+	total := new(big.Int).Add(r.Miner, r.UncleBonus)
+	for _, reward := range r.Uncles {
+		total.Add(total, reward)
+	}
+	for _, dev := range r.Dev {
+		total.Add(total, dev.Amount)
+	}
+	return total
+}
+
+// CalcBlockRewards computes the rewards credited for a block with the given
+// header and uncles according to the chain's reward schedule.
+func CalcBlockRewards(config *params.ChainConfig, header *types.Header, uncles []*types.Header) *BlockRewards {
+	rewards := &BlockRewards{
+		Era:        config.Ethash.RewardEra(header.Number),
+		Miner:      new(big.Int),
+		UncleBonus: new(big.Int),
+		Uncles:     make([]*big.Int, len(uncles)),
+	}
+	for i := range rewards.Uncles {
+		rewards.Uncles[i] = new(big.Int)
+	}
+	era := rewards.Era
+	if era == nil {
+		return rewards
+	}
+	rewards.Miner.Set(era.BlockReward)
+
+	// Accumulate the rewards for the miner and any included uncles
+	reward := new(big.Int).Set(era.BlockReward)
+	for i, uncle := range uncles {
+		if era.Uncles.RewardUncles {
+			r := rewards.Uncles[i]
+			r.Add(uncle.Number, big8)
+			r.Sub(r, header.Number)
+			r.Mul(r, reward)
+			r.Div(r, big8)
+		}
+		if era.Uncles.InclusionDivisor != nil {
+			reward.Add(reward, new(big.Int).Div(reward, era.Uncles.InclusionDivisor))
+		}
+	}
+	rewards.UncleBonus.Sub(reward, era.BlockReward)
+
+	for _, dev := range era.DevRewards {
+		rewards.Dev = append(rewards.Dev, params.DevReward{Address: dev.Address, Amount: new(big.Int).Set(dev.Amount)})
+	}
+	return rewards
+}
+
+// ScheduledIssuance returns the amount of coins the reward schedule issues in
+// the blocks 1..number, counting the static block rewards and the dev-fund
+// payouts. Uncle rewards depend on the actual chain and are not included.
+func ScheduledIssuance(config *params.ChainConfig, number uint64) *big.Int {
+	var (
+		eras   = config.Ethash.RewardEras()
+		issued = new(big.Int)
+	)
+	for i := range eras {
+		// Find the range of blocks [first, last] the era was active for
+		first := eras[i].Block.Uint64()
+		if first == 0 {
+			first = 1 // the genesis block is not rewarded
+		}
+		if first > number {
+			break
+		}
+		last := number
+		if i+1 < len(eras) && eras[i+1].Block.Uint64() <= number {
+			last = eras[i+1].Block.Uint64() - 1
+		}
+		if last < first {
+			continue
+		}
+		// Multiply the per block issuance by the number of blocks in the range
+		perBlock := new(big.Int).Set(eras[i].BlockReward)
+		for _, dev := range eras[i].DevRewards {
+			perBlock.Add(perBlock, dev.Amount)
+		}
+		issued.Add(issued, perBlock.Mul(perBlock, new(big.Int).SetUint64(last-first+1)))
+	}
+	return issued
+}
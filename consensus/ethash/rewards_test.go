@@ -0,0 +1,84 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/params"
+)
+
+// Tests that the reward breakdown honours a custom uncle policy.
+func TestCalcBlockRewardsUncles(t *testing.T) {
+	config := &params.ChainConfig{Ethash: &params.EthashConfig{Eras: []params.RewardEra{{
+		Block:       big.NewInt(0),
+		BlockReward: big.NewInt(3200),
+		Uncles:      params.UnclePolicy{InclusionDivisor: big.NewInt(32), RewardUncles: true},
+		DevRewards:  []params.DevReward{{Address: common.Address{0x01}, Amount: big.NewInt(100)}},
+	}}}}
+	header := &types.Header{Number: big.NewInt(10)}
+	uncles := []*types.Header{{Number: big.NewInt(9)}, {Number: big.NewInt(8)}}
+
+	rewards := CalcBlockRewards(config, header, uncles)
+	if rewards.Miner.Cmp(big.NewInt(3200)) != 0 {
+		t.Errorf("miner reward mismatch: have %v, want %v", rewards.Miner, 3200)
+	}
+	// The bonus compounds: 3200/32 = 100, then 3300/32 = 103
+	if rewards.UncleBonus.Cmp(big.NewInt(203)) != 0 {
+		t.Errorf("uncle bonus mismatch: have %v, want %v", rewards.UncleBonus, 203)
+	}
+	// Uncle rewards are based on the running reward: 7/8 of 3200, 6/8 of 3300
+	if rewards.Uncles[0].Cmp(big.NewInt(2800)) != 0 || rewards.Uncles[1].Cmp(big.NewInt(2475)) != 0 {
+		t.Errorf("uncle rewards mismatch: have %v, want [2800 2475]", rewards.Uncles)
+	}
+	if total := rewards.Total(); total.Cmp(big.NewInt(3200+203+2800+2475+100)) != 0 {
+		t.Errorf("total mismatch: have %v, want %v", total, 3200+203+2800+2475+100)
+	}
+}
+
+// Tests that the scheduled issuance sums the eras correctly across boundaries.
+func TestScheduledIssuance(t *testing.T) {
+	perBlock := func(miner, dev int64) *big.Int {
+		return new(big.Int).Add(new(big.Int).Mul(big.NewInt(miner), big.NewInt(params.Finney)), new(big.Int).Mul(big.NewInt(4*dev), big.NewInt(params.Szabo)))
+	}
+	mul := func(x *big.Int, n int64) *big.Int { return new(big.Int).Mul(x, big.NewInt(n)) }
+	add := func(xs ...*big.Int) *big.Int {
+		sum := new(big.Int)
+		for _, x := range xs {
+			sum.Add(sum, x)
+		}
+		return sum
+	}
+	tests := []struct {
+		number uint64
+		want   *big.Int
+	}{
+		{0, new(big.Int)},
+		{1, perBlock(8000, 0)},
+		{5000, mul(perBlock(8000, 0), 5000)},
+		{5001, add(mul(perBlock(8000, 0), 5000), perBlock(8000, 250000))},
+		{2500001, add(mul(perBlock(8000, 0), 5000), mul(perBlock(8000, 250000), 2495000), perBlock(4000, 187500))},
+	}
+	for i, tt := range tests {
+		if have := ScheduledIssuance(params.MainnetChainConfig, tt.number); have.Cmp(tt.want) != 0 {
+			t.Errorf("test %d: issuance mismatch at block %d: have %v, want %v", i, tt.number, have, tt.want)
+		}
+	}
+}
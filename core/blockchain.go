@@ -69,6 +69,9 @@ type CacheConfig struct {
 	Disabled      bool          // Whether to disable trie write caching (archive node)
 	TrieNodeLimit int           // Memory limit (MB) at which to flush the current in-memory trie to disk
 	TrieTimeLimit time.Duration // Time limit after which to flush the current in-memory trie to disk
+
+	StatePruning   bool   // Whether to periodically delete historical state from disk
+	StateRetention uint64 // Number of recent persisted states to retain when pruning
 }
 
 // BlockChain represents the canonical chain given a database with a genesis
@@ -93,6 +96,9 @@ type BlockChain struct {
 	triegc *prque.Prque   // Priority queue mapping block numbers to tries to gc
 	gcproc time.Duration  // Accumulates canonical block processing for trie dumping
 
+	pruner  *state.Pruner // Background state pruner, if one is running
+	prunemu sync.Mutex    // Lock protecting the pruner and state flushes
+
 	hc            *HeaderChain
 	rmLogsFeed    event.Feed
 	chainFeed     event.Feed
@@ -189,6 +195,10 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 			}
 		}
 	}
+	// Resume any interrupted state pruning
+	if cacheConfig.StatePruning && !cacheConfig.Disabled && state.PruneInterrupted(db) {
+		bc.startStatePruning()
+	}
 	// Take ownership of this particular state
 	go bc.update()
 	return bc, nil
@@ -387,6 +397,11 @@ func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
 	return state.New(root, bc.stateCache)
 }
 
+// StateCache returns the caching database underpinning the blockchain instance.
+func (bc *BlockChain) StateCache() state.Database {
+	return bc.stateCache
+}
+
 // Reset purges the entire blockchain, restoring it to its genesis state.
 func (bc *BlockChain) Reset() error {
 	return bc.ResetWithGenesisBlock(bc.genesisBlock)
@@ -539,8 +554,7 @@ func (bc *BlockChain) HasBlock(hash common.Hash, number uint64) bool {
 	if bc.blockCache.Contains(hash) {
 		return true
 	}
-	ok, _ := bc.db.Has(blockBodyKey(hash, number))
-	return ok
+	return HasBody(bc.db, hash, number)
 }
 
 // HasState checks if state trie is fully present in the database or not.
@@ -655,7 +669,7 @@ func (bc *BlockChain) Stop() {
 				recent := bc.GetBlockByNumber(number - offset)
 
 				log.Info("Writing cached state to disk", "block", recent.Number(), "hash", recent.Hash(), "root", recent.Root())
-				if err := triedb.Commit(recent.Root(), true); err != nil {
+				if err := bc.commitState(recent.Root()); err != nil {
 					log.Error("Failed to commit recent state trie", "err", err)
 				}
 			}
@@ -937,7 +951,7 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 				}
 				// If optimum or critical limits reached, write to disk
 				if chosen >= lastWrite+triesInMemory || size >= 2*limit || bc.gcproc >= 2*bc.cacheConfig.TrieTimeLimit {
-					triedb.Commit(header.Root, true)
+					bc.commitState(header.Root)
 					lastWrite = chosen
 					bc.gcproc = 0
 				}
@@ -952,6 +966,10 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 				triedb.Dereference(root.(common.Hash), common.Hash{})
 			}
 		}
+		// Periodically delete the historical state from disk if requested
+		if bc.cacheConfig.StatePruning && block.NumberU64()%statePruneInterval == 0 {
+			bc.startStatePruning()
+		}
 	}
 	if err := WriteBlockReceipts(batch, block.Hash(), block.NumberU64(), receipts); err != nil {
 		return NonStatTy, err
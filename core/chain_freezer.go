@@ -0,0 +1,208 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/ethdb"
+	"github.com/TeamEGEM/go-egem/log"
+)
+
+const (
+	// freezerRecheckInterval is the frequency to check the key-value database for
+	// chain progression that might permit new blocks to be frozen into immutable
+	// storage.
+	freezerRecheckInterval = time.Minute
+
+	// freezerBatchLimit is the maximum number of blocks to freeze in one batch
+	// before doing an fsync and deleting it from the key-value store.
+	freezerBatchLimit = 30000
+
+	// MinFreezerThreshold is the smallest allowed distance from the chain head
+	// beyond which blocks are considered immutable and may be frozen. Reorgs deeper
+	// than the threshold cannot be handled anymore.
+	MinFreezerThreshold = 1024
+)
+
+// errNoAncientStore is returned if freezing is requested on a database without
+// an ancient store.
+var errNoAncientStore = errors.New("database has no ancient store")
+
+// ChainFreezer periodically moves the canonical blocks, receipts and total
+// difficulties past the immutability threshold from the key-value store into
+// the append-only ancient store of the database.
+type ChainFreezer struct {
+	db        ethdb.Database // Database to move the ancient chain data within
+	threshold uint64         // Number of blocks behind the head to keep in the key-value store
+
+	quit chan struct{}  // Quit channel to stop the freezing loop
+	wg   sync.WaitGroup // Wait group to wait for the freezing loop to terminate
+}
+
+// NewChainFreezer creates a chain freezer for the given database, keeping the
+// most recent threshold blocks in the key-value store.
+func NewChainFreezer(db ethdb.Database, threshold uint64) (*ChainFreezer, error) {
+	store, ok := db.(ethdb.AncientStore)
+	if !ok {
+		return nil, errNoAncientStore
+	}
+	if _, err := store.Ancients(); err != nil {
+		return nil, errNoAncientStore
+	}
+	if threshold < MinFreezerThreshold {
+		log.Warn("Sanitizing invalid freezer threshold", "provided", threshold, "updated", MinFreezerThreshold)
+		threshold = MinFreezerThreshold
+	}
+	return &ChainFreezer{
+		db:        db,
+		threshold: threshold,
+		quit:      make(chan struct{}),
+	}, nil
+}
+
+// Start launches the background freezing loop.
+func (f *ChainFreezer) Start() {
+	f.wg.Add(1)
+	go f.loop()
+}
+
+// Stop terminates the background freezing loop, waiting for any running batch
+// to finish.
+func (f *ChainFreezer) Stop() {
+	close(f.quit)
+	f.wg.Wait()
+}
+
+// loop periodically freezes the chain data that became immutable since the
+// last run. Existing databases are migrated batch by batch the same way.
+func (f *ChainFreezer) loop() {
+	defer f.wg.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if _, err := FreezeAncients(f.db, f.threshold, f.quit); err != nil {
+				log.Error("Failed to freeze ancient chain data", "err", err)
+			}
+			timer.Reset(freezerRecheckInterval)
+
+		case <-f.quit:
+			return
+		}
+	}
+}
+
+// FreezeAncients moves all the canonical blocks more than threshold blocks
+// behind the current head from the key-value store into the ancient store,
+// returning the number of blocks frozen. The genesis block is retained in the
+// key-value store too.
+//
+// Data is appended and synced to the ancient store before it is deleted from
+// the key-value store, so an interrupted run leaves at most duplicate data
+// behind, which is cleaned up by the next one.
+func FreezeAncients(db ethdb.Database, threshold uint64, abort <-chan struct{}) (uint64, error) {
+	store, ok := db.(ethdb.AncientStore)
+	if !ok {
+		return 0, errNoAncientStore
+	}
+	var total uint64
+	for {
+		// Find the range of blocks which became immutable
+		hash := GetHeadBlockHash(db)
+		if hash == (common.Hash{}) {
+			return total, nil
+		}
+		head := GetBlockNumber(db, hash)
+		if head == missingNumber || head <= threshold {
+			return total, nil
+		}
+		first, err := store.Ancients()
+		if err != nil {
+			return total, errNoAncientStore
+		}
+		limit := head - threshold
+		if first >= limit {
+			return total, nil
+		}
+		if limit-first > freezerBatchLimit {
+			limit = first + freezerBatchLimit
+		}
+		// Move the next batch of blocks into the ancient store
+		var (
+			start  = time.Now()
+			hashes []common.Hash
+		)
+		for number := first; number < limit; number++ {
+			hash := GetCanonicalHash(db, number)
+			if hash == (common.Hash{}) {
+				return total, fmt.Errorf("canonical hash missing, can't freeze block #%d", number)
+			}
+			header, _ := db.Get(headerKey(hash, number))
+			if len(header) == 0 {
+				return total, fmt.Errorf("block header missing, can't freeze block #%d", number)
+			}
+			body, _ := db.Get(blockBodyKey(hash, number))
+			if len(body) == 0 {
+				return total, fmt.Errorf("block body missing, can't freeze block #%d", number)
+			}
+			receipts, _ := db.Get(blockReceiptsKey(hash, number))
+			if len(receipts) == 0 {
+				return total, fmt.Errorf("block receipts missing, can't freeze block #%d", number)
+			}
+			td, _ := db.Get(tdKey(hash, number))
+			if len(td) == 0 {
+				return total, fmt.Errorf("total difficulty missing, can't freeze block #%d", number)
+			}
+			if err := store.AppendAncient(number, hash[:], header, body, receipts, td); err != nil {
+				return total, err
+			}
+			hashes = append(hashes, hash)
+		}
+		if err := store.SyncAncients(); err != nil {
+			return total, err
+		}
+		// Wipe the frozen data from the key-value store, keeping the hash to number
+		// mappings for lookups. Side chain data at frozen heights is left alone.
+		for i, hash := range hashes {
+			number := first + uint64(i)
+			if number == 0 {
+				continue
+			}
+			DeleteCanonicalHash(db, number)
+			db.Delete(headerKey(hash, number))
+			DeleteBody(db, hash, number)
+			DeleteBlockReceipts(db, hash, number)
+			DeleteTd(db, hash, number)
+		}
+		total += uint64(len(hashes))
+		log.Info("Froze ancient chain segment", "blocks", len(hashes), "first", first, "last", limit-1, "elapsed", common.PrettyDuration(time.Since(start)))
+
+		select {
+		case <-abort:
+			return total, nil
+		default:
+		}
+	}
+}
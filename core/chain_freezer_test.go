@@ -0,0 +1,128 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/consensus/ethash"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/core/vm"
+	"github.com/TeamEGEM/go-egem/crypto"
+	"github.com/TeamEGEM/go-egem/ethdb"
+	"github.com/TeamEGEM/go-egem/params"
+)
+
+// Tests that freezing the old chain segment moves it out of the key-value store
+// and that all the read accessors transparently serve it from the ancient store.
+func TestFreezeAncients(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chain-freezer")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := ethdb.NewLDBDatabaseWithFreezer(dir, 0, 0, filepath.Join(dir, "ancient"))
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{address: {Balance: big.NewInt(1000000000)}},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainId)
+	)
+	blocks, receipts := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, MinFreezerThreshold+100, func(i int, block *BlockGen) {
+		if i%10 == 0 {
+			tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x00}, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+			if err != nil {
+				panic(err)
+			}
+			block.AddTx(tx)
+		}
+	})
+	chain, err := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	chain.Stop()
+
+	// Freeze the chain and make sure only the immutable segment was moved
+	frozen, err := FreezeAncients(db, MinFreezerThreshold, nil)
+	if err != nil {
+		t.Fatalf("failed to freeze ancients: %v", err)
+	}
+	if frozen != 100 {
+		t.Fatalf("frozen block count mismatch: have %d, want %d", frozen, 100)
+	}
+	if ancients, _ := db.Ancients(); ancients != frozen {
+		t.Fatalf("ancient store size mismatch: have %d, want %d", ancients, frozen)
+	}
+	if frozen, err := FreezeAncients(db, MinFreezerThreshold, nil); err != nil || frozen != 0 {
+		t.Fatalf("repeated freezing moved data: %d, %v", frozen, err)
+	}
+	for i, block := range blocks {
+		hash, number := block.Hash(), block.NumberU64()
+		if number < frozen {
+			if ok, _ := db.Has(blockBodyKey(hash, number)); ok {
+				t.Fatalf("block #%d: body still in key-value store", number)
+			}
+		}
+		if have := GetCanonicalHash(db, number); have != hash {
+			t.Fatalf("block #%d: canonical hash mismatch: have %x, want %x", number, have, hash)
+		}
+		if !HasHeader(db, hash, number) || !HasBody(db, hash, number) {
+			t.Fatalf("block #%d: header or body reported missing", number)
+		}
+		if have := GetBlock(db, hash, number); have == nil || have.Hash() != hash || len(have.Transactions()) != len(block.Transactions()) {
+			t.Fatalf("block #%d: block mismatch: have %v", number, have)
+		}
+		if have := GetBlockReceipts(db, hash, number); types.DeriveSha(have) != types.DeriveSha(receipts[i]) {
+			t.Fatalf("block #%d: receipts mismatch", number)
+		}
+		if have := GetTd(db, hash, number); have == nil || have.Cmp(chain.GetTd(hash, number)) != 0 {
+			t.Fatalf("block #%d: total difficulty mismatch: have %v", number, have)
+		}
+	}
+	// Ensure the chain can be reloaded from the frozen database
+	chain, err = NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to reopen blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if head := chain.CurrentBlock().Hash(); head != blocks[len(blocks)-1].Hash() {
+		t.Fatalf("head mismatch after reopen: have %x, want %x", head, blocks[len(blocks)-1].Hash())
+	}
+	if block := chain.GetBlockByNumber(50); block == nil || block.Hash() != blocks[49].Hash() {
+		t.Fatalf("ancient block mismatch after reopen: have %v", block)
+	}
+}
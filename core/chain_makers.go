@@ -169,7 +169,9 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, engine conse
 		blockchain, _ := NewBlockChain(db, nil, config, engine, vm.Config{})
 		defer blockchain.Stop()
 
-		b := &BlockGen{i: i, parent: parent, chain: blocks, chainReader: blockchain, statedb: statedb, config: config, engine: engine}
+		chainReader := &generatedChainReader{BlockChain: blockchain, blocks: blocks[:i]}
+
+		b := &BlockGen{i: i, parent: parent, chain: blocks, chainReader: chainReader, statedb: statedb, config: config, engine: engine}
 		b.header = makeHeader(b.chainReader, parent, statedb, b.engine)
 
 		// Mutate the state and block according to any hard-fork specs
@@ -228,16 +230,37 @@ func makeHeader(chain consensus.ChainReader, parent *types.Block, state *state.S
 		Root:       state.IntermediateRoot(chain.Config().IsEIP158(parent.Number())),
 		ParentHash: parent.Hash(),
 		Coinbase:   parent.Coinbase(),
-		Difficulty: engine.CalcDifficulty(chain, time.Uint64(), &types.Header{
-			Number:     parent.Number(),
-			Time:       new(big.Int).Sub(time, big.NewInt(10)),
-			Difficulty: parent.Difficulty(),
-			UncleHash:  parent.UncleHash(),
-		}),
-		GasLimit: CalcGasLimit(parent),
-		Number:   new(big.Int).Add(parent.Number(), common.Big1),
-		Time:     time,
+		Difficulty: engine.CalcDifficulty(chain, time.Uint64(), parent.Header()),
+		GasLimit:   CalcGasLimit(parent),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		Time:       time,
+	}
+}
+
+// generatedChainReader is a chain reader which also serves the blocks generated
+// so far by GenerateChain, allowing the engine to walk the ancestors of a block
+// that are not yet stored in the database.
+type generatedChainReader struct {
+	*BlockChain
+	blocks []*types.Block
+}
+
+// GetHeader retrieves a generated header, or one from the database if not found.
+func (r *generatedChainReader) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if block := r.GetBlock(hash, number); block != nil {
+		return block.Header()
+	}
+	return nil
+}
+
+// GetBlock retrieves a generated block, or one from the database if not found.
+func (r *generatedChainReader) GetBlock(hash common.Hash, number uint64) *types.Block {
+	for i := len(r.blocks) - 1; i >= 0; i-- {
+		if r.blocks[i].NumberU64() == number && r.blocks[i].Hash() == hash {
+			return r.blocks[i]
+		}
 	}
+	return r.BlockChain.GetBlock(hash, number)
 }
 
 // newCanonical creates a chain database, and injects a deterministic canonical
@@ -19,6 +19,7 @@ package core
 import (
 	"fmt"
 	"math/big"
+	"testing"
 
 	"github.com/TeamEGEM/go-egem/consensus/ethash"
 	"github.com/TeamEGEM/go-egem/core/types"
@@ -98,3 +99,46 @@ func ExampleGenerateChain() {
 	// balance of addr2: 10000
 	// balance of addr3: 19687500000000001000
 }
+
+// Tests that generated chains feed the ancestors of a block to the engine, so
+// difficulty algorithms averaging over a window of blocks produce the same
+// difficulties as the importing chain.
+func TestGenerateChainDifficultyWindow(t *testing.T) {
+	var (
+		engine = ethash.NewFaker()
+		config = &params.ChainConfig{
+			HomesteadBlock: new(big.Int),
+			Ethash: &params.EthashConfig{
+				Difficulty: []params.DifficultyFork{{Block: big.NewInt(1), Algorithm: params.DifficultyLWMA, Window: 8}},
+			},
+		}
+		gspec = &Genesis{Config: config, Difficulty: big.NewInt(1 << 20)}
+	)
+	gendb, _ := ethdb.NewMemDatabase()
+	genesis := gspec.MustCommit(gendb)
+
+	blocks, _ := GenerateChain(config, genesis, engine, gendb, 32, func(i int, b *BlockGen) {
+		b.OffsetTime(int64(i % 5))
+	})
+	// Without the ancestors LWMA falls back to the parent difficulty, so the
+	// generated difficulties must adjust
+	var averaged bool
+	for i := 2; i < len(blocks); i++ {
+		if blocks[i].Difficulty().Cmp(blocks[i-1].Difficulty()) != 0 {
+			averaged = true
+		}
+	}
+	if !averaged {
+		t.Fatalf("generated difficulties never adjusted")
+	}
+	// Importing the chain re-verifies every difficulty against the stored ancestors
+	chaindb, _ := ethdb.NewMemDatabase()
+	gspec.MustCommit(chaindb)
+
+	chain, _ := NewBlockChain(chaindb, nil, config, engine, vm.Config{})
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import generated block %d: %v", n, err)
+	}
+}
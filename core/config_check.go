@@ -0,0 +1,56 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/TeamEGEM/go-egem/log"
+	"github.com/TeamEGEM/go-egem/params"
+)
+
+// CheckChainConfig validates the chain configuration a node is about to run
+// with and, if it passes, logs the resolved reward schedule and difficulty forks.
+// Rewards are logged in ether as the miner and the total dev-fund payout per block.
+func CheckChainConfig(config *params.ChainConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if config.Ethash == nil {
+		return nil
+	}
+	var eras, forks []string
+	for _, era := range config.Ethash.RewardEras() {
+		eras = append(eras, fmt.Sprintf("#%v:%s+%s", era.Block, etherString(era.BlockReward), etherString(era.DevPayout())))
+	}
+	for _, fork := range config.Ethash.Difficulty {
+		forks = append(forks, fmt.Sprintf("#%v:%s", fork.Block, fork.Algorithm))
+	}
+	ctx := []interface{}{"rewards", strings.Join(eras, " ")}
+	if len(forks) > 0 {
+		ctx = append(ctx, "difficulty", strings.Join(forks, " "))
+	}
+	log.Info("Resolved chain schedule", ctx...)
+	return nil
+}
+
+// etherString formats a wei amount in whole ether units for logging.
+func etherString(wei *big.Int) string {
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.Ether)).Text('f', -1)
+}
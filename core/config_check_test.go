@@ -0,0 +1,87 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/log"
+	"github.com/TeamEGEM/go-egem/params"
+)
+
+// Tests that chain configs are validated before the schedule is logged, and that
+// exactly one schedule line is logged for valid ethash configs.
+func TestCheckChainConfig(t *testing.T) {
+	fund := params.DevReward{Address: common.HexToAddress("0x01"), Amount: big.NewInt(params.Finney)}
+
+	tests := []struct {
+		config  *params.ChainConfig
+		ok      bool
+		rewards string // Substring of the logged reward schedule, empty if nothing is logged
+	}{
+		// Configs without ethash are valid, but have no schedule to log
+		{&params.ChainConfig{Clique: &params.CliqueConfig{Period: 15}}, true, ""},
+		// The main network schedule is logged in ether per block
+		{params.MainnetChainConfig, true, "#0:8+0 #5001:8+1"},
+		// Custom schedules sum up the dev-fund payouts
+		{&params.ChainConfig{Ethash: &params.EthashConfig{Eras: []params.RewardEra{
+			{Block: big.NewInt(0), BlockReward: big.NewInt(2 * params.Ether), DevRewards: []params.DevReward{fund}},
+		}}}, true, "#0:2+0.001"},
+		// Invalid schedules are rejected before logging
+		{&params.ChainConfig{Ethash: &params.EthashConfig{Eras: []params.RewardEra{
+			{Block: big.NewInt(10), BlockReward: big.NewInt(params.Ether)},
+			{Block: big.NewInt(5), BlockReward: big.NewInt(params.Ether)},
+		}}}, false, ""},
+		{&params.ChainConfig{Ethash: &params.EthashConfig{Eras: []params.RewardEra{
+			{Block: big.NewInt(0), BlockReward: big.NewInt(params.Ether), DevRewards: []params.DevReward{fund}},
+			{Block: big.NewInt(10), BlockReward: big.NewInt(params.Ether), DevRewards: []params.DevReward{fund, {Address: common.HexToAddress("0x02"), Amount: big.NewInt(params.Finney)}}},
+		}}}, false, ""},
+		{&params.ChainConfig{Ethash: &params.EthashConfig{
+			Difficulty: []params.DifficultyFork{{Block: big.NewInt(10), Algorithm: "unknown"}},
+		}}, false, ""},
+	}
+	defer log.Root().SetHandler(log.Root().GetHandler())
+
+	for i, test := range tests {
+		var logged []*log.Record
+		log.Root().SetHandler(log.FuncHandler(func(r *log.Record) error {
+			logged = append(logged, r)
+			return nil
+		}))
+		err := CheckChainConfig(test.config)
+		if (err == nil) != test.ok {
+			t.Errorf("test %d: validity mismatch: have %v, want ok=%v", i, err, test.ok)
+			continue
+		}
+		if test.rewards == "" {
+			if len(logged) != 0 {
+				t.Errorf("test %d: unexpected schedule logged: %v", i, logged[0].Ctx)
+			}
+			continue
+		}
+		if len(logged) != 1 {
+			t.Errorf("test %d: logged lines mismatch: have %d, want 1", i, len(logged))
+			continue
+		}
+		if rewards, ok := logged[0].Ctx[1].(string); !ok || logged[0].Ctx[0] != "rewards" || !strings.Contains(rewards, test.rewards) {
+			t.Errorf("test %d: logged schedule mismatch: have %v, want rewards containing %q", i, logged[0].Ctx, test.rewards)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/core/state"
+	"github.com/TeamEGEM/go-egem/ethdb"
+	"github.com/TeamEGEM/go-egem/log"
+)
+
+// maxUnaccountedKeys is the maximum number of unaccounted keys collected by a
+// database inspection. Any further ones are only counted.
+const maxUnaccountedKeys = 1000
+
+// errInspectionAborted is returned if a database inspection is interrupted.
+var errInspectionAborted = errors.New("database inspection aborted")
+
+// metadataKeys are the singleton entries of the chain database, including the
+// ones owned by packages outside of core.
+var metadataKeys = [][]byte{
+	headHeaderKey,
+	headBlockKey,
+	headFastKey,
+	trieSyncKey,
+	[]byte("BlockchainVersion"),
+
+	// Progress markers and statistics of the state pruner, the eth database
+	// upgrade and the les server respectively
+	state.PruneProgressKey,
+	[]byte("dbUpgrade_20170714deduplicateData"),
+	[]byte("_requestCostStats"),
+}
+
+// DatabaseStat accumulates the number and total size of the database entries
+// of a data category.
+type DatabaseStat struct {
+	Name  string             // Name of the data category
+	Count uint64             // Number of entries in the category
+	Size  common.StorageSize // Total size of the keys and values
+}
+
+// add accounts a database entry of the given size.
+func (s *DatabaseStat) add(size int) {
+	s.Count++
+	s.Size += common.StorageSize(size)
+}
+
+// DatabaseInspection is the storage breakdown of a chain database.
+type DatabaseInspection struct {
+	Categories []*DatabaseStat // Key-value store entries per data category
+	Ancients   []*DatabaseStat // Ancient store tables, empty without an ancient store
+
+	Unaccounted     DatabaseStat // Key-value store entries not in any category
+	UnaccountedKeys [][]byte     // The first unaccounted keys, at most maxUnaccountedKeys
+}
+
+// inspectCategory is a data category of the chain database, recognized by the
+// layout of its keys.
+type inspectCategory struct {
+	name  string
+	match func(key []byte) bool
+}
+
+// keyLayout returns a matcher for keys with the given prefix and total length.
+func keyLayout(prefix []byte, length int) func(key []byte) bool {
+	return func(key []byte) bool {
+		return len(key) == length && bytes.HasPrefix(key, prefix)
+	}
+}
+
+// keyPrefixes returns a matcher for keys with any of the given prefixes.
+func keyPrefixes(prefixes ...string) func(key []byte) bool {
+	return func(key []byte) bool {
+		for _, prefix := range prefixes {
+			if bytes.HasPrefix(key, []byte(prefix)) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// inspectCategories lists the data categories of the chain database. Keys are
+// matched against them in order, so the more specific layouts come first.
+var inspectCategories = []inspectCategory{
+	{"Headers", keyLayout(headerPrefix, 1+8+common.HashLength)},
+	{"Total difficulties", func(key []byte) bool {
+		return keyLayout(headerPrefix, 1+8+common.HashLength+1)(key) && bytes.HasSuffix(key, tdSuffix)
+	}},
+	{"Canonical hashes", func(key []byte) bool {
+		return keyLayout(headerPrefix, 1+8+1)(key) && bytes.HasSuffix(key, numSuffix)
+	}},
+	{"Header numbers", keyLayout(blockHashPrefix, 1+common.HashLength)},
+	{"Bodies", keyLayout(bodyPrefix, 1+8+common.HashLength)},
+	{"Receipts", keyLayout(blockReceiptsPrefix, 1+8+common.HashLength)},
+	{"Transaction lookups", keyLayout(lookupPrefix, 1+common.HashLength)},
+	{"Bloom bits", keyLayout(bloomBitsPrefix, 1+2+8+common.HashLength)},
+	{"Issuance", keyLayout(issuancePrefix, 1+8+common.HashLength)},
+	{"Trie nodes and contract codes", func(key []byte) bool {
+		return len(key) == common.HashLength
+	}},
+	{"Trie preimages", keyLayout([]byte(preimagePrefix), len(preimagePrefix)+common.HashLength)},
+	{"Chain indexers", keyPrefixes(string(BloomBitsIndexPrefix), string(IssuanceIndexPrefix), "chtIndex-", "bltIndex-")},
+	{"Light client tries", keyPrefixes("cht-", "blt-", "chtRoot-", "bltRoot-")},
+	{"Legacy data", func(key []byte) bool {
+		return keyLayout(oldReceiptsPrefix, len(oldReceiptsPrefix)+common.HashLength)(key) ||
+			(len(key) == common.HashLength+1 && bytes.HasSuffix(key, oldTxMetaSuffix))
+	}},
+	{"Metadata", func(key []byte) bool {
+		if keyLayout(configPrefix, len(configPrefix)+common.HashLength)(key) {
+			return true
+		}
+		for _, meta := range metadataKeys {
+			if bytes.Equal(key, meta) {
+				return true
+			}
+		}
+		return false
+	}},
+}
+
+// InspectDatabase walks the entire chain database and breaks its storage down
+// by data category, along with the tables of the ancient store if there is one.
+func InspectDatabase(db ethdb.Database, abort <-chan struct{}) (*DatabaseInspection, error) {
+	result := new(DatabaseInspection)
+	for _, category := range inspectCategories {
+		result.Categories = append(result.Categories, &DatabaseStat{Name: category.name})
+	}
+	result.Unaccounted.Name = "Unaccounted"
+
+	it := db.NewIteratorWithRange(nil, nil)
+	defer it.Release()
+
+	var (
+		count  uint64
+		start  = time.Now()
+		logged = time.Now()
+	)
+	for it.Next() {
+		key, size := it.Key(), len(it.Key())+len(it.Value())
+
+		matched := false
+		for i, category := range inspectCategories {
+			if category.match(key) {
+				result.Categories[i].add(size)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			result.Unaccounted.add(size)
+			if len(result.UnaccountedKeys) < maxUnaccountedKeys {
+				result.UnaccountedKeys = append(result.UnaccountedKeys, common.CopyBytes(key))
+			}
+		}
+		count++
+		if count%1000 == 0 {
+			select {
+			case <-abort:
+				return result, errInspectionAborted
+			default:
+			}
+			if time.Since(logged) > 8*time.Second {
+				log.Info("Inspecting database", "count", count, "elapsed", common.PrettyDuration(time.Since(start)))
+				logged = time.Now()
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return result, err
+	}
+	// Account the ancient store tables, if the database has one
+	if store, ok := db.(ethdb.AncientReader); ok {
+		if frozen, err := store.Ancients(); err == nil {
+			for _, kind := range []string{ethdb.FreezerHeaderTable, ethdb.FreezerBodiesTable, ethdb.FreezerReceiptTable, ethdb.FreezerDifficultyTable, ethdb.FreezerHashTable} {
+				size, err := store.AncientSize(kind)
+				if err != nil {
+					return result, err
+				}
+				result.Ancients = append(result.Ancients, &DatabaseStat{Name: kind, Count: frozen, Size: common.StorageSize(size)})
+			}
+		}
+	}
+	log.Info("Inspected database", "count", count, "elapsed", common.PrettyDuration(time.Since(start)))
+	return result, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/ethdb"
+	"github.com/TeamEGEM/go-egem/params"
+)
+
+// Tests that the database inspection attributes every entry to its category
+// and reports the unknown ones as unaccounted.
+func TestInspectDatabase(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	tx := types.NewTransaction(1, common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, []*types.Transaction{tx}, nil, nil)
+	hash, number := block.Hash(), block.NumberU64()
+
+	WriteBlock(db, block)
+	WriteTd(db, hash, number, big.NewInt(1))
+	WriteCanonicalHash(db, hash, number)
+	WriteBlockReceipts(db, hash, number, types.Receipts{})
+	WriteTxLookupEntries(db, block)
+	WriteBloomBits(db, 1, 0, hash, []byte{0x01})
+	WriteHeadBlockHash(db, hash)
+	WriteBlockChainVersion(db, 3)
+	WriteChainConfig(db, hash, params.TestChainConfig)
+	WritePreimages(db, number, map[common.Hash][]byte{{0x01}: {0x02}})
+	db.Put(common.Hash{0x02}.Bytes(), []byte{0x03})
+	db.Put([]byte("unknown"), []byte{0x04})
+
+	result, err := InspectDatabase(db, nil)
+	if err != nil {
+		t.Fatalf("failed to inspect database: %v", err)
+	}
+	want := map[string]uint64{
+		"Headers":                       1,
+		"Total difficulties":            1,
+		"Canonical hashes":              1,
+		"Header numbers":                1,
+		"Bodies":                        1,
+		"Receipts":                      1,
+		"Transaction lookups":           1,
+		"Bloom bits":                    1,
+		"Trie nodes and contract codes": 1,
+		"Trie preimages":                1,
+		"Metadata":                      3,
+	}
+	var total uint64
+	for _, stat := range result.Categories {
+		if stat.Count != want[stat.Name] {
+			t.Errorf("%s: item count mismatch: have %d, want %d", stat.Name, stat.Count, want[stat.Name])
+		}
+		if stat.Count > 0 && stat.Size == 0 {
+			t.Errorf("%s: size not accounted", stat.Name)
+		}
+		total += stat.Count
+	}
+	if result.Unaccounted.Count != 1 || len(result.UnaccountedKeys) != 1 || !bytes.Equal(result.UnaccountedKeys[0], []byte("unknown")) {
+		t.Errorf("unaccounted keys mismatch: have %d, %q", result.Unaccounted.Count, result.UnaccountedKeys)
+	}
+	if total+result.Unaccounted.Count != uint64(db.Len()) {
+		t.Errorf("total item count mismatch: have %d, want %d", total+result.Unaccounted.Count, db.Len())
+	}
+	if len(result.Ancients) != 0 {
+		t.Errorf("ancient tables reported without an ancient store: %d", len(result.Ancients))
+	}
+}
@@ -58,12 +58,14 @@ var (
 	blockReceiptsPrefix = []byte("r") // blockReceiptsPrefix + num (uint64 big endian) + hash -> block receipts
 	lookupPrefix        = []byte("l") // lookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix     = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
+	issuancePrefix      = []byte("I") // issuancePrefix + num (uint64 big endian) + hash -> cumulative issuance
 
 	preimagePrefix = "secure-key-"              // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
+	IssuanceIndexPrefix  = []byte("iI") // IssuanceIndexPrefix is the data table of the issuance indexer to track its progress
 
 	// used by old db, now only used for conversion
 	oldReceiptsPrefix = []byte("receipts-")
@@ -83,6 +85,42 @@ type TxLookupEntry struct {
 	Index      uint64
 }
 
+// Issuance is the amount of coins created by block rewards, split up by the kind
+// of credit.
+type Issuance struct {
+	Miner          *big.Int // Static block rewards credited to miners
+	UncleInclusion *big.Int // Bonuses credited to miners for including uncles
+	Uncles         *big.Int // Rewards credited to the miners of uncles
+	DevFunds       *big.Int // Payouts credited to dev-fund addresses
+}
+
+// NewIssuance creates an empty issuance tally.
+func NewIssuance() *Issuance {
+	return &Issuance{
+		Miner:          new(big.Int),
+		UncleInclusion: new(big.Int),
+		Uncles:         new(big.Int),
+		DevFunds:       new(big.Int),
+	}
+}
+
+// Copy creates a deep copy of the issuance tally.
+func (i *Issuance) Copy() *Issuance {
+	return &Issuance{
+		Miner:          new(big.Int).Set(i.Miner),
+		UncleInclusion: new(big.Int).Set(i.UncleInclusion),
+		Uncles:         new(big.Int).Set(i.Uncles),
+		DevFunds:       new(big.Int).Set(i.DevFunds),
+	}
+}
+
+// Total returns the total amount of coins issued.
+func (i *Issuance) Total() *big.Int {
+	total := new(big.Int).Add(i.Miner, i.UncleInclusion)
+	total.Add(total, i.Uncles)
+	return total.Add(total, i.DevFunds)
+}
+
 // encodeBlockNumber encodes a block number as big endian uint64
 func encodeBlockNumber(number uint64) []byte {
 	enc := make([]byte, 8)
@@ -90,8 +128,38 @@ func encodeBlockNumber(number uint64) []byte {
 	return enc
 }
 
+// isAncient checks whether the canonical block with the given hash and number
+// has been moved into the ancient store of the database.
+func isAncient(db DatabaseReader, hash common.Hash, number uint64) bool {
+	reader, ok := db.(ethdb.AncientReader)
+	if !ok {
+		return false
+	}
+	if frozen, _ := reader.Ancients(); number >= frozen {
+		return false
+	}
+	stored, _ := reader.Ancient(ethdb.FreezerHashTable, number)
+	return bytes.Equal(stored, hash[:])
+}
+
+// readAncient retrieves the data of the given kind belonging to a canonical
+// block from the ancient store, or nil if the block has not been frozen.
+func readAncient(db DatabaseReader, kind string, hash common.Hash, number uint64) []byte {
+	if !isAncient(db, hash, number) {
+		return nil
+	}
+	data, _ := db.(ethdb.AncientReader).Ancient(kind, number)
+	return data
+}
+
 // GetCanonicalHash retrieves a hash assigned to a canonical block number.
 func GetCanonicalHash(db DatabaseReader, number uint64) common.Hash {
+	if reader, ok := db.(ethdb.AncientReader); ok {
+		if frozen, _ := reader.Ancients(); number < frozen {
+			data, _ := reader.Ancient(ethdb.FreezerHashTable, number)
+			return common.BytesToHash(data)
+		}
+	}
 	data, _ := db.Get(append(append(headerPrefix, encodeBlockNumber(number)...), numSuffix...))
 	if len(data) == 0 {
 		return common.Hash{}
@@ -160,10 +228,22 @@ func GetTrieSyncProgress(db DatabaseReader) uint64 {
 // GetHeaderRLP retrieves a block header in its raw RLP database encoding, or nil
 // if the header's not found.
 func GetHeaderRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
+	if data := readAncient(db, ethdb.FreezerHeaderTable, hash, number); len(data) > 0 {
+		return data
+	}
 	data, _ := db.Get(headerKey(hash, number))
 	return data
 }
 
+// HasHeader verifies the existence of a block header corresponding to the hash.
+func HasHeader(db ethdb.Database, hash common.Hash, number uint64) bool {
+	if isAncient(db, hash, number) {
+		return true
+	}
+	ok, _ := db.Has(headerKey(hash, number))
+	return ok
+}
+
 // GetHeader retrieves the block header corresponding to the hash, nil if none
 // found.
 func GetHeader(db DatabaseReader, hash common.Hash, number uint64) *types.Header {
@@ -181,10 +261,22 @@ func GetHeader(db DatabaseReader, hash common.Hash, number uint64) *types.Header
 
 // GetBodyRLP retrieves the block body (transactions and uncles) in RLP encoding.
 func GetBodyRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
+	if data := readAncient(db, ethdb.FreezerBodiesTable, hash, number); len(data) > 0 {
+		return data
+	}
 	data, _ := db.Get(blockBodyKey(hash, number))
 	return data
 }
 
+// HasBody verifies the existence of a block body corresponding to the hash.
+func HasBody(db ethdb.Database, hash common.Hash, number uint64) bool {
+	if isAncient(db, hash, number) {
+		return true
+	}
+	ok, _ := db.Has(blockBodyKey(hash, number))
+	return ok
+}
+
 func headerKey(hash common.Hash, number uint64) []byte {
 	return append(append(headerPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
@@ -193,6 +285,14 @@ func blockBodyKey(hash common.Hash, number uint64) []byte {
 	return append(append(bodyPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+func tdKey(hash common.Hash, number uint64) []byte {
+	return append(headerKey(hash, number), tdSuffix...)
+}
+
+func blockReceiptsKey(hash common.Hash, number uint64) []byte {
+	return append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
 // GetBody retrieves the block body (transactons, uncles) corresponding to the
 // hash, nil if none found.
 func GetBody(db DatabaseReader, hash common.Hash, number uint64) *types.Body {
@@ -211,7 +311,10 @@ func GetBody(db DatabaseReader, hash common.Hash, number uint64) *types.Body {
 // GetTd retrieves a block's total difficulty corresponding to the hash, nil if
 // none found.
 func GetTd(db DatabaseReader, hash common.Hash, number uint64) *big.Int {
-	data, _ := db.Get(append(append(append(headerPrefix, encodeBlockNumber(number)...), hash[:]...), tdSuffix...))
+	data := readAncient(db, ethdb.FreezerDifficultyTable, hash, number)
+	if len(data) == 0 {
+		data, _ = db.Get(append(append(append(headerPrefix, encodeBlockNumber(number)...), hash[:]...), tdSuffix...))
+	}
 	if len(data) == 0 {
 		return nil
 	}
@@ -246,7 +349,10 @@ func GetBlock(db DatabaseReader, hash common.Hash, number uint64) *types.Block {
 // GetBlockReceipts retrieves the receipts generated by the transactions included
 // in a block given by its hash.
 func GetBlockReceipts(db DatabaseReader, hash common.Hash, number uint64) types.Receipts {
-	data, _ := db.Get(append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash[:]...))
+	data := readAncient(db, ethdb.FreezerReceiptTable, hash, number)
+	if len(data) == 0 {
+		data, _ = db.Get(append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash[:]...))
+	}
 	if len(data) == 0 {
 		return nil
 	}
@@ -352,6 +458,21 @@ func GetBloomBits(db DatabaseReader, bit uint, section uint64, head common.Hash)
 	return db.Get(key)
 }
 
+// GetIssuance retrieves the cumulative issuance up to and including the block
+// with the given hash and number, or nil if it has not been indexed.
+func GetIssuance(db DatabaseReader, hash common.Hash, number uint64) *Issuance {
+	data, _ := db.Get(append(append(issuancePrefix, encodeBlockNumber(number)...), hash.Bytes()...))
+	if len(data) == 0 {
+		return nil
+	}
+	issuance := new(Issuance)
+	if err := rlp.Decode(bytes.NewReader(data), issuance); err != nil {
+		log.Error("Invalid issuance RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return issuance
+}
+
 // WriteCanonicalHash stores the canonical hash for the given block number.
 func WriteCanonicalHash(db ethdb.Putter, hash common.Hash, number uint64) error {
 	key := append(append(headerPrefix, encodeBlockNumber(number)...), numSuffix...)
@@ -513,6 +634,20 @@ func WriteBloomBits(db ethdb.Putter, bit uint, section uint64, head common.Hash,
 	}
 }
 
+// WriteIssuance stores the cumulative issuance up to and including the block
+// with the given hash and number.
+func WriteIssuance(db ethdb.Putter, hash common.Hash, number uint64, issuance *Issuance) error {
+	data, err := rlp.EncodeToBytes(issuance)
+	if err != nil {
+		return err
+	}
+	key := append(append(issuancePrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+	if err := db.Put(key, data); err != nil {
+		log.Crit("Failed to store issuance", "err", err)
+	}
+	return nil
+}
+
 // DeleteCanonicalHash removes the number to hash canonical mapping.
 func DeleteCanonicalHash(db DatabaseDeleter, number uint64) {
 	db.Delete(append(append(headerPrefix, encodeBlockNumber(number)...), numSuffix...))
@@ -19,6 +19,7 @@ package state
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 
 	"github.com/TeamEGEM/go-egem/common"
 	"github.com/TeamEGEM/go-egem/rlp"
@@ -71,6 +72,23 @@ func (self *StateDB) RawDump() Dump {
 	return dump
 }
 
+// TotalBalance sums the balances of all the accounts in the committed state trie.
+// It iterates the entire trie, so it's only suitable for small states such as the
+// genesis allocation.
+func (self *StateDB) TotalBalance() (*big.Int, error) {
+	total := new(big.Int)
+
+	it := trie.NewIterator(self.trie.NodeIterator(nil))
+	for it.Next() {
+		var data Account
+		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
+			return nil, err
+		}
+		total.Add(total, data.Balance)
+	}
+	return total, it.Err
+}
+
 func (self *StateDB) Dump() []byte {
 	json, err := json.MarshalIndent(self.RawDump(), "", "    ")
 	if err != nil {
@@ -0,0 +1,281 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/crypto"
+	"github.com/TeamEGEM/go-egem/ethdb"
+	"github.com/TeamEGEM/go-egem/log"
+	"github.com/TeamEGEM/go-egem/rlp"
+	"github.com/TeamEGEM/go-egem/trie"
+)
+
+const (
+	// pruneSweepBatch is the number of database entries swept (or accounts marked)
+	// between giving concurrent state writers a chance to run.
+	pruneSweepBatch = 10000
+
+	// pruneLogInterval is the time between two pruning progress reports.
+	pruneLogInterval = 8 * time.Second
+)
+
+var (
+	// PruneProgressKey tracks the last database key swept by an interrupted
+	// pruning run, allowing it to be resumed.
+	PruneProgressKey = []byte("StatePruneProgress")
+
+	// ErrPruneAborted is returned if a pruning run was interrupted before it
+	// finished. Its progress is saved and picked up by the next run.
+	ErrPruneAborted = errors.New("state pruning aborted")
+)
+
+// Pruner deletes all the state trie nodes and contract codes from a database
+// that are not reachable from a set of retained state roots.
+//
+// Pruning is done in two phases: all the entries reachable from the retained
+// roots are marked in memory first, after which the database is swept and every
+// unmarked state entry deleted. New states may be written to the database while
+// sweeping, as long as they are passed through Protect.
+type Pruner struct {
+	db     Database       // State database to resolve the retained tries through
+	diskdb ethdb.Database // Persistent database to delete the unreachable entries from
+
+	marked    map[common.Hash]struct{} // Hashes of the reachable trie nodes and codes
+	protected []common.Hash            // Roots written through Protect, not yet marked
+	lock      sync.Mutex               // Lock serialising the marking with the sweeping
+}
+
+// NewPruner creates a state pruner deleting unreachable entries from diskdb,
+// resolving the tries to retain through db.
+func NewPruner(db Database, diskdb ethdb.Database) *Pruner {
+	return &Pruner{
+		db:     db,
+		diskdb: diskdb,
+		marked: make(map[common.Hash]struct{}),
+	}
+}
+
+// PruneInterrupted reports whether a previous pruning run was interrupted
+// before it finished.
+func PruneInterrupted(db ethdb.Database) bool {
+	progress, _ := db.Get(PruneProgressKey)
+	return len(progress) > 0
+}
+
+// Mark flags all the trie nodes and contract codes of the state with the given
+// root as reachable, retaining them when sweeping.
+func (p *Pruner) Mark(root common.Hash, abort <-chan struct{}) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.mark(root, abort, true)
+}
+
+// Protect runs write, which is expected to persist the state with the given root
+// into the database, atomically with regard to sweeping, and schedules the state
+// to be marked as reachable. The marking itself is done by the sweeper before it
+// deletes anything else, so writers aren't held up walking the whole state.
+func (p *Pruner) Protect(root common.Hash, write func() error) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if err := write(); err != nil {
+		return err
+	}
+	p.protected = append(p.protected, root)
+	return nil
+}
+
+// markProtected marks all the states written through Protect since the last call.
+// The pruning lock is assumed to be held. Once the retained roots are marked, new
+// states mostly share their subtries, so only their fresh nodes are walked.
+func (p *Pruner) markProtected(abort <-chan struct{}) error {
+	for len(p.protected) > 0 {
+		if err := p.mark(p.protected[0], abort, false); err != nil {
+			return err
+		}
+		p.protected = p.protected[1:]
+	}
+	return nil
+}
+
+// mark flags all the entries reachable from a state root, skipping subtries that
+// were already marked. The pruning lock is assumed to be held. If yield is set,
+// the lock is periodically released to let state writers through; this is safe
+// as nothing is deleted until all the retained roots are marked.
+func (p *Pruner) mark(root common.Hash, abort <-chan struct{}, yield bool) error {
+	var (
+		start    = time.Now()
+		logged   = time.Now()
+		nodes    = len(p.marked)
+		accounts int
+	)
+	tr, err := p.db.OpenTrie(root)
+	if err != nil {
+		return err
+	}
+	it := tr.NodeIterator(nil)
+	for descend := true; it.Next(descend); {
+		if descend = p.markNode(it.Hash()); !descend || !it.Leaf() {
+			continue
+		}
+		// Reached an account, mark its storage trie and code
+		var account Account
+		if err := rlp.Decode(bytes.NewReader(it.LeafBlob()), &account); err != nil {
+			return err
+		}
+		if account.Root != EmptyRoot {
+			storage, err := p.db.OpenStorageTrie(common.BytesToHash(it.LeafKey()), account.Root)
+			if err != nil {
+				return err
+			}
+			if err := p.markTrie(storage.NodeIterator(nil)); err != nil {
+				return err
+			}
+		}
+		if !bytes.Equal(account.CodeHash, emptyCodeHash) {
+			p.markNode(common.BytesToHash(account.CodeHash))
+		}
+		// Report the progress of long running markings and check for interruption
+		if accounts++; yield && accounts%pruneSweepBatch == 0 {
+			p.lock.Unlock()
+			p.lock.Lock()
+		}
+		if time.Since(logged) > pruneLogInterval {
+			log.Info("Marking reachable state", "root", root, "accounts", accounts, "nodes", len(p.marked)-nodes, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		select {
+		case <-abort:
+			return ErrPruneAborted
+		default:
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	log.Debug("Marked reachable state", "root", root, "nodes", len(p.marked)-nodes, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// markTrie flags all the nodes of a storage trie as reachable.
+func (p *Pruner) markTrie(it trie.NodeIterator) error {
+	for descend := true; it.Next(descend); {
+		descend = p.markNode(it.Hash())
+	}
+	return it.Error()
+}
+
+// markNode flags a single node as reachable, returning whether it was unknown
+// before and its children need to be marked too. Embedded nodes, not stored on
+// their own, are always descended into.
+func (p *Pruner) markNode(hash common.Hash) bool {
+	if hash == (common.Hash{}) {
+		return true
+	}
+	if _, ok := p.marked[hash]; ok {
+		return false
+	}
+	p.marked[hash] = struct{}{}
+	return true
+}
+
+// Sweep iterates over the database and deletes all the state entries that were
+// not marked as reachable. If the sweeping is aborted, its progress is saved and
+// the next run will continue from where this one left off.
+func (p *Pruner) Sweep(abort <-chan struct{}) error {
+	var (
+		start  = time.Now()
+		logged = time.Now()
+
+		swept, deleted int
+		last           []byte
+	)
+	// Resume an interrupted run, or start sweeping from the beginning
+	progress, _ := p.diskdb.Get(PruneProgressKey)
+	if len(progress) > 0 {
+		log.Info("Resuming interrupted state pruning", "key", common.Bytes2Hex(progress))
+	}
+	it := p.diskdb.NewIteratorWithRange(progress, nil)
+	defer it.Release()
+
+	p.lock.Lock()
+	if err := p.markProtected(abort); err != nil {
+		p.lock.Unlock()
+		return err
+	}
+	for it.Next() {
+		// Trie nodes and contract codes are the only entries keyed by a plain hash
+		if key := it.Key(); len(key) == common.HashLength {
+			if _, ok := p.marked[common.BytesToHash(key)]; !ok && isStateEntry(key, it.Value()) {
+				if err := p.diskdb.Delete(key); err != nil {
+					p.lock.Unlock()
+					return err
+				}
+				deleted++
+			}
+		}
+		if swept++; swept%pruneSweepBatch != 0 {
+			continue
+		}
+		// Persist the progress and let state writers through
+		last = common.CopyBytes(it.Key())
+		if err := p.diskdb.Put(PruneProgressKey, last); err != nil {
+			p.lock.Unlock()
+			return err
+		}
+		p.lock.Unlock()
+
+		if time.Since(logged) > pruneLogInterval {
+			log.Info("Pruning state", "swept", swept, "deleted", deleted, "key", common.Bytes2Hex(last), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		select {
+		case <-abort:
+			log.Warn("State pruning aborted", "swept", swept, "deleted", deleted, "elapsed", common.PrettyDuration(time.Since(start)))
+			return ErrPruneAborted
+		default:
+		}
+		p.lock.Lock()
+		if err := p.markProtected(abort); err != nil {
+			p.lock.Unlock()
+			return err
+		}
+	}
+	p.lock.Unlock()
+
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := p.diskdb.Delete(PruneProgressKey); err != nil {
+		return err
+	}
+	log.Info("Pruned state", "swept", swept, "deleted", deleted, "retained", len(p.marked), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// isStateEntry checks whether a hash keyed database entry is a trie node or a
+// contract code, i.e. its key is the hash of its value.
+func isStateEntry(key, value []byte) bool {
+	return bytes.Equal(key, crypto.Keccak256(value))
+}
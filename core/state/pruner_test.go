@@ -0,0 +1,192 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/ethdb"
+)
+
+// newPrunerTestDatabase creates a temporary iterable database for pruning tests.
+func newPrunerTestDatabase(t *testing.T) (*ethdb.LDBDatabase, func()) {
+	dir, err := ioutil.TempDir("", "state-pruner-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	db, err := ethdb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("failed to create database: %v", err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// commitPrunerTestState modifies the accounts and storage of a state, persisting
+// the result into the database.
+func commitPrunerTestState(t *testing.T, db Database, root common.Hash, seed byte) common.Hash {
+	root = updatePrunerTestState(t, db, root, seed)
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to persist state: %v", err)
+	}
+	return root
+}
+
+// updatePrunerTestState modifies the accounts and storage of a state, keeping the
+// result in the memory of the trie database.
+func updatePrunerTestState(t *testing.T, db Database, root common.Hash, seed byte) common.Hash {
+	state, err := New(root, db)
+	if err != nil {
+		t.Fatalf("failed to open state %x: %v", root, err)
+	}
+	for i := byte(0); i < 32; i++ {
+		addr := common.BytesToAddress([]byte{i})
+
+		state.SetBalance(addr, big.NewInt(int64(seed)*int64(i)))
+		state.SetState(addr, common.BytesToHash([]byte{i}), common.BytesToHash([]byte{seed, i}))
+		if i%4 == 0 {
+			state.SetCode(addr, []byte{seed, i, i})
+		}
+	}
+	root, err = state.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	return root
+}
+
+// Tests that pruning deletes all the state entries only reachable from dropped
+// state roots, leaving the retained states and any unrelated data intact.
+func TestStatePruning(t *testing.T) {
+	diskdb, release := newPrunerTestDatabase(t)
+	defer release()
+
+	db := NewDatabase(diskdb)
+	first := commitPrunerTestState(t, db, common.Hash{}, 1)
+	second := commitPrunerTestState(t, db, first, 2)
+	third := commitPrunerTestState(t, db, second, 3)
+
+	// Insert some hash sized keys that are not state entries
+	junk := bytes.Repeat([]byte{0x11}, common.HashLength)
+	diskdb.Put(junk, []byte("not a trie node"))
+
+	// Prune all but the first and the last states
+	pruner := NewPruner(NewDatabase(diskdb), diskdb)
+	for _, root := range []common.Hash{first, third} {
+		if err := pruner.Mark(root, nil); err != nil {
+			t.Fatalf("failed to mark state %x: %v", root, err)
+		}
+	}
+	if err := pruner.Sweep(nil); err != nil {
+		t.Fatalf("failed to sweep database: %v", err)
+	}
+	for _, root := range []common.Hash{first, third} {
+		if err := checkStateConsistency(diskdb, root); err != nil {
+			t.Errorf("retained state %x inconsistent: %v", root, err)
+		}
+	}
+	if ok, _ := diskdb.Has(second[:]); ok {
+		t.Errorf("pruned state root %x still present", second)
+	}
+	if ok, _ := diskdb.Has(junk); !ok {
+		t.Errorf("unrelated database entry deleted")
+	}
+	if PruneInterrupted(diskdb) {
+		t.Errorf("finished pruning reported as interrupted")
+	}
+}
+
+// Tests that the pruning of an interrupted run resumes sweeping from its saved
+// progress.
+func TestStatePruningResume(t *testing.T) {
+	diskdb, release := newPrunerTestDatabase(t)
+	defer release()
+
+	db := NewDatabase(diskdb)
+	first := commitPrunerTestState(t, db, common.Hash{}, 1)
+	second := commitPrunerTestState(t, db, first, 2)
+
+	// Fake an interrupted run that already swept past every key
+	diskdb.Put(PruneProgressKey, bytes.Repeat([]byte{0xff}, common.HashLength))
+	if !PruneInterrupted(diskdb) {
+		t.Fatalf("interrupted pruning not detected")
+	}
+	pruner := NewPruner(NewDatabase(diskdb), diskdb)
+	if err := pruner.Mark(second, nil); err != nil {
+		t.Fatalf("failed to mark state: %v", err)
+	}
+	if err := pruner.Sweep(nil); err != nil {
+		t.Fatalf("failed to sweep database: %v", err)
+	}
+	if ok, _ := diskdb.Has(first[:]); !ok {
+		t.Errorf("resumed sweep deleted entries before its progress marker")
+	}
+	if PruneInterrupted(diskdb) {
+		t.Errorf("progress marker not cleared after resumed pruning")
+	}
+}
+
+// Tests that states written through Protect while pruning are only recorded by
+// the writer, and marked by the sweeper before it deletes anything.
+func TestStatePruningProtect(t *testing.T) {
+	diskdb, release := newPrunerTestDatabase(t)
+	defer release()
+
+	db := NewDatabase(diskdb)
+	first := commitPrunerTestState(t, db, common.Hash{}, 1)
+	second := commitPrunerTestState(t, db, first, 2)
+
+	pruner := NewPruner(NewDatabase(diskdb), diskdb)
+	if err := pruner.Mark(first, nil); err != nil {
+		t.Fatalf("failed to mark state: %v", err)
+	}
+	// Write a new state on top of the dropped one while pruning
+	marked := len(pruner.marked)
+
+	third := updatePrunerTestState(t, db, second, 3)
+	err := pruner.Protect(third, func() error {
+		return db.TrieDB().Commit(third, false)
+	})
+	if err != nil {
+		t.Fatalf("failed to protect state: %v", err)
+	}
+	if len(pruner.marked) != marked {
+		t.Fatalf("protected state marked by the writer: have %d nodes, want %d", len(pruner.marked), marked)
+	}
+	if err := pruner.Sweep(nil); err != nil {
+		t.Fatalf("failed to sweep database: %v", err)
+	}
+	for _, root := range []common.Hash{first, third} {
+		if err := checkStateConsistency(diskdb, root); err != nil {
+			t.Errorf("retained state %x inconsistent: %v", root, err)
+		}
+	}
+	if ok, _ := diskdb.Has(second[:]); ok {
+		t.Errorf("pruned state root %x still present", second)
+	}
+	if len(pruner.protected) != 0 {
+		t.Errorf("protected states left unmarked: %d", len(pruner.protected))
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"time"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/core/state"
+	"github.com/TeamEGEM/go-egem/ethdb"
+	"github.com/TeamEGEM/go-egem/log"
+)
+
+// statePruneInterval is the number of blocks between two online state prunings.
+const statePruneInterval = 50000
+
+// errNoRetainedState is returned if no state is found to retain when pruning.
+var errNoRetainedState = errors.New("no state found to retain")
+
+// RecentStateRoots walks the canonical chain backwards from the current head and
+// returns the roots of the most recent states persisted in the database, up to
+// the requested number, together with the genesis state root.
+func RecentStateRoots(db ethdb.Database, recent uint64) ([]common.Hash, error) {
+	hash := GetHeadBlockHash(db)
+	if hash == (common.Hash{}) {
+		return nil, errors.New("head block missing")
+	}
+	var (
+		roots  []common.Hash
+		header = GetHeader(db, hash, GetBlockNumber(db, hash))
+	)
+	for header != nil && uint64(len(roots)) < recent && header.Number.Sign() > 0 {
+		if ok, _ := db.Has(header.Root[:]); ok {
+			roots = append(roots, header.Root)
+		}
+		header = GetHeader(db, header.ParentHash, header.Number.Uint64()-1)
+	}
+	if len(roots) == 0 {
+		return nil, errNoRetainedState
+	}
+	genesis := GetHeader(db, GetCanonicalHash(db, 0), 0)
+	if genesis == nil {
+		return nil, ErrNoGenesis
+	}
+	return append(roots, genesis.Root), nil
+}
+
+// PruneState deletes all the historical state from an offline database, apart
+// from the most recent persisted states and the genesis state. An aborted run
+// can be resumed by calling PruneState again.
+func PruneState(db ethdb.Database, recent uint64, abort <-chan struct{}) error {
+	roots, err := RecentStateRoots(db, recent)
+	if err != nil {
+		return err
+	}
+	return runPruner(state.NewPruner(state.NewDatabase(db), db), roots, abort)
+}
+
+// runPruner marks the given state roots as reachable and sweeps all other state
+// from the database.
+func runPruner(pruner *state.Pruner, roots []common.Hash, abort <-chan struct{}) error {
+	start := time.Now()
+	for i, root := range roots {
+		log.Info("Marking state to retain", "root", root, "index", i+1, "total", len(roots))
+		if err := pruner.Mark(root, abort); err != nil {
+			return err
+		}
+	}
+	log.Info("Marked state to retain", "roots", len(roots), "elapsed", common.PrettyDuration(time.Since(start)))
+	return pruner.Sweep(abort)
+}
+
+// startStatePruning launches a background state pruning, unless one is already
+// running. While pruning, all state flushed to disk is protected from deletion.
+func (bc *BlockChain) startStatePruning() {
+	bc.prunemu.Lock()
+	defer bc.prunemu.Unlock()
+
+	if bc.pruner != nil {
+		return
+	}
+	pruner := state.NewPruner(bc.stateCache, bc.db)
+	bc.pruner = pruner
+
+	bc.wg.Add(1)
+	go func() {
+		defer bc.wg.Done()
+
+		// The roots are gathered only after the pruner is live, so any state
+		// flushed in between is protected
+		roots, err := RecentStateRoots(bc.db, bc.cacheConfig.StateRetention)
+		if err == nil {
+			err = runPruner(pruner, roots, bc.quit)
+		}
+		switch err {
+		case nil, state.ErrPruneAborted:
+		default:
+			log.Error("State pruning failed", "err", err)
+		}
+		bc.prunemu.Lock()
+		bc.pruner = nil
+		bc.prunemu.Unlock()
+	}()
+}
+
+// commitState flushes the state trie with the given root from memory to disk,
+// protecting it from deletion by any running state pruning.
+func (bc *BlockChain) commitState(root common.Hash) error {
+	bc.prunemu.Lock()
+	defer bc.prunemu.Unlock()
+
+	triedb := bc.stateCache.TrieDB()
+	if bc.pruner == nil {
+		return triedb.Commit(root, true)
+	}
+	return bc.pruner.Protect(root, func() error {
+		return triedb.Commit(root, true)
+	})
+}
@@ -0,0 +1,115 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io"
+	"os"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/log"
+	"github.com/TeamEGEM/go-egem/rlp"
+)
+
+// txMempool is a one-shot snapshot of the remote transactions contained in the
+// pool, written on shutdown and consumed on startup to avoid having to rebuild
+// the pending and queued sets from the network after every restart.
+//
+// Contrary to the local transaction journal, the snapshot is not kept up to date
+// while the pool is running, so a crash loses its contents.
+type txMempool struct {
+	path  string // Filesystem path to store the transactions at
+	limit uint64 // Maximum number of transactions to store in the snapshot
+}
+
+// newTxMempool creates a new mempool snapshot at the given path, bounded to the
+// specified number of transactions.
+func newTxMempool(path string, limit uint64) *txMempool {
+	return &txMempool{
+		path:  path,
+		limit: limit,
+	}
+}
+
+// load parses a mempool snapshot from disk, returning the contained transactions
+// to be injected into the pool. The snapshot is deleted afterwards, so a stale
+// one is never imported twice.
+func (mempool *txMempool) load() (types.Transactions, error) {
+	// Skip the parsing if the snapshot file doesn't exist at all
+	if _, err := os.Stat(mempool.path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	input, err := os.Open(mempool.path)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(mempool.path)
+	defer input.Close()
+
+	// Parse all the transactions, up to the configured limit
+	var (
+		stream  = rlp.NewStream(input, 0)
+		txs     types.Transactions
+		failure error
+	)
+	for uint64(len(txs)) < mempool.limit {
+		tx := new(types.Transaction)
+		if err = stream.Decode(tx); err != nil {
+			if err != io.EOF {
+				failure = err
+			}
+			break
+		}
+		txs = append(txs, tx)
+	}
+	return txs, failure
+}
+
+// save writes the given pending and queued transactions into the snapshot file.
+// Executable transactions take precedence over queued ones, and if the limit is
+// reached, accounts are cut short keeping their lowest nonces only.
+func (mempool *txMempool) save(pending, queued map[common.Address]types.Transactions) error {
+	replacement, err := os.OpenFile(mempool.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	saved := uint64(0)
+	for _, all := range []map[common.Address]types.Transactions{pending, queued} {
+		for _, txs := range all {
+			for _, tx := range txs {
+				if saved >= mempool.limit {
+					break
+				}
+				if err = rlp.Encode(replacement, tx); err != nil {
+					replacement.Close()
+					return err
+				}
+				saved++
+			}
+		}
+	}
+	if err = replacement.Close(); err != nil {
+		return err
+	}
+	// Replace any previous snapshot with the newly generated one
+	if err = os.Rename(mempool.path+".new", mempool.path); err != nil {
+		return err
+	}
+	log.Info("Saved transaction pool snapshot", "transactions", saved)
+	return nil
+}
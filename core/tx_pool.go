@@ -129,6 +129,9 @@ type TxPoolConfig struct {
 	Journal   string        // Journal of local transactions to survive node restarts
 	Rejournal time.Duration // Time interval to regenerate the local transaction journal
 
+	Mempool     string // Snapshot of all remote transactions to survive node restarts (empty = disabled)
+	MempoolSize uint64 // Maximum number of transactions to store in the mempool snapshot
+
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
 
@@ -146,6 +149,8 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	Journal:   "transactions.rlp",
 	Rejournal: time.Hour,
 
+	MempoolSize: 4096,
+
 	PriceLimit: 1,
 	PriceBump:  10,
 
@@ -173,6 +178,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultTxPoolConfig.PriceBump)
 		conf.PriceBump = DefaultTxPoolConfig.PriceBump
 	}
+	if conf.Mempool != "" && conf.MempoolSize < 1 {
+		log.Warn("Sanitizing invalid txpool mempool size", "provided", conf.MempoolSize, "updated", DefaultTxPoolConfig.MempoolSize)
+		conf.MempoolSize = DefaultTxPoolConfig.MempoolSize
+	}
 	return conf
 }
 
@@ -201,6 +210,7 @@ type TxPool struct {
 
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
 	journal *txJournal  // Journal of local transaction to back up to disk
+	mempool *txMempool  // Snapshot of remote transactions to back up to disk
 
 	pending map[common.Address]*txList         // All currently processable transactions
 	queue   map[common.Address]*txList         // Queued but non-processable transactions
@@ -247,6 +257,25 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If the mempool snapshot is enabled, reinject the remote transactions too
+	if config.Mempool != "" {
+		pool.mempool = newTxMempool(config.Mempool, config.MempoolSize)
+
+		txs, err := pool.mempool.load()
+		if err != nil {
+			log.Warn("Failed to load transaction pool snapshot", "err", err)
+		}
+		dropped := 0
+		for _, err := range pool.AddRemotes(txs) {
+			if err != nil {
+				log.Debug("Failed to add snapshotted transaction", "err", err)
+				dropped++
+			}
+		}
+		if len(txs) > 0 {
+			log.Info("Loaded transaction pool snapshot", "transactions", len(txs), "dropped", dropped)
+		}
+	}
 	// Subscribe events from blockchain
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
 
@@ -440,6 +469,11 @@ func (pool *TxPool) Stop() {
 	pool.chainHeadSub.Unsubscribe()
 	pool.wg.Wait()
 
+	if pool.mempool != nil {
+		if err := pool.mempool.save(pool.remote()); err != nil {
+			log.Warn("Failed to save transaction pool snapshot", "err", err)
+		}
+	}
 	if pool.journal != nil {
 		pool.journal.close()
 	}
@@ -473,6 +507,84 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 	log.Info("Transaction pool price threshold updated", "price", price)
 }
 
+// PriceBump returns the minimum price bump percentage currently required to
+// replace an already pooled transaction.
+func (pool *TxPool) PriceBump() uint64 {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.config.PriceBump
+}
+
+// SetPriceBump updates the minimum price bump percentage required to replace an
+// already pooled transaction with one of the same nonce.
+func (pool *TxPool) SetPriceBump(bump uint64) error {
+	if bump < 1 {
+		return fmt.Errorf("invalid price bump %d%%", bump)
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.config.PriceBump = bump
+	log.Info("Transaction pool price bump updated", "bump", bump)
+	return nil
+}
+
+// Remove drops a single transaction from the pool, moving all subsequent
+// transactions of the same account back to the future queue. Dropped local
+// transactions are also removed from the journal so they don't come back after
+// a restart. It returns whether the transaction was found.
+func (pool *TxPool) Remove(hash common.Hash) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	tx, ok := pool.all[hash]
+	if !ok {
+		return false
+	}
+	from, _ := types.Sender(pool.signer, tx) // already validated during insertion
+	pool.removeTx(hash)
+	pool.rejournal(from)
+
+	log.Info("Removed pooled transaction", "hash", hash, "from", from)
+	return true
+}
+
+// RemoveSender drops all pending and queued transactions of an account from the
+// pool and the journal, returning the number of transactions removed.
+func (pool *TxPool) RemoveSender(addr common.Address) int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	var txs types.Transactions
+	if pending := pool.pending[addr]; pending != nil {
+		txs = append(txs, pending.Flatten()...)
+	}
+	if queued := pool.queue[addr]; queued != nil {
+		txs = append(txs, queued.Flatten()...)
+	}
+	// Remove the highest nonces first to avoid pointlessly requeueing the rest
+	for i := len(txs) - 1; i >= 0; i-- {
+		pool.removeTx(txs[i].Hash())
+	}
+	if len(txs) > 0 {
+		pool.rejournal(addr)
+		log.Info("Removed pooled transactions of account", "from", addr, "count", len(txs))
+	}
+	return len(txs)
+}
+
+// rejournal regenerates the local transaction journal if the given account is a
+// local one, dropping any of its transactions no longer in the pool.
+func (pool *TxPool) rejournal(addr common.Address) {
+	if pool.journal == nil || !pool.locals.contains(addr) {
+		return
+	}
+	if err := pool.journal.rotate(pool.local()); err != nil {
+		log.Warn("Failed to rotate local tx journal", "err", err)
+	}
+}
+
 // State returns the virtual managed state of the transaction pool.
 func (pool *TxPool) State() *state.ManagedState {
 	pool.mu.RLock()
@@ -551,6 +663,27 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	return txs
 }
 
+// remote retrieves all currently known non-local transactions, split into the
+// pending and queued sets, groupped by origin account and sorted by nonce.
+func (pool *TxPool) remote() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	pending := make(map[common.Address]types.Transactions)
+	for addr, list := range pool.pending {
+		if !pool.locals.contains(addr) {
+			pending[addr] = list.Flatten()
+		}
+	}
+	queued := make(map[common.Address]types.Transactions)
+	for addr, list := range pool.queue {
+		if !pool.locals.contains(addr) {
+			queued[addr] = list.Flatten()
+		}
+	}
+	return pending, queued
+}
+
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
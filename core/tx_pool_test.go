@@ -1624,6 +1624,193 @@ func testTransactionJournaling(t *testing.T, nolocals bool) {
 	pool.Stop()
 }
 
+// Tests that the replacement price bump can be changed at runtime.
+func TestTransactionReplacementPriceBump(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	if err := pool.SetPriceBump(0); err == nil {
+		t.Fatalf("zero price bump accepted")
+	}
+	if err := pool.SetPriceBump(50); err != nil {
+		t.Fatalf("failed to set price bump: %v", err)
+	}
+	if bump := pool.PriceBump(); bump != 50 {
+		t.Fatalf("price bump mismatch: have %d, want %d", bump, 50)
+	}
+	if err := pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(100), key)); err != nil {
+		t.Fatalf("failed to add original transaction: %v", err)
+	}
+	if err := pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(149), key)); err != ErrReplaceUnderpriced {
+		t.Fatalf("underpriced replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	if err := pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(150), key)); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that transactions can be explicitly evicted from the pool, and that
+// evicted local transactions are dropped from the journal too.
+func TestTransactionRemoval(t *testing.T) {
+	t.Parallel()
+
+	// Create a temporary file for the journal
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary journal: %v", err)
+	}
+	journal := file.Name()
+	defer os.Remove(journal)
+
+	file.Close()
+	os.Remove(journal)
+
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Journal = journal
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	local, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+
+	// Add a few local and remote transactions, with a nonce gap for the locals
+	locals := []*types.Transaction{
+		transaction(0, 100000, local),
+		transaction(1, 100000, local),
+		transaction(2, 100000, local),
+		transaction(4, 100000, local),
+	}
+	for _, tx := range locals {
+		if err := pool.AddLocal(tx); err != nil {
+			t.Fatalf("failed to add local transaction: %v", err)
+		}
+	}
+	for i := uint64(0); i < 3; i++ {
+		if err := pool.AddRemote(transaction(i, 100000, remote)); err != nil {
+			t.Fatalf("failed to add remote transaction: %v", err)
+		}
+	}
+	// Remove a pending local transaction and ensure the subsequent ones are queued
+	if !pool.Remove(locals[1].Hash()) {
+		t.Fatalf("failed to remove local transaction")
+	}
+	if pool.Remove(locals[1].Hash()) {
+		t.Fatalf("removed transaction removed again")
+	}
+	if pending, queued := pool.Stats(); pending != 4 || queued != 2 {
+		t.Fatalf("pool stats mismatch: have %d pending, %d queued, want 4 pending, 2 queued", pending, queued)
+	}
+	// Remove all the transactions of the remote account
+	if removed := pool.RemoveSender(crypto.PubkeyToAddress(remote.PublicKey)); removed != 3 {
+		t.Fatalf("removed transaction count mismatch: have %d, want %d", removed, 3)
+	}
+	if removed := pool.RemoveSender(crypto.PubkeyToAddress(remote.PublicKey)); removed != 0 {
+		t.Fatalf("removed transaction count mismatch: have %d, want %d", removed, 0)
+	}
+	if pending, queued := pool.Stats(); pending != 1 || queued != 2 {
+		t.Fatalf("pool stats mismatch: have %d pending, %d queued, want 1 pending, 2 queued", pending, queued)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Restart the pool and ensure the removed local transaction didn't come back
+	pool.Stop()
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+
+	if pool.Get(locals[1].Hash()) != nil {
+		t.Fatalf("removed local transaction restored from journal")
+	}
+	if pending, queued := pool.Stats(); pending != 1 || queued != 2 {
+		t.Fatalf("pool stats mismatch after restart: have %d pending, %d queued, want 1 pending, 2 queued", pending, queued)
+	}
+	// Remove the remaining local transactions and ensure none of them come back
+	if removed := pool.RemoveSender(crypto.PubkeyToAddress(local.PublicKey)); removed != 3 {
+		t.Fatalf("removed transaction count mismatch: have %d, want %d", removed, 3)
+	}
+	pool.Stop()
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	if pending, queued := pool.Stats(); pending != 0 || queued != 0 {
+		t.Fatalf("pool stats mismatch after restart: have %d pending, %d queued, want empty pool", pending, queued)
+	}
+}
+
+// Tests that remote transactions are saved into the mempool snapshot on shutdown
+// and restored on startup, bounded by the configured snapshot size.
+func TestTransactionMempoolSnapshot(t *testing.T) {
+	t.Parallel()
+
+	// Create a temporary file for the snapshot
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary snapshot: %v", err)
+	}
+	mempool := file.Name()
+	defer os.Remove(mempool)
+
+	file.Close()
+	os.Remove(mempool)
+
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Mempool = mempool
+	config.MempoolSize = 4
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	local, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+
+	// Add a local and a few remote transactions, some of them queued
+	if err := pool.AddLocal(transaction(0, 100000, local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	for _, nonce := range []uint64{0, 1, 2, 4, 5} {
+		if err := pool.AddRemote(transaction(nonce, 100000, remote)); err != nil {
+			t.Fatalf("failed to add remote transaction: %v", err)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != 4 || queued != 2 {
+		t.Fatalf("pool stats mismatch: have %d pending, %d queued, want 4 pending, 2 queued", pending, queued)
+	}
+	// Restart the pool and ensure the remote transactions are restored up to the limit
+	pool.Stop()
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+
+	if pending, queued := pool.Stats(); pending != 3 || queued != 1 {
+		t.Fatalf("pool stats mismatch after restart: have %d pending, %d queued, want 3 pending, 1 queued", pending, queued)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Ensure the snapshot was consumed and isn't loaded again after a crash
+	if _, err := os.Stat(mempool); !os.IsNotExist(err) {
+		t.Fatalf("mempool snapshot not consumed on load: %v", err)
+	}
+	pool.Stop()
+}
+
 // TestTransactionStatusCheck tests that the pool can correctly retrieve the
 // pending status of individual transactions.
 func TestTransactionStatusCheck(t *testing.T) {
@@ -115,6 +115,40 @@ func (api *PublicMinerAPI) SubmitHashrate(hashrate hexutil.Uint64, id common.Has
 	return true
 }
 
+// NewWork creates a subscription that fires with the work package of every new
+// sealing task, in the format of GetWork extended with the block number. Pushed
+// work can be submitted through SubmitWork without calling GetWork first.
+func (api *PublicMinerAPI) NewWork(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		work := make(chan *miner.Work, 1)
+		workSub := api.agent.SubscribeWork(work)
+
+		for {
+			select {
+			case w := <-work:
+				if w == nil {
+					continue
+				}
+				notifier.Notify(rpcSub.ID, miner.WorkPackage(w.Block))
+			case <-rpcSub.Err():
+				workSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				workSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // PrivateMinerAPI provides private RPC methods to control the miner.
 // These methods can be abused by external users and must be considered insecure for use by untrusted users.
 type PrivateMinerAPI struct {
@@ -457,6 +491,17 @@ func (api *PrivateDebugAPI) GetModifiedAccountsByHash(startHash common.Hash, end
 	return api.getModifiedAccounts(startBlock, endBlock)
 }
 
+// DevFundReport audits the dev-fund payouts of the canonical blocks between from
+// and to (inclusive, defaulting to the head), reporting the credits of every
+// address per reward era checked against the balance changes of the addresses.
+func (api *PrivateDebugAPI) DevFundReport(from uint64, to *uint64) (*DevFundReport, error) {
+	end := api.eth.blockchain.CurrentBlock().NumberU64()
+	if to != nil {
+		end = *to
+	}
+	return AuditDevFunds(api.eth.blockchain, from, end)
+}
+
 func (api *PrivateDebugAPI) getModifiedAccounts(startBlock, endBlock *types.Block) ([]common.Address, error) {
 	if startBlock.Number().Uint64() >= endBlock.Number().Uint64() {
 		return nil, fmt.Errorf("start block height (%d) must be less than end block height (%d)", startBlock.Number().Uint64(), endBlock.Number().Uint64())
@@ -184,6 +184,18 @@ func (b *EthApiBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Sub
 	return b.eth.TxPool().SubscribeTxPreEvent(ch)
 }
 
+func (b *EthApiBackend) RemovePoolTransaction(hash common.Hash) bool {
+	return b.eth.TxPool().Remove(hash)
+}
+
+func (b *EthApiBackend) RemovePoolSender(addr common.Address) int {
+	return b.eth.TxPool().RemoveSender(addr)
+}
+
+func (b *EthApiBackend) SetPoolPriceBump(bump uint64) error {
+	return b.eth.TxPool().SetPriceBump(bump)
+}
+
 func (b *EthApiBackend) Downloader() *downloader.Downloader {
 	return b.eth.Downloader()
 }
@@ -208,6 +220,10 @@ func (b *EthApiBackend) AccountManager() *accounts.Manager {
 	return b.eth.AccountManager()
 }
 
+func (b *EthApiBackend) MultisigStore() string {
+	return b.eth.config.MultisigStore
+}
+
 func (b *EthApiBackend) BloomStatus() (uint64, uint64) {
 	sections, _, _ := b.eth.bloomIndexer.Sections()
 	return params.BloomBitsBlocks, sections
@@ -0,0 +1,284 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/consensus/ethash"
+	"github.com/TeamEGEM/go-egem/core"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/params"
+	"github.com/TeamEGEM/go-egem/rpc"
+)
+
+// errNoRewardSchedule is returned if reward information is requested on a chain
+// that is not sealed by ethash.
+var errNoRewardSchedule = errors.New("chain has no ethash reward schedule")
+
+// PublicRewardAPI provides an API to inspect the block rewards issued by the
+// ethash reward schedule and the resulting coin supply.
+type PublicRewardAPI struct {
+	e *Ethereum
+
+	genesisOnce   sync.Once // Ensures the genesis supply is only summed once
+	genesisSupply *big.Int  // Total balance allocated in the genesis block
+	genesisErr    error     // Error encountered while summing the genesis supply
+}
+
+// NewPublicRewardAPI creates a new API to query block rewards.
+func NewPublicRewardAPI(e *Ethereum) *PublicRewardAPI {
+	return &PublicRewardAPI{e: e}
+}
+
+// RPCRewardEra is a reward era of the schedule in its RPC representation.
+type RPCRewardEra struct {
+	Block                 hexutil.Uint64 `json:"block"`
+	BlockReward           *hexutil.Big   `json:"blockReward"`
+	UncleInclusionDivisor *hexutil.Big   `json:"uncleInclusionDivisor"`
+	RewardUncles          bool           `json:"rewardUncles"`
+	DevRewards            []RPCDevReward `json:"devRewards"`
+}
+
+// RPCDevReward is a dev-fund payout in its RPC representation.
+type RPCDevReward struct {
+	Address common.Address `json:"address"`
+	Amount  *hexutil.Big   `json:"amount"`
+}
+
+// RPCUncleReward is the reward credited to the coinbase of an included uncle.
+type RPCUncleReward struct {
+	Hash   common.Hash    `json:"hash"`
+	Number hexutil.Uint64 `json:"number"`
+	Miner  common.Address `json:"miner"`
+	Reward *hexutil.Big   `json:"reward"`
+}
+
+// RPCBlockReward is the breakdown of all the credits applied when a block was
+// finalized.
+type RPCBlockReward struct {
+	Number               hexutil.Uint64   `json:"number"`
+	Hash                 common.Hash      `json:"hash"`
+	Era                  hexutil.Uint64   `json:"era"`
+	Miner                common.Address   `json:"miner"`
+	BlockReward          *hexutil.Big     `json:"blockReward"`
+	UncleInclusionReward *hexutil.Big     `json:"uncleInclusionReward"`
+	Uncles               []RPCUncleReward `json:"uncles"`
+	DevRewards           []RPCDevReward   `json:"devRewards"`
+	Total                *hexutil.Big     `json:"total"`
+}
+
+// RPCSupply is the projected coin supply at a given height.
+type RPCSupply struct {
+	Number  hexutil.Uint64 `json:"number"`
+	Genesis *hexutil.Big   `json:"genesis"`
+	Issued  *hexutil.Big   `json:"issued"`
+	Total   *hexutil.Big   `json:"total"`
+}
+
+// GetBlockReward returns the rewards credited by the block with the given number.
+func (api *PublicRewardAPI) GetBlockReward(blockNr rpc.BlockNumber) (*RPCBlockReward, error) {
+	var block *types.Block
+	switch blockNr {
+	case rpc.PendingBlockNumber:
+		block = api.e.miner.PendingBlock()
+	case rpc.LatestBlockNumber:
+		block = api.e.blockchain.CurrentBlock()
+	default:
+		block = api.e.blockchain.GetBlockByNumber(uint64(blockNr))
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", blockNr)
+	}
+	return api.blockReward(block)
+}
+
+// GetBlockRewardByHash returns the rewards credited by the block with the given hash.
+func (api *PublicRewardAPI) GetBlockRewardByHash(hash common.Hash) (*RPCBlockReward, error) {
+	block := api.e.blockchain.GetBlockByHash(hash)
+	if block == nil {
+		return nil, fmt.Errorf("block %x not found", hash)
+	}
+	return api.blockReward(block)
+}
+
+// blockReward assembles the reward breakdown of a block.
+func (api *PublicRewardAPI) blockReward(block *types.Block) (*RPCBlockReward, error) {
+	config := api.e.chainConfig
+	if config.Ethash == nil {
+		return nil, errNoRewardSchedule
+	}
+	uncles := block.Uncles()
+	rewards := ethash.CalcBlockRewards(config, block.Header(), uncles)
+
+	result := &RPCBlockReward{
+		Number:               hexutil.Uint64(block.NumberU64()),
+		Hash:                 block.Hash(),
+		Miner:                block.Coinbase(),
+		BlockReward:          (*hexutil.Big)(rewards.Miner),
+		UncleInclusionReward: (*hexutil.Big)(rewards.UncleBonus),
+		Uncles:               make([]RPCUncleReward, len(uncles)),
+		DevRewards:           make([]RPCDevReward, len(rewards.Dev)),
+		Total:                (*hexutil.Big)(rewards.Total()),
+	}
+	if rewards.Era != nil {
+		result.Era = hexutil.Uint64(rewardEraIndex(config.Ethash.RewardEras(), rewards.Era.Block))
+	}
+	for i, uncle := range uncles {
+		result.Uncles[i] = RPCUncleReward{
+			Hash:   uncle.Hash(),
+			Number: hexutil.Uint64(uncle.Number.Uint64()),
+			Miner:  uncle.Coinbase,
+			Reward: (*hexutil.Big)(rewards.Uncles[i]),
+		}
+	}
+	for i, dev := range rewards.Dev {
+		result.DevRewards[i] = RPCDevReward{Address: dev.Address, Amount: (*hexutil.Big)(dev.Amount)}
+	}
+	return result, nil
+}
+
+// RewardSchedule returns all the reward eras of the chain.
+func (api *PublicRewardAPI) RewardSchedule() ([]RPCRewardEra, error) {
+	config := api.e.chainConfig
+	if config.Ethash == nil {
+		return nil, errNoRewardSchedule
+	}
+	eras := config.Ethash.RewardEras()
+
+	schedule := make([]RPCRewardEra, len(eras))
+	for i, era := range eras {
+		schedule[i] = RPCRewardEra{
+			Block:                 hexutil.Uint64(era.Block.Uint64()),
+			BlockReward:           (*hexutil.Big)(era.BlockReward),
+			UncleInclusionDivisor: (*hexutil.Big)(era.Uncles.InclusionDivisor),
+			RewardUncles:          era.Uncles.RewardUncles,
+			DevRewards:            make([]RPCDevReward, len(era.DevRewards)),
+		}
+		for j, dev := range era.DevRewards {
+			schedule[i].DevRewards[j] = RPCDevReward{Address: dev.Address, Amount: (*hexutil.Big)(dev.Amount)}
+		}
+	}
+	return schedule, nil
+}
+
+// TotalSupply returns the projected coin supply at the given height: the genesis
+// allocation plus all the block rewards and dev-fund payouts the schedule issues
+// up to and including that block. The height may lie in the future. Uncle rewards
+// are not projected.
+func (api *PublicRewardAPI) TotalSupply(blockNr rpc.BlockNumber) (*RPCSupply, error) {
+	config := api.e.chainConfig
+	if config.Ethash == nil {
+		return nil, errNoRewardSchedule
+	}
+	number := uint64(blockNr)
+	switch blockNr {
+	case rpc.PendingBlockNumber:
+		number = api.e.blockchain.CurrentBlock().NumberU64() + 1
+	case rpc.LatestBlockNumber:
+		number = api.e.blockchain.CurrentBlock().NumberU64()
+	}
+	genesis, err := api.genesisAllocation()
+	if err != nil {
+		return nil, err
+	}
+	issued := ethash.ScheduledIssuance(config, number)
+
+	return &RPCSupply{
+		Number:  hexutil.Uint64(number),
+		Genesis: (*hexutil.Big)(genesis),
+		Issued:  (*hexutil.Big)(issued),
+		Total:   (*hexutil.Big)(new(big.Int).Add(genesis, issued)),
+	}, nil
+}
+
+// RPCIssuance is the actual coin supply of the canonical chain at a given block.
+type RPCIssuance struct {
+	Number         hexutil.Uint64 `json:"number"`
+	Hash           common.Hash    `json:"hash"`
+	Genesis        *hexutil.Big   `json:"genesis"`
+	Miner          *hexutil.Big   `json:"miner"`
+	UncleInclusion *hexutil.Big   `json:"uncleInclusion"`
+	Uncles         *hexutil.Big   `json:"uncles"`
+	DevFunds       *hexutil.Big   `json:"devFunds"`
+	Issued         *hexutil.Big   `json:"issued"`
+	Total          *hexutil.Big   `json:"total"`
+}
+
+// CirculatingSupply returns the coin supply of the canonical chain at the given
+// block, as recorded by the issuance index.
+func (api *PublicRewardAPI) CirculatingSupply(blockNr rpc.BlockNumber) (*RPCIssuance, error) {
+	config := api.e.chainConfig
+	if config.Ethash == nil {
+		return nil, errNoRewardSchedule
+	}
+	number := uint64(blockNr)
+	if blockNr == rpc.LatestBlockNumber || blockNr == rpc.PendingBlockNumber {
+		number = api.e.blockchain.CurrentBlock().NumberU64()
+	}
+	genesis, err := api.genesisAllocation()
+	if err != nil {
+		return nil, err
+	}
+	issuance, err := GetCumulativeIssuance(api.e.chainDb, config, number)
+	if err != nil {
+		return nil, err
+	}
+	issued := issuance.Total()
+
+	return &RPCIssuance{
+		Number:         hexutil.Uint64(number),
+		Hash:           core.GetCanonicalHash(api.e.chainDb, number),
+		Genesis:        (*hexutil.Big)(genesis),
+		Miner:          (*hexutil.Big)(issuance.Miner),
+		UncleInclusion: (*hexutil.Big)(issuance.UncleInclusion),
+		Uncles:         (*hexutil.Big)(issuance.Uncles),
+		DevFunds:       (*hexutil.Big)(issuance.DevFunds),
+		Issued:         (*hexutil.Big)(issued),
+		Total:          (*hexutil.Big)(new(big.Int).Add(genesis, issued)),
+	}, nil
+}
+
+// genesisAllocation returns the total balance allocated in the genesis block,
+// summing it up on first use.
+func (api *PublicRewardAPI) genesisAllocation() (*big.Int, error) {
+	api.genesisOnce.Do(func() {
+		statedb, err := api.e.blockchain.StateAt(api.e.blockchain.Genesis().Root())
+		if err != nil {
+			api.genesisErr = err
+			return
+		}
+		api.genesisSupply, api.genesisErr = statedb.TotalBalance()
+	})
+	return api.genesisSupply, api.genesisErr
+}
+
+// rewardEraIndex returns the position of the era starting at the given block in
+// the schedule.
+func rewardEraIndex(eras []params.RewardEra, block *big.Int) int {
+	for i := range eras {
+		if eras[i].Block.Cmp(block) == 0 {
+			return i
+		}
+	}
+	return 0
+}
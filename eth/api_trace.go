@@ -0,0 +1,388 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/core"
+	"github.com/TeamEGEM/go-egem/core/state"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/core/vm"
+	"github.com/TeamEGEM/go-egem/eth/tracers"
+	"github.com/TeamEGEM/go-egem/params"
+	"github.com/TeamEGEM/go-egem/rpc"
+)
+
+// maxTraceFilterBlocks is the maximum number of blocks a single trace_filter
+// request is allowed to reexecute.
+const maxTraceFilterBlocks = 10000
+
+// PrivateTraceAPI provides the Parity style trace_ namespace, reporting the
+// internal calls of transactions in a flat format using the native call tracer.
+type PrivateTraceAPI struct {
+	debug *PrivateDebugAPI
+}
+
+// NewPrivateTraceAPI creates a new API definition for the trace_ namespace.
+func NewPrivateTraceAPI(config *params.ChainConfig, eth *Ethereum) *PrivateTraceAPI {
+	return &PrivateTraceAPI{debug: NewPrivateDebugAPI(config, eth)}
+}
+
+// TraceFilterArgs are the criteria of a trace_filter request.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`
+	FromAddress []common.Address `json:"fromAddress"`
+	ToAddress   []common.Address `json:"toAddress"`
+	After       *uint64          `json:"after"`
+	Count       *uint64          `json:"count"`
+}
+
+// TraceReplayResult is the result of replaying a single transaction with the
+// requested trace types.
+type TraceReplayResult struct {
+	Output          hexutil.Bytes                           `json:"output"`
+	StateDiff       map[common.Address]*tracers.AccountDiff `json:"stateDiff"`
+	Trace           []*tracers.ParityTrace                  `json:"trace"`
+	VmTrace         interface{}                             `json:"vmTrace"`
+	TransactionHash common.Hash                             `json:"transactionHash"`
+}
+
+// txParityResult is the outcome of tracing a single transaction of a block.
+type txParityResult struct {
+	output    []byte
+	traces    []*tracers.ParityTrace
+	stateDiff map[common.Address]*tracers.AccountDiff
+}
+
+// Block returns the flat call traces of all the transactions in a block.
+func (api *PrivateTraceAPI) Block(ctx context.Context, number rpc.BlockNumber) ([]*tracers.ParityTrace, error) {
+	block, err := api.blockByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	results, err := api.traceBlock(ctx, block, false)
+	if err != nil {
+		return nil, err
+	}
+	var traces []*tracers.ParityTrace
+	for _, result := range results {
+		traces = append(traces, result.traces...)
+	}
+	return traces, nil
+}
+
+// Transaction returns the flat call traces of a single transaction.
+func (api *PrivateTraceAPI) Transaction(ctx context.Context, hash common.Hash) ([]*tracers.ParityTrace, error) {
+	tx, blockHash, number, index := core.GetTransaction(api.debug.eth.ChainDb(), hash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %x not found", hash)
+	}
+	msg, vmctx, statedb, err := api.debug.computeTxEnv(blockHash, int(index), defaultTraceReexec)
+	if err != nil {
+		return nil, err
+	}
+	result, err := api.traceTx(msg, vmctx, statedb, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, trace := range result.traces {
+		trace.BlockHash, trace.BlockNumber = &blockHash, &number
+		trace.TransactionHash, trace.TransactionPosition = &hash, &index
+	}
+	return result.traces, nil
+}
+
+// Filter returns the flat call traces within a block range, matching the given
+// sender and recipient addresses.
+func (api *PrivateTraceAPI) Filter(ctx context.Context, args TraceFilterArgs) ([]*tracers.ParityTrace, error) {
+	// Resolve the block range to trace
+	from, to := rpc.LatestBlockNumber, rpc.LatestBlockNumber
+	if args.FromBlock != nil {
+		from = *args.FromBlock
+	}
+	if args.ToBlock != nil {
+		to = *args.ToBlock
+	}
+	start, err := api.blockByNumber(from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := api.blockByNumber(to)
+	if err != nil {
+		return nil, err
+	}
+	if start.NumberU64() > end.NumberU64() {
+		return nil, fmt.Errorf("invalid block range #%d-#%d", start.NumberU64(), end.NumberU64())
+	}
+	if end.NumberU64()-start.NumberU64() >= maxTraceFilterBlocks {
+		return nil, fmt.Errorf("block range too large: %d > %d", end.NumberU64()-start.NumberU64()+1, maxTraceFilterBlocks)
+	}
+	// Trace the blocks one by one, gathering the matching traces
+	var (
+		fromAddresses = addressSet(args.FromAddress)
+		toAddresses   = addressSet(args.ToAddress)
+		skipped       uint64
+		traces        []*tracers.ParityTrace
+	)
+	for number := start.NumberU64(); number <= end.NumberU64(); number++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		block := api.debug.eth.blockchain.GetBlockByNumber(number)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", number)
+		}
+		results, err := api.traceBlock(ctx, block, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			for _, trace := range result.traces {
+				if !matchTrace(trace, fromAddresses, toAddresses) {
+					continue
+				}
+				if args.After != nil && skipped < *args.After {
+					skipped++
+					continue
+				}
+				traces = append(traces, trace)
+				if args.Count != nil && uint64(len(traces)) >= *args.Count {
+					return traces, nil
+				}
+			}
+		}
+	}
+	return traces, nil
+}
+
+// ReplayBlockTransactions reexecutes all the transactions in a block, returning
+// the requested trace types for each of them. Supported types are "trace" and
+// "stateDiff".
+func (api *PrivateTraceAPI) ReplayBlockTransactions(ctx context.Context, number rpc.BlockNumber, traceTypes []string) ([]*TraceReplayResult, error) {
+	var wantTrace, wantDiff bool
+	for _, kind := range traceTypes {
+		switch kind {
+		case "trace":
+			wantTrace = true
+		case "stateDiff":
+			wantDiff = true
+		default:
+			return nil, fmt.Errorf("unsupported trace type %q", kind)
+		}
+	}
+	block, err := api.blockByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	results, err := api.traceBlock(ctx, block, wantDiff)
+	if err != nil {
+		return nil, err
+	}
+	replays := make([]*TraceReplayResult, len(results))
+	for i, result := range results {
+		replays[i] = &TraceReplayResult{
+			Output:          result.output,
+			TransactionHash: block.Transactions()[i].Hash(),
+		}
+		if wantTrace {
+			// Replayed traces don't carry block and transaction infos
+			for _, trace := range result.traces {
+				trace.BlockHash, trace.BlockNumber = nil, nil
+				trace.TransactionHash, trace.TransactionPosition = nil, nil
+			}
+			replays[i].Trace = result.traces
+		}
+		if wantDiff {
+			replays[i].StateDiff = result.stateDiff
+		}
+	}
+	return replays, nil
+}
+
+// blockByNumber retrieves a block by number, resolving the meta block numbers.
+func (api *PrivateTraceAPI) blockByNumber(number rpc.BlockNumber) (*types.Block, error) {
+	var block *types.Block
+
+	switch number {
+	case rpc.PendingBlockNumber:
+		block = api.debug.eth.miner.PendingBlock()
+	case rpc.LatestBlockNumber:
+		block = api.debug.eth.blockchain.CurrentBlock()
+	default:
+		block = api.debug.eth.blockchain.GetBlockByNumber(uint64(number))
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	return block, nil
+}
+
+// traceBlock reexecutes all the transactions of a block on top of its parent
+// state, tracing each of them with the native Parity tracer.
+func (api *PrivateTraceAPI) traceBlock(ctx context.Context, block *types.Block, diff bool) ([]*txParityResult, error) {
+	if block.NumberU64() == 0 {
+		return nil, nil
+	}
+	parent := api.debug.eth.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %x not found", block.ParentHash())
+	}
+	statedb, err := api.debug.computeStateDB(parent, defaultTraceReexec)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		signer  = types.MakeSigner(api.debug.config, block.Number())
+		hash    = block.Hash()
+		number  = block.NumberU64()
+		results = make([]*txParityResult, len(block.Transactions()))
+	)
+	for i, tx := range block.Transactions() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		msg, _ := tx.AsMessage(signer)
+		vmctx := core.NewEVMContext(msg, block.Header(), api.debug.eth.blockchain, nil)
+
+		statedb.Prepare(tx.Hash(), hash, i)
+		result, err := api.traceTx(msg, vmctx, statedb, diff)
+		if err != nil {
+			return nil, fmt.Errorf("tx %x failed: %v", tx.Hash(), err)
+		}
+		txHash, index := tx.Hash(), uint64(i)
+		for _, trace := range result.traces {
+			trace.BlockHash, trace.BlockNumber = &hash, &number
+			trace.TransactionHash, trace.TransactionPosition = &txHash, &index
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// traceTx executes a single message on top of the given state with the native
+// Parity tracer, optionally computing the state changes it caused. The state is
+// left finalised, ready for the next transaction.
+func (api *PrivateTraceAPI) traceTx(msg core.Message, vmctx vm.Context, statedb *state.StateDB, diff bool) (*txParityResult, error) {
+	var pre *state.StateDB
+	if diff {
+		pre = statedb.Copy()
+	}
+	tracer := tracers.NewParityTracer()
+	vmenv := vm.NewEVM(vmctx, statedb, api.debug.config, vm.Config{Debug: true, Tracer: tracer})
+
+	output, gasUsed, failed, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas()))
+	if err != nil {
+		return nil, err
+	}
+	statedb.Finalise(api.debug.config.IsEIP158(vmctx.BlockNumber))
+
+	result := &txParityResult{output: output, traces: tracer.Traces()}
+	if result.traces == nil {
+		// Plain transfers to new accounts don't reach the tracer, report them anyway
+		result.traces = []*tracers.ParityTrace{plainTransferTrace(msg, gasUsed, failed)}
+	}
+	if diff {
+		touched := []common.Address{msg.From(), vmctx.Coinbase}
+		if msg.To() != nil {
+			touched = append(touched, *msg.To())
+		}
+		result.stateDiff = tracer.StateDiff(pre, statedb, touched...)
+	}
+	return result, nil
+}
+
+// plainTransferTrace creates the trace of a message that didn't execute any EVM
+// code and thus wasn't seen by the tracer.
+func plainTransferTrace(msg core.Message, gasUsed uint64, failed bool) *tracers.ParityTrace {
+	var (
+		from  = msg.From()
+		gas   = hexutil.Uint64(msg.Gas())
+		input = hexutil.Bytes(msg.Data())
+	)
+	trace := &tracers.ParityTrace{
+		Type:         "call",
+		TraceAddress: []int{},
+		Action: tracers.ParityAction{
+			CallType: "call",
+			From:     &from,
+			To:       msg.To(),
+			Gas:      &gas,
+			Input:    &input,
+			Value:    (*hexutil.Big)(msg.Value()),
+		},
+	}
+	if failed {
+		trace.Error = "execution failed"
+	} else {
+		trace.Result = &tracers.ParityResult{GasUsed: hexutil.Uint64(gasUsed), Output: new(hexutil.Bytes)}
+	}
+	return trace
+}
+
+// addressSet converts a list of addresses into a set, or nil if empty.
+func addressSet(addrs []common.Address) map[common.Address]struct{} {
+	if len(addrs) == 0 {
+		return nil
+	}
+	set := make(map[common.Address]struct{})
+	for _, addr := range addrs {
+		set[addr] = struct{}{}
+	}
+	return set
+}
+
+// matchTrace checks whether a trace matches the sender and recipient filters of
+// a trace_filter request. Empty filters match everything.
+func matchTrace(trace *tracers.ParityTrace, from, to map[common.Address]struct{}) bool {
+	if from != nil {
+		sender := trace.Action.From
+		if sender == nil {
+			sender = trace.Action.Address
+		}
+		if sender == nil {
+			return false
+		}
+		if _, ok := from[*sender]; !ok {
+			return false
+		}
+	}
+	if to != nil {
+		recipient := trace.Action.To
+		if recipient == nil {
+			recipient = trace.Action.RefundAddress
+		}
+		if recipient == nil && trace.Result != nil {
+			recipient = trace.Result.Address
+		}
+		if recipient == nil {
+			return false
+		}
+		if _, ok := to[*recipient]; !ok {
+			return false
+		}
+	}
+	return true
+}
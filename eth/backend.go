@@ -73,7 +73,8 @@ type Ethereum struct {
 	lesServer       LesServer
 
 	// DB interfaces
-	chainDb ethdb.Database // Block chain database
+	chainDb ethdb.Database     // Block chain database
+	freezer *core.ChainFreezer // Ancient chain data migrator (nil if disabled)
 
 	eventMux       *event.TypeMux
 	engine         consensus.Engine
@@ -82,9 +83,13 @@ type Ethereum struct {
 	bloomRequests chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
 
+	issuanceIndexer *core.ChainIndexer // Issuance indexer tracking the coin supply (nil if not ethash)
+
 	ApiBackend *EthApiBackend
 
 	miner     *miner.Miner
+	stratum   *miner.StratumServer // Stratum server for pooled miners (nil if disabled)
+	notifier  *miner.WorkNotifier  // HTTP work notifications for external miners (nil if disabled)
 	gasPrice  *big.Int
 	etherbase common.Address
 
@@ -108,7 +113,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if !config.SyncMode.IsValid() {
 		return nil, fmt.Errorf("invalid sync mode %d", config.SyncMode)
 	}
-	chainDb, err := CreateDB(ctx, config, "chaindata")
+	chainDb, err := CreateDB(ctx, config, "chaindata", config.DatabaseFreezer)
 	if err != nil {
 		return nil, err
 	}
@@ -118,6 +123,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		return nil, genesisErr
 	}
 	log.Info("Initialised chain configuration", "config", chainConfig)
+	if err := core.CheckChainConfig(chainConfig); err != nil {
+		return nil, err
+	}
 
 	eth := &Ethereum{
 		config:         config,
@@ -134,6 +142,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		bloomRequests:  make(chan chan *bloombits.Retrieval),
 		bloomIndexer:   NewBloomIndexer(chainDb, params.BloomBitsBlocks),
 	}
+	if chainConfig.Ethash != nil {
+		eth.issuanceIndexer = NewIssuanceIndexer(chainDb, chainConfig, issuanceSectionSize)
+	}
 
 	log.Info("Initialising Ethereum protocol", "versions", ProtocolVersions, "network", config.NetworkId)
 
@@ -146,7 +157,13 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	}
 	var (
 		vmConfig    = vm.Config{EnablePreimageRecording: config.EnablePreimageRecording}
-		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout}
+		cacheConfig = &core.CacheConfig{
+			Disabled:       config.NoPruning,
+			TrieNodeLimit:  config.TrieCache,
+			TrieTimeLimit:  config.TrieTimeout,
+			StatePruning:   config.StatePruning,
+			StateRetention: config.StateRetention,
+		}
 	)
 	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, eth.chainConfig, eth.engine, vmConfig)
 	if err != nil {
@@ -159,18 +176,48 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		core.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 	eth.bloomIndexer.Start(eth.blockchain)
+	if eth.issuanceIndexer != nil {
+		eth.issuanceIndexer.Start(eth.blockchain)
+	}
+	// Migrate the immutable chain segment into the ancient store, if there's one
+	if config.FreezerThreshold > 0 {
+		if freezer, err := core.NewChainFreezer(chainDb, config.FreezerThreshold); err == nil {
+			eth.freezer = freezer
+		}
+	}
 
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.Mempool != "" {
+		config.TxPool.Mempool = ctx.ResolvePath(config.TxPool.Mempool)
+	}
 	eth.txPool = core.NewTxPool(config.TxPool, eth.chainConfig, eth.blockchain)
 
+	if config.MultisigStore != "" {
+		config.MultisigStore = ctx.ResolvePath(config.MultisigStore)
+	}
+
 	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.SyncMode, config.NetworkId, eth.eventMux, eth.txPool, eth.engine, eth.blockchain, chainDb); err != nil {
 		return nil, err
 	}
 	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.engine)
 	eth.miner.SetExtra(makeExtraData(config.ExtraData))
 
+	if config.Stratum.Addr != "" || len(config.MinerNotify) > 0 {
+		agent := miner.NewRemoteAgent(eth.blockchain, eth.engine)
+		eth.miner.Register(agent)
+
+		if config.Stratum.Addr != "" {
+			if eth.stratum, err = miner.NewStratumServer(agent, config.Stratum); err != nil {
+				return nil, err
+			}
+		}
+		if len(config.MinerNotify) > 0 {
+			eth.notifier = miner.NewWorkNotifier(agent, config.MinerNotify)
+		}
+	}
+
 	eth.ApiBackend = &EthApiBackend{eth, nil}
 	gpoParams := config.GPO
 	if gpoParams.Default == nil {
@@ -198,9 +245,10 @@ func makeExtraData(extra []byte) []byte {
 	return extra
 }
 
-// CreateDB creates the chain database.
-func CreateDB(ctx *node.ServiceContext, config *Config, name string) (ethdb.Database, error) {
-	db, err := ctx.OpenDatabase(name, config.DatabaseCache, config.DatabaseHandles)
+// CreateDB creates the chain database, attaching an ancient store in the freezer
+// directory unless it's empty.
+func CreateDB(ctx *node.ServiceContext, config *Config, name string, freezer string) (ethdb.Database, error) {
+	db, err := ctx.OpenDatabaseWithFreezer(name, config.DatabaseCache, config.DatabaseHandles, freezer)
 	if err != nil {
 		return nil, err
 	}
@@ -249,6 +297,10 @@ func (s *Ethereum) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	// The reward API is served under the egem namespace, aliased into eth. A
+	// single instance backs both so the genesis supply is only summed once.
+	rewards := NewPublicRewardAPI(s)
+
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
@@ -261,6 +313,16 @@ func (s *Ethereum) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicMinerAPI(s),
 			Public:    true,
+		}, {
+			Namespace: "egem",
+			Version:   "1.0",
+			Service:   rewards,
+			Public:    true,
+		}, {
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   rewards,
+			Public:    true,
 		}, {
 			Namespace: "eth",
 			Version:   "1.0",
@@ -289,6 +351,10 @@ func (s *Ethereum) APIs() []rpc.API {
 			Namespace: "debug",
 			Version:   "1.0",
 			Service:   NewPrivateDebugAPI(s.chainConfig, s),
+		}, {
+			Namespace: "trace",
+			Version:   "1.0",
+			Service:   NewPrivateTraceAPI(s.chainConfig, s),
 		}, {
 			Namespace: "net",
 			Version:   "1.0",
@@ -405,6 +471,18 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
+	// Start serving pooled and external miners if requested
+	if s.stratum != nil {
+		if err := s.stratum.Start(); err != nil {
+			return err
+		}
+	}
+	if s.notifier != nil {
+		s.notifier.Start()
+	}
+	if s.freezer != nil {
+		s.freezer.Start()
+	}
 	return nil
 }
 
@@ -415,12 +493,24 @@ func (s *Ethereum) Stop() error {
 		s.stopDbUpgrade()
 	}
 	s.bloomIndexer.Close()
+	if s.issuanceIndexer != nil {
+		s.issuanceIndexer.Close()
+	}
+	if s.freezer != nil {
+		s.freezer.Stop()
+	}
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
 	if s.lesServer != nil {
 		s.lesServer.Stop()
 	}
 	s.txPool.Stop()
+	if s.stratum != nil {
+		s.stratum.Stop()
+	}
+	if s.notifier != nil {
+		s.notifier.Stop()
+	}
 	s.miner.Stop()
 	s.eventMux.Stop()
 
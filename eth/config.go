@@ -30,6 +30,7 @@ import (
 	"github.com/TeamEGEM/go-egem/core"
 	"github.com/TeamEGEM/go-egem/eth/downloader"
 	"github.com/TeamEGEM/go-egem/eth/gasprice"
+	"github.com/TeamEGEM/go-egem/miner"
 	"github.com/TeamEGEM/go-egem/params"
 )
 
@@ -43,18 +44,23 @@ var DefaultConfig = Config{
 		DatasetsInMem:  1,
 		DatasetsOnDisk: 2,
 	},
-	NetworkId:     1987,
-	LightPeers:    100,
-	DatabaseCache: 768,
-	TrieCache:     256,
-	TrieTimeout:   5 * time.Minute,
-	GasPrice:      big.NewInt(18 * params.Shannon),
+	NetworkId:        1987,
+	LightPeers:       100,
+	DatabaseCache:    768,
+	DatabaseFreezer:  "ancient",
+	FreezerThreshold: 90000,
+	TrieCache:        256,
+	TrieTimeout:      5 * time.Minute,
+	StateRetention:   128,
+	GasPrice:         big.NewInt(18 * params.Shannon),
+	Stratum:          miner.DefaultStratumConfig,
 
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
 		Blocks:     20,
 		Percentile: 60,
 	},
+	MultisigStore: "multisig.json",
 }
 
 func init() {
@@ -83,6 +89,10 @@ type Config struct {
 	SyncMode  downloader.SyncMode
 	NoPruning bool
 
+	// State pruning options
+	StatePruning   bool   // Whether to periodically delete historical state from disk
+	StateRetention uint64 // Number of recent persisted states to retain when pruning
+
 	// Light client options
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
@@ -91,6 +101,8 @@ type Config struct {
 	SkipBcVersionCheck bool `toml:"-"`
 	DatabaseHandles    int  `toml:"-"`
 	DatabaseCache      int
+	DatabaseFreezer    string // Ancient store directory, relative to the chain database (empty = disabled)
+	FreezerThreshold   uint64 // Number of recent blocks kept out of the ancient store (0 = no freezing)
 	TrieCache          int
 	TrieTimeout        time.Duration
 
@@ -99,6 +111,10 @@ type Config struct {
 	MinerThreads int            `toml:",omitempty"`
 	ExtraData    []byte         `toml:",omitempty"`
 	GasPrice     *big.Int
+	MinerNotify  []string `toml:",omitempty"` // HTTP URLs to post new work packages to
+
+	// Stratum mining server options
+	Stratum miner.StratumConfig
 
 	// Ethash options
 	Ethash ethash.Config
@@ -112,6 +128,9 @@ type Config struct {
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// Multisig proposal store, relative to the node's instance directory (empty = in-memory)
+	MultisigStore string
+
 	// Miscellaneous options
 	DocRoot string `toml:"-"`
 }
@@ -0,0 +1,226 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/consensus/ethash"
+	"github.com/TeamEGEM/go-egem/core"
+	"github.com/TeamEGEM/go-egem/core/types"
+)
+
+// DevFundCredit is the total paid to a dev-fund address within a reward era.
+type DevFundCredit struct {
+	Address common.Address `json:"address"`
+	Blocks  hexutil.Uint64 `json:"blocks"` // Number of blocks paying the address
+	Amount  *hexutil.Big   `json:"amount"`
+}
+
+// DevFundEra is the dev-fund payout of a reward era within the audited range.
+type DevFundEra struct {
+	Era     hexutil.Uint64  `json:"era"`
+	From    hexutil.Uint64  `json:"from"` // First audited block of the era
+	To      hexutil.Uint64  `json:"to"`   // Last audited block of the era
+	Credits []DevFundCredit `json:"credits"`
+}
+
+// DevFundAccount reconciles the dev-fund credits of an address with the change
+// of its balance over the audited range.
+//
+// The expected change is the sum of the dev-fund credits, the value received and
+// sent by transactions (including the gas paid) and the income from mining. Any
+// remaining difference is reported as unexplained, which is usually caused by
+// internal transfers of contracts that are not visible from the transactions.
+type DevFundAccount struct {
+	Address     common.Address `json:"address"`
+	Credited    *hexutil.Big   `json:"credited"`
+	Received    *hexutil.Big   `json:"received"` // Value of transactions sent to the address
+	Sent        *hexutil.Big   `json:"sent"`     // Value and fees of transactions sent by the address
+	Mined       *hexutil.Big   `json:"mined"`    // Rewards and fees of blocks mined by the address
+	Before      *hexutil.Big   `json:"before"`   // Balance before the first audited block (nil if state missing)
+	After       *hexutil.Big   `json:"after"`    // Balance after the last audited block (nil if state missing)
+	Unexplained *hexutil.Big   `json:"unexplained"`
+	Consistent  bool           `json:"consistent"`
+}
+
+// DevFundReport is the dev-fund payout audit of a range of canonical blocks.
+type DevFundReport struct {
+	From     hexutil.Uint64   `json:"from"`
+	To       hexutil.Uint64   `json:"to"`
+	Eras     []DevFundEra     `json:"eras"`
+	Accounts []DevFundAccount `json:"accounts"`
+}
+
+// devFundFlows accumulates the balance flows of a dev-fund address.
+type devFundFlows struct {
+	credited, received, sent, mined *big.Int
+}
+
+// AuditDevFunds walks the canonical blocks in the range [from, to], summing up
+// the dev-fund credits of every address per reward era, and checks them against
+// the balance changes of the addresses if the states around the range are
+// available.
+func AuditDevFunds(chain *core.BlockChain, from, to uint64) (*DevFundReport, error) {
+	config := chain.Config()
+	if config.Ethash == nil {
+		return nil, errNoRewardSchedule
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid range: from #%d > to #%d", from, to)
+	}
+	if head := chain.CurrentBlock().NumberU64(); to > head {
+		return nil, fmt.Errorf("block #%d beyond head #%d", to, head)
+	}
+	report := &DevFundReport{From: hexutil.Uint64(from), To: hexutil.Uint64(to)}
+	if from == 0 {
+		from = 1 // The genesis block is not rewarded
+	}
+	// Track the flows of every address paid by an era overlapping the range
+	var (
+		eras  = config.Ethash.RewardEras()
+		flows = make(map[common.Address]*devFundFlows)
+	)
+	for i, era := range eras {
+		if era.Block.Uint64() > to || (i+1 < len(eras) && eras[i+1].Block.Uint64() <= from) {
+			continue
+		}
+		for _, dev := range era.DevRewards {
+			flows[dev.Address] = &devFundFlows{new(big.Int), new(big.Int), new(big.Int), new(big.Int)}
+		}
+	}
+	// Sum up the credits per era along with the other flows of the addresses
+	index := make(map[common.Address]int) // Position of the credits in the current era
+	for number := from; number <= to && len(flows) > 0; number++ {
+		block := chain.GetBlockByNumber(number)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", number)
+		}
+		rewards := ethash.CalcBlockRewards(config, block.Header(), block.Uncles())
+		if err := addDevFundFlows(chain, block, rewards, flows); err != nil {
+			return nil, err
+		}
+		if rewards.Era == nil || len(rewards.Dev) == 0 {
+			continue
+		}
+		era := hexutil.Uint64(rewardEraIndex(eras, rewards.Era.Block))
+		if n := len(report.Eras); n == 0 || report.Eras[n-1].Era != era {
+			report.Eras = append(report.Eras, DevFundEra{Era: era, From: hexutil.Uint64(number)})
+			index = make(map[common.Address]int)
+		}
+		current := &report.Eras[len(report.Eras)-1]
+		current.To = hexutil.Uint64(number)
+
+		for _, dev := range rewards.Dev {
+			pos, ok := index[dev.Address]
+			if !ok {
+				pos = len(current.Credits)
+				index[dev.Address] = pos
+				current.Credits = append(current.Credits, DevFundCredit{Address: dev.Address, Amount: new(hexutil.Big)})
+			}
+			credit := &current.Credits[pos]
+			credit.Blocks++
+			(*big.Int)(credit.Amount).Add((*big.Int)(credit.Amount), dev.Amount)
+
+			flows[dev.Address].credited.Add(flows[dev.Address].credited, dev.Amount)
+		}
+	}
+	// Reconcile the flows with the balance changes if the states are available
+	before, _ := chain.StateAt(chain.GetHeaderByNumber(from - 1).Root)
+	after, _ := chain.StateAt(chain.GetHeaderByNumber(to).Root)
+
+	for addr, flow := range flows {
+		account := DevFundAccount{
+			Address:  addr,
+			Credited: (*hexutil.Big)(flow.credited),
+			Received: (*hexutil.Big)(flow.received),
+			Sent:     (*hexutil.Big)(flow.sent),
+			Mined:    (*hexutil.Big)(flow.mined),
+		}
+		if before != nil && after != nil {
+			balanceBefore, balanceAfter := before.GetBalance(addr), after.GetBalance(addr)
+
+			unexplained := new(big.Int).Sub(balanceAfter, balanceBefore)
+			unexplained.Sub(unexplained, flow.credited)
+			unexplained.Sub(unexplained, flow.received)
+			unexplained.Add(unexplained, flow.sent)
+			unexplained.Sub(unexplained, flow.mined)
+
+			account.Before = (*hexutil.Big)(balanceBefore)
+			account.After = (*hexutil.Big)(balanceAfter)
+			account.Unexplained = (*hexutil.Big)(unexplained)
+			account.Consistent = unexplained.Sign() == 0
+		}
+		report.Accounts = append(report.Accounts, account)
+	}
+	sort.Slice(report.Accounts, func(i, j int) bool {
+		return report.Accounts[i].Address.Hex() < report.Accounts[j].Address.Hex()
+	})
+	return report, nil
+}
+
+// addDevFundFlows adds the transaction and mining flows of a block touching any
+// of the tracked addresses.
+func addDevFundFlows(chain *core.BlockChain, block *types.Block, rewards *ethash.BlockRewards, flows map[common.Address]*devFundFlows) error {
+	fees := new(big.Int)
+	if txs := block.Transactions(); len(txs) > 0 {
+		receipts := chain.GetReceiptsByHash(block.Hash())
+		if len(receipts) != len(txs) {
+			return fmt.Errorf("receipts of block #%d [%x…] not found", block.NumberU64(), block.Hash().Bytes()[:4])
+		}
+		signer := types.MakeSigner(chain.Config(), block.Number())
+		for i, tx := range txs {
+			fee := new(big.Int).Mul(new(big.Int).SetUint64(receipts[i].GasUsed), tx.GasPrice())
+			fees.Add(fees, fee)
+
+			// Only successful transactions transfer value, but all of them pay for gas
+			success := len(receipts[i].PostState) > 0 || receipts[i].Status == types.ReceiptStatusSuccessful
+
+			from, err := types.Sender(signer, tx)
+			if err != nil {
+				return fmt.Errorf("invalid transaction %x: %v", tx.Hash(), err)
+			}
+			if flow := flows[from]; flow != nil {
+				flow.sent.Add(flow.sent, fee)
+				if success {
+					flow.sent.Add(flow.sent, tx.Value())
+				}
+			}
+			if to := tx.To(); to != nil && success {
+				if flow := flows[*to]; flow != nil {
+					flow.received.Add(flow.received, tx.Value())
+				}
+			}
+		}
+	}
+	// Add the rewards and fees of the block and its uncles if mined by the address
+	if flow := flows[block.Coinbase()]; flow != nil {
+		flow.mined.Add(flow.mined, rewards.Miner)
+		flow.mined.Add(flow.mined, rewards.UncleBonus)
+		flow.mined.Add(flow.mined, fees)
+	}
+	for i, uncle := range block.Uncles() {
+		if flow := flows[uncle.Coinbase]; flow != nil {
+			flow.mined.Add(flow.mined, rewards.Uncles[i])
+		}
+	}
+	return nil
+}
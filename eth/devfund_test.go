@@ -0,0 +1,136 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/consensus/ethash"
+	"github.com/TeamEGEM/go-egem/core"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/core/vm"
+	"github.com/TeamEGEM/go-egem/crypto"
+	"github.com/TeamEGEM/go-egem/ethdb"
+	"github.com/TeamEGEM/go-egem/params"
+)
+
+// Tests that the dev-fund audit reports the credits per era and reconciles them
+// with the balance changes caused by transactions and mining.
+func TestAuditDevFunds(t *testing.T) {
+	var (
+		oldFund   = common.HexToAddress("0x00000000000000000000000000000000000000de")
+		newKey, _ = crypto.GenerateKey()
+		newFund   = crypto.PubkeyToAddress(newKey.PublicKey)
+		recipient = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+		signer    = types.HomesteadSigner{}
+		config    = &params.ChainConfig{
+			ChainId:        big.NewInt(1),
+			HomesteadBlock: big.NewInt(0),
+			Ethash: &params.EthashConfig{Eras: []params.RewardEra{
+				{Block: big.NewInt(0), BlockReward: big.NewInt(params.Ether)},
+				{
+					Block:       big.NewInt(5),
+					BlockReward: big.NewInt(params.Ether),
+					DevRewards:  []params.DevReward{{Address: oldFund, Amount: big.NewInt(params.Finney)}},
+				},
+				{
+					Block:       big.NewInt(12),
+					BlockReward: big.NewInt(params.Ether),
+					DevRewards:  []params.DevReward{{Address: newFund, Amount: big.NewInt(2 * params.Finney)}},
+				},
+			}},
+		}
+		db, _   = ethdb.NewMemDatabase()
+		gspec   = &core.Genesis{Config: config, Alloc: core.GenesisAlloc{testBank: {Balance: big.NewInt(params.Ether)}}}
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, _ := core.GenerateChain(config, genesis, ethash.NewFaker(), db, 20, func(i int, block *core.BlockGen) {
+		switch i {
+		case 6:
+			// Fund the old address through a transaction
+			tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank), oldFund, big.NewInt(1000), 21000, big.NewInt(1), nil), signer, testBankKey)
+			block.AddTx(tx)
+		case 8:
+			// Mine a block with the old fund as coinbase
+			block.SetCoinbase(oldFund)
+		case 9:
+			block.SetCoinbase(common.Address{})
+		case 16:
+			// Spend from the new fund, paying for gas
+			tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(newFund), recipient, big.NewInt(params.Finney), 21000, big.NewInt(1), nil), signer, newKey)
+			block.AddTx(tx)
+		}
+	})
+	chain, err := core.NewBlockChain(db, nil, config, ethash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	report, err := AuditDevFunds(chain, 3, 18)
+	if err != nil {
+		t.Fatalf("failed to audit dev funds: %v", err)
+	}
+	// Check the credits of each era within the range
+	if len(report.Eras) != 2 {
+		t.Fatalf("era count mismatch: have %d, want 2", len(report.Eras))
+	}
+	eras := []struct {
+		era, from, to uint64
+		fund          common.Address
+		amount        int64
+	}{
+		{1, 5, 11, oldFund, 7 * params.Finney},
+		{2, 12, 18, newFund, 7 * 2 * params.Finney},
+	}
+	for i, want := range eras {
+		era := report.Eras[i]
+		if uint64(era.Era) != want.era || uint64(era.From) != want.from || uint64(era.To) != want.to {
+			t.Errorf("era %d: range mismatch: have %d #%d-#%d, want %d #%d-#%d", i, era.Era, era.From, era.To, want.era, want.from, want.to)
+		}
+		if len(era.Credits) != 1 || era.Credits[0].Address != want.fund || (*big.Int)(era.Credits[0].Amount).Cmp(big.NewInt(want.amount)) != 0 {
+			t.Errorf("era %d: credits mismatch: have %+v, want %x %d", i, era.Credits, want.fund, want.amount)
+		}
+	}
+	// Check that the balance changes are fully explained
+	if len(report.Accounts) != 2 {
+		t.Fatalf("account count mismatch: have %d, want 2", len(report.Accounts))
+	}
+	for _, account := range report.Accounts {
+		if account.Unexplained == nil {
+			t.Fatalf("%x: balances not reconciled", account.Address)
+		}
+		if !account.Consistent {
+			t.Errorf("%x: unexplained balance change %v", account.Address, (*big.Int)(account.Unexplained))
+		}
+		switch account.Address {
+		case oldFund:
+			if (*big.Int)(account.Received).Cmp(big.NewInt(1000)) != 0 || (*big.Int)(account.Mined).Cmp(big.NewInt(params.Ether)) != 0 {
+				t.Errorf("old fund flows mismatch: received %v, mined %v", (*big.Int)(account.Received), (*big.Int)(account.Mined))
+			}
+		case newFund:
+			if want := big.NewInt(params.Finney + 21000); (*big.Int)(account.Sent).Cmp(want) != 0 {
+				t.Errorf("new fund sent mismatch: have %v, want %v", (*big.Int)(account.Sent), want)
+			}
+		}
+	}
+}
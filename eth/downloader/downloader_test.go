@@ -603,6 +603,39 @@ func (dlp *downloadTesterPeer) RequestNodeData(hashes []common.Hash) error {
 	return nil
 }
 
+// ServesStateRanges reports that the tester peer can serve the state in ranges.
+func (dlp *downloadTesterPeer) ServesStateRanges() bool {
+	return true
+}
+
+// RequestAccountRange constructs a range of accounts of the given state root
+// based on the hashes present in the peer's state database.
+func (dlp *downloadTesterPeer) RequestAccountRange(root common.Hash, origin common.Hash, limit common.Hash, bytes uint64) error {
+	dlp.waitDelay()
+
+	dlp.dl.lock.RLock()
+	defer dlp.dl.lock.RUnlock()
+
+	hashes, accounts, proof := ServeAccountRange(trie.NewDatabase(dlp.dl.peerDb), root, origin, limit, bytes)
+	go dlp.dl.downloader.DeliverAccountRange(dlp.id, hashes, accounts, proof)
+
+	return nil
+}
+
+// RequestStorageRanges constructs the storage ranges of a batch of accounts of
+// the given state root based on the peer's state database.
+func (dlp *downloadTesterPeer) RequestStorageRanges(root common.Hash, accounts []common.Hash, origin common.Hash, bytes uint64) error {
+	dlp.waitDelay()
+
+	dlp.dl.lock.RLock()
+	defer dlp.dl.lock.RUnlock()
+
+	hashes, slots, proof := ServeStorageRanges(trie.NewDatabase(dlp.dl.peerDb), root, accounts, origin, bytes)
+	go dlp.dl.downloader.DeliverStorageRanges(dlp.id, hashes, slots, proof)
+
+	return nil
+}
+
 // assertOwnChain checks if the local chain contains the correct number of items
 // of the various chain components.
 func assertOwnChain(t *testing.T, tester *downloadTester, length int) {
@@ -662,6 +695,7 @@ func assertOwnForkedChain(t *testing.T, tester *downloadTester, common int, leng
 func TestCanonicalSynchronisation62(t *testing.T)      { testCanonicalSynchronisation(t, 62, FullSync) }
 func TestCanonicalSynchronisation63Full(t *testing.T)  { testCanonicalSynchronisation(t, 63, FullSync) }
 func TestCanonicalSynchronisation63Fast(t *testing.T)  { testCanonicalSynchronisation(t, 63, FastSync) }
+func TestCanonicalSynchronisation63Snap(t *testing.T)  { testCanonicalSynchronisation(t, 63, SnapSync) }
 func TestCanonicalSynchronisation64Full(t *testing.T)  { testCanonicalSynchronisation(t, 64, FullSync) }
 func TestCanonicalSynchronisation64Fast(t *testing.T)  { testCanonicalSynchronisation(t, 64, FastSync) }
 func TestCanonicalSynchronisation64Light(t *testing.T) { testCanonicalSynchronisation(t, 64, LightSync) }
@@ -690,6 +724,7 @@ func testCanonicalSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 func TestThrottling62(t *testing.T)     { testThrottling(t, 62, FullSync) }
 func TestThrottling63Full(t *testing.T) { testThrottling(t, 63, FullSync) }
 func TestThrottling63Fast(t *testing.T) { testThrottling(t, 63, FastSync) }
+func TestThrottling63Snap(t *testing.T) { testThrottling(t, 63, SnapSync) }
 func TestThrottling64Full(t *testing.T) { testThrottling(t, 64, FullSync) }
 func TestThrottling64Fast(t *testing.T) { testThrottling(t, 64, FastSync) }
 
@@ -732,7 +767,7 @@ func testThrottling(t *testing.T, protocol int, mode SyncMode) {
 			tester.lock.Lock()
 			tester.downloader.queue.lock.Lock()
 			cached = len(tester.downloader.queue.blockDonePool)
-			if mode == FastSync {
+			if mode == FastSync || mode == SnapSync {
 				if receipts := len(tester.downloader.queue.receiptDonePool); receipts < cached {
 					//if tester.downloader.queue.resultCache[receipts].Header.Number.Uint64() < tester.downloader.queue.fastSyncPivot {
 					cached = receipts
@@ -776,6 +811,7 @@ func testThrottling(t *testing.T, protocol int, mode SyncMode) {
 func TestForkedSync62(t *testing.T)      { testForkedSync(t, 62, FullSync) }
 func TestForkedSync63Full(t *testing.T)  { testForkedSync(t, 63, FullSync) }
 func TestForkedSync63Fast(t *testing.T)  { testForkedSync(t, 63, FastSync) }
+func TestForkedSync63Snap(t *testing.T)  { testForkedSync(t, 63, SnapSync) }
 func TestForkedSync64Full(t *testing.T)  { testForkedSync(t, 64, FullSync) }
 func TestForkedSync64Fast(t *testing.T)  { testForkedSync(t, 64, FastSync) }
 func TestForkedSync64Light(t *testing.T) { testForkedSync(t, 64, LightSync) }
@@ -1023,6 +1059,7 @@ func testMultiSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 func TestMultiProtoSynchronisation62(t *testing.T)      { testMultiProtoSync(t, 62, FullSync) }
 func TestMultiProtoSynchronisation63Full(t *testing.T)  { testMultiProtoSync(t, 63, FullSync) }
 func TestMultiProtoSynchronisation63Fast(t *testing.T)  { testMultiProtoSync(t, 63, FastSync) }
+func TestMultiProtoSynchronisation63Snap(t *testing.T)  { testMultiProtoSync(t, 63, SnapSync) }
 func TestMultiProtoSynchronisation64Full(t *testing.T)  { testMultiProtoSync(t, 64, FullSync) }
 func TestMultiProtoSynchronisation64Fast(t *testing.T)  { testMultiProtoSync(t, 64, FastSync) }
 func TestMultiProtoSynchronisation64Light(t *testing.T) { testMultiProtoSync(t, 64, LightSync) }
@@ -1062,6 +1099,7 @@ func testMultiProtoSync(t *testing.T, protocol int, mode SyncMode) {
 func TestEmptyShortCircuit62(t *testing.T)      { testEmptyShortCircuit(t, 62, FullSync) }
 func TestEmptyShortCircuit63Full(t *testing.T)  { testEmptyShortCircuit(t, 63, FullSync) }
 func TestEmptyShortCircuit63Fast(t *testing.T)  { testEmptyShortCircuit(t, 63, FastSync) }
+func TestEmptyShortCircuit63Snap(t *testing.T)  { testEmptyShortCircuit(t, 63, SnapSync) }
 func TestEmptyShortCircuit64Full(t *testing.T)  { testEmptyShortCircuit(t, 64, FullSync) }
 func TestEmptyShortCircuit64Fast(t *testing.T)  { testEmptyShortCircuit(t, 64, FastSync) }
 func TestEmptyShortCircuit64Light(t *testing.T) { testEmptyShortCircuit(t, 64, LightSync) }
@@ -1100,7 +1138,7 @@ func testEmptyShortCircuit(t *testing.T, protocol int, mode SyncMode) {
 		}
 	}
 	for _, receipt := range receipts {
-		if mode == FastSync && len(receipt) > 0 {
+		if (mode == FastSync || mode == SnapSync) && len(receipt) > 0 {
 			receiptsNeeded++
 		}
 	}
@@ -1669,6 +1707,7 @@ func TestDeliverHeadersHang(t *testing.T) {
 		{62, FullSync},
 		{63, FullSync},
 		{63, FastSync},
+		{63, SnapSync},
 		{64, FullSync},
 		{64, FastSync},
 		{64, LightSync},
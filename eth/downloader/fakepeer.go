@@ -23,6 +23,7 @@ import (
 	"github.com/TeamEGEM/go-egem/core"
 	"github.com/TeamEGEM/go-egem/core/types"
 	"github.com/TeamEGEM/go-egem/ethdb"
+	"github.com/TeamEGEM/go-egem/trie"
 )
 
 // FakePeer is a mock downloader peer that operates on a local database instance
@@ -158,3 +159,25 @@ func (p *FakePeer) RequestNodeData(hashes []common.Hash) error {
 	p.dl.DeliverNodeData(p.id, data)
 	return nil
 }
+
+// ServesStateRanges implements downloader.RangePeer, the local database always
+// being able to serve the state in ranges.
+func (p *FakePeer) ServesStateRanges() bool {
+	return true
+}
+
+// RequestAccountRange implements downloader.RangePeer, returning a range of accounts
+// of the state trie with the given root.
+func (p *FakePeer) RequestAccountRange(root common.Hash, origin common.Hash, limit common.Hash, bytes uint64) error {
+	hashes, accounts, proof := ServeAccountRange(trie.NewDatabase(p.db), root, origin, limit, bytes)
+	p.dl.DeliverAccountRange(p.id, hashes, accounts, proof)
+	return nil
+}
+
+// RequestStorageRanges implements downloader.RangePeer, returning the storage slots
+// of a batch of accounts of the state trie with the given root.
+func (p *FakePeer) RequestStorageRanges(root common.Hash, accounts []common.Hash, origin common.Hash, bytes uint64) error {
+	hashes, slots, proof := ServeStorageRanges(trie.NewDatabase(p.db), root, accounts, origin, bytes)
+	p.dl.DeliverStorageRanges(p.id, hashes, slots, proof)
+	return nil
+}
@@ -90,6 +90,16 @@ type Peer interface {
 	RequestNodeData([]common.Hash) error
 }
 
+// RangePeer encapsulates the methods required by a peer to also serve the state
+// in contiguous ranges, as used by snap sync. Whether it can do so may change
+// over the lifetime of the peer.
+type RangePeer interface {
+	Peer
+	ServesStateRanges() bool
+	RequestAccountRange(root common.Hash, origin common.Hash, limit common.Hash, bytes uint64) error
+	RequestStorageRanges(root common.Hash, accounts []common.Hash, origin common.Hash, bytes uint64) error
+}
+
 // lightPeerWrapper wraps a LightPeer struct, stubbing out the Peer-only methods.
 type lightPeerWrapper struct {
 	peer LightPeer
@@ -222,6 +232,53 @@ func (p *peerConnection) FetchNodeData(hashes []common.Hash) error {
 	return nil
 }
 
+// FetchAccountRange sends an account range retrieval request to the remote peer.
+// The request shares the node data activity state, a peer serving either only
+// one state request at a time.
+func (p *peerConnection) FetchAccountRange(root common.Hash, origin common.Hash, limit common.Hash, bytes uint64) error {
+	// Sanity check the peer's capabilities
+	peer := p.rangePeer()
+	if peer == nil {
+		panic(fmt.Sprintf("account range fetch requested on peer %s not serving state ranges", p.id))
+	}
+	// Short circuit if the peer is already fetching
+	if !atomic.CompareAndSwapInt32(&p.stateIdle, 0, 1) {
+		return errAlreadyFetching
+	}
+	p.stateStarted = time.Now()
+
+	go peer.RequestAccountRange(root, origin, limit, bytes)
+
+	return nil
+}
+
+// FetchStorageRanges sends a storage range retrieval request to the remote peer.
+func (p *peerConnection) FetchStorageRanges(root common.Hash, accounts []common.Hash, origin common.Hash, bytes uint64) error {
+	// Sanity check the peer's capabilities
+	peer := p.rangePeer()
+	if peer == nil {
+		panic(fmt.Sprintf("storage range fetch requested on peer %s not serving state ranges", p.id))
+	}
+	// Short circuit if the peer is already fetching
+	if !atomic.CompareAndSwapInt32(&p.stateIdle, 0, 1) {
+		return errAlreadyFetching
+	}
+	p.stateStarted = time.Now()
+
+	go peer.RequestStorageRanges(root, accounts, origin, bytes)
+
+	return nil
+}
+
+// rangePeer returns the peer as one able to serve state ranges, or nil if it
+// can't currently do so.
+func (p *peerConnection) rangePeer() RangePeer {
+	if peer, ok := p.peer.(RangePeer); ok && peer.ServesStateRanges() {
+		return peer
+	}
+	return nil
+}
+
 // SetHeadersIdle sets the peer to idle, allowing it to execute new header retrieval
 // requests. Its estimated header retrieval throughput is updated with that measured
 // just now.
@@ -522,6 +579,20 @@ func (ps *peerSet) NodeDataIdlePeers() ([]*peerConnection, int) {
 	return ps.idlePeers(63, 64, idle, throughput)
 }
 
+// StateRangeIdlePeers retrieves a flat list of all the currently node-data-idle
+// peers able to serve state ranges, ordered by their reputation.
+func (ps *peerSet) StateRangeIdlePeers() ([]*peerConnection, int) {
+	idle := func(p *peerConnection) bool {
+		return atomic.LoadInt32(&p.stateIdle) == 0 && p.rangePeer() != nil
+	}
+	throughput := func(p *peerConnection) float64 {
+		p.lock.RLock()
+		defer p.lock.RUnlock()
+		return p.stateThroughput
+	}
+	return ps.idlePeers(63, 64, idle, throughput)
+}
+
 // idlePeers retrieves a flat list of all currently idle peers satisfying the
 // protocol version constraints, using the provided function to check idleness.
 // The resulting set of peers are sorted by their measure throughput.
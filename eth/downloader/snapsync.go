@@ -0,0 +1,682 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/core/state"
+	"github.com/TeamEGEM/go-egem/crypto"
+	"github.com/TeamEGEM/go-egem/ethdb"
+	"github.com/TeamEGEM/go-egem/log"
+	"github.com/TeamEGEM/go-egem/rlp"
+	"github.com/TeamEGEM/go-egem/trie"
+)
+
+const (
+	snapAccountTasks    = 16    // Number of account ranges to retrieve concurrently
+	snapPendingLimit    = 8192  // Number of accounts waiting for storage or code to stop retrieving new ones at
+	snapCommitThreshold = 16384 // Number of accounts of a range to flush to disk at once
+)
+
+var (
+	// errInvalidRange is returned if a delivered state range fails verification.
+	errInvalidRange = errors.New("invalid state range")
+
+	// emptyCode is the known hash of the empty EVM bytecode.
+	emptyCode = crypto.Keccak256Hash(nil)
+)
+
+// accountTask is a contiguous range of the account trie, retrieved in order.
+type accountTask struct {
+	next common.Hash // Hash of the next account to retrieve
+	last common.Hash // Hash of the last account covered by the range
+	trie *trie.Trie  // Accounts of the range inserted so far
+
+	active   bool                     // Whether a retrieval request is in flight
+	fetched  bool                     // Whether all accounts of the range have been retrieved
+	waiting  map[common.Hash]struct{} // Retrieved accounts waiting for their storage or code
+	inserted int                      // Number of accounts inserted since the last flush
+}
+
+// snapAccount is a retrieved account waiting for its storage and code.
+type snapAccount struct {
+	task    *accountTask
+	hash    common.Hash
+	blob    []byte
+	waiting int  // Number of storage and code retrievals still pending
+	failed  bool // Whether the storage or the code could not be retrieved
+}
+
+// storageTask is the storage trie of an account, retrieved in order.
+type storageTask struct {
+	account *snapAccount
+	root    common.Hash // Root hash of the storage trie
+	next    common.Hash // Hash of the next storage slot to retrieve
+	trie    *trie.Trie  // Storage slots retrieved so far (nil until partially retrieved)
+}
+
+// codeTask is a contract code waiting for retrieval.
+type codeTask struct {
+	accounts []*snapAccount      // Accounts waiting for the code
+	attempts map[string]struct{} // Peers the code was already requested from
+	active   bool                // Whether a retrieval request is in flight
+}
+
+// snapSync retrieves the state trie in contiguous ranges of accounts and storage
+// slots, verified by the merkle proofs of the range edges instead of node by
+// node. Accounts are only inserted into the trie once their storage and code are
+// stored, so any trie node written to disk has its entire subtrie written too.
+// Nodes spanning accounts that are still missing never match the real trie, so
+// the node based sync following it only has to heal the gaps.
+type snapSync struct {
+	s      *stateSync
+	triedb *trie.Database
+
+	accounts []*accountTask            // Account ranges of the state trie
+	storages []*storageTask            // Storage tries waiting for retrieval
+	codes    map[common.Hash]*codeTask // Contract codes waiting for retrieval
+	pending  int                       // Number of accounts waiting for storage or code
+	active   map[string]*stateReq      // Currently in-flight requests
+	stale    map[string]struct{}       // Peers unable to serve the state ranges
+
+	synced struct {
+		accounts, slots, codes uint64
+	}
+	start  time.Time
+	logged time.Time
+}
+
+// snapProgress is the account range retrieval progress of a snap sync. It is
+// kept across pivot moves, so the ranges already retrieved for an older root are
+// not retrieved again, leaving them to the node based sync to heal instead.
+type snapProgress struct {
+	triedb   *trie.Database // Database the tries of the ranges are committed into
+	accounts []*accountTask // Account ranges of the state trie
+}
+
+// newSnapProgress creates the retrieval progress of a fresh snap sync, with the
+// account trie split up into ranges of equal width.
+func newSnapProgress(db ethdb.Database) *snapProgress {
+	progress := &snapProgress{triedb: trie.NewDatabase(db)}
+
+	step := 256 / snapAccountTasks
+	for i := 0; i < snapAccountTasks; i++ {
+		task := &accountTask{waiting: make(map[common.Hash]struct{})}
+		task.next[0] = byte(i * step)
+		for j := range task.last {
+			task.last[j] = 0xff
+		}
+		task.last[0] = byte((i+1)*step - 1)
+		task.trie, _ = trie.New(common.Hash{}, progress.triedb)
+
+		progress.accounts = append(progress.accounts, task)
+	}
+	return progress
+}
+
+// rewind prepares the account ranges of an interrupted sync to be resumed for a
+// new state root. Accounts still waiting for their storage or code can't be
+// completed from the new root, so their ranges are rewound to the first of them.
+func (p *snapProgress) rewind() {
+	for _, task := range p.accounts {
+		task.active = false
+		if len(task.waiting) == 0 {
+			continue
+		}
+		first := task.last
+		for hash := range task.waiting {
+			if compareHashes(hash, first) < 0 {
+				first = hash
+			}
+		}
+		task.next, task.fetched = first, false
+		task.waiting = make(map[common.Hash]struct{})
+	}
+}
+
+// newSnapSync creates a range retrieval scheduler for the state of a sync,
+// resuming the account ranges retrieved by previous syncs of the downloader.
+func newSnapSync(s *stateSync) *snapSync {
+	if s.d.snapProgress == nil {
+		s.d.snapProgress = newSnapProgress(s.d.stateDB)
+	} else {
+		s.d.snapProgress.rewind()
+	}
+	return &snapSync{
+		s:        s,
+		triedb:   s.d.snapProgress.triedb,
+		accounts: s.d.snapProgress.accounts,
+		codes:    make(map[common.Hash]*codeTask),
+		active:   make(map[string]*stateReq),
+		stale:    make(map[string]struct{}),
+		start:    time.Now(),
+		logged:   time.Now(),
+	}
+}
+
+// syncRanges retrieves the state in ranges from the peers able to serve them,
+// until either everything is retrieved or nobody is left to retrieve it from.
+func (s *stateSync) syncRanges(newPeer chan *peerConnection) error {
+	snap := newSnapSync(s)
+	for !snap.done() {
+		snap.assignTasks()
+		if len(snap.active) == 0 {
+			// Nobody is able to serve anything, leave the rest to the node sync
+			break
+		}
+		select {
+		case <-newPeer:
+			// New peer arrived, try to assign it download tasks
+
+		case <-s.cancel:
+			return errCancelStateFetch
+
+		case <-s.d.cancelCh:
+			return errCancelStateFetch
+
+		case req := <-s.deliver:
+			// Response, disconnect or timeout triggered, process the outcome
+			delete(snap.active, req.peer.id)
+			delivered, err := snap.process(req)
+			if err != nil {
+				log.Warn("State range write error", "err", err)
+				return err
+			}
+			req.peer.SetNodeDataIdle(delivered)
+		}
+		snap.report(false)
+	}
+	// Flush whatever was retrieved of the unfinished ranges
+	for _, task := range snap.accounts {
+		if err := snap.commit(task.trie); err != nil {
+			return err
+		}
+	}
+	snap.report(true)
+	return nil
+}
+
+// done returns whether the entire state was retrieved.
+func (snap *snapSync) done() bool {
+	for _, task := range snap.accounts {
+		if !task.fetched || len(task.waiting) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// assignTasks attempts to assign new state range and contract code retrievals to
+// all idle peers. Storage is preferred over accounts to bound the number of the
+// accounts waiting for it.
+func (snap *snapSync) assignTasks() {
+	peers, _ := snap.s.d.peers.StateRangeIdlePeers()
+	for _, p := range peers {
+		if _, ok := snap.stale[p.id]; ok {
+			continue
+		}
+		req := snap.storageRequest(p)
+		if req == nil {
+			req = snap.accountRequest(p)
+		}
+		if req != nil {
+			snap.send(req)
+		}
+	}
+	peers, _ = snap.s.d.peers.NodeDataIdlePeers()
+	for _, p := range peers {
+		if req := snap.codeRequest(p); req != nil {
+			snap.send(req)
+		}
+	}
+}
+
+// accountRequest creates a retrieval request for the next account range that is
+// not being retrieved yet.
+func (snap *snapSync) accountRequest(p *peerConnection) *stateReq {
+	if snap.pending >= snapPendingLimit {
+		return nil
+	}
+	for _, task := range snap.accounts {
+		if !task.active && !task.fetched {
+			task.active = true
+			return &stateReq{peer: p, timeout: snap.s.d.requestTTL(), account: task}
+		}
+	}
+	return nil
+}
+
+// storageRequest creates a retrieval request for a batch of queued storage tries.
+// Only the first one may be resumed from a storage slot, the others are retrieved
+// from their beginning.
+func (snap *snapSync) storageRequest(p *peerConnection) *stateReq {
+	if len(snap.storages) == 0 {
+		return nil
+	}
+	var (
+		tasks []*storageTask
+		rest  = snap.storages[:0]
+	)
+	for _, task := range snap.storages {
+		if len(tasks) < MaxStorageRangeFetch && (len(tasks) == 0 || task.next == (common.Hash{})) {
+			tasks = append(tasks, task)
+		} else {
+			rest = append(rest, task)
+		}
+	}
+	for i := len(rest); i < len(snap.storages); i++ {
+		snap.storages[i] = nil
+	}
+	snap.storages = rest
+
+	return &stateReq{peer: p, timeout: snap.s.d.requestTTL(), storages: tasks}
+}
+
+// codeRequest creates a retrieval request for the queued contract codes not yet
+// requested from the peer, proportional to its estimated capacity.
+func (snap *snapSync) codeRequest(p *peerConnection) *stateReq {
+	var (
+		capacity = p.NodeDataCapacity(snap.s.d.requestRTT())
+		hashes   []common.Hash
+	)
+	for hash, task := range snap.codes {
+		if len(hashes) >= capacity {
+			break
+		}
+		if _, ok := task.attempts[p.id]; ok || task.active {
+			continue
+		}
+		task.attempts[p.id] = struct{}{}
+		task.active = true
+		hashes = append(hashes, hash)
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	return &stateReq{peer: p, timeout: snap.s.d.requestTTL(), items: hashes}
+}
+
+// send starts tracking a retrieval request and sends it to its peer.
+func (snap *snapSync) send(req *stateReq) {
+	select {
+	case snap.s.d.trackStateReq <- req:
+	case <-snap.s.cancel:
+		return
+	case <-snap.s.d.cancelCh:
+		return
+	}
+	snap.active[req.peer.id] = req
+
+	switch {
+	case req.account != nil:
+		req.peer.log.Trace("Requesting account range", "origin", req.account.next, "limit", req.account.last)
+		req.peer.FetchAccountRange(snap.s.root, req.account.next, req.account.last, uint64(MaxRangeFetch))
+
+	case len(req.storages) > 0:
+		accounts := make([]common.Hash, len(req.storages))
+		for i, task := range req.storages {
+			accounts[i] = task.account.hash
+		}
+		req.peer.log.Trace("Requesting storage ranges", "count", len(accounts), "origin", req.storages[0].next)
+		req.peer.FetchStorageRanges(snap.s.root, accounts, req.storages[0].next, uint64(MaxRangeFetch))
+
+	default:
+		req.peer.log.Trace("Requesting contract codes", "count", len(req.items))
+		req.peer.FetchNodeData(req.items)
+	}
+}
+
+// process handles the outcome of a retrieval request, returning the number of
+// items delivered by the peer.
+func (snap *snapSync) process(req *stateReq) (int, error) {
+	switch {
+	case req.account != nil:
+		return snap.processAccounts(req)
+	case len(req.storages) > 0:
+		return snap.processStorages(req)
+	default:
+		return snap.processCodes(req)
+	}
+}
+
+// processAccounts verifies a delivered account range and schedules the storage
+// and code retrievals of its accounts.
+func (snap *snapSync) processAccounts(req *stateReq) (int, error) {
+	task := req.account
+	task.active = false
+
+	if req.dropped {
+		return 0, nil
+	}
+	if req.timedOut() {
+		snap.stale[req.peer.id] = struct{}{}
+		return 0, nil
+	}
+	pack := req.ranges.(*accountRangePack)
+	if len(pack.hashes) == 0 && len(pack.proof) == 0 {
+		// The peer doesn't have the state (any more), don't bother it again
+		req.peer.log.Debug("Peer lacks the synced state", "root", snap.s.root)
+		snap.stale[req.peer.id] = struct{}{}
+		return 0, nil
+	}
+	keys := make([][]byte, len(pack.hashes))
+	for i, hash := range pack.hashes {
+		keys[i] = common.CopyBytes(hash[:])
+	}
+	more, err := trie.VerifyRangeProof(snap.s.root, task.next[:], keys, pack.accounts, proofDatabase(pack.proof))
+	if err != nil {
+		req.peer.log.Warn("Invalid account range", "origin", task.next, "err", err)
+		snap.s.d.dropPeer(req.peer.id)
+		return 0, nil
+	}
+	// Discard the accounts beyond the range of the task
+	hashes, accounts := pack.hashes, pack.accounts
+	for i, hash := range hashes {
+		if compareHashes(hash, task.last) > 0 {
+			hashes, accounts, more = hashes[:i], accounts[:i], false
+			break
+		}
+	}
+	for i, hash := range hashes {
+		if err := snap.track(&snapAccount{task: task, hash: hash, blob: accounts[i]}); err != nil {
+			return i, err
+		}
+	}
+	if more && len(hashes) > 0 {
+		task.next, more = incHash(hashes[len(hashes)-1])
+	}
+	if !more {
+		task.fetched = true
+	}
+	return len(hashes), snap.check(task)
+}
+
+// track schedules the storage and code retrievals of an account not yet present
+// in the database, inserting it into its range right away if there are none.
+func (snap *snapSync) track(acc *snapAccount) error {
+	var account state.Account
+	if err := rlp.DecodeBytes(acc.blob, &account); err != nil {
+		// Proven but undecodable, leave it to the node sync to sort out
+		return nil
+	}
+	if account.Root != state.EmptyRoot && !snap.stored(account.Root) {
+		acc.waiting++
+		snap.storages = append(snap.storages, &storageTask{account: acc, root: account.Root})
+	}
+	if hash := common.BytesToHash(account.CodeHash); hash != emptyCode && !snap.stored(hash) {
+		acc.waiting++
+		task := snap.codes[hash]
+		if task == nil {
+			task = &codeTask{attempts: make(map[string]struct{})}
+			snap.codes[hash] = task
+		}
+		task.accounts = append(task.accounts, acc)
+	}
+	if acc.waiting == 0 {
+		return snap.insert(acc)
+	}
+	acc.task.waiting[acc.hash] = struct{}{}
+	snap.pending++
+	return nil
+}
+
+// resolve marks a storage or code retrieval of an account as finished, inserting
+// the account into its range once it's not waiting for anything else.
+func (snap *snapSync) resolve(acc *snapAccount, failed bool) error {
+	acc.failed = acc.failed || failed
+	if acc.waiting--; acc.waiting > 0 {
+		return nil
+	}
+	delete(acc.task.waiting, acc.hash)
+	snap.pending--
+
+	if acc.failed {
+		return snap.check(acc.task)
+	}
+	return snap.insert(acc)
+}
+
+// insert adds an account with all its data present into the trie of its range,
+// flushing the trie if the range is finished or enough accounts accumulated.
+func (snap *snapSync) insert(acc *snapAccount) error {
+	task := acc.task
+	if err := task.trie.TryUpdate(acc.hash[:], acc.blob); err != nil {
+		return err
+	}
+	snap.synced.accounts++
+	if task.inserted++; task.inserted >= snapCommitThreshold {
+		if err := snap.commit(task.trie); err != nil {
+			return err
+		}
+		task.inserted = 0
+	}
+	return snap.check(task)
+}
+
+// check flushes the trie of a range once all its accounts are retrieved.
+func (snap *snapSync) check(task *accountTask) error {
+	if !task.fetched || len(task.waiting) > 0 {
+		return nil
+	}
+	task.inserted = 0
+	return snap.commit(task.trie)
+}
+
+// processStorages verifies a batch of delivered storage ranges, requeueing the
+// storage tries not or only partially delivered.
+func (snap *snapSync) processStorages(req *stateReq) (int, error) {
+	tasks := req.storages
+	defer func() {
+		snap.storages = append(tasks, snap.storages...)
+	}()
+	if req.dropped {
+		return 0, nil
+	}
+	if req.timedOut() {
+		snap.stale[req.peer.id] = struct{}{}
+		return 0, nil
+	}
+	pack := req.ranges.(*storageRangesPack)
+	if len(pack.slots) == 0 {
+		// The peer doesn't have the state (any more), don't bother it again
+		req.peer.log.Debug("Peer lacks the synced state", "root", snap.s.root)
+		snap.stale[req.peer.id] = struct{}{}
+		return 0, nil
+	}
+	if len(pack.slots) > len(tasks) || len(pack.hashes) != len(pack.slots) {
+		req.peer.log.Warn("Invalid storage ranges", "requested", len(tasks), "delivered", len(pack.slots))
+		snap.s.d.dropPeer(req.peer.id)
+		return 0, nil
+	}
+	for i, slots := range pack.slots {
+		task := tasks[0]
+
+		keys := make([][]byte, len(pack.hashes[i]))
+		for j, hash := range pack.hashes[i] {
+			keys[j] = common.CopyBytes(hash[:])
+		}
+		var err error
+		if len(keys) != len(slots) {
+			err = errInvalidRange
+		} else if i == len(pack.slots)-1 && len(pack.proof) > 0 {
+			err = snap.processStorageRange(task, keys, slots, pack.proof)
+		} else {
+			err = snap.processStorage(task, keys, slots)
+		}
+		if err == errInvalidRange {
+			req.peer.log.Warn("Invalid storage range", "account", task.account.hash, "origin", task.next)
+			snap.s.d.dropPeer(req.peer.id)
+			return i, nil
+		}
+		if err != nil {
+			return i, err
+		}
+		snap.synced.slots += uint64(len(slots))
+		if task.next == (common.Hash{}) {
+			tasks = tasks[1:]
+		}
+	}
+	return len(pack.slots), nil
+}
+
+// processStorage verifies and stores an entire storage trie delivered at once.
+func (snap *snapSync) processStorage(task *storageTask, keys [][]byte, slots [][]byte) error {
+	if task.next != (common.Hash{}) {
+		return errInvalidRange
+	}
+	tr, _ := trie.New(common.Hash{}, snap.triedb)
+	for i, key := range keys {
+		if err := tr.TryUpdate(key, slots[i]); err != nil {
+			return errInvalidRange
+		}
+	}
+	if tr.Hash() != task.root {
+		return errInvalidRange
+	}
+	if err := snap.commit(tr); err != nil {
+		return err
+	}
+	return snap.resolve(task.account, false)
+}
+
+// processStorageRange verifies and stores a proven range of a storage trie,
+// finishing the storage trie if the range is its last one.
+func (snap *snapSync) processStorageRange(task *storageTask, keys [][]byte, slots [][]byte, proof [][]byte) error {
+	more, err := trie.VerifyRangeProof(task.root, task.next[:], keys, slots, proofDatabase(proof))
+	if err != nil {
+		return errInvalidRange
+	}
+	if task.trie == nil {
+		task.trie, _ = trie.New(common.Hash{}, snap.triedb)
+	}
+	for i, key := range keys {
+		if err := task.trie.TryUpdate(key, slots[i]); err != nil {
+			return err
+		}
+	}
+	if err := snap.commit(task.trie); err != nil {
+		return err
+	}
+	if more && len(keys) > 0 {
+		task.next, more = incHash(common.BytesToHash(keys[len(keys)-1]))
+	}
+	if more {
+		return nil
+	}
+	task.next = common.Hash{}
+	return snap.resolve(task.account, task.trie.Hash() != task.root)
+}
+
+// processCodes stores the delivered contract codes, giving up on the ones that
+// all peers were asked for already.
+func (snap *snapSync) processCodes(req *stateReq) (int, error) {
+	var (
+		batch     = snap.s.d.stateDB.NewBatch()
+		delivered []*codeTask
+	)
+	for _, blob := range req.response {
+		hash := crypto.Keccak256Hash(blob)
+		if task := snap.codes[hash]; task != nil && task.active {
+			if err := batch.Put(hash[:], blob); err != nil {
+				return 0, err
+			}
+			delivered = append(delivered, task)
+			delete(snap.codes, hash)
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return 0, fmt.Errorf("DB write error: %v", err)
+	}
+	snap.synced.codes += uint64(len(delivered))
+
+	for _, task := range delivered {
+		for _, acc := range task.accounts {
+			if err := snap.resolve(acc, false); err != nil {
+				return len(delivered), err
+			}
+		}
+	}
+	// Requeue the missing codes, unless nobody else is left to ask
+	npeers := snap.s.d.peers.Len()
+	for _, hash := range req.items {
+		task := snap.codes[hash]
+		if task == nil {
+			continue
+		}
+		task.active = false
+		if len(task.attempts) < npeers {
+			continue
+		}
+		delete(snap.codes, hash)
+		for _, acc := range task.accounts {
+			if err := snap.resolve(acc, true); err != nil {
+				return len(delivered), err
+			}
+		}
+	}
+	return len(delivered), nil
+}
+
+// commit writes the nodes of a trie to disk.
+func (snap *snapSync) commit(tr *trie.Trie) error {
+	root, err := tr.Commit(nil)
+	if err != nil {
+		return err
+	}
+	if err := snap.triedb.Commit(root, false); err != nil {
+		return fmt.Errorf("DB write error: %v", err)
+	}
+	return nil
+}
+
+// stored returns whether a trie node or contract code is present in the database.
+func (snap *snapSync) stored(hash common.Hash) bool {
+	ok, _ := snap.s.d.stateDB.Has(hash[:])
+	return ok
+}
+
+// report displays the progress of the range retrievals in the user logs, either
+// periodically or once they are finished.
+func (snap *snapSync) report(final bool) {
+	if !final && time.Since(snap.logged) < 8*time.Second {
+		return
+	}
+	snap.logged = time.Now()
+
+	context := []interface{}{
+		"accounts", snap.synced.accounts, "slots", snap.synced.slots, "codes", snap.synced.codes,
+		"elapsed", common.PrettyDuration(time.Since(snap.start)),
+	}
+	if final {
+		log.Info("Retrieved state ranges", context...)
+	} else {
+		log.Info("Retrieving state ranges", append(context, "pending", snap.pending)...)
+	}
+}
+
+// proofDatabase collects merkle proof nodes into a database keyed by their hash.
+func proofDatabase(proof [][]byte) ethdb.Database {
+	db, _ := ethdb.NewMemDatabase()
+	for _, node := range proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	return db
+}
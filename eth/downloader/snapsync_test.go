@@ -0,0 +1,222 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/core/state"
+	"github.com/TeamEGEM/go-egem/ethdb"
+	"github.com/TeamEGEM/go-egem/event"
+	"github.com/TeamEGEM/go-egem/trie"
+)
+
+// makeSnapState creates a state with plenty of accounts, some of them contracts
+// with storage, one of which is too large to be retrieved in a single range.
+func makeSnapState(t *testing.T) (ethdb.Database, common.Hash) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+	for i := 0; i < 1000; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		statedb.AddBalance(addr, big.NewInt(int64(i+1)))
+		statedb.SetNonce(addr, uint64(i))
+
+		if i%10 == 0 {
+			statedb.SetCode(addr, []byte{byte(i), 0x01, 0x02})
+			for j := 0; j < i%30+1; j++ {
+				statedb.SetState(addr, common.BigToHash(big.NewInt(int64(j))), common.BigToHash(big.NewInt(int64(i+j+1))))
+			}
+		}
+	}
+	large := common.BigToAddress(big.NewInt(1000000))
+	for j := 0; j < 20000; j++ {
+		statedb.SetState(large, common.BigToHash(big.NewInt(int64(j))), common.BytesToHash(common.LeftPadBytes([]byte{0xff}, 32)))
+	}
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := statedb.Database().TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+	return db, root
+}
+
+// Tests that a state can be retrieved in ranges and verified, leaving almost
+// nothing to be healed by the node based sync.
+func TestSnapStateSync(t *testing.T) {
+	srcDb, root := makeSnapState(t)
+
+	dstDb, _ := ethdb.NewMemDatabase()
+	dl := New(SnapSync, dstDb, new(event.TypeMux), nil, nil, func(string) {})
+	defer dl.Terminate()
+
+	dl.cancelCh = make(chan struct{})
+	if err := dl.RegisterPeer("peer", 63, NewFakePeer("peer", srcDb, nil, dl)); err != nil {
+		t.Fatalf("failed to register peer: %v", err)
+	}
+	if err := dl.syncState(root).Wait(); err != nil {
+		t.Fatalf("failed to sync state: %v", err)
+	}
+	if healed := dl.syncStatsState.processed; healed > snapAccountTasks+1 {
+		t.Errorf("too many trie nodes healed: have %d, want at most %d", healed, snapAccountTasks+1)
+	}
+	checkSnapState(t, dstDb, root)
+}
+
+// Tests that a state is still synced by healing it if no peer can serve ranges.
+func TestSnapStateSyncFallback(t *testing.T) {
+	srcDb, root := makeSnapState(t)
+
+	dstDb, _ := ethdb.NewMemDatabase()
+	dl := New(SnapSync, dstDb, new(event.TypeMux), nil, nil, func(string) {})
+	defer dl.Terminate()
+
+	dl.cancelCh = make(chan struct{})
+	if err := dl.RegisterPeer("peer", 63, &nodeDataPeer{NewFakePeer("peer", srcDb, nil, dl)}); err != nil {
+		t.Fatalf("failed to register peer: %v", err)
+	}
+	if err := dl.syncState(root).Wait(); err != nil {
+		t.Fatalf("failed to sync state: %v", err)
+	}
+	checkSnapState(t, dstDb, root)
+}
+
+// countingPeer is a mock peer counting the account ranges requested from it.
+type countingPeer struct {
+	*FakePeer
+	ranges int32
+}
+
+// RequestAccountRange implements downloader.RangePeer, counting the request.
+func (p *countingPeer) RequestAccountRange(root common.Hash, origin common.Hash, limit common.Hash, bytes uint64) error {
+	atomic.AddInt32(&p.ranges, 1)
+	return p.FakePeer.RequestAccountRange(root, origin, limit, bytes)
+}
+
+// Tests that the account ranges retrieved for a state root are kept when the
+// pivot moves, the differences to the new root being healed instead.
+func TestSnapStateSyncPivotMove(t *testing.T) {
+	srcDb, root := makeSnapState(t)
+
+	dstDb, _ := ethdb.NewMemDatabase()
+	dl := New(SnapSync, dstDb, new(event.TypeMux), nil, nil, func(string) {})
+	defer dl.Terminate()
+
+	dl.cancelCh = make(chan struct{})
+	peer := &countingPeer{FakePeer: NewFakePeer("peer", srcDb, nil, dl)}
+	if err := dl.RegisterPeer("peer", 63, peer); err != nil {
+		t.Fatalf("failed to register peer: %v", err)
+	}
+	if err := dl.syncState(root).Wait(); err != nil {
+		t.Fatalf("failed to sync state: %v", err)
+	}
+	// Change a few accounts and move over to the resulting root
+	statedb, _ := state.New(root, state.NewDatabase(srcDb))
+	for i := 0; i < 10; i++ {
+		statedb.AddBalance(common.BigToAddress(big.NewInt(int64(i*100+1))), big.NewInt(1))
+	}
+	moved, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := statedb.Database().TrieDB().Commit(moved, false); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+	requested := atomic.LoadInt32(&peer.ranges)
+	if err := dl.syncState(moved).Wait(); err != nil {
+		t.Fatalf("failed to sync moved state: %v", err)
+	}
+	if again := atomic.LoadInt32(&peer.ranges) - requested; again != 0 {
+		t.Errorf("account ranges retrieved again: have %d, want 0", again)
+	}
+	checkSnapState(t, dstDb, moved)
+}
+
+// nodeDataPeer is a mock peer only able to serve the state node by node, hiding
+// the state range methods of the wrapped peer.
+type nodeDataPeer struct {
+	Peer
+}
+
+// corruptingPeer is a mock peer serving account ranges with tampered accounts.
+type corruptingPeer struct {
+	*FakePeer
+}
+
+// RequestAccountRange implements downloader.RangePeer, corrupting the first account
+// of the served range.
+func (p *corruptingPeer) RequestAccountRange(root common.Hash, origin common.Hash, limit common.Hash, bytes uint64) error {
+	hashes, accounts, proof := ServeAccountRange(trie.NewDatabase(p.db), root, origin, limit, bytes)
+	if len(accounts) > 0 {
+		accounts[0] = common.CopyBytes(accounts[0])
+		accounts[0][len(accounts[0])-1]++
+	}
+	p.dl.DeliverAccountRange(p.id, hashes, accounts, proof)
+	return nil
+}
+
+// Tests that invalid state ranges are rejected and their peer dropped, the state
+// getting synced from the remaining peers.
+func TestSnapStateSyncInvalidRanges(t *testing.T) {
+	srcDb, root := makeSnapState(t)
+
+	var (
+		dl      *Downloader
+		dropped []string
+	)
+	dstDb, _ := ethdb.NewMemDatabase()
+	dl = New(SnapSync, dstDb, new(event.TypeMux), nil, nil, func(id string) {
+		dropped = append(dropped, id)
+		dl.UnregisterPeer(id)
+	})
+	defer dl.Terminate()
+
+	dl.cancelCh = make(chan struct{})
+	if err := dl.RegisterPeer("bad", 63, &corruptingPeer{NewFakePeer("bad", srcDb, nil, dl)}); err != nil {
+		t.Fatalf("failed to register peer: %v", err)
+	}
+	if err := dl.RegisterPeer("good", 63, &nodeDataPeer{NewFakePeer("good", srcDb, nil, dl)}); err != nil {
+		t.Fatalf("failed to register peer: %v", err)
+	}
+	if err := dl.syncState(root).Wait(); err != nil {
+		t.Fatalf("failed to sync state: %v", err)
+	}
+	if len(dropped) != 1 || dropped[0] != "bad" {
+		t.Errorf("dropped peers mismatch: have %v, want [bad]", dropped)
+	}
+	checkSnapState(t, dstDb, root)
+}
+
+// checkSnapState verifies that the entire state with the given root is present
+// in the database.
+func checkSnapState(t *testing.T, db ethdb.Database, root common.Hash) {
+	statedb, err := state.New(root, state.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("failed to open synced state: %v", err)
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+	}
+	if it.Error != nil {
+		t.Fatalf("synced state inconsistent: %v", it.Error)
+	}
+}
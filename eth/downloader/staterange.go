@@ -0,0 +1,175 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"bytes"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/core/state"
+	"github.com/TeamEGEM/go-egem/ethdb"
+	"github.com/TeamEGEM/go-egem/rlp"
+	"github.com/TeamEGEM/go-egem/trie"
+)
+
+// ServeAccountRange retrieves the accounts of the state trie with the given root
+// from origin onwards, stopping after the first account past limit or once about
+// the given number of bytes have been gathered. The accounts are returned along
+// with the merkle proofs of origin and of the last account. If the state is not
+// available, neither accounts nor proofs are returned.
+func ServeAccountRange(triedb *trie.Database, root common.Hash, origin common.Hash, limit common.Hash, bytes uint64) ([]common.Hash, [][]byte, [][]byte) {
+	tr, err := trie.New(root, triedb)
+	if err != nil {
+		return nil, nil, nil
+	}
+	hashes, accounts, err := serveRange(tr, origin, limit, bytes)
+	if err != nil {
+		return nil, nil, nil
+	}
+	proof, err := proveRange(tr, origin, hashes)
+	if err != nil {
+		return nil, nil, nil
+	}
+	return hashes, accounts, proof
+}
+
+// ServeStorageRanges retrieves the storage slots of the given accounts of the
+// state trie with the given root, the first account's starting from origin and
+// the rest's from the beginning. Accounts are served in order until about the
+// given number of bytes have been gathered, the last one possibly partially. A
+// proof is attached only if the last served storage range doesn't span its whole
+// trie, otherwise the ranges are complete and can be verified by their roots.
+func ServeStorageRanges(triedb *trie.Database, root common.Hash, accounts []common.Hash, origin common.Hash, bytes uint64) ([][]common.Hash, [][][]byte, [][]byte) {
+	tr, err := trie.New(root, triedb)
+	if err != nil {
+		return nil, nil, nil
+	}
+	var (
+		hashes [][]common.Hash
+		slots  [][][]byte
+		size   uint64
+	)
+	for i, hash := range accounts {
+		if size >= bytes {
+			break
+		}
+		// Open the storage trie of the account, stopping at unknown ones
+		blob, err := tr.TryGet(hash[:])
+		if err != nil || blob == nil {
+			break
+		}
+		var account state.Account
+		if err := rlp.DecodeBytes(blob, &account); err != nil {
+			break
+		}
+		storage, err := trie.New(account.Root, triedb)
+		if err != nil {
+			break
+		}
+		start := common.Hash{}
+		if i == 0 {
+			start = origin
+		}
+		keys, values, err := serveRange(storage, start, common.Hash{}, bytes-size)
+		if err != nil {
+			break
+		}
+		hashes = append(hashes, keys)
+		slots = append(slots, values)
+		for _, value := range values {
+			size += uint64(common.HashLength + len(value))
+		}
+		// If the range doesn't cover the entire storage, prove it and stop
+		if start != (common.Hash{}) || (len(keys) > 0 && hasMore(storage, keys[len(keys)-1])) {
+			proof, err := proveRange(storage, start, keys)
+			if err != nil {
+				return nil, nil, nil
+			}
+			return hashes, slots, proof
+		}
+	}
+	return hashes, slots, nil
+}
+
+// serveRange collects the leaves of a trie from origin onwards until the first
+// one past limit (unless limit is zero) or until about the given number of bytes
+// have been gathered.
+func serveRange(tr *trie.Trie, origin common.Hash, limit common.Hash, size uint64) ([]common.Hash, [][]byte, error) {
+	var (
+		keys   []common.Hash
+		values [][]byte
+		served uint64
+	)
+	it := trie.NewIterator(tr.NodeIterator(origin[:]))
+	for it.Next() {
+		key := common.BytesToHash(it.Key)
+		keys = append(keys, key)
+		values = append(values, common.CopyBytes(it.Value))
+
+		served += uint64(common.HashLength + len(it.Value))
+		if served >= size || (limit != (common.Hash{}) && compareHashes(key, limit) > 0) {
+			break
+		}
+	}
+	return keys, values, it.Err
+}
+
+// hasMore reports whether the trie contains a leaf after the given key.
+func hasMore(tr *trie.Trie, key common.Hash) bool {
+	next, ok := incHash(key)
+	if !ok {
+		return false
+	}
+	it := trie.NewIterator(tr.NodeIterator(next[:]))
+	return it.Next()
+}
+
+// proveRange collects the merkle proofs of origin and of the last of the keys.
+func proveRange(tr *trie.Trie, origin common.Hash, keys []common.Hash) ([][]byte, error) {
+	proofDb, _ := ethdb.NewMemDatabase()
+	if err := tr.Prove(origin[:], 0, proofDb); err != nil {
+		return nil, err
+	}
+	if len(keys) > 0 {
+		if err := tr.Prove(keys[len(keys)-1][:], 0, proofDb); err != nil {
+			return nil, err
+		}
+	}
+	var proof [][]byte
+	for _, key := range proofDb.Keys() {
+		node, _ := proofDb.Get(key)
+		proof = append(proof, node)
+	}
+	return proof, nil
+}
+
+// incHash returns the hash following the given one, or false if there is none.
+func incHash(hash common.Hash) (common.Hash, bool) {
+	for i := len(hash) - 1; i >= 0; i-- {
+		if hash[i] < 0xff {
+			hash[i]++
+			return hash, true
+		}
+		hash[i] = 0
+	}
+	return common.Hash{}, false
+}
+
+// compareHashes returns an integer comparing two hashes lexicographically.
+func compareHashes(a, b common.Hash) int {
+	return bytes.Compare(a[:], b[:])
+}
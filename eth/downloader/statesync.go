@@ -41,11 +41,20 @@ type stateReq struct {
 	peer     *peerConnection            // Peer that we're requesting from
 	response [][]byte                   // Response data of the peer (nil for timeouts)
 	dropped  bool                       // Flag whether the peer dropped off early
+
+	account  *accountTask   // Account range being retrieved (snap sync only)
+	storages []*storageTask // Storage ranges being retrieved (snap sync only)
+	ranges   dataPack       // State range response of the peer (nil for timeouts)
 }
 
 // timedOut returns if this request timed out.
 func (req *stateReq) timedOut() bool {
-	return req.response == nil
+	return req.response == nil && req.ranges == nil
+}
+
+// ranged returns if this request retrieves state ranges instead of trie nodes.
+func (req *stateReq) ranged() bool {
+	return req.account != nil || len(req.storages) > 0
 }
 
 // stateSyncStats is a collection of progress stats to report during a state trie
@@ -80,6 +89,8 @@ func (d *Downloader) stateFetcher() {
 			}
 		case <-d.stateCh:
 			// Ignore state responses while no sync is running.
+		case <-d.rangeCh:
+			// Ignore state range responses while no sync is running.
 		case <-d.quitCh:
 			return
 		}
@@ -141,7 +152,7 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 		case pack := <-d.stateCh:
 			// Discard any data not requested (or previsouly timed out)
 			req := active[pack.PeerId()]
-			if req == nil {
+			if req == nil || req.ranged() {
 				log.Debug("Unrequested node data", "peer", pack.PeerId(), "len", pack.Items())
 				continue
 			}
@@ -152,6 +163,21 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 			finished = append(finished, req)
 			delete(active, pack.PeerId())
 
+		// Handle incoming state range packs:
+		case pack := <-d.rangeCh:
+			// Discard any data not requested (or previsouly timed out)
+			req := active[pack.PeerId()]
+			if req == nil || !req.ranged() {
+				log.Debug("Unrequested state ranges", "peer", pack.PeerId(), "len", pack.Items())
+				continue
+			}
+			// Finalize the request and queue up for processing
+			req.timer.Stop()
+			req.ranges = pack
+
+			finished = append(finished, req)
+			delete(active, pack.PeerId())
+
 			// Handle dropped peer connections:
 		case p := <-peerDrop:
 			// Skip if no request is currently pending
@@ -214,6 +240,9 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 type stateSync struct {
 	d *Downloader // Downloader instance to access and manage current peerset
 
+	root common.Hash // State root currently being synced
+	snap bool        // Whether to retrieve the state in ranges before healing it
+
 	sched  *trie.TrieSync             // State trie sync scheduler defining the tasks
 	keccak hash.Hash                  // Keccak256 hasher to verify deliveries with
 	tasks  map[common.Hash]*stateTask // Set of tasks currently queued for retrieval
@@ -239,6 +268,8 @@ type stateTask struct {
 func newStateSync(d *Downloader, root common.Hash) *stateSync {
 	return &stateSync{
 		d:       d,
+		root:    root,
+		snap:    d.mode == SnapSync,
 		sched:   state.NewStateSync(root, d.stateDB),
 		keccak:  sha3.NewKeccak256(),
 		tasks:   make(map[common.Hash]*stateTask),
@@ -280,6 +311,13 @@ func (s *stateSync) loop() error {
 	peerSub := s.d.peers.SubscribeNewPeers(newPeer)
 	defer peerSub.Unsubscribe()
 
+	// Retrieve as much of the state as possible in ranges if requested, leaving
+	// only the gaps to be filled in by the trie node retrievals
+	if s.snap {
+		if err := s.syncRanges(newPeer); err != nil {
+			return err
+		}
+	}
 	// Keep assigning new tasks until the sync completes or aborts
 	for s.sched.Pending() > 0 {
 		if err := s.commit(false); err != nil {
@@ -50,7 +50,8 @@ type Filter struct {
 	backend Backend
 
 	db         ethdb.Database
-	begin, end int64
+	block      common.Hash // Block hash if filtering a single block
+	begin, end int64       // Range interval if filtering multiple blocks
 	addresses  []common.Address
 	topics     [][]common.Hash
 
@@ -92,9 +93,25 @@ func New(backend Backend, begin, end int64, addresses []common.Address, topics [
 	}
 }
 
+// NewBlockFilter creates a new filter which directly inspects the contents of
+// a block to figure out whether it is interesting or not.
+func NewBlockFilter(backend Backend, block common.Hash, addresses []common.Address, topics [][]common.Hash) *Filter {
+	return &Filter{
+		backend:   backend,
+		block:     block,
+		addresses: addresses,
+		topics:    topics,
+		db:        backend.ChainDb(),
+	}
+}
+
 // Logs searches the blockchain for matching log entries, returning all from the
 // first block that contains matches, updating the start of the filter accordingly.
 func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
+	// If we're doing singleton block filtering, execute and return
+	if f.block != (common.Hash{}) {
+		return f.checkMatches(ctx, f.block)
+	}
 	// Figure out the limits of the filter range
 	header, _ := f.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
 	if header == nil {
@@ -165,7 +182,7 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 			if header == nil || err != nil {
 				return logs, err
 			}
-			found, err := f.checkMatches(ctx, header)
+			found, err := f.checkMatches(ctx, header.Hash())
 			if err != nil {
 				return logs, err
 			}
@@ -188,7 +205,7 @@ func (f *Filter) unindexedLogs(ctx context.Context, end uint64) ([]*types.Log, e
 			return logs, err
 		}
 		if bloomFilter(header.Bloom, f.addresses, f.topics) {
-			found, err := f.checkMatches(ctx, header)
+			found, err := f.checkMatches(ctx, header.Hash())
 			if err != nil {
 				return logs, err
 			}
@@ -198,11 +215,11 @@ func (f *Filter) unindexedLogs(ctx context.Context, end uint64) ([]*types.Log, e
 	return logs, nil
 }
 
-// checkMatches checks if the receipts belonging to the given header contain any log events that
+// checkMatches checks if the receipts belonging to the given block contain any log events that
 // match the filter criteria. This function is called when the bloom filter signals a potential match.
-func (f *Filter) checkMatches(ctx context.Context, header *types.Header) (logs []*types.Log, err error) {
+func (f *Filter) checkMatches(ctx context.Context, hash common.Hash) (logs []*types.Log, err error) {
 	// Get the logs of the block
-	logsList, err := f.backend.GetLogs(ctx, header.Hash())
+	logsList, err := f.backend.GetLogs(ctx, hash)
 	if err != nil {
 		return nil, err
 	}
@@ -214,7 +231,7 @@ func (f *Filter) checkMatches(ctx context.Context, header *types.Header) (logs [
 	if len(logs) > 0 {
 		// We have matching logs, check if we need to resolve full logs via the light client
 		if logs[0].TxHash == (common.Hash{}) {
-			receipts, err := f.backend.GetReceipts(ctx, header.Hash())
+			receipts, err := f.backend.GetReceipts(ctx, hash)
 			if err != nil {
 				return nil, err
 			}
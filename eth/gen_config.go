@@ -11,6 +11,7 @@ import (
 	"github.com/TeamEGEM/go-egem/core"
 	"github.com/TeamEGEM/go-egem/eth/downloader"
 	"github.com/TeamEGEM/go-egem/eth/gasprice"
+	"github.com/TeamEGEM/go-egem/miner"
 )
 
 var _ = (*configMarshaling)(nil)
@@ -29,10 +30,13 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		MinerThreads            int            `toml:",omitempty"`
 		ExtraData               hexutil.Bytes  `toml:",omitempty"`
 		GasPrice                *big.Int
+		MinerNotify             []string `toml:",omitempty"`
+		Stratum                 miner.StratumConfig
 		Ethash                  ethash.Config
 		TxPool                  core.TxPoolConfig
 		GPO                     gasprice.Config
 		EnablePreimageRecording bool
+		MultisigStore           string
 		DocRoot                 string `toml:"-"`
 	}
 	var enc Config
@@ -48,10 +52,13 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.MinerThreads = c.MinerThreads
 	enc.ExtraData = c.ExtraData
 	enc.GasPrice = c.GasPrice
+	enc.MinerNotify = c.MinerNotify
+	enc.Stratum = c.Stratum
 	enc.Ethash = c.Ethash
 	enc.TxPool = c.TxPool
 	enc.GPO = c.GPO
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
+	enc.MultisigStore = c.MultisigStore
 	enc.DocRoot = c.DocRoot
 	return &enc, nil
 }
@@ -70,10 +77,13 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		MinerThreads            *int            `toml:",omitempty"`
 		ExtraData               *hexutil.Bytes  `toml:",omitempty"`
 		GasPrice                *big.Int
+		MinerNotify             []string `toml:",omitempty"`
+		Stratum                 *miner.StratumConfig
 		Ethash                  *ethash.Config
 		TxPool                  *core.TxPoolConfig
 		GPO                     *gasprice.Config
 		EnablePreimageRecording *bool
+		MultisigStore           *string
 		DocRoot                 *string `toml:"-"`
 	}
 	var dec Config
@@ -116,6 +126,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.GasPrice != nil {
 		c.GasPrice = dec.GasPrice
 	}
+	if dec.MinerNotify != nil {
+		c.MinerNotify = dec.MinerNotify
+	}
+	if dec.Stratum != nil {
+		c.Stratum = *dec.Stratum
+	}
 	if dec.Ethash != nil {
 		c.Ethash = *dec.Ethash
 	}
@@ -128,6 +144,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.EnablePreimageRecording != nil {
 		c.EnablePreimageRecording = *dec.EnablePreimageRecording
 	}
+	if dec.MultisigStore != nil {
+		c.MultisigStore = *dec.MultisigStore
+	}
 	if dec.DocRoot != nil {
 		c.DocRoot = *dec.DocRoot
 	}
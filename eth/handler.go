@@ -67,6 +67,7 @@ type ProtocolManager struct {
 	networkId uint64
 
 	fastSync  uint32 // Flag whether fast sync is enabled (gets disabled if we already have blocks)
+	snapSync  uint32 // Flag whether fast sync should download the state in ranges where possible
 	acceptTxs uint32 // Flag whether we're considered synchronised (enables transaction processing)
 
 	txpool      txPool
@@ -113,18 +114,21 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		quitSync:    make(chan struct{}),
 	}
 	// Figure out whether to allow fast sync or not
-	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() > 0 {
+	if (mode == downloader.FastSync || mode == downloader.SnapSync) && blockchain.CurrentBlock().NumberU64() > 0 {
 		log.Warn("Blockchain not empty, fast sync disabled")
 		mode = downloader.FullSync
 	}
-	if mode == downloader.FastSync {
+	if mode == downloader.FastSync || mode == downloader.SnapSync {
 		manager.fastSync = uint32(1)
 	}
+	if mode == downloader.SnapSync {
+		manager.snapSync = uint32(1)
+	}
 	// Initiate a sub-protocol for every implemented version we can handle
 	manager.SubProtocols = make([]p2p.Protocol, 0, len(ProtocolVersions))
 	for i, version := range ProtocolVersions {
 		// Skip protocol version if incompatible with the mode of operation
-		if mode == downloader.FastSync && version < eth63 {
+		if (mode == downloader.FastSync || mode == downloader.SnapSync) && version < eth63 {
 			continue
 		}
 		// Compatible; initialise the sub-protocol
@@ -158,6 +162,19 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 	if len(manager.SubProtocols) == 0 {
 		return nil, errIncompatibleConfig
 	}
+	// Serve the state in ranges on a separate sub-protocol, attached to the eth
+	// session of the same peer
+	for i, version := range RangeProtocolVersions {
+		version := version // Closure for the run
+		manager.SubProtocols = append(manager.SubProtocols, p2p.Protocol{
+			Name:    RangeProtocolName,
+			Version: version,
+			Length:  RangeProtocolLengths[i],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				return manager.handleRanges(newRangePeer(int(version), p, rw))
+			},
+		})
+	}
 	// Construct the different synchronisation mechanisms
 	manager.downloader = downloader.New(mode, chaindb, manager.eventMux, blockchain, nil, manager.removePeer)
 
@@ -313,6 +330,109 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	}
 }
 
+// handleRanges is the callback invoked to manage the life cycle of a state range
+// protocol session. When this function terminates, the peer is disconnected.
+func (pm *ProtocolManager) handleRanges(p *rangePeer) error {
+	if err := pm.peers.RegisterRanges(p); err != nil {
+		p.Log().Debug("State range session registration failed", "err", err)
+		return err
+	}
+	defer pm.peers.UnregisterRanges(p.id)
+
+	for {
+		if err := pm.handleRangeMsg(p); err != nil {
+			p.Log().Debug("State range message handling failed", "err", err)
+			return err
+		}
+	}
+}
+
+// handleRangeMsg is invoked whenever an inbound message is received from a
+// remote peer on the state range protocol. The remote connection is torn down
+// upon returning any error.
+func (pm *ProtocolManager) handleRangeMsg(p *rangePeer) error {
+	// Read the next message from the remote peer, and ensure it's fully consumed
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	// Handle the message depending on its contents
+	switch {
+	case msg.Code == GetAccountRangeMsg:
+		// Decode the account range retrieval message
+		var query getAccountRangeData
+		if err := msg.Decode(&query); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		if query.Bytes > softResponseLimit {
+			query.Bytes = softResponseLimit
+		}
+		// Gather the accounts until the range or network limit is reached
+		triedb := pm.blockchain.StateCache().TrieDB()
+		hashes, accounts, proof := downloader.ServeAccountRange(triedb, query.Root, query.Origin, query.Limit, query.Bytes)
+		return p.SendAccountRange(hashes, accounts, proof)
+
+	case msg.Code == AccountRangeMsg:
+		// A range of accounts arrived to one of our previous requests
+		var res accountRangeData
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if len(res.Hashes) != len(res.Accounts) {
+			return errResp(ErrDecode, "msg %v: %d hashes for %d accounts", msg, len(res.Hashes), len(res.Accounts))
+		}
+		// Deliver all to the downloader
+		if err := pm.downloader.DeliverAccountRange(p.id, res.Hashes, res.Accounts, res.Proof); err != nil {
+			log.Debug("Failed to deliver account range", "err", err)
+		}
+
+	case msg.Code == GetStorageRangesMsg:
+		// Decode the storage ranges retrieval message
+		var query getStorageRangesData
+		if err := msg.Decode(&query); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		if query.Bytes > softResponseLimit {
+			query.Bytes = softResponseLimit
+		}
+		if len(query.Accounts) > downloader.MaxStorageRangeFetch {
+			query.Accounts = query.Accounts[:downloader.MaxStorageRangeFetch]
+		}
+		// Gather the storage slots until the network limit is reached
+		triedb := pm.blockchain.StateCache().TrieDB()
+		hashes, slots, proof := downloader.ServeStorageRanges(triedb, query.Root, query.Accounts, query.Origin, query.Bytes)
+		return p.SendStorageRanges(hashes, slots, proof)
+
+	case msg.Code == StorageRangesMsg:
+		// A batch of storage ranges arrived to one of our previous requests
+		var res storageRangesData
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if len(res.Hashes) != len(res.Slots) {
+			return errResp(ErrDecode, "msg %v: %d slot hash lists for %d slot lists", msg, len(res.Hashes), len(res.Slots))
+		}
+		for i := range res.Hashes {
+			if len(res.Hashes[i]) != len(res.Slots[i]) {
+				return errResp(ErrDecode, "msg %v: %d hashes for %d slots", msg, len(res.Hashes[i]), len(res.Slots[i]))
+			}
+		}
+		// Deliver all to the downloader
+		if err := pm.downloader.DeliverStorageRanges(p.id, res.Hashes, res.Slots, res.Proof); err != nil {
+			log.Debug("Failed to deliver storage ranges", "err", err)
+		}
+
+	default:
+		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+	}
+	return nil
+}
+
 // handleMsg is invoked whenever an inbound message is received from a remote
 // peer. The remote connection is torn down upon returning any error.
 func (pm *ProtocolManager) handleMsg(p *peer) error {
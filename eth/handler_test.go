@@ -20,6 +20,7 @@ import (
 	"math"
 	"math/big"
 	"math/rand"
+	"reflect"
 	"testing"
 	"time"
 
@@ -448,6 +449,57 @@ func testGetReceipt(t *testing.T, protocol int) {
 	}
 }
 
+// Tests that account ranges can be retrieved over the state range protocol, the
+// session getting attached to the eth peer with the same id.
+func TestGetAccountRange(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 4, nil, nil)
+	peer, _ := newTestPeer("peer", 63, pm, true)
+	defer peer.close()
+
+	// Run the state range protocol alongside the eth one
+	app, net := p2p.MsgPipe()
+	defer app.Close()
+
+	go pm.handleRanges(newRangePeer(range1, peer.Peer, net))
+
+	var (
+		root  = pm.blockchain.CurrentBlock().Root()
+		limit = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+	)
+	hashes, accounts, proof := downloader.ServeAccountRange(pm.blockchain.StateCache().TrieDB(), root, common.Hash{}, limit, softResponseLimit)
+	if len(hashes) == 0 {
+		t.Fatalf("no accounts to serve")
+	}
+	p2p.Send(app, GetAccountRangeMsg, &getAccountRangeData{Root: root, Limit: limit, Bytes: softResponseLimit})
+	msg, err := app.ReadMsg()
+	if err != nil {
+		t.Fatalf("failed to read account range: %v", err)
+	}
+	var res accountRangeData
+	if err := msg.Decode(&res); err != nil {
+		t.Fatalf("failed to decode account range: %v", err)
+	}
+	if !reflect.DeepEqual(res.Hashes, hashes) || !reflect.DeepEqual(res.Accounts, accounts) {
+		t.Fatalf("account range mismatch: have %x, want %x", res.Hashes, hashes)
+	}
+	// The proof nodes are collected in no particular order
+	nodes := make(map[string]bool)
+	for _, node := range proof {
+		nodes[string(node)] = true
+	}
+	for _, node := range res.Proof {
+		if !nodes[string(node)] {
+			t.Fatalf("unexpected proof node %x", node)
+		}
+	}
+	if len(res.Proof) != len(proof) {
+		t.Fatalf("proof length mismatch: have %d, want %d", len(res.Proof), len(proof))
+	}
+	if !peer.ServesStateRanges() {
+		t.Fatalf("state range session not attached to the eth peer")
+	}
+}
+
 // Tests that post eth protocol handshake, DAO fork-enabled clients also execute
 // a DAO "challenge" verifying each others' DAO fork headers to ensure they're on
 // compatible chains.
@@ -0,0 +1,164 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/consensus/ethash"
+	"github.com/TeamEGEM/go-egem/core"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/ethdb"
+	"github.com/TeamEGEM/go-egem/params"
+)
+
+const (
+	// issuanceSectionSize is the number of blocks in a single issuance index section.
+	issuanceSectionSize = 4096
+
+	// issuanceConfirms is the number of confirmation blocks before an issuance
+	// section is considered probably final and indexed.
+	issuanceConfirms = 256
+
+	// issuanceThrottling is the time to wait between processing two consecutive
+	// index sections. It's useful during chain upgrades to prevent disk overload.
+	issuanceThrottling = 100 * time.Millisecond
+)
+
+// IssuanceIndexer implements a core.ChainIndexer, recording the cumulative coin
+// issuance of the ethash reward schedule for every canonical block.
+type IssuanceIndexer struct {
+	config *params.ChainConfig // Chain config holding the reward schedule
+	db     ethdb.Database      // Database instance to read blocks from and write index data into
+	size   uint64              // Section size to index the issuance in
+
+	batch ethdb.Batch    // Batch accumulating the index data of the current section
+	total *core.Issuance // Cumulative issuance up to the last processed header
+	err   error          // Error encountered while processing the current section
+}
+
+// NewIssuanceIndexer returns a chain indexer that records the cumulative coin
+// issuance of the canonical chain.
+func NewIssuanceIndexer(db ethdb.Database, config *params.ChainConfig, size uint64) *core.ChainIndexer {
+	backend := &IssuanceIndexer{
+		config: config,
+		db:     db,
+		size:   size,
+	}
+	table := ethdb.NewTable(db, string(core.IssuanceIndexPrefix))
+
+	return core.NewChainIndexer(db, table, backend, size, issuanceConfirms, issuanceThrottling, "issuance")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new issuance section on
+// top of the cumulative issuance of the previous one.
+func (b *IssuanceIndexer) Reset(section uint64, lastSectionHead common.Hash) error {
+	b.batch, b.err = b.db.NewBatch(), nil
+	if section == 0 {
+		b.total = core.NewIssuance()
+		return nil
+	}
+	total := core.GetIssuance(b.db, lastSectionHead, section*b.size-1)
+	if total == nil {
+		return fmt.Errorf("issuance of section head %x unknown", lastSectionHead)
+	}
+	b.total = total
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding the rewards of a new header
+// to the cumulative issuance.
+func (b *IssuanceIndexer) Process(header *types.Header) {
+	if b.err != nil {
+		return
+	}
+	number, hash := header.Number.Uint64(), header.Hash()
+	if number > 0 {
+		body := core.GetBody(b.db, hash, number)
+		if body == nil {
+			// Header chain is ahead of the block bodies (fast sync), retry later
+			b.err = fmt.Errorf("block body #%d [%x…] not found", number, hash[:4])
+			return
+		}
+		addIssuance(b.total, ethash.CalcBlockRewards(b.config, header, body.Uncles))
+	}
+	b.err = core.WriteIssuance(b.batch, hash, number, b.total)
+}
+
+// Commit implements core.ChainIndexerBackend, writing out the issuance of the
+// processed section into the database.
+func (b *IssuanceIndexer) Commit() error {
+	if b.err != nil {
+		return b.err
+	}
+	return b.batch.Write()
+}
+
+// addIssuance adds the credits of a single block to an issuance tally.
+func addIssuance(total *core.Issuance, rewards *ethash.BlockRewards) {
+	total.Miner.Add(total.Miner, rewards.Miner)
+	total.UncleInclusion.Add(total.UncleInclusion, rewards.UncleBonus)
+	for _, reward := range rewards.Uncles {
+		total.Uncles.Add(total.Uncles, reward)
+	}
+	for _, dev := range rewards.Dev {
+		total.DevFunds.Add(total.DevFunds, dev.Amount)
+	}
+}
+
+// GetCumulativeIssuance returns the cumulative issuance of the canonical chain up
+// to and including the given block. Indexed blocks are served directly from the
+// database, any remaining blocks beyond the index are summed up on the fly.
+func GetCumulativeIssuance(db ethdb.Database, config *params.ChainConfig, number uint64) (*core.Issuance, error) {
+	hash := core.GetCanonicalHash(db, number)
+	if hash == (common.Hash{}) {
+		return nil, fmt.Errorf("canonical block #%d unknown", number)
+	}
+	// Since every entry covers all its ancestors and is keyed by the block hash,
+	// the entries of the canonical chain always form a contiguous range starting
+	// at the genesis. Find the last one not beyond the requested block.
+	var (
+		lo, hi = uint64(0), number + 1 // invariant: lo indexed (or genesis), hi not indexed
+		total  = core.NewIssuance()
+	)
+	if issuance := core.GetIssuance(db, hash, number); issuance != nil {
+		return issuance, nil
+	}
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		if core.GetIssuance(db, core.GetCanonicalHash(db, mid), mid) != nil {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	if issuance := core.GetIssuance(db, core.GetCanonicalHash(db, lo), lo); issuance != nil {
+		total = issuance
+	}
+	// Sum up the rewards of the blocks not yet indexed
+	for n := lo + 1; n <= number; n++ {
+		hash := core.GetCanonicalHash(db, n)
+		block := core.GetBlock(db, hash, n)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d [%x…] not found", n, hash[:4])
+		}
+		addIssuance(total, ethash.CalcBlockRewards(config, block.Header(), block.Uncles()))
+	}
+	return total, nil
+}
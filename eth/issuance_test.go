@@ -0,0 +1,111 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/consensus/ethash"
+	"github.com/TeamEGEM/go-egem/core"
+	"github.com/TeamEGEM/go-egem/core/state"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/ethdb"
+	"github.com/TeamEGEM/go-egem/params"
+)
+
+// Tests that the issuance index, whether built or computed on the fly, matches
+// the coins actually credited into the state.
+func TestIssuanceIndexer(t *testing.T) {
+	var (
+		devFund   = common.HexToAddress("0x00000000000000000000000000000000000000de")
+		uncleBase = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+		config    = &params.ChainConfig{
+			ChainId:        big.NewInt(1),
+			HomesteadBlock: big.NewInt(0),
+			Ethash: &params.EthashConfig{Eras: []params.RewardEra{
+				{
+					Block:       big.NewInt(0),
+					BlockReward: big.NewInt(params.Ether),
+					Uncles:      params.UnclePolicy{InclusionDivisor: big.NewInt(32), RewardUncles: true},
+				},
+				{
+					Block:       big.NewInt(10),
+					BlockReward: big.NewInt(params.Ether / 2),
+					Uncles:      params.UnclePolicy{InclusionDivisor: big.NewInt(32)},
+					DevRewards:  []params.DevReward{{Address: devFund, Amount: big.NewInt(params.Finney)}},
+				},
+			}},
+		}
+		db, _   = ethdb.NewMemDatabase()
+		gspec   = &core.Genesis{Config: config, Alloc: core.GenesisAlloc{testBank: {Balance: big.NewInt(1000000)}}}
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, _ := core.GenerateChain(config, genesis, ethash.NewFaker(), db, 20, func(i int, block *core.BlockGen) {
+		if i == 5 || i == 12 {
+			block.AddUncle(&types.Header{ParentHash: block.PrevBlock(i - 2).Hash(), Number: big.NewInt(int64(i)), Coinbase: uncleBase})
+		}
+	})
+	for _, block := range blocks {
+		core.WriteBlock(db, block)
+		core.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+	}
+	head := blocks[len(blocks)-1]
+
+	// Ensure the issuance computed from the raw chain matches the state
+	statedb, _ := state.New(head.Root(), state.NewDatabase(db))
+	supply, _ := statedb.TotalBalance()
+
+	issuance, err := GetCumulativeIssuance(db, config, head.NumberU64())
+	if err != nil {
+		t.Fatalf("failed to compute issuance: %v", err)
+	}
+	if total := new(big.Int).Add(issuance.Total(), big.NewInt(1000000)); total.Cmp(supply) != 0 {
+		t.Fatalf("issuance mismatch: have %v, want %v", total, supply)
+	}
+	if issuance.Uncles.Sign() == 0 || issuance.UncleInclusion.Sign() == 0 || issuance.DevFunds.Cmp(big.NewInt(11*params.Finney)) != 0 {
+		t.Fatalf("issuance not split up correctly: %+v", issuance)
+	}
+	// Index the first two sections and ensure lookups are consistent
+	backend := &IssuanceIndexer{config: config, db: db, size: 8}
+	prev := common.Hash{}
+	for section := uint64(0); section < 2; section++ {
+		if err := backend.Reset(section, prev); err != nil {
+			t.Fatalf("section %d: failed to reset indexer: %v", section, err)
+		}
+		for n := section * 8; n < (section+1)*8; n++ {
+			header := core.GetHeader(db, core.GetCanonicalHash(db, n), n)
+			backend.Process(header)
+			prev = header.Hash()
+		}
+		if err := backend.Commit(); err != nil {
+			t.Fatalf("section %d: failed to commit index: %v", section, err)
+		}
+	}
+	if core.GetIssuance(db, blocks[14].Hash(), 15) == nil {
+		t.Fatalf("indexed issuance missing")
+	}
+	indexed, err := GetCumulativeIssuance(db, config, head.NumberU64())
+	if err != nil {
+		t.Fatalf("failed to retrieve issuance: %v", err)
+	}
+	if !reflect.DeepEqual(indexed, issuance) {
+		t.Fatalf("indexed issuance mismatch: have %+v, want %+v", indexed, issuance)
+	}
+}
@@ -34,6 +34,7 @@ var (
 	errClosed            = errors.New("peer set is closed")
 	errAlreadyRegistered = errors.New("peer is already registered")
 	errNotRegistered     = errors.New("peer is not registered")
+	errNoStateRanges     = errors.New("peer doesn't serve state ranges")
 )
 
 const (
@@ -59,9 +60,10 @@ type peer struct {
 	version  int         // Protocol version negotiated
 	forkDrop *time.Timer // Timed connection dropper if forks aren't validated in time
 
-	head common.Hash
-	td   *big.Int
-	lock sync.RWMutex
+	head   common.Hash
+	td     *big.Int
+	ranges *rangePeer // State range protocol session, if the peer runs it
+	lock   sync.RWMutex
 
 	knownTxs    *set.Set // Set of transaction hashes known to be known by this peer
 	knownBlocks *set.Set // Set of block hashes known to be known by this peer
@@ -228,6 +230,49 @@ func (p *peer) RequestReceipts(hashes []common.Hash) error {
 	return p2p.Send(p.rw, GetReceiptsMsg, hashes)
 }
 
+// ServesStateRanges reports whether the peer runs the state range protocol too,
+// allowing state to be retrieved from it in contiguous ranges.
+func (p *peer) ServesStateRanges() bool {
+	return p.rangePeer() != nil
+}
+
+// RequestAccountRange fetches a range of accounts of the state trie with the
+// given root over the state range protocol.
+func (p *peer) RequestAccountRange(root common.Hash, origin common.Hash, limit common.Hash, bytes uint64) error {
+	rp := p.rangePeer()
+	if rp == nil {
+		return errNoStateRanges
+	}
+	return rp.RequestAccountRange(root, origin, limit, bytes)
+}
+
+// RequestStorageRanges fetches the storage slots of a batch of accounts of the
+// state trie with the given root over the state range protocol.
+func (p *peer) RequestStorageRanges(root common.Hash, accounts []common.Hash, origin common.Hash, bytes uint64) error {
+	rp := p.rangePeer()
+	if rp == nil {
+		return errNoStateRanges
+	}
+	return rp.RequestStorageRanges(root, accounts, origin, bytes)
+}
+
+// rangePeer retrieves the state range protocol session of the peer, if any.
+func (p *peer) rangePeer() *rangePeer {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.ranges
+}
+
+// setRangePeer attaches or detaches (nil) the state range protocol session of
+// the peer.
+func (p *peer) setRangePeer(rp *rangePeer) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.ranges = rp
+}
+
 // Handshake executes the eth protocol handshake, negotiating version number,
 // network IDs, difficulties, head and genesis blocks.
 func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis common.Hash) error {
@@ -301,6 +346,7 @@ func (p *peer) String() string {
 // the Ethereum sub-protocol.
 type peerSet struct {
 	peers  map[string]*peer
+	ranges map[string]*rangePeer // State range sessions, attached to the eth peers with the same id
 	lock   sync.RWMutex
 	closed bool
 }
@@ -308,7 +354,8 @@ type peerSet struct {
 // newPeerSet creates a new peer set to track the active participants.
 func newPeerSet() *peerSet {
 	return &peerSet{
-		peers: make(map[string]*peer),
+		peers:  make(map[string]*peer),
+		ranges: make(map[string]*rangePeer),
 	}
 }
 
@@ -325,6 +372,9 @@ func (ps *peerSet) Register(p *peer) error {
 		return errAlreadyRegistered
 	}
 	ps.peers[p.id] = p
+	if rp, ok := ps.ranges[p.id]; ok {
+		p.setRangePeer(rp)
+	}
 	return nil
 }
 
@@ -341,6 +391,41 @@ func (ps *peerSet) Unregister(id string) error {
 	return nil
 }
 
+// RegisterRanges injects a new state range protocol session into the set,
+// attaching it to the eth peer with the same id, now or once it registers.
+func (ps *peerSet) RegisterRanges(rp *rangePeer) error {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	if ps.closed {
+		return errClosed
+	}
+	if _, ok := ps.ranges[rp.id]; ok {
+		return errAlreadyRegistered
+	}
+	ps.ranges[rp.id] = rp
+	if p, ok := ps.peers[rp.id]; ok {
+		p.setRangePeer(rp)
+	}
+	return nil
+}
+
+// UnregisterRanges removes a state range protocol session from the set,
+// detaching it from its eth peer.
+func (ps *peerSet) UnregisterRanges(id string) error {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	if _, ok := ps.ranges[id]; !ok {
+		return errNotRegistered
+	}
+	delete(ps.ranges, id)
+	if p, ok := ps.peers[id]; ok {
+		p.setRangePeer(nil)
+	}
+	return nil
+}
+
 // Peer retrieves the registered peer with the given id.
 func (ps *peerSet) Peer(id string) *peer {
 	ps.lock.RLock()
@@ -413,5 +498,56 @@ func (ps *peerSet) Close() {
 	for _, p := range ps.peers {
 		p.Disconnect(p2p.DiscQuitting)
 	}
+	for _, rp := range ps.ranges {
+		rp.Disconnect(p2p.DiscQuitting)
+	}
 	ps.closed = true
 }
+
+// rangePeer is a state range protocol session with a remote peer, serving and
+// retrieving contiguous ranges of the state trie.
+type rangePeer struct {
+	id string
+
+	*p2p.Peer
+	rw p2p.MsgReadWriter
+
+	version int // Protocol version negotiated
+}
+
+func newRangePeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *rangePeer {
+	id := p.ID()
+
+	return &rangePeer{
+		Peer:    p,
+		rw:      rw,
+		version: version,
+		id:      fmt.Sprintf("%x", id[:8]),
+	}
+}
+
+// SendAccountRange sends a range of accounts of a state trie, along with the
+// proofs of the range edges.
+func (p *rangePeer) SendAccountRange(hashes []common.Hash, accounts [][]byte, proof [][]byte) error {
+	return p2p.Send(p.rw, AccountRangeMsg, &accountRangeData{Hashes: hashes, Accounts: accounts, Proof: proof})
+}
+
+// SendStorageRanges sends the storage slots of a batch of accounts, along with
+// the proof of the last range if it's partial.
+func (p *rangePeer) SendStorageRanges(hashes [][]common.Hash, slots [][][]byte, proof [][]byte) error {
+	return p2p.Send(p.rw, StorageRangesMsg, &storageRangesData{Hashes: hashes, Slots: slots, Proof: proof})
+}
+
+// RequestAccountRange fetches a range of accounts of the state trie with the
+// given root, from origin up to limit, capped at about the given size in bytes.
+func (p *rangePeer) RequestAccountRange(root common.Hash, origin common.Hash, limit common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching range of accounts", "root", root, "origin", origin, "limit", limit, "bytes", bytes)
+	return p2p.Send(p.rw, GetAccountRangeMsg, &getAccountRangeData{Root: root, Origin: origin, Limit: limit, Bytes: bytes})
+}
+
+// RequestStorageRanges fetches the storage slots of a batch of accounts of the
+// state trie with the given root, the first one's from origin onwards.
+func (p *rangePeer) RequestStorageRanges(root common.Hash, accounts []common.Hash, origin common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching storage ranges", "root", root, "count", len(accounts), "origin", origin, "bytes", bytes)
+	return p2p.Send(p.rw, GetStorageRangesMsg, &getStorageRangesData{Root: root, Accounts: accounts, Origin: origin, Bytes: bytes})
+}
@@ -32,6 +32,8 @@ import (
 const (
 	eth62 = 62
 	eth63 = 63
+
+	range1 = 1
 )
 
 // Official short name of the protocol used during capability negotiation.
@@ -43,6 +45,16 @@ var ProtocolVersions = []uint{eth63, eth62}
 // Number of implemented message corresponding to different protocol versions.
 var ProtocolLengths = []uint64{17, 8}
 
+// Official short name of the state range protocol, run alongside eth by peers
+// able to serve contiguous ranges of the state trie.
+var RangeProtocolName = "staterange"
+
+// Supported versions of the state range protocol (first is primary).
+var RangeProtocolVersions = []uint{range1}
+
+// Number of implemented message corresponding to different state range protocol versions.
+var RangeProtocolLengths = []uint64{4}
+
 //const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 const (
 	NetworkId          = 1987
@@ -67,6 +79,14 @@ const (
 	ReceiptsMsg    = 0x10
 )
 
+// state range protocol message codes
+const (
+	GetAccountRangeMsg  = 0x00
+	AccountRangeMsg     = 0x01
+	GetStorageRangesMsg = 0x02
+	StorageRangesMsg    = 0x03
+)
+
 type errCode int
 
 const (
@@ -184,3 +204,37 @@ type blockBody struct {
 
 // blockBodiesData is the network packet for block content distribution.
 type blockBodiesData []*blockBody
+
+// getAccountRangeData is the network packet for requesting a range of accounts
+// of a state trie.
+type getAccountRangeData struct {
+	Root   common.Hash // Root of the state trie to serve the accounts of
+	Origin common.Hash // Hash of the first account to retrieve
+	Limit  common.Hash // Hash of the last account to retrieve
+	Bytes  uint64      // Soft limit on the response size
+}
+
+// accountRangeData is the network packet for a range of accounts, consisting of
+// the account hashes, the raw accounts and the merkle proofs of the range edges.
+type accountRangeData struct {
+	Hashes   []common.Hash
+	Accounts [][]byte
+	Proof    [][]byte
+}
+
+// getStorageRangesData is the network packet for requesting the storage slots
+// of a list of accounts of a state trie.
+type getStorageRangesData struct {
+	Root     common.Hash   // Root of the state trie containing the accounts
+	Accounts []common.Hash // Hashes of the accounts to serve the storage of
+	Origin   common.Hash   // Hash of the first storage slot of the first account
+	Bytes    uint64        // Soft limit on the response size
+}
+
+// storageRangesData is the network packet for the storage slots of a list of
+// accounts, along with the merkle proofs of the last range if it's partial.
+type storageRangesData struct {
+	Hashes [][]common.Hash
+	Slots  [][][]byte
+	Proof  [][]byte
+}
@@ -0,0 +1,108 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/core/vm"
+)
+
+// FourByteTracer is a native Go implementation of the 4byteTracer JavaScript
+// tracer, collecting the 4byte method identifiers of all the calls made, along
+// with the size of the supplied data, so a reversed signature can be matched
+// against the size of the data.
+type FourByteTracer struct {
+	ids   map[string]int // Aggregated 4byte identifiers found, keyed by id and data size
+	input []byte         // Input data of the outer call
+}
+
+// NewFourByteTracer creates a new native 4byte tracer.
+func NewFourByteTracer() *FourByteTracer {
+	return &FourByteTracer{
+		ids: make(map[string]int),
+	}
+}
+
+// store saves the given identifier and data size.
+func (t *FourByteTracer) store(id []byte, size uint64) {
+	t.ids[fmt.Sprintf("%s-%d", hexutil.Bytes(id), size)]++
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t *FourByteTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.input = common.CopyBytes(input)
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t *FourByteTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	// Skip any opcodes that are not internal calls, finding the input offset of the rest
+	var inPos int
+	switch op {
+	case vm.CALL, vm.CALLCODE:
+		inPos = 3 // gas, addr, val, memin, meminsz, memout, memoutsz
+	case vm.DELEGATECALL, vm.STATICCALL:
+		inPos = 2 // gas, addr, memin, meminsz, memout, memoutsz
+	default:
+		return nil
+	}
+	// Skip any pre-compile invocations, those are just fancy opcodes
+	if _, ok := vm.PrecompiledContractsByzantium[common.BigToAddress(peek(stack, 1))]; ok {
+		return nil
+	}
+	// Gather internal call details
+	if inSize := peek(stack, inPos+1).Uint64(); inSize >= 4 {
+		inOff := peek(stack, inPos).Uint64()
+		t.store(memorySlice(memory, inOff, inOff+4), inSize-4)
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *FourByteTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *FourByteTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+// Result returns the gathered identifiers, including the one of the outer call.
+func (t *FourByteTracer) Result() map[string]int {
+	ids := make(map[string]int)
+	for id, count := range t.ids {
+		ids[id] = count
+	}
+	if len(t.input) > 4 {
+		ids[fmt.Sprintf("%s-%d", hexutil.Bytes(t.input[:4]), len(t.input)-4)]++
+	}
+	return ids
+}
+
+// GetResult returns the identifiers in the same JSON format as the JavaScript
+// 4byteTracer does.
+func (t *FourByteTracer) GetResult() (json.RawMessage, error) {
+	return json.Marshal(t.Result())
+}
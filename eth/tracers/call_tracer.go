@@ -0,0 +1,280 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/core/vm"
+)
+
+// CallFrame is a single call made during the execution of a transaction, along
+// with all the internal calls it made in turn.
+type CallFrame struct {
+	Type    string          `json:"type"`
+	From    common.Address  `json:"from"`
+	To      *common.Address `json:"to,omitempty"`
+	Value   *hexutil.Big    `json:"value,omitempty"`
+	Gas     *hexutil.Uint64 `json:"gas,omitempty"`
+	GasUsed *hexutil.Uint64 `json:"gasUsed,omitempty"`
+	Input   hexutil.Bytes   `json:"input"`
+	Output  *hexutil.Bytes  `json:"output,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	Time    string          `json:"time,omitempty"`
+	Calls   []*CallFrame    `json:"calls,omitempty"`
+}
+
+// callFrame is a call frame still being executed, tracking the additional data
+// needed to finalize it when it returns.
+type callFrame struct {
+	*CallFrame
+
+	gasIn   uint64 // Gas available before the call opcode was executed
+	gasCost uint64 // Gas cost of the call opcode itself
+	outOff  uint64 // Memory offset to retrieve the call output from
+	outLen  uint64 // Length of the call output to retrieve from memory
+}
+
+// CallTracer is a native Go implementation of the callTracer JavaScript tracer,
+// extracting all the internal calls made by a transaction into a call tree.
+type CallTracer struct {
+	callstack []*callFrame // Current recursive call stack of the EVM execution
+	descended bool         // Whether we've just descended into an inner call
+}
+
+// NewCallTracer creates a new native call tracer.
+func NewCallTracer() *CallTracer {
+	return &CallTracer{}
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t *CallTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	root := &CallFrame{
+		Type:  "CALL",
+		From:  from,
+		To:    &to,
+		Value: (*hexutil.Big)(new(big.Int).Set(value)),
+		Gas:   newUint64(gas),
+		Input: common.CopyBytes(input),
+	}
+	if create {
+		root.Type = "CREATE"
+	}
+	t.callstack = []*callFrame{{CallFrame: root}}
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t *CallTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	// Capture any errors immediately
+	if err != nil {
+		return t.CaptureFault(env, pc, op, gas, cost, memory, stack, contract, depth, err)
+	}
+	switch op {
+	case vm.CREATE:
+		// If a new contract is being created, add to the call stack
+		inOff := peek(stack, 1).Uint64()
+		inEnd := inOff + peek(stack, 2).Uint64()
+
+		t.callstack = append(t.callstack, &callFrame{
+			CallFrame: &CallFrame{
+				Type:  op.String(),
+				From:  contract.Address(),
+				Input: memorySlice(memory, inOff, inEnd),
+				Value: (*hexutil.Big)(new(big.Int).Set(peek(stack, 0))),
+			},
+			gasIn:   gas,
+			gasCost: cost,
+		})
+		t.descended = true
+		return nil
+
+	case vm.SELFDESTRUCT:
+		// If a contract is being self destructed, gather that as a subcall too
+		to := common.BigToAddress(peek(stack, 0))
+
+		top := t.callstack[len(t.callstack)-1]
+		top.Calls = append(top.Calls, &CallFrame{
+			Type:  op.String(),
+			From:  contract.Address(),
+			To:    &to,
+			Value: (*hexutil.Big)(new(big.Int).Set(env.StateDB.GetBalance(contract.Address()))),
+		})
+		return nil
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		// Skip any pre-compile invocations, those are just fancy opcodes
+		to := common.BigToAddress(peek(stack, 1))
+		if _, ok := vm.PrecompiledContractsByzantium[to]; ok {
+			return nil
+		}
+		off := 1
+		if op == vm.DELEGATECALL || op == vm.STATICCALL {
+			off = 0
+		}
+		inOff := peek(stack, 2+off).Uint64()
+		inEnd := inOff + peek(stack, 3+off).Uint64()
+
+		call := &callFrame{
+			CallFrame: &CallFrame{
+				Type:  op.String(),
+				From:  contract.Address(),
+				To:    &to,
+				Input: memorySlice(memory, inOff, inEnd),
+			},
+			gasIn:   gas,
+			gasCost: cost,
+			outOff:  peek(stack, 4+off).Uint64(),
+			outLen:  peek(stack, 5+off).Uint64(),
+		}
+		if off == 1 {
+			call.Value = (*hexutil.Big)(new(big.Int).Set(peek(stack, 2)))
+		}
+		t.callstack = append(t.callstack, call)
+		t.descended = true
+		return nil
+	}
+	// If we've just descended into an inner call, retrieve it's true allowance. We
+	// need to extract if from within the call as there may be funky gas dynamics
+	// with regard to requested and actually given gas (2300 stipend, 63/64 rule).
+	if t.descended {
+		if depth >= len(t.callstack) {
+			t.callstack[len(t.callstack)-1].Gas = newUint64(gas)
+		}
+		t.descended = false
+	}
+	// If an existing call is returning, pop off the call stack
+	if op == vm.REVERT {
+		t.callstack[len(t.callstack)-1].Error = "execution reverted"
+		return nil
+	}
+	if depth == len(t.callstack)-1 {
+		call := t.callstack[len(t.callstack)-1]
+		t.callstack = t.callstack[:len(t.callstack)-1]
+
+		ret := peek(stack, 0)
+		if call.Type == vm.CREATE.String() {
+			// If the call was a CREATE, retrieve the contract address and output code
+			call.GasUsed = newUint64(call.gasIn - call.gasCost - gas)
+
+			if ret.Sign() != 0 {
+				to := common.BigToAddress(ret)
+				code := hexutil.Bytes(env.StateDB.GetCode(to))
+
+				call.To, call.Output = &to, &code
+			} else if call.Error == "" {
+				call.Error = "internal failure"
+			}
+		} else if call.Gas != nil {
+			// If the call was a contract call, retrieve the gas usage and output
+			call.GasUsed = newUint64(call.gasIn - call.gasCost + uint64(*call.Gas) - gas)
+
+			if ret.Sign() != 0 {
+				output := memorySlice(memory, call.outOff, call.outOff+call.outLen)
+				call.Output = &output
+			} else if call.Error == "" {
+				call.Error = "internal failure"
+			}
+		}
+		// Inject the call into the previous one
+		top := t.callstack[len(t.callstack)-1]
+		top.Calls = append(top.Calls, call.CallFrame)
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *CallTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	// If the topmost call already reverted, don't handle the additional fault again
+	if t.callstack[len(t.callstack)-1].Error != "" {
+		return nil
+	}
+	// Pop off the just failed call, consuming all available gas
+	call := t.callstack[len(t.callstack)-1]
+	call.Error = err.Error()
+	if call.Gas != nil {
+		call.GasUsed = newUint64(uint64(*call.Gas))
+	}
+	// Flatten the failed call into its parent, unless it's the outermost one
+	if len(t.callstack) > 1 {
+		t.callstack = t.callstack[:len(t.callstack)-1]
+
+		top := t.callstack[len(t.callstack)-1]
+		top.Calls = append(top.Calls, call.CallFrame)
+	}
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *CallTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	root := t.callstack[0]
+
+	root.GasUsed = newUint64(gasUsed)
+	root.Time = d.String()
+	if root.Error == "" && err != nil {
+		root.Error = err.Error()
+	}
+	if root.Error == "" {
+		out := hexutil.Bytes(common.CopyBytes(output))
+		root.Output = &out
+	}
+	return nil
+}
+
+// Result returns the call tree gathered during the execution of the transaction,
+// or nil if nothing was executed.
+func (t *CallTracer) Result() *CallFrame {
+	if len(t.callstack) == 0 {
+		return nil
+	}
+	return t.callstack[0].CallFrame
+}
+
+// GetResult returns the call tree in the same JSON format as the JavaScript
+// callTracer does.
+func (t *CallTracer) GetResult() (json.RawMessage, error) {
+	return json.Marshal(t.Result())
+}
+
+// newUint64 returns a pointer to a hex encodable copy of the given number.
+func newUint64(n uint64) *hexutil.Uint64 {
+	return (*hexutil.Uint64)(&n)
+}
+
+// peek returns the n-th item from the top of the stack, or zero if the stack is
+// not deep enough.
+func peek(stack *vm.Stack, n int) *big.Int {
+	data := stack.Data()
+	if len(data) <= n {
+		return new(big.Int)
+	}
+	return data[len(data)-n-1]
+}
+
+// memorySlice returns a copy of the memory contents between the given offsets, or
+// an empty slice if the range is out of bounds.
+func memorySlice(memory *vm.Memory, begin, end uint64) hexutil.Bytes {
+	if end < begin || uint64(memory.Len()) < end {
+		return hexutil.Bytes{}
+	}
+	return hexutil.Bytes(common.CopyBytes(memory.Get(int64(begin), int64(end-begin))))
+}
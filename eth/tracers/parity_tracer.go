@@ -0,0 +1,249 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/core/state"
+	"github.com/TeamEGEM/go-egem/core/vm"
+)
+
+// ParityTrace is a single call of a transaction in the flat format of the Parity
+// trace_ namespace. The block and transaction fields are filled in by the caller.
+type ParityTrace struct {
+	Action              ParityAction  `json:"action"`
+	BlockHash           *common.Hash  `json:"blockHash,omitempty"`
+	BlockNumber         *uint64       `json:"blockNumber,omitempty"`
+	Error               string        `json:"error,omitempty"`
+	Result              *ParityResult `json:"result"`
+	Subtraces           int           `json:"subtraces"`
+	TraceAddress        []int         `json:"traceAddress"`
+	TransactionHash     *common.Hash  `json:"transactionHash,omitempty"`
+	TransactionPosition *uint64       `json:"transactionPosition,omitempty"`
+	Type                string        `json:"type"`
+}
+
+// ParityAction is the action of a Parity trace. Depending on the trace type, it
+// is either a call, a contract creation or a self destruct.
+type ParityAction struct {
+	CallType      string          `json:"callType,omitempty"`
+	From          *common.Address `json:"from,omitempty"`
+	To            *common.Address `json:"to,omitempty"`
+	Gas           *hexutil.Uint64 `json:"gas,omitempty"`
+	Input         *hexutil.Bytes  `json:"input,omitempty"`
+	Init          *hexutil.Bytes  `json:"init,omitempty"`
+	Value         *hexutil.Big    `json:"value,omitempty"`
+	Address       *common.Address `json:"address,omitempty"`
+	RefundAddress *common.Address `json:"refundAddress,omitempty"`
+	Balance       *hexutil.Big    `json:"balance,omitempty"`
+}
+
+// ParityResult is the result of a successful call or contract creation.
+type ParityResult struct {
+	GasUsed hexutil.Uint64  `json:"gasUsed"`
+	Output  *hexutil.Bytes  `json:"output,omitempty"`
+	Address *common.Address `json:"address,omitempty"`
+	Code    *hexutil.Bytes  `json:"code,omitempty"`
+}
+
+// AccountDiff is the change of a single account in the format of the Parity
+// stateDiff trace. Each field is either "=" if unchanged, or an object keyed by
+// "+" (created), "-" (deleted) or "*" (modified, with "from" and "to" values).
+type AccountDiff struct {
+	Balance interface{}                 `json:"balance"`
+	Nonce   interface{}                 `json:"nonce"`
+	Code    interface{}                 `json:"code"`
+	Storage map[common.Hash]interface{} `json:"storage"`
+}
+
+// ParityTracer is a native call tracer which, apart from the call tree, tracks
+// the storage slots written, allowing it to produce the Parity style flat traces
+// and state diffs.
+type ParityTracer struct {
+	*CallTracer
+
+	storage map[common.Address]map[common.Hash]struct{} // Storage slots written during execution
+}
+
+// NewParityTracer creates a new Parity style native tracer.
+func NewParityTracer() *ParityTracer {
+	return &ParityTracer{
+		CallTracer: NewCallTracer(),
+		storage:    make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t *ParityTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if err == nil && op == vm.SSTORE {
+		addr := contract.Address()
+		if t.storage[addr] == nil {
+			t.storage[addr] = make(map[common.Hash]struct{})
+		}
+		t.storage[addr][common.BigToHash(peek(stack, 0))] = struct{}{}
+	}
+	return t.CallTracer.CaptureState(env, pc, op, gas, cost, memory, stack, contract, depth, err)
+}
+
+// Traces returns the flattened call traces of the executed transaction, or nil
+// if no execution was captured.
+func (t *ParityTracer) Traces() []*ParityTrace {
+	root := t.Result()
+	if root == nil {
+		return nil
+	}
+	return flattenCallFrame(root, []int{}, nil)
+}
+
+// flattenCallFrame converts a call frame and all its subcalls into Parity traces,
+// appending them to the given list in depth first order.
+func flattenCallFrame(frame *CallFrame, address []int, traces []*ParityTrace) []*ParityTrace {
+	trace := &ParityTrace{
+		Error:        frame.Error,
+		Subtraces:    len(frame.Calls),
+		TraceAddress: address,
+	}
+	var (
+		gas     hexutil.Uint64
+		gasUsed hexutil.Uint64
+	)
+	if frame.Gas != nil {
+		gas = *frame.Gas
+	}
+	if frame.GasUsed != nil {
+		gasUsed = *frame.GasUsed
+	}
+	from, input := frame.From, frame.Input
+
+	switch frame.Type {
+	case "CREATE":
+		trace.Type = "create"
+		trace.Action = ParityAction{From: &from, Gas: &gas, Init: &input, Value: frame.Value}
+		if frame.Error == "" {
+			trace.Result = &ParityResult{GasUsed: gasUsed, Address: frame.To, Code: frame.Output}
+		}
+	case "SELFDESTRUCT":
+		trace.Type = "suicide"
+		trace.Action = ParityAction{Address: &from, RefundAddress: frame.To, Balance: frame.Value}
+
+	default:
+		trace.Type = "call"
+		trace.Action = ParityAction{CallType: strings.ToLower(frame.Type), From: &from, To: frame.To, Gas: &gas, Input: &input, Value: frame.Value}
+		if trace.Action.Value == nil {
+			trace.Action.Value = new(hexutil.Big)
+		}
+		if frame.Error == "" {
+			output := frame.Output
+			if output == nil {
+				output = new(hexutil.Bytes)
+			}
+			trace.Result = &ParityResult{GasUsed: gasUsed, Output: output}
+		}
+	}
+	traces = append(traces, trace)
+	for i, call := range frame.Calls {
+		subaddress := make([]int, len(address)+1)
+		copy(subaddress, address)
+		subaddress[len(address)] = i
+
+		traces = flattenCallFrame(call, subaddress, traces)
+	}
+	return traces
+}
+
+// StateDiff compares the state before and after the execution of the traced
+// transaction, returning the changes of every account touched by the execution
+// and the additional accounts specified (e.g. the miner).
+//
+// The post state needs to be finalised, so that deleted accounts are reported.
+func (t *ParityTracer) StateDiff(pre, post *state.StateDB, touched ...common.Address) map[common.Address]*AccountDiff {
+	// Gather all the accounts that may have been modified
+	accounts := make(map[common.Address]struct{})
+	for _, addr := range touched {
+		accounts[addr] = struct{}{}
+	}
+	for addr := range t.storage {
+		accounts[addr] = struct{}{}
+	}
+	var collect func(frame *CallFrame)
+	collect = func(frame *CallFrame) {
+		accounts[frame.From] = struct{}{}
+		if frame.To != nil {
+			accounts[*frame.To] = struct{}{}
+		}
+		for _, call := range frame.Calls {
+			collect(call)
+		}
+	}
+	if root := t.Result(); root != nil {
+		collect(root)
+	}
+	// Compare the accounts one by one, skipping unchanged ones
+	diffs := make(map[common.Address]*AccountDiff)
+	for addr := range accounts {
+		existed, exists := pre.Exist(addr), post.Exist(addr)
+		if !existed && !exists {
+			continue
+		}
+		var (
+			preBalance, postBalance = (*hexutil.Big)(pre.GetBalance(addr)), (*hexutil.Big)(post.GetBalance(addr))
+			preNonce, postNonce     = hexutil.Uint64(pre.GetNonce(addr)), hexutil.Uint64(post.GetNonce(addr))
+			preCode, postCode       = hexutil.Bytes(pre.GetCode(addr)), hexutil.Bytes(post.GetCode(addr))
+		)
+		diff := &AccountDiff{
+			Balance: diffValue(existed, exists, preBalance, postBalance, preBalance.ToInt().Cmp(postBalance.ToInt()) == 0),
+			Nonce:   diffValue(existed, exists, preNonce, postNonce, preNonce == postNonce),
+			Code:    diffValue(existed, exists, preCode, postCode, bytes.Equal(preCode, postCode)),
+			Storage: make(map[common.Hash]interface{}),
+		}
+		changed := existed != exists || diff.Balance != "=" || diff.Nonce != "=" || diff.Code != "="
+
+		for key := range t.storage[addr] {
+			preValue, postValue := pre.GetState(addr, key), post.GetState(addr, key)
+			if !exists {
+				postValue = common.Hash{}
+			}
+			if preValue == postValue {
+				continue
+			}
+			diff.Storage[key] = diffValue(existed, exists, preValue, postValue, false)
+			changed = true
+		}
+		if changed {
+			diffs[addr] = diff
+		}
+	}
+	return diffs
+}
+
+// diffValue returns the Parity stateDiff representation of a single value.
+func diffValue(existed, exists bool, from, to interface{}, equal bool) interface{} {
+	switch {
+	case !existed && exists:
+		return map[string]interface{}{"+": to}
+	case existed && !exists:
+		return map[string]interface{}{"-": from}
+	case equal:
+		return "="
+	default:
+		return map[string]interface{}{"*": map[string]interface{}{"from": from, "to": to}}
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/core"
+	"github.com/TeamEGEM/go-egem/core/state"
+	"github.com/TeamEGEM/go-egem/core/vm"
+	"github.com/TeamEGEM/go-egem/ethdb"
+	"github.com/TeamEGEM/go-egem/params"
+)
+
+// Tests that the Parity tracer flattens the call tree of an execution and reports
+// the state changes it caused.
+func TestParityTracer(t *testing.T) {
+	var (
+		sender = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		outer  = common.HexToAddress("0x2000000000000000000000000000000000000002")
+		inner  = common.HexToAddress("0x3000000000000000000000000000000000000003")
+	)
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+	statedb.SetBalance(sender, big.NewInt(1000))
+	statedb.SetBalance(outer, big.NewInt(10))
+
+	// The outer contract sends 1 wei to the inner one, which stores 0x2a into slot 1
+	statedb.SetCode(outer, append(append([]byte{0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x01, 0x73}, inner[:]...), 0x61, 0xff, 0xff, 0xf1, 0x00))
+	statedb.SetCode(inner, []byte{0x60, 0x2a, 0x60, 0x01, 0x55, 0x00})
+	statedb.Finalise(true)
+
+	pre := statedb.Copy()
+
+	tracer := NewParityTracer()
+	context := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		BlockNumber: big.NewInt(1),
+		Time:        big.NewInt(0),
+		Difficulty:  big.NewInt(0),
+		GasLimit:    1000000,
+		GasPrice:    big.NewInt(1),
+	}
+	evm := vm.NewEVM(context, statedb, params.TestChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	if _, _, err := evm.Call(vm.AccountRef(sender), outer, nil, 100000, big.NewInt(5)); err != nil {
+		t.Fatalf("failed to execute call: %v", err)
+	}
+	statedb.Finalise(true)
+
+	// Check the flattened call traces
+	traces := tracer.Traces()
+	if len(traces) != 2 {
+		t.Fatalf("trace count mismatch: have %d, want %d", len(traces), 2)
+	}
+	if traces[0].Type != "call" || traces[0].Action.CallType != "call" || *traces[0].Action.To != outer || traces[0].Subtraces != 1 || len(traces[0].TraceAddress) != 0 {
+		t.Errorf("outer trace mismatch: %+v", traces[0])
+	}
+	if traces[0].Result == nil || traces[0].Error != "" {
+		t.Errorf("outer trace failed: %+v", traces[0])
+	}
+	if *traces[1].Action.From != outer || *traces[1].Action.To != inner || traces[1].Action.Value.ToInt().Int64() != 1 {
+		t.Errorf("inner trace action mismatch: %+v", traces[1].Action)
+	}
+	if !reflect.DeepEqual(traces[1].TraceAddress, []int{0}) || traces[1].Subtraces != 0 {
+		t.Errorf("inner trace position mismatch: address %v, subtraces %d", traces[1].TraceAddress, traces[1].Subtraces)
+	}
+	// Check the state changes of all involved accounts
+	diff := tracer.StateDiff(pre, statedb, sender)
+	if len(diff) != 3 {
+		t.Fatalf("state diff account count mismatch: have %d, want %d", len(diff), 3)
+	}
+	balance := func(from, to int64) interface{} {
+		return map[string]interface{}{"*": map[string]interface{}{"from": (*hexutil.Big)(big.NewInt(from)), "to": (*hexutil.Big)(big.NewInt(to))}}
+	}
+	if have, want := diff[sender].Balance, balance(1000, 995); !reflect.DeepEqual(have, want) {
+		t.Errorf("sender balance diff mismatch: have %v, want %v", have, want)
+	}
+	if have, want := diff[outer].Balance, balance(10, 14); !reflect.DeepEqual(have, want) {
+		t.Errorf("outer balance diff mismatch: have %v, want %v", have, want)
+	}
+	if diff[inner].Nonce != "=" || diff[inner].Code != "=" {
+		t.Errorf("inner unchanged fields reported: nonce %v, code %v", diff[inner].Nonce, diff[inner].Code)
+	}
+	slot := common.BigToHash(big.NewInt(1))
+	want := map[string]interface{}{"*": map[string]interface{}{"from": common.Hash{}, "to": common.BigToHash(big.NewInt(0x2a))}}
+	if have := diff[inner].Storage[slot]; !reflect.DeepEqual(have, want) {
+		t.Errorf("inner storage diff mismatch: have %v, want %v", have, want)
+	}
+}
@@ -14,16 +14,43 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
-// Package tracers is a collection of JavaScript transaction tracers.
+// Package tracers is a collection of JavaScript and native Go transaction tracers.
 package tracers
 
 import (
+	"encoding/json"
 	"strings"
 	"unicode"
 
+	"github.com/TeamEGEM/go-egem/core/vm"
 	"github.com/TeamEGEM/go-egem/eth/tracers/internal/tracers"
 )
 
+// NativeTracer is a transaction tracer implemented in Go, producing the same
+// output as its built in JavaScript counterpart.
+type NativeTracer interface {
+	vm.Tracer
+
+	// GetResult returns the JSON encoded result of the tracing.
+	GetResult() (json.RawMessage, error)
+}
+
+// natives contains the constructors of the built in native tracers by name.
+var natives = map[string]func() NativeTracer{
+	"callTracer":     func() NativeTracer { return NewCallTracer() },
+	"prestateTracer": func() NativeTracer { return NewPrestateTracer() },
+	"4byteTracer":    func() NativeTracer { return NewFourByteTracer() },
+}
+
+// NewNative creates a native Go tracer by name, returning false if there is no
+// native implementation of the requested tracer.
+func NewNative(name string) (NativeTracer, bool) {
+	if constructor, ok := natives[name]; ok {
+		return constructor(), true
+	}
+	return nil, false
+}
+
 // all contains all the built in JavaScript tracers by name.
 var all = make(map[string]string)
 
@@ -119,6 +119,16 @@ type callTracerTest struct {
 // Iterates over all the input-output datasets in the tracer test harness and
 // runs the JavaScript tracers against them.
 func TestCallTracer(t *testing.T) {
+	testCallTracer(t, func() (NativeTracer, error) { return New("callTracer") })
+}
+
+// Iterates over all the input-output datasets in the tracer test harness and
+// runs the native Go call tracer against them.
+func TestNativeCallTracer(t *testing.T) {
+	testCallTracer(t, func() (NativeTracer, error) { return NewCallTracer(), nil })
+}
+
+func testCallTracer(t *testing.T, newTracer func() (NativeTracer, error)) {
 	files, err := ioutil.ReadDir("testdata")
 	if err != nil {
 		t.Fatalf("failed to retrieve tracer test suite: %v", err)
@@ -131,57 +141,16 @@ func TestCallTracer(t *testing.T) {
 		t.Run(camel(strings.TrimSuffix(strings.TrimPrefix(file.Name(), "call_tracer_"), ".json")), func(t *testing.T) {
 			t.Parallel()
 
-			// Call tracer test found, read if from disk
-			blob, err := ioutil.ReadFile(filepath.Join("testdata", file.Name()))
-			if err != nil {
-				t.Fatalf("failed to read testcase: %v", err)
-			}
-			test := new(callTracerTest)
-			if err := json.Unmarshal(blob, test); err != nil {
-				t.Fatalf("failed to parse testcase: %v", err)
-			}
-			// Configure a blockchain with the given prestate
-			tx := new(types.Transaction)
-			if err := rlp.DecodeBytes(common.FromHex(test.Input), tx); err != nil {
-				t.Fatalf("failed to parse testcase input: %v", err)
-			}
-			signer := types.MakeSigner(test.Genesis.Config, new(big.Int).SetUint64(uint64(test.Context.Number)))
-			origin, _ := signer.Sender(tx)
-
-			context := vm.Context{
-				CanTransfer: core.CanTransfer,
-				Transfer:    core.Transfer,
-				Origin:      origin,
-				Coinbase:    test.Context.Miner,
-				BlockNumber: new(big.Int).SetUint64(uint64(test.Context.Number)),
-				Time:        new(big.Int).SetUint64(uint64(test.Context.Time)),
-				Difficulty:  (*big.Int)(test.Context.Difficulty),
-				GasLimit:    uint64(test.Context.GasLimit),
-				GasPrice:    tx.GasPrice(),
-			}
-			db, _ := ethdb.NewMemDatabase()
-			statedb := tests.MakePreState(db, test.Genesis.Alloc)
+			test := loadCallTracerTest(t, file.Name())
 
-			// Create the tracer, the EVM environment and run it
-			tracer, err := New("callTracer")
+			// Create the tracer and run the test transaction through it
+			tracer, err := newTracer()
 			if err != nil {
 				t.Fatalf("failed to create call tracer: %v", err)
 			}
-			evm := vm.NewEVM(context, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+			res := runCallTracerTest(t, test, tracer)
 
-			msg, err := tx.AsMessage(signer)
-			if err != nil {
-				t.Fatalf("failed to prepare transaction for tracing: %v", err)
-			}
-			st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
-			if _, _, _, err = st.TransitionDb(); err != nil {
-				t.Fatalf("failed to execute transaction: %v", err)
-			}
-			// Retrieve the trace result and compare against the etalon
-			res, err := tracer.GetResult()
-			if err != nil {
-				t.Fatalf("failed to retrieve trace result: %v", err)
-			}
+			// Compare the trace result against the etalon
 			ret := new(callTrace)
 			if err := json.Unmarshal(res, ret); err != nil {
 				t.Fatalf("failed to unmarshal trace result: %v", err)
@@ -192,3 +161,106 @@ func TestCallTracer(t *testing.T) {
 		})
 	}
 }
+
+// Iterates over all the input-output datasets in the tracer test harness and
+// checks that the native tracers produce the same output as the JavaScript ones.
+func TestNativeTracers(t *testing.T) {
+	files, err := ioutil.ReadDir("testdata")
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for name := range natives {
+		for _, file := range files {
+			if !strings.HasPrefix(file.Name(), "call_tracer_") {
+				continue
+			}
+			name, file := name, file // capture range variables
+			t.Run(name+"/"+camel(strings.TrimSuffix(strings.TrimPrefix(file.Name(), "call_tracer_"), ".json")), func(t *testing.T) {
+				t.Parallel()
+
+				test := loadCallTracerTest(t, file.Name())
+
+				// Run the test transaction through both the JavaScript and the native tracer
+				jsTracer, err := New(name)
+				if err != nil {
+					t.Fatalf("failed to create JavaScript tracer: %v", err)
+				}
+				nativeTracer, ok := NewNative(name)
+				if !ok {
+					t.Fatalf("native tracer missing")
+				}
+				var want, have interface{}
+				if err := json.Unmarshal(runCallTracerTest(t, test, jsTracer), &want); err != nil {
+					t.Fatalf("failed to unmarshal JavaScript trace result: %v", err)
+				}
+				if err := json.Unmarshal(runCallTracerTest(t, test, nativeTracer), &have); err != nil {
+					t.Fatalf("failed to unmarshal native trace result: %v", err)
+				}
+				// The execution time can't match, drop it from the call traces
+				if name == "callTracer" {
+					delete(want.(map[string]interface{}), "time")
+					delete(have.(map[string]interface{}), "time")
+				}
+				if !reflect.DeepEqual(have, want) {
+					t.Fatalf("trace mismatch: have %+v, want %+v", have, want)
+				}
+			})
+		}
+	}
+}
+
+// loadCallTracerTest reads and parses a tracer test case from the test harness.
+func loadCallTracerTest(t *testing.T, name string) *callTracerTest {
+	blob, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read testcase: %v", err)
+	}
+	test := new(callTracerTest)
+	if err := json.Unmarshal(blob, test); err != nil {
+		t.Fatalf("failed to parse testcase: %v", err)
+	}
+	return test
+}
+
+// runCallTracerTest executes the transaction of a tracer test case on top of its
+// prestate with the given tracer enabled, returning the result of the tracer.
+func runCallTracerTest(t *testing.T, test *callTracerTest, tracer NativeTracer) json.RawMessage {
+	// Configure a blockchain with the given prestate
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(test.Input), tx); err != nil {
+		t.Fatalf("failed to parse testcase input: %v", err)
+	}
+	signer := types.MakeSigner(test.Genesis.Config, new(big.Int).SetUint64(uint64(test.Context.Number)))
+	origin, _ := signer.Sender(tx)
+
+	context := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Origin:      origin,
+		Coinbase:    test.Context.Miner,
+		BlockNumber: new(big.Int).SetUint64(uint64(test.Context.Number)),
+		Time:        new(big.Int).SetUint64(uint64(test.Context.Time)),
+		Difficulty:  (*big.Int)(test.Context.Difficulty),
+		GasLimit:    uint64(test.Context.GasLimit),
+		GasPrice:    tx.GasPrice(),
+	}
+	db, _ := ethdb.NewMemDatabase()
+	statedb := tests.MakePreState(db, test.Genesis.Alloc)
+
+	// Create the EVM environment and run the transaction
+	evm := vm.NewEVM(context, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+
+	msg, err := tx.AsMessage(signer)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if _, _, _, err = st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	return res
+}
@@ -0,0 +1,133 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/core/state"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/crypto"
+	"github.com/TeamEGEM/go-egem/ethdb"
+	"github.com/TeamEGEM/go-egem/rlp"
+	"github.com/TeamEGEM/go-egem/trie"
+)
+
+// AccountProof is the Merkle proof of an account and some of its storage slots,
+// as returned by eth_getProof (EIP-1186).
+type AccountProof struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageProof  `json:"storageProof"`
+}
+
+// StorageProof is the Merkle proof of a single storage slot of an account.
+type StorageProof struct {
+	Key   common.Hash     `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// ProofAt returns the Merkle proof of the given account and storage keys.
+// The block number can be nil, in which case the proof is taken from the latest known block.
+func (ec *Client) ProofAt(ctx context.Context, account common.Address, keys []common.Hash, blockNumber *big.Int) (*AccountProof, error) {
+	if keys == nil {
+		keys = []common.Hash{}
+	}
+	var result AccountProof
+	err := ec.c.CallContext(ctx, &result, "eth_getProof", account, keys, toBlockNumArg(blockNumber))
+	return &result, err
+}
+
+// Verify checks that the account proof and all contained storage proofs are
+// consistent with the given state root, i.e. that the reported balance, nonce,
+// code hash, storage root and slot values are the ones stored in the tries.
+func (p *AccountProof) Verify(root common.Hash) error {
+	// Verify the account itself against the state root
+	blob, err := verifyProof(root, crypto.Keccak256(p.Address[:]), p.AccountProof)
+	if err != nil {
+		return fmt.Errorf("invalid account proof: %v", err)
+	}
+	account := state.Account{Balance: new(big.Int), Root: types.EmptyRootHash, CodeHash: crypto.Keccak256(nil)}
+	if blob != nil {
+		if err := rlp.DecodeBytes(blob, &account); err != nil {
+			return fmt.Errorf("invalid account encoding: %v", err)
+		}
+	}
+	if p.Balance == nil || account.Balance.Cmp(p.Balance.ToInt()) != 0 {
+		return fmt.Errorf("balance mismatch: have %v, proven %v", p.Balance, account.Balance)
+	}
+	if account.Nonce != uint64(p.Nonce) {
+		return fmt.Errorf("nonce mismatch: have %d, proven %d", p.Nonce, account.Nonce)
+	}
+	if !bytes.Equal(account.CodeHash, p.CodeHash[:]) {
+		return fmt.Errorf("code hash mismatch: have %x, proven %x", p.CodeHash, account.CodeHash)
+	}
+	if account.Root != p.StorageHash {
+		return fmt.Errorf("storage hash mismatch: have %x, proven %x", p.StorageHash, account.Root)
+	}
+	// Verify all the storage slots against the proven storage root
+	for _, slot := range p.StorageProof {
+		if err := slot.Verify(account.Root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verify checks that the storage proof is consistent with the given storage root
+// and that the reported value is the one stored in the trie.
+func (p *StorageProof) Verify(root common.Hash) error {
+	proven := new(big.Int)
+	if root != types.EmptyRootHash {
+		blob, err := verifyProof(root, crypto.Keccak256(p.Key[:]), p.Proof)
+		if err != nil {
+			return fmt.Errorf("invalid storage proof for %x: %v", p.Key, err)
+		}
+		if blob != nil {
+			var value []byte
+			if err := rlp.DecodeBytes(blob, &value); err != nil {
+				return fmt.Errorf("invalid storage encoding for %x: %v", p.Key, err)
+			}
+			proven.SetBytes(value)
+		}
+	}
+	if p.Value == nil || proven.Cmp(p.Value.ToInt()) != 0 {
+		return fmt.Errorf("storage value mismatch for %x: have %v, proven %v", p.Key, p.Value, proven)
+	}
+	return nil
+}
+
+// verifyProof checks a list of encoded trie nodes against the given root hash,
+// returning the value stored at key, or nil if the proof shows its absence.
+func verifyProof(root common.Hash, key []byte, proof []hexutil.Bytes) ([]byte, error) {
+	db, _ := ethdb.NewMemDatabase()
+	for _, node := range proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	value, err, _ := trie.VerifyProof(root, key, db)
+	return value, err
+}
@@ -27,8 +27,8 @@ import (
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
 	"github.com/syndtr/goleveldb/leveldb/filter"
-	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 var OpenFileLimit = 64
@@ -46,6 +46,8 @@ type LDBDatabase struct {
 	quitLock sync.Mutex      // Mutex protecting the quit channel access
 	quitChan chan chan error // Quit channel to stop the metrics collection before closing the database
 
+	freezer *Freezer // Ancient store of old canonical chain data, if configured
+
 	log log.Logger // Contextual logger tracking the database path
 }
 
@@ -83,6 +85,20 @@ func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
 	}, nil
 }
 
+// NewLDBDatabaseWithFreezer returns a LevelDB wrapped object, backed by an ancient
+// store in the given directory for the old canonical chain data.
+func NewLDBDatabaseWithFreezer(file string, cache int, handles int, freezer string) (*LDBDatabase, error) {
+	db, err := NewLDBDatabase(file, cache, handles)
+	if err != nil {
+		return nil, err
+	}
+	if db.freezer, err = NewFreezer(freezer); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
 // Path returns the path to the database directory.
 func (db *LDBDatabase) Path() string {
 	return db.fn
@@ -117,8 +133,53 @@ func (db *LDBDatabase) Delete(key []byte) error {
 	return db.db.Delete(key, nil)
 }
 
-func (db *LDBDatabase) NewIterator() iterator.Iterator {
-	return db.db.NewIterator(nil, nil)
+// NewIteratorWithPrefix creates a binary-alphabetical iterator over the subset
+// of database content with a particular key prefix.
+func (db *LDBDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return db.db.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+// NewIteratorWithRange creates a binary-alphabetical iterator over the subset
+// of database content in the key range [start, limit).
+func (db *LDBDatabase) NewIteratorWithRange(start []byte, limit []byte) Iterator {
+	return db.db.NewIterator(&util.Range{Start: start, Limit: limit}, nil)
+}
+
+// DeleteRange removes all the keys in the range [start, limit).
+func (db *LDBDatabase) DeleteRange(start []byte, limit []byte) error {
+	it := db.db.NewIterator(&util.Range{Start: start, Limit: limit}, nil)
+	defer it.Release()
+
+	batch := new(leveldb.Batch)
+	for it.Next() {
+		batch.Delete(it.Key())
+		if len(batch.Dump()) >= IdealBatchSize {
+			if err := db.db.Write(batch, nil); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return db.db.Write(batch, nil)
+}
+
+// Stat returns a particular internal stat of the database, the general LevelDB
+// statistics by default. The "leveldb." prefix of the property may be omitted.
+func (db *LDBDatabase) Stat(property string) (string, error) {
+	if property == "" {
+		property = "leveldb.stats"
+	} else if !strings.HasPrefix(property, "leveldb.") {
+		property = "leveldb." + property
+	}
+	return db.db.GetProperty(property)
+}
+
+// Compact flattens the underlying data store for the given key range [start, limit).
+func (db *LDBDatabase) Compact(start []byte, limit []byte) error {
+	return db.db.CompactRange(util.Range{Start: start, Limit: limit})
 }
 
 func (db *LDBDatabase) Close() {
@@ -133,6 +194,11 @@ func (db *LDBDatabase) Close() {
 			db.log.Error("Metrics collection failed", "err", err)
 		}
 	}
+	if db.freezer != nil {
+		if err := db.freezer.Close(); err != nil {
+			db.log.Error("Failed to close ancient database", "err", err)
+		}
+	}
 	err := db.db.Close()
 	if err == nil {
 		db.log.Info("Database closed")
@@ -141,6 +207,64 @@ func (db *LDBDatabase) Close() {
 	}
 }
 
+// HasAncient returns an indicator whether the specified data exists in the
+// ancient store.
+func (db *LDBDatabase) HasAncient(kind string, number uint64) (bool, error) {
+	if db.freezer == nil {
+		return false, nil
+	}
+	return db.freezer.HasAncient(kind, number)
+}
+
+// Ancient retrieves an ancient binary blob from the append-only immutable files.
+func (db *LDBDatabase) Ancient(kind string, number uint64) ([]byte, error) {
+	if db.freezer == nil {
+		return nil, errNotSupported
+	}
+	return db.freezer.Ancient(kind, number)
+}
+
+// Ancients returns the number of blocks frozen into the ancient store.
+func (db *LDBDatabase) Ancients() (uint64, error) {
+	if db.freezer == nil {
+		return 0, errNotSupported
+	}
+	return db.freezer.Ancients()
+}
+
+// AncientSize returns the ancient size of the specified category.
+func (db *LDBDatabase) AncientSize(kind string) (uint64, error) {
+	if db.freezer == nil {
+		return 0, errNotSupported
+	}
+	return db.freezer.AncientSize(kind)
+}
+
+// AppendAncient injects all binary blobs belong to block at the end of the
+// append-only immutable table files.
+func (db *LDBDatabase) AppendAncient(number uint64, hash, header, body, receipts, td []byte) error {
+	if db.freezer == nil {
+		return errNotSupported
+	}
+	return db.freezer.AppendAncient(number, hash, header, body, receipts, td)
+}
+
+// TruncateAncients discards all but the first n ancient blocks.
+func (db *LDBDatabase) TruncateAncients(n uint64) error {
+	if db.freezer == nil {
+		return errNotSupported
+	}
+	return db.freezer.TruncateAncients(n)
+}
+
+// SyncAncients flushes all in-memory ancient store data to disk.
+func (db *LDBDatabase) SyncAncients() error {
+	if db.freezer == nil {
+		return errNotSupported
+	}
+	return db.freezer.SyncAncients()
+}
+
 func (db *LDBDatabase) LDB() *leveldb.DB {
 	return db.db
 }
@@ -352,6 +476,88 @@ func (dt *table) Close() {
 	// Do nothing; don't close the underlying DB.
 }
 
+// NewIteratorWithPrefix creates a binary-alphabetical iterator over the subset
+// of the table content with a particular key prefix.
+func (dt *table) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return &tableIterator{
+		it:     dt.db.NewIteratorWithPrefix(append([]byte(dt.prefix), prefix...)),
+		prefix: dt.prefix,
+	}
+}
+
+// NewIteratorWithRange creates a binary-alphabetical iterator over the subset
+// of the table content in the key range [start, limit).
+func (dt *table) NewIteratorWithRange(start []byte, limit []byte) Iterator {
+	start, limit = dt.keyRange(start, limit)
+	return &tableIterator{
+		it:     dt.db.NewIteratorWithRange(start, limit),
+		prefix: dt.prefix,
+	}
+}
+
+// DeleteRange removes all the keys in the range [start, limit) of the table.
+func (dt *table) DeleteRange(start []byte, limit []byte) error {
+	start, limit = dt.keyRange(start, limit)
+	return dt.db.DeleteRange(start, limit)
+}
+
+// Stat returns a particular internal stat of the underlying database.
+func (dt *table) Stat(property string) (string, error) {
+	return dt.db.Stat(property)
+}
+
+// Compact flattens the underlying data store for the key range [start, limit)
+// of the table.
+func (dt *table) Compact(start []byte, limit []byte) error {
+	start, limit = dt.keyRange(start, limit)
+	return dt.db.Compact(start, limit)
+}
+
+// keyRange converts a key range of the table into the range of the underlying
+// database, bounding open ends by the table prefix.
+func (dt *table) keyRange(start []byte, limit []byte) ([]byte, []byte) {
+	bounds := util.BytesPrefix([]byte(dt.prefix))
+
+	start = append([]byte(dt.prefix), start...)
+	if limit == nil {
+		limit = bounds.Limit
+	} else {
+		limit = append([]byte(dt.prefix), limit...)
+	}
+	return start, limit
+}
+
+// tableIterator is a wrapper around a database iterator that strips the table
+// prefix from the keys.
+type tableIterator struct {
+	it     Iterator
+	prefix string
+}
+
+func (it *tableIterator) Next() bool {
+	return it.it.Next()
+}
+
+func (it *tableIterator) Error() error {
+	return it.it.Error()
+}
+
+func (it *tableIterator) Key() []byte {
+	key := it.it.Key()
+	if key == nil {
+		return nil
+	}
+	return key[len(it.prefix):]
+}
+
+func (it *tableIterator) Value() []byte {
+	return it.it.Value()
+}
+
+func (it *tableIterator) Release() {
+	it.it.Release()
+}
+
 type tableBatch struct {
 	batch  Batch
 	prefix string
@@ -22,6 +22,7 @@ import (
 	"io/ioutil"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 
@@ -190,3 +191,87 @@ func testParallelPutGet(db ethdb.Database, t *testing.T) {
 	}
 	pending.Wait()
 }
+
+func TestLDB_Iterator(t *testing.T) {
+	db, remove := newTestLDB()
+	defer remove()
+	testIterator(db, t)
+}
+
+func TestMemoryDB_Iterator(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	testIterator(db, t)
+}
+
+func TestTable_Iterator(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	db.Put([]byte("a"), []byte("outside"))
+	db.Put([]byte("z"), []byte("outside"))
+	testIterator(ethdb.NewTable(db, "t-"), t)
+
+	for _, key := range []string{"a", "z"} {
+		if ok, _ := db.Has([]byte(key)); !ok {
+			t.Errorf("key %q outside the table deleted", key)
+		}
+	}
+}
+
+// iterateKeys collects the keys of an iterator, checking their values too.
+func iterateKeys(it ethdb.Iterator, t *testing.T) []string {
+	defer it.Release()
+
+	var keys []string
+	for it.Next() {
+		if !bytes.Equal(it.Value(), append([]byte("v"), it.Key()...)) {
+			t.Fatalf("value mismatch for %q: %q", it.Key(), it.Value())
+		}
+		keys = append(keys, string(it.Key()))
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	return keys
+}
+
+func testIterator(db ethdb.Database, t *testing.T) {
+	t.Parallel()
+
+	for _, k := range []string{"b2", "a1", "c", "b1", "a2", "b"} {
+		if err := db.Put([]byte(k), []byte("v"+k)); err != nil {
+			t.Fatalf("put failed: %v", err)
+		}
+	}
+	tests := []struct {
+		it   ethdb.Iterator
+		want string
+	}{
+		{db.NewIteratorWithRange(nil, nil), "a1,a2,b,b1,b2,c"},
+		{db.NewIteratorWithRange([]byte("a2"), []byte("b2")), "a2,b,b1"},
+		{db.NewIteratorWithRange([]byte("b"), nil), "b,b1,b2,c"},
+		{db.NewIteratorWithRange(nil, []byte("b")), "a1,a2"},
+		{db.NewIteratorWithPrefix([]byte("b")), "b,b1,b2"},
+		{db.NewIteratorWithPrefix([]byte("d")), ""},
+		{db.NewIteratorWithPrefix(nil), "a1,a2,b,b1,b2,c"},
+	}
+	for i, tt := range tests {
+		if have := strings.Join(iterateKeys(tt.it, t), ","); have != tt.want {
+			t.Errorf("test %d: keys mismatch: have %q, want %q", i, have, tt.want)
+		}
+	}
+	// Delete a range and ensure only the keys within it are gone
+	if err := db.DeleteRange([]byte("a2"), []byte("b2")); err != nil {
+		t.Fatalf("range delete failed: %v", err)
+	}
+	if have := strings.Join(iterateKeys(db.NewIteratorWithRange(nil, nil), t), ","); have != "a1,b2,c" {
+		t.Errorf("keys mismatch after range delete: have %q, want %q", have, "a1,b2,c")
+	}
+	if err := db.DeleteRange(nil, nil); err != nil {
+		t.Fatalf("full range delete failed: %v", err)
+	}
+	if keys := iterateKeys(db.NewIteratorWithRange(nil, nil), t); len(keys) != 0 {
+		t.Errorf("keys left after full range delete: %v", keys)
+	}
+	if err := db.Compact(nil, nil); err != nil {
+		t.Errorf("compaction failed: %v", err)
+	}
+}
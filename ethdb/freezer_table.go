@@ -0,0 +1,458 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/TeamEGEM/go-egem/log"
+	"github.com/golang/snappy"
+)
+
+var (
+	// errClosed is returned if an operation attempts to read from or write to the
+	// freezer table after it has already been closed.
+	errClosed = errors.New("closed")
+
+	// errOutOfBounds is returned if the item requested is not contained within the
+	// freezer table.
+	errOutOfBounds = errors.New("out of bounds")
+
+	// errOutOrderInsert is returned if the user attempts to inject out-of-order
+	// binary blobs into the freezer.
+	errOutOrderInsert = errors.New("the append operation is out-order")
+)
+
+const (
+	// indexEntrySize is the size of a single index entry: a 2 byte data file
+	// number followed by a 4 byte end offset within that file.
+	indexEntrySize = 6
+
+	// freezerTableSize is the maximum size of a single data file of a table,
+	// before a new one is started.
+	freezerTableSize = 2 * 1000 * 1000 * 1000
+)
+
+// indexEntry is the position of the end of an item within the data files.
+type indexEntry struct {
+	filenum uint32 // Data file the item ends in (stored as uint16)
+	offset  uint32 // Offset within the data file where the item ends
+}
+
+// unmarshal decodes an index entry from its binary form.
+func (i *indexEntry) unmarshal(b []byte) {
+	i.filenum = uint32(binary.BigEndian.Uint16(b[:2]))
+	i.offset = binary.BigEndian.Uint32(b[2:6])
+}
+
+// marshal encodes an index entry into its binary form.
+func (i *indexEntry) marshal() []byte {
+	b := make([]byte, indexEntrySize)
+	binary.BigEndian.PutUint16(b[:2], uint16(i.filenum))
+	binary.BigEndian.PutUint32(b[2:6], i.offset)
+	return b
+}
+
+// freezerTable is an append-only table of binary blobs, stored in a sequence of
+// flat data files and indexed by their position. The first index entry is a
+// sentinel, every following one marks the end of an item, so item i spans from
+// the end of item i-1 to its own end (or the start of its data file).
+type freezerTable struct {
+	items uint64 // Number of items stored in the table (atomic, keep first for alignment)
+
+	name          string
+	path          string
+	noCompression bool   // Whether to store the blobs raw, or snappy compressed
+	maxFileSize   uint32 // Maximum size of a data file before starting a new one
+
+	index   *os.File            // File descriptor of the item index
+	head    *os.File            // File descriptor of the data file being appended to
+	files   map[uint32]*os.File // Open data files, keyed by their number
+	headID  uint32              // Number of the data file being appended to
+	headLen uint32              // Number of bytes written into the head data file
+
+	lock sync.RWMutex // Mutex protecting the data files from concurrent access
+}
+
+// newTable opens a freezer table with the default data file size, creating it
+// if it doesn't exist yet.
+func newTable(path string, name string, noCompression bool) (*freezerTable, error) {
+	return newCustomTable(path, name, freezerTableSize, noCompression)
+}
+
+// newCustomTable opens a freezer table with a custom data file size limit,
+// repairing any inconsistency left behind by a crash.
+func newCustomTable(path string, name string, maxFileSize uint32, noCompression bool) (*freezerTable, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+	ext := "ridx"
+	if !noCompression {
+		ext = "cidx"
+	}
+	index, err := os.OpenFile(filepath.Join(path, fmt.Sprintf("%s.%s", name, ext)), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	tab := &freezerTable{
+		name:          name,
+		path:          path,
+		noCompression: noCompression,
+		maxFileSize:   maxFileSize,
+		index:         index,
+		files:         make(map[uint32]*os.File),
+	}
+	if err := tab.repair(); err != nil {
+		tab.Close()
+		return nil, err
+	}
+	return tab, nil
+}
+
+// repair cross checks the index with the head data file, truncating whichever
+// one is ahead of the other, and opens all the data files.
+func (t *freezerTable) repair() error {
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	// Ensure the index contains the sentinel and only whole entries
+	if stat.Size() == 0 {
+		if _, err := t.index.Write((&indexEntry{}).marshal()); err != nil {
+			return err
+		}
+		stat, err = t.index.Stat()
+		if err != nil {
+			return err
+		}
+	}
+	indexSize := stat.Size()
+	if overflow := indexSize % indexEntrySize; overflow != 0 {
+		indexSize -= overflow
+		if err := t.index.Truncate(indexSize); err != nil {
+			return err
+		}
+	}
+	// Drop index entries pointing beyond the data, and data beyond the index
+	buffer := make([]byte, indexEntrySize)
+
+	var last indexEntry
+	if _, err := t.index.ReadAt(buffer, indexSize-indexEntrySize); err != nil {
+		return err
+	}
+	last.unmarshal(buffer)
+
+	if t.head, err = t.openFile(last.filenum, true); err != nil {
+		return err
+	}
+	if stat, err = t.head.Stat(); err != nil {
+		return err
+	}
+	dataSize := stat.Size()
+	for int64(last.offset) != dataSize {
+		if int64(last.offset) < dataSize {
+			log.Warn("Truncating dangling freezer data", "table", t.name, "indexed", last.offset, "stored", dataSize)
+			if err := t.head.Truncate(int64(last.offset)); err != nil {
+				return err
+			}
+			dataSize = int64(last.offset)
+			continue
+		}
+		log.Warn("Truncating dangling freezer index", "table", t.name, "indexed", last.offset, "stored", dataSize)
+		indexSize -= indexEntrySize
+		if err := t.index.Truncate(indexSize); err != nil {
+			return err
+		}
+		if _, err := t.index.ReadAt(buffer, indexSize-indexEntrySize); err != nil {
+			return err
+		}
+		var prev indexEntry
+		prev.unmarshal(buffer)
+
+		// If the previous item ends in an older data file, switch over to it
+		if prev.filenum != last.filenum {
+			t.releaseFile(last.filenum)
+			if t.head, err = t.openFile(prev.filenum, true); err != nil {
+				return err
+			}
+			if stat, err = t.head.Stat(); err != nil {
+				return err
+			}
+			dataSize = stat.Size()
+		}
+		last = prev
+	}
+	if err := t.index.Sync(); err != nil {
+		return err
+	}
+	if err := t.head.Sync(); err != nil {
+		return err
+	}
+	t.headID, t.headLen = last.filenum, last.offset
+	atomic.StoreUint64(&t.items, uint64(indexSize/indexEntrySize-1))
+
+	// Delete any data files past the head, and open all older ones for reading
+	for num := t.headID + 1; ; num++ {
+		if _, err := os.Stat(t.fileName(num)); os.IsNotExist(err) {
+			break
+		}
+		if err := os.Remove(t.fileName(num)); err != nil {
+			return err
+		}
+	}
+	for num := uint32(0); num < t.headID; num++ {
+		if _, err := t.openFile(num, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileName returns the path of the data file with the given number.
+func (t *freezerTable) fileName(num uint32) string {
+	ext := "rdat"
+	if !t.noCompression {
+		ext = "cdat"
+	}
+	return filepath.Join(t.path, fmt.Sprintf("%s.%04d.%s", t.name, num, ext))
+}
+
+// openFile opens a data file, either for appending or read only, caching the
+// descriptor.
+func (t *freezerTable) openFile(num uint32, append bool) (*os.File, error) {
+	if f, ok := t.files[num]; ok {
+		return f, nil
+	}
+	flags := os.O_RDONLY
+	if append {
+		flags = os.O_RDWR | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(t.fileName(num), flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+	t.files[num] = f
+	return f, nil
+}
+
+// releaseFile closes a data file and forgets its descriptor.
+func (t *freezerTable) releaseFile(num uint32) {
+	if f, ok := t.files[num]; ok {
+		f.Close()
+		delete(t.files, num)
+	}
+}
+
+// Append injects a binary blob at the end of the freezer table. The item number
+// is a precautionary parameter to ensure data correctness, but the table will
+// reject already existing data.
+func (t *freezerTable) Append(item uint64, blob []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil || t.head == nil {
+		return errClosed
+	}
+	if atomic.LoadUint64(&t.items) != item {
+		return errOutOrderInsert
+	}
+	if !t.noCompression {
+		blob = snappy.Encode(nil, blob)
+	}
+	// Start a new data file if the current one would grow too large
+	size := uint32(len(blob))
+	if t.headLen > 0 && uint64(t.headLen)+uint64(size) > uint64(t.maxFileSize) {
+		if err := t.head.Sync(); err != nil {
+			return err
+		}
+		// Reopen the finished data file read only, and start the next one
+		t.releaseFile(t.headID)
+		if _, err := t.openFile(t.headID, false); err != nil {
+			return err
+		}
+		head, err := t.openFile(t.headID+1, true)
+		if err != nil {
+			return err
+		}
+		t.head, t.headID, t.headLen = head, t.headID+1, 0
+	}
+	if _, err := t.head.Write(blob); err != nil {
+		return err
+	}
+	t.headLen += size
+
+	if _, err := t.index.Write((&indexEntry{filenum: t.headID, offset: t.headLen}).marshal()); err != nil {
+		return err
+	}
+	atomic.AddUint64(&t.items, 1)
+	return nil
+}
+
+// Retrieve looks up the data offset of an item with the given number and
+// retrieves the raw binary blob from the data file.
+func (t *freezerTable) Retrieve(item uint64) ([]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.index == nil || t.head == nil {
+		return nil, errClosed
+	}
+	if atomic.LoadUint64(&t.items) <= item {
+		return nil, errOutOfBounds
+	}
+	// Read the start and end positions of the item
+	buffer := make([]byte, 2*indexEntrySize)
+	if _, err := t.index.ReadAt(buffer, int64(item*indexEntrySize)); err != nil {
+		return nil, err
+	}
+	var start, end indexEntry
+	start.unmarshal(buffer[:indexEntrySize])
+	end.unmarshal(buffer[indexEntrySize:])
+
+	if start.filenum != end.filenum {
+		start.offset = 0
+	}
+	file, ok := t.files[end.filenum]
+	if !ok {
+		return nil, fmt.Errorf("missing data file %d", end.filenum)
+	}
+	blob := make([]byte, end.offset-start.offset)
+	if _, err := file.ReadAt(blob, int64(start.offset)); err != nil {
+		return nil, err
+	}
+	if t.noCompression {
+		return blob, nil
+	}
+	return snappy.Decode(nil, blob)
+}
+
+// Has returns an indicator whether the specified item is stored in the table.
+func (t *freezerTable) Has(item uint64) bool {
+	return atomic.LoadUint64(&t.items) > item
+}
+
+// Items returns the number of items stored in the table.
+func (t *freezerTable) Items() uint64 {
+	return atomic.LoadUint64(&t.items)
+}
+
+// truncate discards any recent data above the provided threshold number.
+func (t *freezerTable) truncate(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if atomic.LoadUint64(&t.items) <= items {
+		return nil
+	}
+	log.Warn("Truncating freezer table", "table", t.name, "items", atomic.LoadUint64(&t.items), "limit", items)
+
+	if err := t.index.Truncate(int64(items+1) * indexEntrySize); err != nil {
+		return err
+	}
+	buffer := make([]byte, indexEntrySize)
+	if _, err := t.index.ReadAt(buffer, int64(items*indexEntrySize)); err != nil {
+		return err
+	}
+	var last indexEntry
+	last.unmarshal(buffer)
+
+	// Drop all the data files past the new head and truncate the head itself
+	if last.filenum != t.headID {
+		for num := last.filenum + 1; num <= t.headID; num++ {
+			t.releaseFile(num)
+			if err := os.Remove(t.fileName(num)); err != nil {
+				return err
+			}
+		}
+		t.releaseFile(last.filenum)
+		head, err := t.openFile(last.filenum, true)
+		if err != nil {
+			return err
+		}
+		t.head = head
+	}
+	if err := t.head.Truncate(int64(last.offset)); err != nil {
+		return err
+	}
+	t.headID, t.headLen = last.filenum, last.offset
+	atomic.StoreUint64(&t.items, items)
+	return nil
+}
+
+// size returns the total data size of the table, including the index.
+func (t *freezerTable) size() (uint64, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	stat, err := t.index.Stat()
+	if err != nil {
+		return 0, err
+	}
+	total := uint64(stat.Size())
+	for _, file := range t.files {
+		if stat, err = file.Stat(); err != nil {
+			return 0, err
+		}
+		total += uint64(stat.Size())
+	}
+	return total, nil
+}
+
+// Sync pushes any pending data from memory out to disk.
+func (t *freezerTable) Sync() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil || t.head == nil {
+		return errClosed
+	}
+	if err := t.index.Sync(); err != nil {
+		return err
+	}
+	return t.head.Sync()
+}
+
+// Close closes all the open files of the table.
+func (t *freezerTable) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var errs []error
+	if t.index != nil {
+		if err := t.index.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for num, file := range t.files {
+		if err := file.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(t.files, num)
+	}
+	t.index, t.head = nil, nil
+
+	if errs != nil {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testBlob returns a deterministic, somewhat compressible blob for an item.
+func testBlob(item uint64) []byte {
+	return bytes.Repeat([]byte(fmt.Sprintf("item-%d;", item)), 4)
+}
+
+// checkRetrieve verifies that the given range of items can be read back.
+func checkRetrieve(t *testing.T, table *freezerTable, from, to uint64) {
+	for i := from; i < to; i++ {
+		blob, err := table.Retrieve(i)
+		if err != nil {
+			t.Fatalf("item %d: failed to retrieve: %v", i, err)
+		}
+		if !bytes.Equal(blob, testBlob(i)) {
+			t.Fatalf("item %d: blob mismatch: have %x, want %x", i, blob, testBlob(i))
+		}
+	}
+}
+
+// Tests that items written into a table can be read back, across data file
+// boundaries and after reopening.
+func TestFreezerTableAppendRetrieve(t *testing.T) {
+	for _, noCompression := range []bool{false, true} {
+		dir, err := ioutil.TempDir("", "freezer")
+		if err != nil {
+			t.Fatalf("failed to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(dir)
+
+		table, err := newCustomTable(dir, "test", 100, noCompression)
+		if err != nil {
+			t.Fatalf("failed to create table: %v", err)
+		}
+		for i := uint64(0); i < 50; i++ {
+			if err := table.Append(i, testBlob(i)); err != nil {
+				t.Fatalf("item %d: failed to append: %v", i, err)
+			}
+		}
+		if err := table.Append(100, testBlob(100)); err != errOutOrderInsert {
+			t.Fatalf("out of order append: have %v, want %v", err, errOutOrderInsert)
+		}
+		if _, err := table.Retrieve(50); err != errOutOfBounds {
+			t.Fatalf("out of bounds retrieval: have %v, want %v", err, errOutOfBounds)
+		}
+		checkRetrieve(t, table, 0, 50)
+		if len(table.files) < 2 {
+			t.Fatalf("data not split across files: %d files", len(table.files))
+		}
+		table.Close()
+
+		if table, err = newCustomTable(dir, "test", 100, noCompression); err != nil {
+			t.Fatalf("failed to reopen table: %v", err)
+		}
+		if items := table.Items(); items != 50 {
+			t.Fatalf("item count mismatch after reopen: have %d, want %d", items, 50)
+		}
+		checkRetrieve(t, table, 0, 50)
+		table.Close()
+	}
+}
+
+// Tests that a table is repaired on open if the index or the data was only
+// partially written before a crash.
+func TestFreezerTableRepair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	table, err := newCustomTable(dir, "test", 100, true)
+	if err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	for i := uint64(0); i < 20; i++ {
+		if err := table.Append(i, testBlob(i)); err != nil {
+			t.Fatalf("item %d: failed to append: %v", i, err)
+		}
+	}
+	head := table.fileName(table.headID)
+	table.Close()
+
+	// Chop a few bytes off the head data file, dangling the last index entry
+	stat, err := os.Stat(head)
+	if err != nil {
+		t.Fatalf("failed to stat head file: %v", err)
+	}
+	if err := os.Truncate(head, stat.Size()-2); err != nil {
+		t.Fatalf("failed to truncate head file: %v", err)
+	}
+	if table, err = newCustomTable(dir, "test", 100, true); err != nil {
+		t.Fatalf("failed to reopen table: %v", err)
+	}
+	if items := table.Items(); items != 19 {
+		t.Fatalf("item count mismatch after data loss: have %d, want %d", items, 19)
+	}
+	checkRetrieve(t, table, 0, 19)
+	table.Close()
+
+	// Chop half an entry off the index, dangling the last data item
+	index := filepath.Join(dir, "test.ridx")
+	if stat, err = os.Stat(index); err != nil {
+		t.Fatalf("failed to stat index file: %v", err)
+	}
+	if err := os.Truncate(index, stat.Size()-indexEntrySize/2); err != nil {
+		t.Fatalf("failed to truncate index file: %v", err)
+	}
+	if table, err = newCustomTable(dir, "test", 100, true); err != nil {
+		t.Fatalf("failed to reopen table: %v", err)
+	}
+	if items := table.Items(); items != 18 {
+		t.Fatalf("item count mismatch after index loss: have %d, want %d", items, 18)
+	}
+	checkRetrieve(t, table, 0, 18)
+
+	// Make sure the table can be appended to after the repair
+	for i := uint64(18); i < 30; i++ {
+		if err := table.Append(i, testBlob(i)); err != nil {
+			t.Fatalf("item %d: failed to append: %v", i, err)
+		}
+	}
+	checkRetrieve(t, table, 0, 30)
+	table.Close()
+}
+
+// Tests that truncating a table drops the data files past the new head and
+// that the table can be appended to afterwards.
+func TestFreezerTableTruncate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	table, err := newCustomTable(dir, "test", 100, false)
+	if err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	for i := uint64(0); i < 50; i++ {
+		if err := table.Append(i, testBlob(i)); err != nil {
+			t.Fatalf("item %d: failed to append: %v", i, err)
+		}
+	}
+	last := table.headID
+	if err := table.truncate(3); err != nil {
+		t.Fatalf("failed to truncate table: %v", err)
+	}
+	if items := table.Items(); items != 3 {
+		t.Fatalf("item count mismatch after truncation: have %d, want %d", items, 3)
+	}
+	if _, err := os.Stat(table.fileName(last)); !os.IsNotExist(err) {
+		t.Fatalf("truncated data file still present: %v", err)
+	}
+	checkRetrieve(t, table, 0, 3)
+
+	for i := uint64(3); i < 10; i++ {
+		if err := table.Append(i, testBlob(i)); err != nil {
+			t.Fatalf("item %d: failed to append: %v", i, err)
+		}
+	}
+	checkRetrieve(t, table, 0, 10)
+}
+
+// Tests that the freezer keeps all its tables aligned, even if some of them were
+// ahead of the others when the freezer was shut down.
+func TestFreezerAlignment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	freezer, err := NewFreezer(dir)
+	if err != nil {
+		t.Fatalf("failed to create freezer: %v", err)
+	}
+	for i := uint64(0); i < 10; i++ {
+		blob := testBlob(i)
+		if err := freezer.AppendAncient(i, blob, blob, blob, blob, blob); err != nil {
+			t.Fatalf("block %d: failed to append: %v", i, err)
+		}
+	}
+	if err := freezer.AppendAncient(20, nil, nil, nil, nil, nil); err == nil {
+		t.Fatalf("out of order append succeeded")
+	}
+	// Simulate a crash in the middle of appending a block
+	if err := freezer.tables[FreezerHashTable].Append(10, testBlob(10)); err != nil {
+		t.Fatalf("failed to append to hash table: %v", err)
+	}
+	freezer.Close()
+
+	if freezer, err = NewFreezer(dir); err != nil {
+		t.Fatalf("failed to reopen freezer: %v", err)
+	}
+	defer freezer.Close()
+
+	if frozen, _ := freezer.Ancients(); frozen != 10 {
+		t.Fatalf("frozen count mismatch: have %d, want %d", frozen, 10)
+	}
+	for kind, table := range freezer.tables {
+		if items := table.Items(); items != 10 {
+			t.Fatalf("%s: item count mismatch: have %d, want %d", kind, items, 10)
+		}
+	}
+	blob, err := freezer.Ancient(FreezerReceiptTable, 7)
+	if err != nil || !bytes.Equal(blob, testBlob(7)) {
+		t.Fatalf("ancient retrieval mismatch: have %x, %v; want %x", blob, err, testBlob(7))
+	}
+	if err := freezer.TruncateAncients(5); err != nil {
+		t.Fatalf("failed to truncate freezer: %v", err)
+	}
+	if ok, _ := freezer.HasAncient(FreezerHeaderTable, 5); ok {
+		t.Fatalf("truncated block still present")
+	}
+}
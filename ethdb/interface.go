@@ -28,11 +28,109 @@ type Putter interface {
 // Database wraps all database operations. All methods are safe for concurrent use.
 type Database interface {
 	Putter
+	Iteratee
 	Get(key []byte) ([]byte, error)
 	Has(key []byte) (bool, error)
 	Delete(key []byte) error
 	Close()
 	NewBatch() Batch
+
+	// DeleteRange removes all the keys in the range [start, limit). A nil start
+	// denotes the beginning of the key space, a nil limit its end.
+	DeleteRange(start []byte, limit []byte) error
+
+	// Stat returns a particular internal stat of the database, or the general
+	// statistics for an empty property.
+	Stat(property string) (string, error)
+
+	// Compact flattens the underlying data store for the given key range
+	// [start, limit), discarding deleted and overwritten versions and rearranging
+	// the data to reduce the cost of accessing it. A nil start denotes the
+	// beginning of the key space, a nil limit its end.
+	Compact(start []byte, limit []byte) error
+}
+
+// Iterator iterates over a database's key/value pairs in ascending key order.
+//
+// When it encounters an error any seek will return false and will yield no key/
+// value pairs. The error can be queried by calling the Error method. Calling
+// Release is still necessary.
+//
+// An iterator must be released after use, but it is not necessary to read an
+// iterator until exhaustion. An iterator is not safe for concurrent use, but it
+// is safe to use multiple iterators concurrently.
+type Iterator interface {
+	// Next moves the iterator to the next key/value pair. It returns whether the
+	// iterator is exhausted.
+	Next() bool
+
+	// Error returns any accumulated error. Exhausting all the key/value pairs
+	// is not considered to be an error.
+	Error() error
+
+	// Key returns the key of the current key/value pair, or nil if done. The caller
+	// should not modify the contents of the returned slice, and its contents may
+	// change on the next call to Next.
+	Key() []byte
+
+	// Value returns the value of the current key/value pair, or nil if done. The
+	// caller should not modify the contents of the returned slice, and its contents
+	// may change on the next call to Next.
+	Value() []byte
+
+	// Release releases associated resources. Release should always succeed and can
+	// be called multiple times without causing error.
+	Release()
+}
+
+// Iteratee wraps the iterator creation methods of a database.
+type Iteratee interface {
+	// NewIteratorWithPrefix creates a binary-alphabetical iterator over the subset
+	// of database content with a particular key prefix.
+	NewIteratorWithPrefix(prefix []byte) Iterator
+
+	// NewIteratorWithRange creates a binary-alphabetical iterator over the subset
+	// of database content in the key range [start, limit). A nil start denotes the
+	// beginning of the key space, a nil limit its end.
+	NewIteratorWithRange(start []byte, limit []byte) Iterator
+}
+
+// AncientReader contains the methods required to read from the immutable ancient
+// store of canonical chain data.
+type AncientReader interface {
+	// HasAncient returns an indicator whether the specified data exists in the
+	// ancient store.
+	HasAncient(kind string, number uint64) (bool, error)
+
+	// Ancient retrieves an ancient binary blob from the append-only immutable files.
+	Ancient(kind string, number uint64) ([]byte, error)
+
+	// Ancients returns the number of blocks frozen into the ancient store.
+	Ancients() (uint64, error)
+
+	// AncientSize returns the ancient size of the specified category.
+	AncientSize(kind string) (uint64, error)
+}
+
+// AncientWriter contains the methods required to write to the immutable ancient
+// store of canonical chain data.
+type AncientWriter interface {
+	// AppendAncient injects all binary blobs belong to block at the end of the
+	// append-only immutable table files.
+	AppendAncient(number uint64, hash, header, body, receipts, td []byte) error
+
+	// TruncateAncients discards all but the first n ancient blocks.
+	TruncateAncients(n uint64) error
+
+	// SyncAncients flushes all in-memory ancient store data to disk.
+	SyncAncients() error
+}
+
+// AncientStore contains all the methods required to read from and write to the
+// ancient store.
+type AncientStore interface {
+	AncientReader
+	AncientWriter
 }
 
 // Batch is a write-only database that commits changes to its host database
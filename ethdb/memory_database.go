@@ -18,9 +18,11 @@ package ethdb
 
 import (
 	"errors"
+	"sort"
 	"sync"
 
 	"github.com/TeamEGEM/go-egem/common"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 /*
@@ -90,6 +92,58 @@ func (db *MemDatabase) Delete(key []byte) error {
 
 func (db *MemDatabase) Close() {}
 
+// NewIteratorWithPrefix creates a binary-alphabetical iterator over a snapshot
+// of the database content with a particular key prefix.
+func (db *MemDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	bounds := util.BytesPrefix(prefix)
+	return db.NewIteratorWithRange(bounds.Start, bounds.Limit)
+}
+
+// NewIteratorWithRange creates a binary-alphabetical iterator over a snapshot
+// of the database content in the key range [start, limit).
+func (db *MemDatabase) NewIteratorWithRange(start []byte, limit []byte) Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	var keys []string
+	for key := range db.db {
+		if inRange(key, start, limit) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = db.db[key]
+	}
+	return &memIterator{keys: keys, values: values, index: -1}
+}
+
+// DeleteRange removes all the keys in the range [start, limit).
+func (db *MemDatabase) DeleteRange(start []byte, limit []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	for key := range db.db {
+		if inRange(key, start, limit) {
+			delete(db.db, key)
+		}
+	}
+	return nil
+}
+
+// Stat returns a particular internal stat of the database. Memory databases
+// don't track any.
+func (db *MemDatabase) Stat(property string) (string, error) {
+	return "", errors.New("unknown property")
+}
+
+// Compact is a no-op, memory databases have nothing to flatten.
+func (db *MemDatabase) Compact(start []byte, limit []byte) error {
+	return nil
+}
+
 func (db *MemDatabase) NewBatch() Batch {
 	return &memBatch{db: db}
 }
@@ -128,3 +182,47 @@ func (b *memBatch) Reset() {
 	b.writes = b.writes[:0]
 	b.size = 0
 }
+
+// inRange reports whether a key is within the range [start, limit), with a nil
+// limit denoting the end of the key space.
+func inRange(key string, start []byte, limit []byte) bool {
+	return key >= string(start) && (limit == nil || key < string(limit))
+}
+
+// memIterator iterates over a sorted snapshot of the contents of a memory
+// database.
+type memIterator struct {
+	keys   []string
+	values [][]byte
+	index  int
+}
+
+func (it *memIterator) Next() bool {
+	if it.index >= len(it.keys) {
+		return false
+	}
+	it.index++
+	return it.index < len(it.keys)
+}
+
+func (it *memIterator) Error() error {
+	return nil
+}
+
+func (it *memIterator) Key() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return []byte(it.keys[it.index])
+}
+
+func (it *memIterator) Value() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return it.values[it.index]
+}
+
+func (it *memIterator) Release() {
+	it.keys, it.values, it.index = nil, nil, -1
+}
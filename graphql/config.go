@@ -0,0 +1,48 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+// DefaultConfig contains default settings for the GraphQL server.
+var DefaultConfig = Config{
+	Host:         "localhost",
+	Port:         8547,
+	VirtualHosts: []string{"localhost"},
+}
+
+// Config contains the configuration parameters of the GraphQL server.
+type Config struct {
+	// Host is the host interface on which to start the GraphQL server.
+	Host string `toml:",omitempty"`
+
+	// Port is the TCP port number on which to start the GraphQL server. The
+	// default zero value is valid and will pick a port number randomly (useful
+	// for ephemeral nodes).
+	Port int `toml:",omitempty"`
+
+	// Cors is the Cross-Origin Resource Sharing header to send to requesting
+	// clients. Please be aware that CORS is a browser enforced security, it's
+	// fully useless for custom HTTP clients.
+	Cors []string `toml:",omitempty"`
+
+	// VirtualHosts is the list of virtual hostnames which are allowed on incoming
+	// requests. This is by default {'localhost'}. Using this prevents attacks like
+	// DNS rebinding, which bypasses SOP by simply masquerading as being within the
+	// same origin. These attacks do not utilize CORS, since they are not
+	// cross-domain. By explicitly checking the Host-header, the server will not
+	// allow requests made from other domains.
+	VirtualHosts []string `toml:",omitempty"`
+}
@@ -0,0 +1,667 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package graphql provides a GraphQL interface to Ethereum node data.
+package graphql
+
+import (
+	"context"
+	"errors"
+
+	ethereum "github.com/TeamEGEM/go-egem"
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/core"
+	"github.com/TeamEGEM/go-egem/core/state"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/eth/filters"
+	"github.com/TeamEGEM/go-egem/internal/ethapi"
+	"github.com/TeamEGEM/go-egem/rlp"
+	"github.com/TeamEGEM/go-egem/rpc"
+)
+
+// maxBlockRange is the maximum number of blocks that can be requested at once
+// by a single blocks query.
+const maxBlockRange = 1024
+
+var (
+	errBlockNotFound     = errors.New("block not found")
+	errBlockNotCanonical = errors.New("block not in the canonical chain")
+	errBlockRange        = errors.New("block range too large")
+	errTdNotFound        = errors.New("total difficulty not found")
+)
+
+// Account represents an Ethereum account at a particular block.
+type Account struct {
+	backend     ethapi.Backend
+	address     common.Address
+	blockNumber rpc.BlockNumber
+	blockHash   common.Hash // Hash of the block if it must be canonical, empty otherwise
+}
+
+// newAccount creates an account resolver at the given block, or at the latest
+// block if none is given.
+func newAccount(backend ethapi.Backend, address common.Address, block *hexutil.Uint64) *Account {
+	number := rpc.LatestBlockNumber
+	if block != nil {
+		number = rpc.BlockNumber(*block)
+	}
+	return &Account{backend: backend, address: address, blockNumber: number}
+}
+
+// getState retrieves the state the account is resolved against.
+func (a *Account) getState(ctx context.Context) (*state.StateDB, error) {
+	statedb, header, err := a.backend.StateAndHeaderByNumber(ctx, a.blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if statedb == nil || header == nil {
+		return nil, errBlockNotFound
+	}
+	if a.blockHash != (common.Hash{}) && header.Hash() != a.blockHash {
+		return nil, errBlockNotCanonical
+	}
+	return statedb, nil
+}
+
+func (a *Account) Address(ctx context.Context) (common.Address, error) {
+	return a.address, nil
+}
+
+func (a *Account) Balance(ctx context.Context) (hexutil.Big, error) {
+	statedb, err := a.getState(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return hexutil.Big(*statedb.GetBalance(a.address)), statedb.Error()
+}
+
+func (a *Account) TransactionCount(ctx context.Context) (hexutil.Uint64, error) {
+	statedb, err := a.getState(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return hexutil.Uint64(statedb.GetNonce(a.address)), statedb.Error()
+}
+
+func (a *Account) Code(ctx context.Context) (hexutil.Bytes, error) {
+	statedb, err := a.getState(ctx)
+	if err != nil {
+		return hexutil.Bytes{}, err
+	}
+	return hexutil.Bytes(statedb.GetCode(a.address)), statedb.Error()
+}
+
+func (a *Account) Storage(ctx context.Context, args struct{ Slot common.Hash }) (common.Hash, error) {
+	statedb, err := a.getState(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return statedb.GetState(a.address, args.Slot), statedb.Error()
+}
+
+// Log represents an individual log message. All arguments are mandatory.
+type Log struct {
+	backend     ethapi.Backend
+	transaction *Transaction
+	log         *types.Log
+}
+
+func (l *Log) Transaction(ctx context.Context) (*Transaction, error) {
+	return l.transaction, nil
+}
+
+func (l *Log) Account(ctx context.Context, args BlockNumberArgs) *Account {
+	return newAccount(l.backend, l.log.Address, args.Block)
+}
+
+func (l *Log) Index(ctx context.Context) int32 {
+	return int32(l.log.Index)
+}
+
+func (l *Log) Topics(ctx context.Context) []common.Hash {
+	return l.log.Topics
+}
+
+func (l *Log) Data(ctx context.Context) hexutil.Bytes {
+	return hexutil.Bytes(l.log.Data)
+}
+
+// Transaction represents an Ethereum transaction, either mined in a block or
+// pending in the transaction pool.
+type Transaction struct {
+	backend ethapi.Backend
+	tx      *types.Transaction
+	block   *types.Block // Block the transaction was mined in, nil if pending
+	index   uint64       // Index of the transaction in its block
+}
+
+func (t *Transaction) Hash(ctx context.Context) common.Hash {
+	return t.tx.Hash()
+}
+
+func (t *Transaction) InputData(ctx context.Context) hexutil.Bytes {
+	return hexutil.Bytes(t.tx.Data())
+}
+
+func (t *Transaction) Gas(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(t.tx.Gas())
+}
+
+func (t *Transaction) GasPrice(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*t.tx.GasPrice())
+}
+
+func (t *Transaction) Value(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*t.tx.Value())
+}
+
+func (t *Transaction) Nonce(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(t.tx.Nonce())
+}
+
+func (t *Transaction) To(ctx context.Context, args BlockNumberArgs) *Account {
+	to := t.tx.To()
+	if to == nil {
+		return nil
+	}
+	return newAccount(t.backend, *to, args.Block)
+}
+
+func (t *Transaction) From(ctx context.Context, args BlockNumberArgs) (*Account, error) {
+	var signer types.Signer = types.FrontierSigner{}
+	if t.tx.Protected() {
+		signer = types.NewEIP155Signer(t.tx.ChainId())
+	}
+	from, err := types.Sender(signer, t.tx)
+	if err != nil {
+		return nil, err
+	}
+	return newAccount(t.backend, from, args.Block), nil
+}
+
+func (t *Transaction) Block(ctx context.Context) *Block {
+	if t.block == nil {
+		return nil
+	}
+	return &Block{backend: t.backend, block: t.block}
+}
+
+func (t *Transaction) Index(ctx context.Context) *int32 {
+	if t.block == nil {
+		return nil
+	}
+	index := int32(t.index)
+	return &index
+}
+
+// getReceipt retrieves the receipt of the transaction, or nil if it is still
+// pending.
+func (t *Transaction) getReceipt(ctx context.Context) (*types.Receipt, error) {
+	if t.block == nil {
+		return nil, nil
+	}
+	receipts, err := t.backend.GetReceipts(ctx, t.block.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(receipts)) <= t.index {
+		return nil, nil
+	}
+	return receipts[t.index], nil
+}
+
+func (t *Transaction) Status(ctx context.Context) (*hexutil.Uint64, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil || len(receipt.PostState) > 0 {
+		return nil, err
+	}
+	status := hexutil.Uint64(receipt.Status)
+	return &status, nil
+}
+
+func (t *Transaction) GasUsed(ctx context.Context) (*hexutil.Uint64, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	used := hexutil.Uint64(receipt.GasUsed)
+	return &used, nil
+}
+
+func (t *Transaction) CumulativeGasUsed(ctx context.Context) (*hexutil.Uint64, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	used := hexutil.Uint64(receipt.CumulativeGasUsed)
+	return &used, nil
+}
+
+func (t *Transaction) CreatedContract(ctx context.Context, args BlockNumberArgs) (*Account, error) {
+	if t.tx.To() != nil {
+		return nil, nil
+	}
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	return newAccount(t.backend, receipt.ContractAddress, args.Block), nil
+}
+
+func (t *Transaction) Logs(ctx context.Context) (*[]*Log, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	logs := make([]*Log, 0, len(receipt.Logs))
+	for _, log := range receipt.Logs {
+		logs = append(logs, &Log{backend: t.backend, transaction: t, log: log})
+	}
+	return &logs, nil
+}
+
+// Block represents an Ethereum block, or an ommer (uncle) of one, in which
+// case only its header is known.
+type Block struct {
+	backend ethapi.Backend
+	block   *types.Block
+}
+
+func (b *Block) Number(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(b.block.NumberU64())
+}
+
+func (b *Block) Hash(ctx context.Context) common.Hash {
+	return b.block.Hash()
+}
+
+func (b *Block) GasLimit(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(b.block.GasLimit())
+}
+
+func (b *Block) GasUsed(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(b.block.GasUsed())
+}
+
+func (b *Block) Parent(ctx context.Context) (*Block, error) {
+	if b.block.NumberU64() == 0 {
+		return nil, nil
+	}
+	parent, err := b.backend.GetBlock(ctx, b.block.ParentHash())
+	if err != nil || parent == nil {
+		return nil, err
+	}
+	return &Block{backend: b.backend, block: parent}, nil
+}
+
+func (b *Block) Difficulty(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*b.block.Difficulty())
+}
+
+func (b *Block) Timestamp(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*b.block.Time())
+}
+
+func (b *Block) Nonce(ctx context.Context) hexutil.Bytes {
+	nonce := types.EncodeNonce(b.block.Nonce())
+	return hexutil.Bytes(nonce[:])
+}
+
+func (b *Block) MixHash(ctx context.Context) common.Hash {
+	return b.block.MixDigest()
+}
+
+func (b *Block) TransactionsRoot(ctx context.Context) common.Hash {
+	return b.block.TxHash()
+}
+
+func (b *Block) StateRoot(ctx context.Context) common.Hash {
+	return b.block.Root()
+}
+
+func (b *Block) ReceiptsRoot(ctx context.Context) common.Hash {
+	return b.block.ReceiptHash()
+}
+
+func (b *Block) OmmerHash(ctx context.Context) common.Hash {
+	return b.block.UncleHash()
+}
+
+func (b *Block) OmmerCount(ctx context.Context) int32 {
+	return int32(len(b.block.Uncles()))
+}
+
+func (b *Block) Ommers(ctx context.Context) []*Block {
+	ommers := make([]*Block, 0, len(b.block.Uncles()))
+	for _, uncle := range b.block.Uncles() {
+		ommers = append(ommers, &Block{backend: b.backend, block: types.NewBlockWithHeader(uncle)})
+	}
+	return ommers
+}
+
+func (b *Block) ExtraData(ctx context.Context) hexutil.Bytes {
+	return hexutil.Bytes(b.block.Extra())
+}
+
+func (b *Block) LogsBloom(ctx context.Context) hexutil.Bytes {
+	return hexutil.Bytes(b.block.Bloom().Bytes())
+}
+
+func (b *Block) TotalDifficulty(ctx context.Context) (hexutil.Big, error) {
+	td := b.backend.GetTd(b.block.Hash())
+	if td == nil {
+		return hexutil.Big{}, errTdNotFound
+	}
+	return hexutil.Big(*td), nil
+}
+
+// BlockNumberArgs encapsulates arguments to accessors that specify a block number.
+type BlockNumberArgs struct {
+	Block *hexutil.Uint64
+}
+
+func (b *Block) Miner(ctx context.Context, args BlockNumberArgs) *Account {
+	if args.Block != nil {
+		return newAccount(b.backend, b.block.Coinbase(), args.Block)
+	}
+	return b.account(b.block.Coinbase())
+}
+
+func (b *Block) TransactionCount(ctx context.Context) int32 {
+	return int32(len(b.block.Transactions()))
+}
+
+func (b *Block) Transactions(ctx context.Context) []*Transaction {
+	txs := make([]*Transaction, 0, len(b.block.Transactions()))
+	for i, tx := range b.block.Transactions() {
+		txs = append(txs, &Transaction{backend: b.backend, tx: tx, block: b.block, index: uint64(i)})
+	}
+	return txs
+}
+
+func (b *Block) TransactionAt(ctx context.Context, args struct{ Index int32 }) *Transaction {
+	txs := b.block.Transactions()
+	if args.Index < 0 || int(args.Index) >= len(txs) {
+		return nil
+	}
+	return &Transaction{backend: b.backend, tx: txs[args.Index], block: b.block, index: uint64(args.Index)}
+}
+
+// BlockFilterCriteria encapsulates criteria passed to a `logs` accessor inside
+// a block.
+type BlockFilterCriteria struct {
+	Addresses *[]common.Address // restricts matches to events created by specific contracts
+	Topics    *[][]common.Hash  // restricts matches to particular event topics
+}
+
+func (b *Block) Logs(ctx context.Context, args struct{ Filter BlockFilterCriteria }) ([]*Log, error) {
+	var addresses []common.Address
+	if args.Filter.Addresses != nil {
+		addresses = *args.Filter.Addresses
+	}
+	var topics [][]common.Hash
+	if args.Filter.Topics != nil {
+		topics = *args.Filter.Topics
+	}
+	filter := filters.NewBlockFilter(filters.Backend(b.backend), b.block.Hash(), addresses, topics)
+	return runFilter(ctx, b.backend, filter)
+}
+
+func (b *Block) Account(ctx context.Context, args struct{ Address common.Address }) *Account {
+	return b.account(args.Address)
+}
+
+// account creates a resolver for the given account at the state of the block.
+func (b *Block) account(address common.Address) *Account {
+	return &Account{
+		backend:     b.backend,
+		address:     address,
+		blockNumber: rpc.BlockNumber(b.block.NumberU64()),
+		blockHash:   b.block.Hash(),
+	}
+}
+
+// FilterCriteria encapsulates the arguments to `logs` on the root resolver object.
+type FilterCriteria struct {
+	FromBlock *hexutil.Uint64   // beginning of the queried range, nil means latest block
+	ToBlock   *hexutil.Uint64   // end of the range, nil means latest block
+	Addresses *[]common.Address // restricts matches to events created by specific contracts
+	Topics    *[][]common.Hash  // restricts matches to particular event topics
+}
+
+// runFilter runs a log filter and wraps the matching logs into resolvers along
+// with the transactions that emitted them.
+func runFilter(ctx context.Context, backend ethapi.Backend, filter *filters.Filter) ([]*Log, error) {
+	logs, err := filter.Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		blocks = make(map[common.Hash]*types.Block)
+		result = make([]*Log, 0, len(logs))
+	)
+	for _, log := range logs {
+		block, ok := blocks[log.BlockHash]
+		if !ok {
+			if block, err = backend.GetBlock(ctx, log.BlockHash); err != nil {
+				return nil, err
+			}
+			if block == nil {
+				return nil, errBlockNotFound
+			}
+			blocks[log.BlockHash] = block
+		}
+		txs := block.Transactions()
+		if log.TxIndex >= uint(len(txs)) {
+			return nil, errors.New("log transaction index out of range")
+		}
+		tx := &Transaction{backend: backend, tx: txs[log.TxIndex], block: block, index: uint64(log.TxIndex)}
+		result = append(result, &Log{backend: backend, transaction: tx, log: log})
+	}
+	return result, nil
+}
+
+// Pending represents the current pending state of the node.
+type Pending struct {
+	backend ethapi.Backend
+}
+
+func (p *Pending) TransactionCount(ctx context.Context) (int32, error) {
+	txs, err := p.backend.GetPoolTransactions()
+	return int32(len(txs)), err
+}
+
+func (p *Pending) Transactions(ctx context.Context) ([]*Transaction, error) {
+	txs, err := p.backend.GetPoolTransactions()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Transaction, 0, len(txs))
+	for _, tx := range txs {
+		result = append(result, &Transaction{backend: p.backend, tx: tx})
+	}
+	return result, nil
+}
+
+func (p *Pending) Account(ctx context.Context, args struct{ Address common.Address }) *Account {
+	return &Account{backend: p.backend, address: args.Address, blockNumber: rpc.PendingBlockNumber}
+}
+
+// Resolver is the top-level object in the GraphQL hierarchy.
+type Resolver struct {
+	backend ethapi.Backend
+}
+
+func (r *Resolver) Block(ctx context.Context, args struct {
+	Number *hexutil.Uint64
+	Hash   *common.Hash
+}) (*Block, error) {
+	var (
+		block *types.Block
+		err   error
+	)
+	switch {
+	case args.Hash != nil:
+		block, err = r.backend.GetBlock(ctx, *args.Hash)
+	case args.Number != nil:
+		block, err = r.backend.BlockByNumber(ctx, rpc.BlockNumber(*args.Number))
+	default:
+		block, err = r.backend.BlockByNumber(ctx, rpc.LatestBlockNumber)
+	}
+	if err != nil || block == nil {
+		return nil, err
+	}
+	return &Block{backend: r.backend, block: block}, nil
+}
+
+func (r *Resolver) Blocks(ctx context.Context, args struct {
+	From hexutil.Uint64
+	To   *hexutil.Uint64
+}) ([]*Block, error) {
+	head := r.backend.CurrentBlock().NumberU64()
+
+	to := head
+	if args.To != nil && uint64(*args.To) < head {
+		to = uint64(*args.To)
+	}
+	from := uint64(args.From)
+	if from > to {
+		return []*Block{}, nil
+	}
+	if to-from >= maxBlockRange {
+		return nil, errBlockRange
+	}
+	blocks := make([]*Block, 0, to-from+1)
+	for number := from; number <= to; number++ {
+		block, err := r.backend.BlockByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, &Block{backend: r.backend, block: block})
+	}
+	return blocks, nil
+}
+
+func (r *Resolver) Pending(ctx context.Context) *Pending {
+	return &Pending{backend: r.backend}
+}
+
+func (r *Resolver) Transaction(ctx context.Context, args struct{ Hash common.Hash }) (*Transaction, error) {
+	// Try to return an already finalized transaction
+	if tx, blockHash, _, index := core.GetTransaction(r.backend.ChainDb(), args.Hash); tx != nil {
+		block, err := r.backend.GetBlock(ctx, blockHash)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, errBlockNotFound
+		}
+		return &Transaction{backend: r.backend, tx: tx, block: block, index: index}, nil
+	}
+	// No finalized transaction, try to retrieve it from the pool
+	if tx := r.backend.GetPoolTransaction(args.Hash); tx != nil {
+		return &Transaction{backend: r.backend, tx: tx}, nil
+	}
+	return nil, nil
+}
+
+func (r *Resolver) SendRawTransaction(ctx context.Context, args struct{ Data hexutil.Bytes }) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(args.Data, tx); err != nil {
+		return common.Hash{}, err
+	}
+	return ethapi.SubmitTransaction(ctx, r.backend, tx)
+}
+
+func (r *Resolver) Logs(ctx context.Context, args struct{ Filter FilterCriteria }) ([]*Log, error) {
+	// Convert the RPC block numbers into internal representations
+	begin := rpc.LatestBlockNumber.Int64()
+	if args.Filter.FromBlock != nil {
+		begin = int64(*args.Filter.FromBlock)
+	}
+	end := rpc.LatestBlockNumber.Int64()
+	if args.Filter.ToBlock != nil {
+		end = int64(*args.Filter.ToBlock)
+	}
+	var addresses []common.Address
+	if args.Filter.Addresses != nil {
+		addresses = *args.Filter.Addresses
+	}
+	var topics [][]common.Hash
+	if args.Filter.Topics != nil {
+		topics = *args.Filter.Topics
+	}
+	filter := filters.New(filters.Backend(r.backend), begin, end, addresses, topics)
+	return runFilter(ctx, r.backend, filter)
+}
+
+func (r *Resolver) GasPrice(ctx context.Context) (hexutil.Big, error) {
+	price, err := r.backend.SuggestPrice(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return hexutil.Big(*price), nil
+}
+
+func (r *Resolver) ProtocolVersion(ctx context.Context) int32 {
+	return int32(r.backend.ProtocolVersion())
+}
+
+// SyncState represents the synchronisation status returned from the `syncing`
+// accessor.
+type SyncState struct {
+	progress ethereum.SyncProgress
+}
+
+func (s *SyncState) StartingBlock() hexutil.Uint64 {
+	return hexutil.Uint64(s.progress.StartingBlock)
+}
+
+func (s *SyncState) CurrentBlock() hexutil.Uint64 {
+	return hexutil.Uint64(s.progress.CurrentBlock)
+}
+
+func (s *SyncState) HighestBlock() hexutil.Uint64 {
+	return hexutil.Uint64(s.progress.HighestBlock)
+}
+
+func (s *SyncState) PulledStates() *hexutil.Uint64 {
+	states := hexutil.Uint64(s.progress.PulledStates)
+	return &states
+}
+
+func (s *SyncState) KnownStates() *hexutil.Uint64 {
+	states := hexutil.Uint64(s.progress.KnownStates)
+	return &states
+}
+
+// Syncing returns false in case the node is currently not syncing with the
+// network. It can be up to date or has not yet received the latest block headers
+// from its peers. In case it is synchronizing the sync state is returned.
+func (r *Resolver) Syncing() (*SyncState, error) {
+	progress := r.backend.Downloader().Progress()
+
+	// Return not syncing if the synchronisation already completed
+	if progress.CurrentBlock >= progress.HighestBlock {
+		return nil, nil
+	}
+	// Otherwise gather the block sync stats
+	return &SyncState{progress}, nil
+}
@@ -0,0 +1,256 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/consensus/ethash"
+	"github.com/TeamEGEM/go-egem/core"
+	"github.com/TeamEGEM/go-egem/core/state"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/crypto"
+	"github.com/TeamEGEM/go-egem/ethdb"
+	"github.com/TeamEGEM/go-egem/internal/ethapi"
+	"github.com/TeamEGEM/go-egem/params"
+	"github.com/TeamEGEM/go-egem/rpc"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddress = crypto.PubkeyToAddress(testKey.PublicKey)
+	testRecv    = common.HexToAddress("0x0000000000000000000000000000000000000042")
+)
+
+// testBackend is a chain backend serving a few generated blocks from memory.
+// Only the methods needed by the tested resolvers are implemented.
+type testBackend struct {
+	ethapi.Backend
+
+	db       ethdb.Database
+	blocks   []*types.Block
+	receipts []types.Receipts
+}
+
+// newTestBackend creates a chain of three blocks, each of them with a transfer
+// from the test account.
+func newTestBackend(t *testing.T) *testBackend {
+	db, _ := ethdb.NewMemDatabase()
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  core.GenesisAlloc{testAddress: {Balance: big.NewInt(1000000000)}},
+	}
+	genesis := gspec.MustCommit(db)
+
+	signer := types.NewEIP155Signer(params.TestChainConfig.ChainId)
+	blocks, receipts := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 3, func(i int, gen *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(gen.TxNonce(testAddress), testRecv, big.NewInt(int64(1000*(i+1))), params.TxGas, big.NewInt(1), nil), signer, testKey)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		gen.AddTx(tx)
+	})
+	for _, block := range blocks {
+		core.WriteBlock(db, block)
+		core.WriteTxLookupEntries(db, block)
+	}
+	return &testBackend{
+		db:       db,
+		blocks:   append([]*types.Block{genesis}, blocks...),
+		receipts: append([]types.Receipts{nil}, receipts...),
+	}
+}
+
+func (b *testBackend) ChainDb() ethdb.Database    { return b.db }
+func (b *testBackend) CurrentBlock() *types.Block { return b.blocks[len(b.blocks)-1] }
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return b.CurrentBlock(), nil
+	}
+	if int(number) >= len(b.blocks) {
+		return nil, nil
+	}
+	return b.blocks[number], nil
+}
+
+func (b *testBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	for _, block := range b.blocks {
+		if block.Hash() == hash {
+			return block, nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	for i, block := range b.blocks {
+		if block.Hash() == hash {
+			return b.receipts[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	block, _ := b.BlockByNumber(ctx, number)
+	if block == nil {
+		return nil, nil, nil
+	}
+	statedb, err := state.New(block.Root(), state.NewDatabase(b.db))
+	return statedb, block.Header(), err
+}
+
+func (b *testBackend) GetPoolTransaction(hash common.Hash) *types.Transaction { return nil }
+
+// query runs a GraphQL query against the backend over HTTP and decodes the
+// response into result.
+func query(t *testing.T, backend ethapi.Backend, query string, result interface{}) {
+	service, err := New(&DefaultConfig, backend)
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	server := httptest.NewServer(service.handler)
+	defer server.Close()
+
+	body, _ := json.Marshal(map[string]interface{}{"query": query})
+	res, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to post query: %v", err)
+	}
+	defer res.Body.Close()
+
+	var response struct {
+		Data   json.RawMessage
+		Errors []interface{}
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Errors) > 0 {
+		t.Fatalf("query failed: %v", response.Errors)
+	}
+	if err := json.Unmarshal(response.Data, result); err != nil {
+		t.Fatalf("failed to decode data: %v", err)
+	}
+}
+
+// Tests that the schema can be parsed and bound to the resolvers.
+func TestBuildSchema(t *testing.T) {
+	if _, err := New(&DefaultConfig, nil); err != nil {
+		t.Fatalf("could not create new service: %v", err)
+	}
+}
+
+// Tests that a block can be retrieved along with its transactions, their receipt
+// fields and the state of the accounts involved, all in a single query.
+func TestQueryBlock(t *testing.T) {
+	backend := newTestBackend(t)
+	block := backend.blocks[2]
+
+	var result struct {
+		Block struct {
+			Number       hexutil.Uint64
+			Hash         common.Hash
+			Transactions []struct {
+				Hash    common.Hash
+				Index   int
+				From    struct{ Address common.Address }
+				To      struct{ Balance hexutil.Big }
+				Value   hexutil.Big
+				Status  hexutil.Uint64
+				GasUsed hexutil.Uint64
+			}
+			Account struct {
+				TransactionCount hexutil.Uint64
+			}
+		}
+	}
+	query(t, backend, fmt.Sprintf(`{
+		block(number: 2) {
+			number
+			hash
+			transactions { hash index from { address } to(block: 2) { balance } value status gasUsed }
+			account(address: "%s") { transactionCount }
+		}
+	}`, testAddress.Hex()), &result)
+
+	if uint64(result.Block.Number) != 2 || result.Block.Hash != block.Hash() {
+		t.Errorf("block mismatch: have #%d [%x], want #2 [%x]", result.Block.Number, result.Block.Hash, block.Hash())
+	}
+	if len(result.Block.Transactions) != 1 {
+		t.Fatalf("transaction count mismatch: have %d, want 1", len(result.Block.Transactions))
+	}
+	tx := result.Block.Transactions[0]
+	if tx.Hash != block.Transactions()[0].Hash() {
+		t.Errorf("transaction hash mismatch: have %x, want %x", tx.Hash, block.Transactions()[0].Hash())
+	}
+	if tx.From.Address != testAddress {
+		t.Errorf("sender mismatch: have %x, want %x", tx.From.Address, testAddress)
+	}
+	if balance := tx.To.Balance.ToInt(); balance.Cmp(big.NewInt(3000)) != 0 {
+		t.Errorf("recipient balance mismatch: have %v, want 3000", balance)
+	}
+	if value := tx.Value.ToInt(); value.Cmp(big.NewInt(2000)) != 0 {
+		t.Errorf("value mismatch: have %v, want 2000", value)
+	}
+	if tx.Status != 1 || uint64(tx.GasUsed) != params.TxGas {
+		t.Errorf("receipt mismatch: have status %d gas %d, want 1 and %d", tx.Status, tx.GasUsed, params.TxGas)
+	}
+	if result.Block.Account.TransactionCount != 2 {
+		t.Errorf("nonce mismatch: have %d, want 2", result.Block.Account.TransactionCount)
+	}
+}
+
+// Tests that transactions can be looked up by hash and ranges of blocks be
+// retrieved at once.
+func TestQueryTransactionAndBlocks(t *testing.T) {
+	backend := newTestBackend(t)
+	hash := backend.blocks[3].Transactions()[0].Hash()
+
+	var result struct {
+		Transaction struct {
+			Block struct{ Number hexutil.Uint64 }
+		}
+		Blocks []struct{ Number hexutil.Uint64 }
+	}
+	query(t, backend, fmt.Sprintf(`{
+		transaction(hash: "%s") { block { number } }
+		blocks(from: 1) { number }
+	}`, hash.Hex()), &result)
+
+	if result.Transaction.Block.Number != 3 {
+		t.Errorf("transaction block mismatch: have %d, want 3", result.Transaction.Block.Number)
+	}
+	if len(result.Blocks) != 3 {
+		t.Fatalf("block count mismatch: have %d, want 3", len(result.Blocks))
+	}
+	for i, block := range result.Blocks {
+		if uint64(block.Number) != uint64(i+1) {
+			t.Errorf("block %d: number mismatch: have %d, want %d", i, block.Number, i+1)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/TeamEGEM/go-egem/internal/ethapi"
+	"github.com/TeamEGEM/go-egem/log"
+	"github.com/TeamEGEM/go-egem/p2p"
+	"github.com/TeamEGEM/go-egem/rpc"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+)
+
+// maxRequestContentLength is the maximum size of a GraphQL request body.
+const maxRequestContentLength = 1024 * 128
+
+// Service encapsulates a GraphQL service.
+type Service struct {
+	config   *Config      // Configuration of the HTTP listener
+	handler  http.Handler // Handler serving the GraphQL requests
+	listener net.Listener // Listening socket, nil until the service is started
+}
+
+// New constructs a new GraphQL service instance serving the data of the given
+// backend.
+func New(config *Config, backend ethapi.Backend) (*Service, error) {
+	schema, err := graphqlgo.ParseSchema(schema, &Resolver{backend: backend})
+	if err != nil {
+		return nil, err
+	}
+	return &Service{
+		config:  config,
+		handler: rpc.NewHTTPHandlerStack(&handler{schema: schema}, config.Cors, config.VirtualHosts),
+	}, nil
+}
+
+// Protocols implements node.Service, returning the configured P2P protocols.
+func (s *Service) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service, returning the collection of RPC services offered.
+func (s *Service) APIs() []rpc.API { return nil }
+
+// Start implements node.Service, starting up the HTTP listener serving the
+// GraphQL requests.
+func (s *Service) Start(server *p2p.Server) error {
+	endpoint := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
+
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+
+	go (&http.Server{Handler: s.handler}).Serve(listener)
+	log.Info("GraphQL endpoint opened", "url", fmt.Sprintf("http://%s", listener.Addr()))
+	return nil
+}
+
+// Stop implements node.Service, terminating the HTTP listener.
+func (s *Service) Stop() error {
+	if s.listener != nil {
+		s.listener.Close()
+		s.listener = nil
+		log.Info("GraphQL endpoint closed", "url", fmt.Sprintf("http://%s:%d", s.config.Host, s.config.Port))
+	}
+	return nil
+}
+
+// request is a GraphQL query sent by a client.
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// handler executes GraphQL requests posted as JSON, or passed as URL parameters
+// of GET requests, against a schema.
+type handler struct {
+	schema *graphqlgo.Schema
+}
+
+// ServeHTTP implements http.Handler, executing a single GraphQL request.
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if vars := r.URL.Query().Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	case http.MethodPost:
+		if r.ContentLength > maxRequestContentLength {
+			err := fmt.Sprintf("content length too large (%d>%d)", r.ContentLength, maxRequestContentLength)
+			http.Error(w, err, http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestContentLength)).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	response := h.schema.Exec(r.Context(), req.Query, req.OperationName, req.Variables)
+	blob, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(blob)
+}
@@ -22,7 +22,6 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
-	"strings"
 	"time"
 
 	"github.com/TeamEGEM/go-egem/accounts"
@@ -40,8 +39,6 @@ import (
 	"github.com/TeamEGEM/go-egem/params"
 	"github.com/TeamEGEM/go-egem/rlp"
 	"github.com/TeamEGEM/go-egem/rpc"
-	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 const (
@@ -174,6 +171,39 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	return content
 }
 
+// PrivateTxPoolAPI offers an API to manage the transaction pool, allowing the
+// operator to evict transactions and tune the replacement policy.
+type PrivateTxPoolAPI struct {
+	b Backend
+}
+
+// NewPrivateTxPoolAPI creates a new tx pool service to manage the transaction pool.
+func NewPrivateTxPoolAPI(b Backend) *PrivateTxPoolAPI {
+	return &PrivateTxPoolAPI{b}
+}
+
+// Remove drops the transaction with the given hash from the pool, including the
+// local transaction journal. Subsequent transactions of the same account are
+// moved back to the queue. It returns whether the transaction was found.
+func (s *PrivateTxPoolAPI) Remove(hash common.Hash) bool {
+	return s.b.RemovePoolTransaction(hash)
+}
+
+// RemoveSender drops all transactions of the given account from the pool and the
+// local transaction journal, returning the number of transactions removed.
+func (s *PrivateTxPoolAPI) RemoveSender(addr common.Address) hexutil.Uint {
+	return hexutil.Uint(s.b.RemovePoolSender(addr))
+}
+
+// SetPriceBump sets the minimum gas price bump percentage required to replace a
+// pooled transaction with one of the same nonce.
+func (s *PrivateTxPoolAPI) SetPriceBump(bump hexutil.Uint64) (bool, error) {
+	if err := s.b.SetPoolPriceBump(uint64(bump)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // PublicAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type PublicAccountAPI struct {
@@ -215,10 +245,26 @@ func NewPrivateAccountAPI(b Backend, nonceLock *AddrLocker) *PrivateAccountAPI {
 }
 
 // ListAccounts will return a list of addresses for accounts this node manages.
-func (s *PrivateAccountAPI) ListAccounts() []common.Address {
+// Large account sets can be paged through by skipping the first offset accounts
+// and returning at most limit of them.
+func (s *PrivateAccountAPI) ListAccounts(offset *uint64, limit *uint64) []common.Address {
+	var skip, max uint64
+	if offset != nil {
+		skip = *offset
+	}
+	if limit != nil {
+		max = *limit
+	}
 	addresses := make([]common.Address, 0) // return [] instead of nil if empty
 	for _, wallet := range s.am.Wallets() {
 		for _, account := range wallet.Accounts() {
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if limit != nil && uint64(len(addresses)) >= max {
+				return addresses
+			}
 			addresses = append(addresses, account.Address)
 		}
 	}
@@ -371,7 +417,7 @@ func (s *PrivateAccountAPI) SendTransaction(ctx context.Context, args SendTxArgs
 	if err != nil {
 		return common.Hash{}, err
 	}
-	return submitTransaction(ctx, s.b, signed)
+	return SubmitTransaction(ctx, s.b, signed)
 }
 
 // SignTransaction will create a transaction from the given arguments and
@@ -601,6 +647,83 @@ func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.A
 	return res[:], state.Error()
 }
 
+// AccountResult is the result of an EIP-1186 account proof request.
+type AccountResult struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []string        `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageResult `json:"storageProof"`
+}
+
+// StorageResult is the proof of a single storage slot within an AccountResult.
+type StorageResult struct {
+	Key   string       `json:"key"`
+	Value *hexutil.Big `json:"value"`
+	Proof []string     `json:"proof"`
+}
+
+// GetProof returns the Merkle proof of the given account and optionally some of
+// its storage keys, as specified by EIP-1186.
+func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNr rpc.BlockNumber) (*AccountResult, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	// Gather the storage proofs if the account has a storage trie at all
+	storageHash := types.EmptyRootHash
+	storageProof := make([]StorageResult, len(storageKeys))
+
+	if storageTrie := state.StorageTrie(address); storageTrie != nil {
+		storageHash = storageTrie.Hash()
+		for i, key := range storageKeys {
+			proof, err := state.GetStorageProof(address, common.HexToHash(key))
+			if err != nil {
+				return nil, err
+			}
+			storageProof[i] = StorageResult{
+				Key:   key,
+				Value: (*hexutil.Big)(state.GetState(address, common.HexToHash(key)).Big()),
+				Proof: toHexSlice(proof),
+			}
+		}
+	} else {
+		// Non-existent accounts have empty storage, the account proof covers them
+		for i, key := range storageKeys {
+			storageProof[i] = StorageResult{Key: key, Value: &hexutil.Big{}, Proof: []string{}}
+		}
+	}
+	// Create the account proof and assemble the result
+	accountProof, err := state.GetProof(address)
+	if err != nil {
+		return nil, err
+	}
+	codeHash := state.GetCodeHash(address)
+	if codeHash == (common.Hash{}) {
+		codeHash = crypto.Keccak256Hash(nil)
+	}
+	return &AccountResult{
+		Address:      address,
+		AccountProof: toHexSlice(accountProof),
+		Balance:      (*hexutil.Big)(state.GetBalance(address)),
+		CodeHash:     codeHash,
+		Nonce:        hexutil.Uint64(state.GetNonce(address)),
+		StorageHash:  storageHash,
+		StorageProof: storageProof,
+	}, state.Error()
+}
+
+// toHexSlice encodes a list of binary blobs into a list of hex strings.
+func toHexSlice(b [][]byte) []string {
+	r := make([]string, len(b))
+	for i := range b {
+		r[i] = hexutil.Encode(b[i])
+	}
+	return r
+}
+
 // CallArgs represents the arguments for a call.
 type CallArgs struct {
 	From     common.Address  `json:"from"`
@@ -1167,8 +1290,8 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 	return types.NewTransaction(uint64(*args.Nonce), *args.To, (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input)
 }
 
-// submitTransaction is a helper function that submits tx to txPool and logs a message.
-func submitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (common.Hash, error) {
+// SubmitTransaction is a helper function that submits tx to txPool and logs a message.
+func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (common.Hash, error) {
 	if err := b.SendTx(ctx, tx); err != nil {
 		return common.Hash{}, err
 	}
@@ -1220,7 +1343,7 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 	if err != nil {
 		return common.Hash{}, err
 	}
-	return submitTransaction(ctx, s.b, signed)
+	return SubmitTransaction(ctx, s.b, signed)
 }
 
 // SendRawTransaction will add the signed transaction to the transaction pool.
@@ -1230,7 +1353,7 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encod
 	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
 		return common.Hash{}, err
 	}
-	return submitTransaction(ctx, s.b, tx)
+	return SubmitTransaction(ctx, s.b, tx)
 }
 
 // Sign calculates an ECDSA signature for:
@@ -1412,33 +1535,15 @@ func NewPrivateDebugAPI(b Backend) *PrivateDebugAPI {
 	return &PrivateDebugAPI{b: b}
 }
 
-// ChaindbProperty returns leveldb properties of the chain database.
+// ChaindbProperty returns properties of the chain database.
 func (api *PrivateDebugAPI) ChaindbProperty(property string) (string, error) {
-	ldb, ok := api.b.ChainDb().(interface {
-		LDB() *leveldb.DB
-	})
-	if !ok {
-		return "", fmt.Errorf("chaindbProperty does not work for memory databases")
-	}
-	if property == "" {
-		property = "leveldb.stats"
-	} else if !strings.HasPrefix(property, "leveldb.") {
-		property = "leveldb." + property
-	}
-	return ldb.LDB().GetProperty(property)
+	return api.b.ChainDb().Stat(property)
 }
 
 func (api *PrivateDebugAPI) ChaindbCompact() error {
-	ldb, ok := api.b.ChainDb().(interface {
-		LDB() *leveldb.DB
-	})
-	if !ok {
-		return fmt.Errorf("chaindbCompact does not work for memory databases")
-	}
 	for b := byte(0); b < 255; b++ {
 		log.Info("Compacting chain database", "range", fmt.Sprintf("0x%0.2X-0x%0.2X", b, b+1))
-		err := ldb.LDB().CompactRange(util.Range{Start: []byte{b}, Limit: []byte{b + 1}})
-		if err != nil {
+		if err := api.b.ChainDb().Compact([]byte{b}, []byte{b + 1}); err != nil {
 			log.Error("Database compaction failed", "err", err)
 			return err
 		}
@@ -24,6 +24,7 @@ import (
 	"github.com/TeamEGEM/go-egem/accounts"
 	"github.com/TeamEGEM/go-egem/common"
 	"github.com/TeamEGEM/go-egem/core"
+	"github.com/TeamEGEM/go-egem/core/bloombits"
 	"github.com/TeamEGEM/go-egem/core/state"
 	"github.com/TeamEGEM/go-egem/core/types"
 	"github.com/TeamEGEM/go-egem/core/vm"
@@ -44,6 +45,7 @@ type Backend interface {
 	ChainDb() ethdb.Database
 	EventMux() *event.TypeMux
 	AccountManager() *accounts.Manager
+	MultisigStore() string // Path of the multisig proposal store, empty for in-memory
 
 	// BlockChain API
 	SetHead(number uint64)
@@ -66,6 +68,16 @@ type Backend interface {
 	Stats() (pending int, queued int)
 	TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions)
 	SubscribeTxPreEvent(chan<- core.TxPreEvent) event.Subscription
+	RemovePoolTransaction(txHash common.Hash) bool
+	RemovePoolSender(addr common.Address) int
+	SetPoolPriceBump(bump uint64) error
+
+	// Filter API
+	GetLogs(ctx context.Context, blockHash common.Hash) ([][]*types.Log, error)
+	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
+	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription
+	BloomStatus() (uint64, uint64)
+	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
 
 	ChainConfig() *params.ChainConfig
 	CurrentBlock() *types.Block
@@ -94,6 +106,10 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicTxPoolAPI(apiBackend),
 			Public:    true,
+		}, {
+			Namespace: "txpool",
+			Version:   "1.0",
+			Service:   NewPrivateTxPoolAPI(apiBackend),
 		}, {
 			Namespace: "debug",
 			Version:   "1.0",
@@ -113,6 +129,10 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Version:   "1.0",
 			Service:   NewPrivateAccountAPI(apiBackend, nonceLock),
 			Public:    false,
+		}, {
+			Namespace: "multisig",
+			Version:   "1.0",
+			Service:   NewPrivateMultisigAPI(apiBackend, nonceLock),
 		},
 	}
 }
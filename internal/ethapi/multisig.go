@@ -0,0 +1,692 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TeamEGEM/go-egem/accounts"
+	"github.com/TeamEGEM/go-egem/accounts/abi"
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/common/math"
+	"github.com/TeamEGEM/go-egem/core"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/core/vm"
+	"github.com/TeamEGEM/go-egem/crypto"
+	"github.com/TeamEGEM/go-egem/log"
+	"github.com/TeamEGEM/go-egem/rpc"
+)
+
+// multisigWalletABI is the subset of the SimpleMultiSig wallet interface needed
+// to coordinate proposals: the replay protection nonce, the number of required
+// signatures, the owner set and the execution entry point. The execution call
+// expects the signatures ordered by strictly increasing signer address, each
+// signing keccak256(0x19, 0x00, wallet, destination, value, data, nonce).
+const multisigWalletABI = `[
+	{"constant":true,"inputs":[],"name":"nonce","outputs":[{"name":"","type":"uint256"}],"payable":false,"type":"function"},
+	{"constant":true,"inputs":[],"name":"threshold","outputs":[{"name":"","type":"uint256"}],"payable":false,"type":"function"},
+	{"constant":true,"inputs":[{"name":"","type":"address"}],"name":"isOwner","outputs":[{"name":"","type":"bool"}],"payable":false,"type":"function"},
+	{"constant":false,"inputs":[{"name":"sigV","type":"uint8[]"},{"name":"sigR","type":"bytes32[]"},{"name":"sigS","type":"bytes32[]"},{"name":"destination","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"}],"name":"execute","outputs":[],"payable":false,"type":"function"}
+]`
+
+// multisigCallTimeout is the maximum time allowed for reading the state of a
+// multisig wallet contract.
+const multisigCallTimeout = 5 * time.Second
+
+var multisigABI abi.ABI
+
+func init() {
+	parsed, err := abi.JSON(strings.NewReader(multisigWalletABI))
+	if err != nil {
+		panic(fmt.Sprintf("invalid multisig wallet ABI: %v", err))
+	}
+	multisigABI = parsed
+}
+
+// MultisigProposal is a pending call through a multisig wallet contract along
+// with the owner signatures collected for it so far.
+type MultisigProposal struct {
+	Id         common.Hash                      `json:"id"`
+	Wallet     common.Address                   `json:"wallet"`
+	Proposer   common.Address                   `json:"proposer"`
+	To         common.Address                   `json:"to"`
+	Value      *hexutil.Big                     `json:"value"`
+	Data       hexutil.Bytes                    `json:"data"`
+	Nonce      *hexutil.Big                     `json:"nonce"`
+	Threshold  hexutil.Uint64                   `json:"threshold"`
+	Signatures map[common.Address]hexutil.Bytes `json:"signatures"`
+	Pending    *common.Hash                     `json:"pending,omitempty"`
+	Executed   *common.Hash                     `json:"executed,omitempty"`
+}
+
+// validate checks that a proposal loaded from disk is complete and consistent,
+// so a damaged store can't crash the API later on.
+func (p *MultisigProposal) validate() error {
+	if p.Value == nil {
+		return errors.New("missing value")
+	}
+	if p.Nonce == nil {
+		return errors.New("missing nonce")
+	}
+	if p.Threshold == 0 {
+		return errors.New("missing threshold")
+	}
+	if id := multisigHash(p.Wallet, p.To, p.Value.ToInt(), p.Data, p.Nonce.ToInt()); id != p.Id {
+		return fmt.Errorf("id mismatch: have %s, want %s", p.Id.Hex(), id.Hex())
+	}
+	for signer, sig := range p.Signatures {
+		if len(sig) != 65 {
+			return fmt.Errorf("invalid signature of %s", signer.Hex())
+		}
+	}
+	return nil
+}
+
+// copy returns a deep enough copy of the proposal to hand out of the store.
+func (p *MultisigProposal) copy() *MultisigProposal {
+	cpy := *p
+	cpy.Signatures = make(map[common.Address]hexutil.Bytes, len(p.Signatures))
+	for signer, sig := range p.Signatures {
+		cpy.Signatures[signer] = common.CopyBytes(sig)
+	}
+	return &cpy
+}
+
+// multisigHash calculates the hash the wallet owners need to sign in order to
+// authorize a call through the wallet contract, calculated as
+// keccak256(0x19, 0x00, wallet, destination, value, data, nonce).
+func multisigHash(wallet, to common.Address, value *big.Int, data []byte, nonce *big.Int) common.Hash {
+	return crypto.Keccak256Hash([]byte{0x19, 0x00}, wallet[:], to[:], math.PaddedBigBytes(value, 32), data, math.PaddedBigBytes(nonce, 32))
+}
+
+// recoverMultisigSigner returns the address that produced the given signature
+// over a proposal id. The V value may be either 0/1 or 27/28.
+func recoverMultisigSigner(id common.Hash, sig []byte) (common.Address, error) {
+	if len(sig) != 65 {
+		return common.Address{}, fmt.Errorf("signature must be 65 bytes long")
+	}
+	sig = common.CopyBytes(sig)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	if sig[64] != 0 && sig[64] != 1 {
+		return common.Address{}, fmt.Errorf("invalid Ethereum signature (V is not 0, 1, 27 or 28)")
+	}
+	pub, err := crypto.SigToPub(id[:], sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// multisigStore is the collection of tracked proposals, optionally persisted to
+// a JSON file so they survive node restarts.
+type multisigStore struct {
+	path      string // Path of the persisted store, empty for an in-memory store
+	proposals map[common.Hash]*MultisigProposal
+}
+
+// newMultisigStore creates a proposal store, loading any previously persisted
+// proposals from the given path.
+func newMultisigStore(path string) (*multisigStore, error) {
+	store := &multisigStore{
+		path:      path,
+		proposals: make(map[common.Hash]*MultisigProposal),
+	}
+	if path == "" {
+		return store, nil
+	}
+	blob, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var proposals []*MultisigProposal
+	if err := json.Unmarshal(blob, &proposals); err != nil {
+		return nil, err
+	}
+	for _, p := range proposals {
+		if err := p.validate(); err != nil {
+			return nil, fmt.Errorf("invalid multisig proposal %s: %v", p.Id.Hex(), err)
+		}
+		if p.Signatures == nil {
+			p.Signatures = make(map[common.Address]hexutil.Bytes)
+		}
+		store.proposals[p.Id] = p
+	}
+	return store, nil
+}
+
+// list returns all tracked proposals, ordered by wallet and nonce.
+func (s *multisigStore) list() []*MultisigProposal {
+	proposals := make([]*MultisigProposal, 0, len(s.proposals))
+	for _, p := range s.proposals {
+		proposals = append(proposals, p)
+	}
+	sort.Slice(proposals, func(i, j int) bool {
+		if c := bytes.Compare(proposals[i].Wallet[:], proposals[j].Wallet[:]); c != 0 {
+			return c < 0
+		}
+		if c := proposals[i].Nonce.ToInt().Cmp(proposals[j].Nonce.ToInt()); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(proposals[i].Id[:], proposals[j].Id[:]) < 0
+	})
+	return proposals
+}
+
+// save atomically writes all tracked proposals to disk.
+func (s *multisigStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	blob, err := json.MarshalIndent(s.list(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(s.path), "."+filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(blob); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	f.Close()
+	return os.Rename(f.Name(), s.path)
+}
+
+// MultisigProposalArgs represents the arguments to propose a new call through
+// a multisig wallet contract.
+type MultisigProposalArgs struct {
+	Wallet common.Address `json:"wallet"`
+	From   common.Address `json:"from"`
+	To     common.Address `json:"to"`
+	Value  *hexutil.Big   `json:"value"`
+	Data   hexutil.Bytes  `json:"data"`
+}
+
+// PrivateMultisigAPI coordinates the signatures of multisig wallet owners. It
+// tracks pending proposals, validates the partial signatures submitted for them
+// and sends the execution transaction once enough owners signed.
+//
+// The lock only guards the proposal store, contract calls and transaction
+// submission happen on copies of the proposals without holding it.
+type PrivateMultisigAPI struct {
+	b         Backend
+	nonceLock *AddrLocker
+	store     *multisigStore
+	executing map[common.Hash]struct{} // Proposals with an execution being assembled
+	lock      sync.Mutex
+}
+
+// NewPrivateMultisigAPI creates a new multisig coordination API, persisting its
+// proposals into the store file configured for the backend.
+func NewPrivateMultisigAPI(b Backend, nonceLock *AddrLocker) *PrivateMultisigAPI {
+	store, err := newMultisigStore(b.MultisigStore())
+	if err != nil {
+		log.Error("Failed to load multisig proposals, keeping them in memory only", "path", b.MultisigStore(), "err", err)
+		store, _ = newMultisigStore("")
+	}
+	return &PrivateMultisigAPI{
+		b:         b,
+		nonceLock: nonceLock,
+		store:     store,
+		executing: make(map[common.Hash]struct{}),
+	}
+}
+
+// call executes a read only method of a multisig wallet contract on the latest
+// state and unpacks its result into out.
+func (s *PrivateMultisigAPI) call(ctx context.Context, wallet common.Address, out interface{}, method string, params ...interface{}) error {
+	input, err := multisigABI.Pack(method, params...)
+	if err != nil {
+		return err
+	}
+	args := CallArgs{To: &wallet, Data: input}
+	output, _, failed, err := NewPublicBlockChainAPI(s.b).doCall(ctx, args, rpc.LatestBlockNumber, vm.Config{}, multisigCallTimeout)
+	if err != nil {
+		return err
+	}
+	if failed || len(output) == 0 {
+		return fmt.Errorf("multisig wallet %s failed to answer %s", wallet.Hex(), method)
+	}
+	return multisigABI.Unpack(out, method, output)
+}
+
+// Propose starts tracking a new call through a multisig wallet. The wallet's
+// current nonce and threshold are read from the chain and the returned proposal
+// id is the hash the wallet owners need to sign. The proposer must be a local
+// account, it is used to send the execution transaction.
+func (s *PrivateMultisigAPI) Propose(ctx context.Context, args MultisigProposalArgs) (*MultisigProposal, error) {
+	if _, err := s.b.AccountManager().Find(accounts.Account{Address: args.From}); err != nil {
+		return nil, err
+	}
+	var (
+		nonce     = new(big.Int)
+		threshold = new(big.Int)
+	)
+	if err := s.call(ctx, args.Wallet, &nonce, "nonce"); err != nil {
+		return nil, err
+	}
+	if err := s.call(ctx, args.Wallet, &threshold, "threshold"); err != nil {
+		return nil, err
+	}
+	if threshold.Sign() == 0 || !threshold.IsUint64() {
+		return nil, fmt.Errorf("invalid multisig threshold %v", threshold)
+	}
+	if args.Value == nil {
+		args.Value = new(hexutil.Big)
+	}
+	proposal := &MultisigProposal{
+		Wallet:     args.Wallet,
+		Proposer:   args.From,
+		To:         args.To,
+		Value:      args.Value,
+		Data:       args.Data,
+		Nonce:      (*hexutil.Big)(nonce),
+		Threshold:  hexutil.Uint64(threshold.Uint64()),
+		Signatures: make(map[common.Address]hexutil.Bytes),
+	}
+	proposal.Id = multisigHash(args.Wallet, args.To, args.Value.ToInt(), args.Data, nonce)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.store.proposals[proposal.Id]; ok {
+		return nil, fmt.Errorf("proposal %s already tracked", proposal.Id.Hex())
+	}
+	s.store.proposals[proposal.Id] = proposal
+	if err := s.store.save(); err != nil {
+		delete(s.store.proposals, proposal.Id)
+		return nil, err
+	}
+	log.Info("Tracking multisig proposal", "id", proposal.Id, "wallet", proposal.Wallet, "nonce", nonce, "threshold", threshold)
+	return proposal.copy(), nil
+}
+
+// Proposals returns all proposals tracked by the node, after checking the state
+// of their pending executions.
+func (s *PrivateMultisigAPI) Proposals(ctx context.Context) []*MultisigProposal {
+	s.lock.Lock()
+	var pending []common.Hash
+	for id, p := range s.store.proposals {
+		if p.Pending != nil {
+			pending = append(pending, id)
+		}
+	}
+	s.lock.Unlock()
+
+	for _, id := range pending {
+		if err := s.confirm(ctx, id); err != nil {
+			log.Warn("Failed to check multisig execution", "id", id, "err", err)
+		}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	proposals := s.store.list()
+	for i, p := range proposals {
+		proposals[i] = p.copy()
+	}
+	return proposals
+}
+
+// Proposal returns the tracked proposal with the given id, after checking the
+// state of its pending execution.
+func (s *PrivateMultisigAPI) Proposal(ctx context.Context, id common.Hash) (*MultisigProposal, error) {
+	if err := s.confirm(ctx, id); err != nil {
+		log.Warn("Failed to check multisig execution", "id", id, "err", err)
+	}
+	return s.get(id)
+}
+
+// get returns a copy of the tracked proposal with the given id.
+func (s *PrivateMultisigAPI) get(id common.Hash) (*MultisigProposal, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, ok := s.store.proposals[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown proposal %s", id.Hex())
+	}
+	return p.copy(), nil
+}
+
+// Remove stops tracking the proposal with the given id.
+func (s *PrivateMultisigAPI) Remove(id common.Hash) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, ok := s.store.proposals[id]
+	if !ok {
+		return false, nil
+	}
+	delete(s.store.proposals, id)
+	if err := s.store.save(); err != nil {
+		s.store.proposals[id] = p
+		return false, err
+	}
+	return true, nil
+}
+
+// AddSignature validates and attaches an owner signature to a proposal. The
+// signature must be produced over the proposal id. Once the proposal collected
+// enough signatures, the execution transaction is sent if the proposer account
+// is unlocked.
+func (s *PrivateMultisigAPI) AddSignature(ctx context.Context, id common.Hash, sig hexutil.Bytes) (*MultisigProposal, error) {
+	p, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Executed != nil {
+		return nil, fmt.Errorf("proposal %s already executed in %s", id.Hex(), p.Executed.Hex())
+	}
+	signer, err := recoverMultisigSigner(id, sig)
+	if err != nil {
+		return nil, err
+	}
+	owner := false
+	if err := s.call(ctx, p.Wallet, &owner, "isOwner", signer); err != nil {
+		return nil, err
+	}
+	if !owner {
+		return nil, fmt.Errorf("signer %s is not an owner of multisig wallet %s", signer.Hex(), p.Wallet.Hex())
+	}
+	// Store the signature with a yellow paper V value, as expected by the contract
+	sig = common.CopyBytes(sig)
+	if sig[64] < 27 {
+		sig[64] += 27
+	}
+	s.lock.Lock()
+	p, ok := s.store.proposals[id]
+	if !ok {
+		s.lock.Unlock()
+		return nil, fmt.Errorf("unknown proposal %s", id.Hex())
+	}
+	if p.Executed != nil {
+		s.lock.Unlock()
+		return nil, fmt.Errorf("proposal %s already executed in %s", id.Hex(), p.Executed.Hex())
+	}
+	prev, signed := p.Signatures[signer]
+	p.Signatures[signer] = sig
+	if err := s.store.save(); err != nil {
+		if signed {
+			p.Signatures[signer] = prev
+		} else {
+			delete(p.Signatures, signer)
+		}
+		s.lock.Unlock()
+		return nil, err
+	}
+	log.Info("Added multisig signature", "id", id, "signer", signer, "signatures", len(p.Signatures), "threshold", uint64(p.Threshold))
+
+	ready := uint64(len(p.Signatures)) >= uint64(p.Threshold) && p.Pending == nil
+	s.lock.Unlock()
+
+	if ready {
+		if _, err := s.execute(ctx, id, nil); err != nil {
+			log.Warn("Multisig proposal ready but not executed", "id", id, "err", err)
+		}
+	}
+	return s.get(id)
+}
+
+// Sign signs a proposal with the given local owner account, decrypting its key
+// with the given passphrase, and attaches the signature to the proposal.
+func (s *PrivateMultisigAPI) Sign(ctx context.Context, id common.Hash, signer common.Address, passwd string) (*MultisigProposal, error) {
+	account := accounts.Account{Address: signer}
+
+	wallet, err := s.b.AccountManager().Find(account)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := wallet.SignHashWithPassphrase(account, passwd, id[:])
+	if err != nil {
+		return nil, err
+	}
+	return s.AddSignature(ctx, id, sig)
+}
+
+// Execute sends the execution transaction of a proposal that collected enough
+// signatures. The transaction is signed by the proposer account, unlocking it
+// with the given passphrase if one is provided. A previous execution that was
+// dropped or reverted is detected and can be retried.
+func (s *PrivateMultisigAPI) Execute(ctx context.Context, id common.Hash, passwd *string) (common.Hash, error) {
+	if err := s.confirm(ctx, id); err != nil {
+		return common.Hash{}, err
+	}
+	return s.execute(ctx, id, passwd)
+}
+
+// execute assembles, signs and submits the execution transaction of a proposal.
+// The transaction is only recorded as pending, it's confirmed later on when the
+// proposal is looked up again.
+func (s *PrivateMultisigAPI) execute(ctx context.Context, id common.Hash, passwd *string) (common.Hash, error) {
+	// Reserve the proposal so concurrent calls don't submit it twice
+	s.lock.Lock()
+	p, ok := s.store.proposals[id]
+	switch {
+	case !ok:
+		s.lock.Unlock()
+		return common.Hash{}, fmt.Errorf("unknown proposal %s", id.Hex())
+	case p.Executed != nil:
+		s.lock.Unlock()
+		return common.Hash{}, fmt.Errorf("proposal %s already executed in %s", id.Hex(), p.Executed.Hex())
+	case p.Pending != nil:
+		s.lock.Unlock()
+		return common.Hash{}, fmt.Errorf("proposal %s execution pending in %s", id.Hex(), p.Pending.Hex())
+	}
+	if _, ok := s.executing[id]; ok {
+		s.lock.Unlock()
+		return common.Hash{}, fmt.Errorf("proposal %s already being executed", id.Hex())
+	}
+	s.executing[id] = struct{}{}
+	p = p.copy()
+	s.lock.Unlock()
+
+	defer func() {
+		s.lock.Lock()
+		delete(s.executing, id)
+		s.lock.Unlock()
+	}()
+	hash, err := s.submit(ctx, p, passwd)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if p, ok := s.store.proposals[id]; ok {
+		p.Pending = &hash
+		if err := s.store.save(); err != nil {
+			log.Error("Failed to persist pending multisig execution", "id", id, "err", err)
+		}
+	}
+	return hash, nil
+}
+
+// submit assembles, signs and submits the execution transaction of a proposal.
+func (s *PrivateMultisigAPI) submit(ctx context.Context, p *MultisigProposal, passwd *string) (common.Hash, error) {
+	if uint64(len(p.Signatures)) < uint64(p.Threshold) {
+		return common.Hash{}, fmt.Errorf("proposal has %d of %d required signatures", len(p.Signatures), uint64(p.Threshold))
+	}
+	// Make sure no other call consumed the wallet nonce in the meantime
+	nonce := new(big.Int)
+	if err := s.call(ctx, p.Wallet, &nonce, "nonce"); err != nil {
+		return common.Hash{}, err
+	}
+	if nonce.Cmp(p.Nonce.ToInt()) != 0 {
+		return common.Hash{}, fmt.Errorf("stale proposal: nonce %v, wallet at %v", p.Nonce.ToInt(), nonce)
+	}
+	input, err := packMultisigExecute(p)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	// Look up the wallet containing the proposer and assemble the transaction
+	account := accounts.Account{Address: p.Proposer}
+
+	wallet, err := s.b.AccountManager().Find(account)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	gas, err := NewPublicBlockChainAPI(s.b).EstimateGas(ctx, CallArgs{From: p.Proposer, To: &p.Wallet, Data: input})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	args := SendTxArgs{
+		From: p.Proposer,
+		To:   &p.Wallet,
+		Gas:  &gas,
+		Data: (*hexutil.Bytes)(&input),
+	}
+	// Hold the addresse's mutex around signing to prevent concurrent assignment of
+	// the same nonce to multiple accounts.
+	s.nonceLock.LockAddr(args.From)
+	defer s.nonceLock.UnlockAddr(args.From)
+
+	if err := args.setDefaults(ctx, s.b); err != nil {
+		return common.Hash{}, err
+	}
+	tx := args.toTransaction()
+
+	var chainID *big.Int
+	if config := s.b.ChainConfig(); config.IsEIP155(s.b.CurrentBlock().Number()) {
+		chainID = config.ChainId
+	}
+	var signed *types.Transaction
+	if passwd == nil {
+		signed, err = wallet.SignTx(account, tx, chainID)
+	} else {
+		signed, err = wallet.SignTxWithPassphrase(account, *passwd, tx, chainID)
+	}
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return SubmitTransaction(ctx, s.b, signed)
+}
+
+// confirm checks whether the pending execution transaction of a proposal was
+// finalized. A successful execution marks the proposal executed, a reverted or
+// dropped one is cleared so the proposal can be executed again.
+func (s *PrivateMultisigAPI) confirm(ctx context.Context, id common.Hash) error {
+	s.lock.Lock()
+	p, ok := s.store.proposals[id]
+	if !ok || p.Pending == nil {
+		s.lock.Unlock()
+		return nil
+	}
+	p = p.copy()
+	s.lock.Unlock()
+
+	done, success, err := s.executionStatus(ctx, p)
+	if err != nil || !done {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Bail out if the proposal changed while its execution was being checked
+	current, ok := s.store.proposals[id]
+	if !ok || current.Pending == nil || *current.Pending != *p.Pending {
+		return nil
+	}
+	if success {
+		current.Executed = current.Pending
+		log.Info("Multisig proposal executed", "id", id, "tx", *current.Executed)
+	} else {
+		log.Warn("Multisig execution failed", "id", id, "tx", *current.Pending)
+	}
+	current.Pending = nil
+	if err := s.store.save(); err != nil {
+		current.Pending, current.Executed = p.Pending, nil
+		return err
+	}
+	return nil
+}
+
+// executionStatus reports whether the pending execution transaction of a proposal
+// was finalized and if so, whether it succeeded. The receipt is used if it is
+// available, otherwise an execution that left the transaction pool is considered
+// successful if it consumed the wallet nonce.
+func (s *PrivateMultisigAPI) executionStatus(ctx context.Context, p *MultisigProposal) (bool, bool, error) {
+	if tx, blockHash, _, index := core.GetTransaction(s.b.ChainDb(), *p.Pending); tx != nil {
+		receipts, err := s.b.GetReceipts(ctx, blockHash)
+		if err != nil {
+			return false, false, err
+		}
+		// Pre-Byzantium receipts carry no status, check the nonce for those
+		if index < uint64(len(receipts)) && len(receipts[index].PostState) == 0 {
+			return true, receipts[index].Status == types.ReceiptStatusSuccessful, nil
+		}
+	} else if s.b.GetPoolTransaction(*p.Pending) != nil {
+		return false, false, nil
+	}
+	nonce := new(big.Int)
+	if err := s.call(ctx, p.Wallet, &nonce, "nonce"); err != nil {
+		return false, false, err
+	}
+	return true, nonce.Cmp(p.Nonce.ToInt()) > 0, nil
+}
+
+// packMultisigExecute assembles the execute call of a proposal, using exactly
+// threshold signatures ordered by increasing signer address.
+func packMultisigExecute(p *MultisigProposal) ([]byte, error) {
+	signers := make([]common.Address, 0, len(p.Signatures))
+	for signer := range p.Signatures {
+		signers = append(signers, signer)
+	}
+	if uint64(len(signers)) < uint64(p.Threshold) {
+		return nil, errors.New("not enough signatures")
+	}
+	sort.Slice(signers, func(i, j int) bool {
+		return bytes.Compare(signers[i][:], signers[j][:]) < 0
+	})
+	signers = signers[:p.Threshold]
+
+	var (
+		sigV = make([]uint8, len(signers))
+		sigR = make([][32]byte, len(signers))
+		sigS = make([][32]byte, len(signers))
+	)
+	for i, signer := range signers {
+		sig := p.Signatures[signer]
+		copy(sigR[i][:], sig[:32])
+		copy(sigS[i][:], sig[32:64])
+		sigV[i] = sig[64]
+	}
+	return multisigABI.Pack("execute", sigV, sigR, sigS, p.To, p.Value.ToInt(), []byte(p.Data))
+}
@@ -0,0 +1,254 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/crypto"
+)
+
+// newTestMultisigProposal creates a proposal signed by the given number of
+// random owners.
+func newTestMultisigProposal(t *testing.T, owners int) *MultisigProposal {
+	var (
+		wallet = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		to     = common.HexToAddress("0x2000000000000000000000000000000000000002")
+		value  = big.NewInt(1000000000000000000)
+		data   = []byte{0xde, 0xad, 0xbe, 0xef}
+		nonce  = big.NewInt(7)
+	)
+	proposal := &MultisigProposal{
+		Id:         multisigHash(wallet, to, value, data, nonce),
+		Wallet:     wallet,
+		To:         to,
+		Value:      (*hexutil.Big)(value),
+		Data:       data,
+		Nonce:      (*hexutil.Big)(nonce),
+		Threshold:  2,
+		Signatures: make(map[common.Address]hexutil.Bytes),
+	}
+	for i := 0; i < owners; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		sig, err := crypto.Sign(proposal.Id[:], key)
+		if err != nil {
+			t.Fatalf("failed to sign proposal: %v", err)
+		}
+		sig[64] += 27
+		proposal.Signatures[crypto.PubkeyToAddress(key.PublicKey)] = sig
+	}
+	return proposal
+}
+
+// Tests that signers are recovered from both 0/1 and 27/28 style signatures
+// and that malformed signatures are rejected.
+func TestMultisigSignerRecovery(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	id := multisigHash(common.Address{1}, common.Address{2}, big.NewInt(3), nil, big.NewInt(4))
+	sig, err := crypto.Sign(id[:], key)
+	if err != nil {
+		t.Fatalf("failed to sign hash: %v", err)
+	}
+	if signer, err := recoverMultisigSigner(id, sig); err != nil || signer != addr {
+		t.Fatalf("raw signature: signer mismatch: have %x, %v, want %x", signer, err, addr)
+	}
+	legacy := common.CopyBytes(sig)
+	legacy[64] += 27
+	if signer, err := recoverMultisigSigner(id, legacy); err != nil || signer != addr {
+		t.Fatalf("legacy signature: signer mismatch: have %x, %v, want %x", signer, err, addr)
+	}
+	if legacy[64] < 27 {
+		t.Fatalf("signature modified during recovery")
+	}
+	if _, err := recoverMultisigSigner(id, sig[:64]); err == nil {
+		t.Fatalf("short signature accepted")
+	}
+	invalid := common.CopyBytes(sig)
+	invalid[64] = 5
+	if _, err := recoverMultisigSigner(id, invalid); err == nil {
+		t.Fatalf("invalid V value accepted")
+	}
+	other := multisigHash(common.Address{1}, common.Address{2}, big.NewInt(3), nil, big.NewInt(5))
+	if signer, err := recoverMultisigSigner(other, sig); err == nil && signer == addr {
+		t.Fatalf("signature recovered for a different nonce")
+	}
+}
+
+// Tests that the wallet getters unpack into the types used by the API.
+func TestMultisigGetterUnpacking(t *testing.T) {
+	nonce := new(big.Int)
+	if err := multisigABI.Unpack(&nonce, "nonce", common.LeftPadBytes([]byte{42}, 32)); err != nil {
+		t.Fatalf("failed to unpack nonce: %v", err)
+	}
+	if nonce.Uint64() != 42 {
+		t.Fatalf("nonce mismatch: have %v, want 42", nonce)
+	}
+	owner := false
+	if err := multisigABI.Unpack(&owner, "isOwner", common.LeftPadBytes([]byte{1}, 32)); err != nil {
+		t.Fatalf("failed to unpack owner flag: %v", err)
+	}
+	if !owner {
+		t.Fatalf("owner flag mismatch: have false, want true")
+	}
+}
+
+// Tests that the execution call uses exactly threshold signatures, ordered by
+// increasing signer address.
+func TestMultisigExecutePacking(t *testing.T) {
+	proposal := newTestMultisigProposal(t, 3)
+
+	signers := make([]common.Address, 0, len(proposal.Signatures))
+	for signer := range proposal.Signatures {
+		signers = append(signers, signer)
+	}
+	sort.Slice(signers, func(i, j int) bool { return bytes.Compare(signers[i][:], signers[j][:]) < 0 })
+
+	var (
+		sigV []uint8
+		sigR [][32]byte
+		sigS [][32]byte
+	)
+	for _, signer := range signers[:2] {
+		sig := proposal.Signatures[signer]
+
+		var r, s [32]byte
+		copy(r[:], sig[:32])
+		copy(s[:], sig[32:64])
+		sigV, sigR, sigS = append(sigV, sig[64]), append(sigR, r), append(sigS, s)
+	}
+	want, err := multisigABI.Pack("execute", sigV, sigR, sigS, proposal.To, proposal.Value.ToInt(), []byte(proposal.Data))
+	if err != nil {
+		t.Fatalf("failed to pack reference call: %v", err)
+	}
+	have, err := packMultisigExecute(proposal)
+	if err != nil {
+		t.Fatalf("failed to pack execution: %v", err)
+	}
+	if !bytes.Equal(have, want) {
+		t.Fatalf("execution call mismatch:\nhave %x\nwant %x", have, want)
+	}
+	proposal.Threshold = 4
+	if _, err := packMultisigExecute(proposal); err == nil {
+		t.Fatalf("execution packed without enough signatures")
+	}
+}
+
+// Tests that proposals survive reopening a persisted store.
+func TestMultisigStorePersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "multisig-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "egem", "multisig.json")
+	store, err := newMultisigStore(path)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	proposal := newTestMultisigProposal(t, 2)
+	executed := common.HexToHash("0x01")
+	proposal.Executed = &executed
+
+	pending := newTestMultisigProposal(t, 2)
+	pending.Nonce = (*hexutil.Big)(big.NewInt(8))
+	pending.Id = multisigHash(pending.Wallet, pending.To, pending.Value.ToInt(), pending.Data, pending.Nonce.ToInt())
+	submitted := common.HexToHash("0x02")
+	pending.Pending = &submitted
+	store.proposals[pending.Id] = pending
+
+	store.proposals[proposal.Id] = proposal
+	if err := store.save(); err != nil {
+		t.Fatalf("failed to save store: %v", err)
+	}
+	reopened, err := newMultisigStore(path)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	if len(reopened.proposals) != 2 {
+		t.Fatalf("proposal count mismatch: have %d, want 2", len(reopened.proposals))
+	}
+	if have := reopened.proposals[proposal.Id]; !reflect.DeepEqual(have, proposal) {
+		t.Fatalf("proposal mismatch:\nhave %+v\nwant %+v", have, proposal)
+	}
+	if have := reopened.proposals[pending.Id]; !reflect.DeepEqual(have, pending) {
+		t.Fatalf("pending proposal mismatch:\nhave %+v\nwant %+v", have, pending)
+	}
+	// Corrupt stores must be reported instead of silently overwritten
+	if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatalf("failed to corrupt store: %v", err)
+	}
+	if _, err := newMultisigStore(path); err == nil {
+		t.Fatalf("corrupt store loaded")
+	}
+}
+
+// Tests that incomplete or inconsistent persisted proposals are rejected when
+// loading the store instead of crashing the API later on.
+func TestMultisigStoreValidation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "multisig-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name   string
+		mutate func(p *MultisigProposal)
+	}{
+		{"nil nonce", func(p *MultisigProposal) { p.Nonce = nil }},
+		{"nil value", func(p *MultisigProposal) { p.Value = nil }},
+		{"no threshold", func(p *MultisigProposal) { p.Threshold = 0 }},
+		{"id mismatch", func(p *MultisigProposal) { p.Nonce = (*hexutil.Big)(big.NewInt(8)) }},
+		{"short signature", func(p *MultisigProposal) {
+			for signer, sig := range p.Signatures {
+				p.Signatures[signer] = sig[:64]
+			}
+		}},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, "multisig.json")
+		proposal := newTestMultisigProposal(t, 2)
+		tt.mutate(proposal)
+
+		blob, err := json.Marshal([]*MultisigProposal{proposal})
+		if err != nil {
+			t.Fatalf("%s: failed to encode proposal: %v", tt.name, err)
+		}
+		if err := ioutil.WriteFile(path, blob, 0600); err != nil {
+			t.Fatalf("%s: failed to write store: %v", tt.name, err)
+		}
+		if _, err := newMultisigStore(path); err == nil {
+			t.Errorf("%s: invalid proposal loaded", tt.name)
+		}
+	}
+}
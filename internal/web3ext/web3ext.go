@@ -22,13 +22,16 @@ var Modules = map[string]string{
 	"chequebook": Chequebook_JS,
 	"clique":     Clique_JS,
 	"debug":      Debug_JS,
+	"egem":       Egem_JS,
 	"eth":        Eth_JS,
 	"miner":      Miner_JS,
+	"multisig":   Multisig_JS,
 	"net":        Net_JS,
 	"personal":   Personal_JS,
 	"rpc":        RPC_JS,
 	"shh":        Shh_JS,
 	"swarmfs":    SWARMFS_JS,
+	"trace":      Trace_JS,
 	"txpool":     TxPool_JS,
 }
 
@@ -373,6 +376,12 @@ web3._extend({
 			call: 'debug_storageRangeAt',
 			params: 5,
 		}),
+		new web3._extend.Method({
+			name: 'devFundReport',
+			call: 'debug_devFundReport',
+			params: 2,
+			inputFormatter: [null, null],
+		}),
 		new web3._extend.Method({
 			name: 'getModifiedAccountsByNumber',
 			call: 'debug_getModifiedAccountsByNumber',
@@ -423,6 +432,20 @@ web3._extend({
 			call: 'eth_getRawTransactionByHash',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getProof',
+			call: 'eth_getProof',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getBlockReward',
+			call: function(args) {
+				return (web3._extend.utils.isString(args[0]) && args[0].indexOf('0x') === 0 && args[0].length === 66) ? 'eth_getBlockRewardByHash' : 'eth_getBlockReward';
+			},
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'getRawTransactionFromBlock',
 			call: function(args) {
@@ -449,6 +472,40 @@ web3._extend({
 });
 `
 
+const Egem_JS = `
+web3._extend({
+	property: 'egem',
+	methods: [
+		new web3._extend.Method({
+			name: 'getBlockReward',
+			call: function(args) {
+				return (web3._extend.utils.isString(args[0]) && args[0].indexOf('0x') === 0 && args[0].length === 66) ? 'egem_getBlockRewardByHash' : 'egem_getBlockReward';
+			},
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'totalSupply',
+			call: 'egem_totalSupply',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'circulatingSupply',
+			call: 'egem_circulatingSupply',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+	],
+	properties: [
+		new web3._extend.Property({
+			name: 'rewardSchedule',
+			getter: 'egem_rewardSchedule'
+		}),
+	]
+});
+`
+
 const Miner_JS = `
 web3._extend({
 	property: 'miner',
@@ -489,6 +546,52 @@ web3._extend({
 });
 `
 
+const Multisig_JS = `
+web3._extend({
+	property: 'multisig',
+	methods: [
+		new web3._extend.Method({
+			name: 'propose',
+			call: 'multisig_propose',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getProposal',
+			call: 'multisig_proposal',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'addSignature',
+			call: 'multisig_addSignature',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'sign',
+			call: 'multisig_sign',
+			params: 3,
+			inputFormatter: [null, web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'execute',
+			call: 'multisig_execute',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'remove',
+			call: 'multisig_remove',
+			params: 1
+		}),
+	],
+	properties: [
+		new web3._extend.Property({
+			name: 'proposals',
+			getter: 'multisig_proposals'
+		}),
+	]
+});
+`
+
 const Net_JS = `
 web3._extend({
 	property: 'net',
@@ -608,7 +711,25 @@ web3._extend({
 const TxPool_JS = `
 web3._extend({
 	property: 'txpool',
-	methods: [],
+	methods: [
+		new web3._extend.Method({
+			name: 'remove',
+			call: 'txpool_remove',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'removeSender',
+			call: 'txpool_removeSender',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'setPriceBump',
+			call: 'txpool_setPriceBump',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+	],
 	properties:
 	[
 		new web3._extend.Property({
@@ -631,3 +752,33 @@ web3._extend({
 	]
 });
 `
+
+const Trace_JS = `
+web3._extend({
+	property: 'trace',
+	methods: [
+		new web3._extend.Method({
+			name: 'block',
+			call: 'trace_block',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'transaction',
+			call: 'trace_transaction',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'filter',
+			call: 'trace_filter',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'replayBlockTransactions',
+			call: 'trace_replayBlockTransactions',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+	]
+});
+`
@@ -18,6 +18,7 @@ package les
 
 import (
 	"context"
+	"errors"
 	"math/big"
 
 	"github.com/TeamEGEM/go-egem/accounts"
@@ -133,6 +134,26 @@ func (b *LesApiBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Sub
 	return b.eth.txPool.SubscribeTxPreEvent(ch)
 }
 
+func (b *LesApiBackend) RemovePoolTransaction(txHash common.Hash) bool {
+	if b.eth.txPool.GetTransaction(txHash) == nil {
+		return false
+	}
+	b.eth.txPool.RemoveTx(txHash)
+	return true
+}
+
+func (b *LesApiBackend) RemovePoolSender(addr common.Address) int {
+	pending, queued := b.eth.txPool.Content()
+	txs := append(pending[addr], queued[addr]...)
+
+	b.eth.txPool.RemoveTransactions(txs)
+	return len(txs)
+}
+
+func (b *LesApiBackend) SetPoolPriceBump(bump uint64) error {
+	return errors.New("transaction replacement not supported by light clients")
+}
+
 func (b *LesApiBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
 	return b.eth.blockchain.SubscribeChainEvent(ch)
 }
@@ -177,6 +198,10 @@ func (b *LesApiBackend) AccountManager() *accounts.Manager {
 	return b.eth.accountManager
 }
 
+func (b *LesApiBackend) MultisigStore() string {
+	return b.eth.config.MultisigStore
+}
+
 func (b *LesApiBackend) BloomStatus() (uint64, uint64) {
 	if b.eth.bloomIndexer == nil {
 		return 0, 0
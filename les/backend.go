@@ -80,7 +80,7 @@ type LightEthereum struct {
 }
 
 func New(ctx *node.ServiceContext, config *eth.Config) (*LightEthereum, error) {
-	chainDb, err := eth.CreateDB(ctx, config, "lightchaindata")
+	chainDb, err := eth.CreateDB(ctx, config, "lightchaindata", "")
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +89,13 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightEthereum, error) {
 		return nil, genesisErr
 	}
 	log.Info("Initialised chain configuration", "config", chainConfig)
+	if err := core.CheckChainConfig(chainConfig); err != nil {
+		return nil, err
+	}
 
+	if config.MultisigStore != "" {
+		config.MultisigStore = ctx.ResolvePath(config.MultisigStore)
+	}
 	peers := newPeerSet()
 	quitSync := make(chan struct{})
 
@@ -0,0 +1,153 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/TeamEGEM/go-egem/metrics"
+)
+
+var (
+	// quantiles are the quantiles exported for histograms and timers.
+	quantiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999}
+
+	// resettingQuantiles are the quantiles exported for resetting timers, which
+	// are calculated on a percent scale.
+	resettingQuantiles = []float64{50, 95, 99}
+)
+
+// collector accumulates metrics in the Prometheus text exposition format.
+type collector struct {
+	buff *bytes.Buffer
+}
+
+// newCollector creates a new Prometheus metric aggregator.
+func newCollector() *collector {
+	return &collector{buff: new(bytes.Buffer)}
+}
+
+// Add converts a metric of the go-metrics registry into its Prometheus
+// representation. Unsupported metric types are silently ignored.
+func (c *collector) Add(name string, i interface{}) {
+	switch m := i.(type) {
+	case metrics.Counter:
+		c.addCounter(name, m.Snapshot())
+	case metrics.Gauge:
+		c.addGauge(name, m.Snapshot())
+	case metrics.GaugeFloat64:
+		c.addGaugeFloat64(name, m.Snapshot())
+	case metrics.Histogram:
+		c.addHistogram(name, m.Snapshot())
+	case metrics.Meter:
+		c.addMeter(name, m.Snapshot())
+	case metrics.Timer:
+		c.addTimer(name, m.Snapshot())
+	case metrics.ResettingTimer:
+		c.addResettingTimer(name, m.Snapshot())
+	}
+}
+
+// addCounter exports a counter as a gauge, since go-metrics counters may be
+// decremented, which Prometheus counters must never be.
+func (c *collector) addCounter(name string, m metrics.Counter) {
+	c.writeType(name, "gauge")
+	c.writeValue(name, "", float64(m.Count()))
+}
+
+func (c *collector) addGauge(name string, m metrics.Gauge) {
+	c.writeType(name, "gauge")
+	c.writeValue(name, "", float64(m.Value()))
+}
+
+func (c *collector) addGaugeFloat64(name string, m metrics.GaugeFloat64) {
+	c.writeType(name, "gauge")
+	c.writeValue(name, "", m.Value())
+}
+
+// addMeter exports the number of events marked on a meter as a counter. The
+// rates are left to be derived by Prometheus.
+func (c *collector) addMeter(name string, m metrics.Meter) {
+	c.writeType(name, "counter")
+	c.writeValue(name, "", float64(m.Count()))
+}
+
+func (c *collector) addHistogram(name string, m metrics.Histogram) {
+	c.writeSummary(name, quantiles, m.Percentiles(quantiles), float64(m.Sum()), m.Count())
+}
+
+func (c *collector) addTimer(name string, m metrics.Timer) {
+	c.writeSummary(name, quantiles, m.Percentiles(quantiles), float64(m.Sum()), m.Count())
+}
+
+// addResettingTimer exports the values gathered by a resetting timer since the
+// previous export, if there are any.
+func (c *collector) addResettingTimer(name string, m metrics.ResettingTimer) {
+	values := m.Values()
+	if len(values) == 0 {
+		return
+	}
+	var sum int64
+	for _, value := range values {
+		sum += value
+	}
+	bounds := m.Percentiles(resettingQuantiles)
+
+	ps := make([]float64, len(bounds))
+	qs := make([]float64, len(resettingQuantiles))
+	for i := range bounds {
+		ps[i] = float64(bounds[i])
+		qs[i] = resettingQuantiles[i] / 100
+	}
+	c.writeSummary(name, qs, ps, float64(sum), int64(len(values)))
+}
+
+// writeSummary exports a distribution as a summary along with its quantiles.
+func (c *collector) writeSummary(name string, quantiles []float64, values []float64, sum float64, count int64) {
+	c.writeType(name, "summary")
+	for i, q := range quantiles {
+		c.writeValue(name, fmt.Sprintf(`{quantile="%s"}`, strconv.FormatFloat(q, 'g', -1, 64)), values[i])
+	}
+	c.writeValue(name+"_sum", "", sum)
+	c.writeValue(name+"_count", "", float64(count))
+}
+
+func (c *collector) writeType(name string, kind string) {
+	fmt.Fprintf(c.buff, "# TYPE %s %s\n", name, kind)
+}
+
+func (c *collector) writeValue(name string, labels string, value float64) {
+	fmt.Fprintf(c.buff, "%s%s %s\n", name, labels, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// sanitizeName converts a go-metrics metric name into a valid Prometheus one,
+// replacing all the characters outside of [a-zA-Z0-9_:] with underscores and
+// prefixing names which would start with a digit.
+func sanitizeName(name string) string {
+	out := []byte(name)
+	for i, c := range out {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == ':') {
+			out[i] = '_'
+		}
+	}
+	if len(out) == 0 || (out[0] >= '0' && out[0] <= '9') {
+		out = append([]byte{'_'}, out...)
+	}
+	return string(out)
+}
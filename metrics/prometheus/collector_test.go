@@ -0,0 +1,139 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package prometheus
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TeamEGEM/go-egem/metrics"
+)
+
+func init() {
+	metrics.Enabled = true
+}
+
+// Tests that all metric types are exported in the text exposition format.
+func TestCollect(t *testing.T) {
+	reg := metrics.NewRegistry()
+
+	counter := metrics.NewRegisteredCounter("test/counter", reg)
+	counter.Inc(12345)
+
+	gauge := metrics.NewRegisteredGauge("test/gauge", reg)
+	gauge.Update(23456)
+
+	gaugeFloat64 := metrics.NewRegisteredGaugeFloat64("test/gauge_float64", reg)
+	gaugeFloat64.Update(34567.89)
+
+	histogram := metrics.NewRegisteredHistogram("test/histogram", reg, metrics.NewUniformSample(100))
+	for i := int64(1); i <= 4; i++ {
+		histogram.Update(i)
+	}
+	meter := metrics.NewRegisteredMeter("test/meter", reg)
+	defer meter.Stop()
+	meter.Mark(9999999)
+
+	timer := metrics.NewRegisteredTimer("test/timer", reg)
+	defer timer.Stop()
+	timer.Update(20 * time.Millisecond)
+
+	resetting := metrics.NewRegisteredResettingTimer("test/resetting_timer", reg)
+	resetting.Update(10 * time.Millisecond)
+	resetting.Update(30 * time.Millisecond)
+
+	emptyResetting := metrics.NewRegisteredResettingTimer("test/empty_resetting_timer", reg)
+	emptyResetting.Update(time.Millisecond)
+	emptyResetting.Snapshot()
+
+	want := `# TYPE test_counter gauge
+test_counter 12345
+# TYPE test_gauge gauge
+test_gauge 23456
+# TYPE test_gauge_float64 gauge
+test_gauge_float64 34567.89
+# TYPE test_histogram summary
+test_histogram{quantile="0.5"} 2.5
+test_histogram{quantile="0.75"} 3.75
+test_histogram{quantile="0.95"} 4
+test_histogram{quantile="0.99"} 4
+test_histogram{quantile="0.999"} 4
+test_histogram{quantile="0.9999"} 4
+test_histogram_sum 10
+test_histogram_count 4
+# TYPE test_meter counter
+test_meter 9999999
+# TYPE test_resetting_timer summary
+test_resetting_timer{quantile="0.5"} 10000000
+test_resetting_timer{quantile="0.95"} 30000000
+test_resetting_timer{quantile="0.99"} 30000000
+test_resetting_timer_sum 40000000
+test_resetting_timer_count 2
+# TYPE test_timer summary
+test_timer{quantile="0.5"} 20000000
+test_timer{quantile="0.75"} 20000000
+test_timer{quantile="0.95"} 20000000
+test_timer{quantile="0.99"} 20000000
+test_timer{quantile="0.999"} 20000000
+test_timer{quantile="0.9999"} 20000000
+test_timer_sum 20000000
+test_timer_count 1
+`
+	if have := string(Collect(reg)); have != want {
+		t.Errorf("exposition mismatch:\nhave:\n%s\nwant:\n%s", have, want)
+	}
+	// Resetting timers are reset by the export, so they should be gone now
+	if have := string(Collect(reg)); have == want {
+		t.Errorf("resetting timer not reset by export")
+	}
+}
+
+// Tests that the metrics are served over HTTP with the exposition content type.
+func TestHandler(t *testing.T) {
+	reg := metrics.NewRegistry()
+	metrics.NewRegisteredGauge("test/gauge", reg).Update(1)
+
+	rec := httptest.NewRecorder()
+	Handler(reg).ServeHTTP(rec, httptest.NewRequest("GET", "/debug/metrics/prometheus", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4" {
+		t.Errorf("content type mismatch: have %q", ct)
+	}
+	if body := rec.Body.String(); body != "# TYPE test_gauge gauge\ntest_gauge 1\n" {
+		t.Errorf("body mismatch: have %q", body)
+	}
+}
+
+// Tests that metric names are sanitised into the valid Prometheus charset.
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"eth/downloader/headers/in", "eth_downloader_headers_in"},
+		{"p2p/InboundTraffic", "p2p_InboundTraffic"},
+		{"chain:head-block.number", "chain:head_block_number"},
+		{"3rd/party", "_3rd_party"},
+		{"ünicode", "__nicode"},
+		{"", "_"},
+	}
+	for _, tt := range tests {
+		if have := sanitizeName(tt.name); have != tt.want {
+			t.Errorf("%q: sanitized name mismatch: have %q, want %q", tt.name, have, tt.want)
+		}
+	}
+}
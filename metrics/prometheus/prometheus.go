@@ -0,0 +1,62 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package prometheus exposes go-metrics into a Prometheus format.
+package prometheus
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/TeamEGEM/go-egem/log"
+	"github.com/TeamEGEM/go-egem/metrics"
+)
+
+// Handler returns an HTTP handler which dumps the metrics of the registry in
+// the Prometheus text exposition format.
+func Handler(reg metrics.Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(Collect(reg))
+	})
+}
+
+// Collect converts every metric of the registry into the Prometheus text
+// exposition format, ordered by their sanitised names.
+func Collect(reg metrics.Registry) []byte {
+	// Gather and sort the metrics to make the output stable
+	gathered := make(map[string]interface{})
+	reg.Each(func(name string, i interface{}) {
+		sanitized := sanitizeName(name)
+		if _, ok := gathered[sanitized]; ok {
+			log.Debug("Duplicate Prometheus metric name", "name", name, "sanitized", sanitized)
+			return
+		}
+		gathered[sanitized] = i
+	})
+	names := make([]string, 0, len(gathered))
+	for name := range gathered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Aggregate all the metrics into a single exposition
+	c := newCollector()
+	for _, name := range names {
+		c.Add(name, gathered[name])
+	}
+	return c.buff.Bytes()
+}
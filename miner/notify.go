@@ -0,0 +1,108 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/TeamEGEM/go-egem/event"
+	"github.com/TeamEGEM/go-egem/log"
+)
+
+// workNotifyTimeout is the time allowed for delivering a work package to one of
+// the notification URLs.
+const workNotifyTimeout = time.Second
+
+// WorkNotifier pushes the work packages of a remote agent to a list of HTTP URLs
+// as soon as new sealing work is committed, saving external miners the latency
+// of polling for work.
+type WorkNotifier struct {
+	agent  *RemoteAgent
+	urls   []string
+	client *http.Client
+
+	workCh  chan *Work
+	workSub event.Subscription
+	wg      sync.WaitGroup
+}
+
+// NewWorkNotifier creates a notifier posting the work of a remote agent to the
+// given URLs. The agent must be registered with the miner to receive work.
+func NewWorkNotifier(agent *RemoteAgent, urls []string) *WorkNotifier {
+	return &WorkNotifier{
+		agent:  agent,
+		urls:   urls,
+		client: &http.Client{Timeout: workNotifyTimeout},
+	}
+}
+
+// Start begins pushing new work packages to the notification URLs.
+func (n *WorkNotifier) Start() {
+	n.workCh = make(chan *Work, 1)
+	n.workSub = n.agent.SubscribeWork(n.workCh)
+
+	n.wg.Add(1)
+	go n.loop()
+}
+
+// Stop terminates the notifications, waiting for pending deliveries.
+func (n *WorkNotifier) Stop() {
+	n.workSub.Unsubscribe()
+	n.wg.Wait()
+}
+
+// loop posts every new work package to all the notification URLs concurrently.
+func (n *WorkNotifier) loop() {
+	defer n.wg.Done()
+
+	for {
+		select {
+		case work := <-n.workCh:
+			if work == nil {
+				continue
+			}
+			blob, err := json.Marshal(WorkPackage(work.Block))
+			if err != nil {
+				log.Error("Failed to encode work package", "err", err)
+				continue
+			}
+			for _, url := range n.urls {
+				n.wg.Add(1)
+				go n.notify(url, blob)
+			}
+
+		case <-n.workSub.Err():
+			return
+		}
+	}
+}
+
+// notify posts a single work package to a notification URL.
+func (n *WorkNotifier) notify(url string, blob []byte) {
+	defer n.wg.Done()
+
+	res, err := n.client.Post(url, "application/json", bytes.NewReader(blob))
+	if err != nil {
+		log.Warn("Failed to notify remote miner", "url", url, "err", err)
+		return
+	}
+	res.Body.Close()
+}
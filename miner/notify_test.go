@@ -0,0 +1,122 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TeamEGEM/go-egem/consensus/ethash"
+	"github.com/TeamEGEM/go-egem/core/types"
+)
+
+// Tests that new work packages are posted to all the notification URLs, and
+// that the pushed work can be submitted without polling for it.
+func TestWorkNotifier(t *testing.T) {
+	packages := make(chan [4]string, 2)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var work [4]string
+		if err := json.NewDecoder(r.Body).Decode(&work); err != nil {
+			t.Errorf("failed to decode work package: %v", err)
+		}
+		packages <- work
+	})
+	first, second := httptest.NewServer(handler), httptest.NewServer(handler)
+	defer first.Close()
+	defer second.Close()
+
+	results := make(chan *Result, 1)
+
+	agent := NewRemoteAgent(nil, ethash.NewFaker())
+	agent.SetReturnCh(results)
+	agent.Start()
+	defer agent.Stop()
+
+	notifier := NewWorkNotifier(agent, []string{first.URL, second.URL})
+	notifier.Start()
+	defer notifier.Stop()
+
+	block := pushWork(agent, 10)
+	for i := 0; i < 2; i++ {
+		select {
+		case work := <-packages:
+			if work != WorkPackage(block) {
+				t.Errorf("work package mismatch: have %v, want %v", work, WorkPackage(block))
+			}
+			if work[3] != "0xa" {
+				t.Errorf("block number mismatch: have %s, want 0xa", work[3])
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("notification %d not received", i)
+		}
+	}
+	if !agent.SubmitWork(types.BlockNonce{}, block.MixDigest(), block.HashNoNonce()) {
+		t.Fatalf("pushed work not accepted")
+	}
+	select {
+	case <-results:
+	case <-time.After(time.Second):
+		t.Fatalf("sealed block not returned")
+	}
+}
+
+// Tests that nil work reaching the subscribers is skipped instead of crashing
+// the notification loops.
+func TestWorkNotifierNilWork(t *testing.T) {
+	packages := make(chan [4]string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var work [4]string
+		if err := json.NewDecoder(r.Body).Decode(&work); err != nil {
+			t.Errorf("failed to decode work package: %v", err)
+		}
+		packages <- work
+	}))
+	defer server.Close()
+
+	agent, stratum, _ := newStratumTester(t, ethash.NewFaker(), DefaultShareDifficulty)
+	defer agent.Stop()
+	defer stratum.Stop()
+
+	notifier := NewWorkNotifier(agent, []string{server.URL})
+	notifier.Start()
+	defer notifier.Stop()
+
+	notifier.workCh <- nil
+	stratum.workCh <- nil
+
+	block := pushWork(agent, 3)
+	select {
+	case work := <-packages:
+		if work != WorkPackage(block) {
+			t.Errorf("work package mismatch: have %v, want %v", work, WorkPackage(block))
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("notification not received after nil work")
+	}
+	for i := 0; stratum.currentJob() == nil; i++ {
+		if i == 100 {
+			t.Fatalf("job not created after nil work")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if job := stratum.currentJob(); job.id != "1" || job.hash != block.HashNoNonce() {
+		t.Fatalf("job mismatch: have %s/%x, want 1/%x", job.id, job.hash, block.HashNoNonce())
+	}
+}
@@ -24,9 +24,11 @@ import (
 	"time"
 
 	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
 	"github.com/TeamEGEM/go-egem/consensus"
 	"github.com/TeamEGEM/go-egem/consensus/ethash"
 	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/event"
 	"github.com/TeamEGEM/go-egem/log"
 )
 
@@ -50,6 +52,9 @@ type RemoteAgent struct {
 	hashrateMu sync.RWMutex
 	hashrate   map[common.Hash]hashrate
 
+	workFeed event.Feed
+	scope    event.SubscriptionScope
+
 	running int32 // running indicates whether the agent is active. Call atomically
 }
 
@@ -83,7 +88,10 @@ func (a *RemoteAgent) Start() {
 	}
 	a.quitCh = make(chan struct{})
 	a.workCh = make(chan *Work, 1)
-	go a.loop(a.workCh, a.quitCh)
+
+	feedCh := make(chan *Work, 1)
+	go a.loop(a.workCh, feedCh, a.quitCh)
+	go a.feed(feedCh, a.quitCh)
 }
 
 func (a *RemoteAgent) Stop() {
@@ -94,6 +102,13 @@ func (a *RemoteAgent) Stop() {
 	close(a.workCh)
 }
 
+// SubscribeWork registers a subscription for new sealing work, pushed whenever
+// the miner commits a new block to be sealed. The work is registered for remote
+// submissions before it's sent.
+func (a *RemoteAgent) SubscribeWork(ch chan<- *Work) event.Subscription {
+	return a.scope.Track(a.workFeed.Subscribe(ch))
+}
+
 // GetHashRate returns the accumulated hashrate of all identifier combined
 func (a *RemoteAgent) GetHashRate() (tot int64) {
 	a.hashrateMu.RLock()
@@ -114,16 +129,8 @@ func (a *RemoteAgent) GetWork() ([3]string, error) {
 
 	if a.currentWork != nil {
 		block := a.currentWork.Block
-
-		res[0] = block.HashNoNonce().Hex()
-		seedHash := ethash.SeedHash(block.NumberU64())
-		res[1] = common.BytesToHash(seedHash).Hex()
-		// Calculate the "target" to be returned to the external miner
-		n := big.NewInt(1)
-		n.Lsh(n, 255)
-		n.Div(n, block.Difficulty())
-		n.Lsh(n, 1)
-		res[2] = common.BytesToHash(n.Bytes()).Hex()
+		work := WorkPackage(block)
+		copy(res[:], work[:3])
 
 		a.work[block.HashNoNonce()] = a.currentWork
 		return res, nil
@@ -131,6 +138,36 @@ func (a *RemoteAgent) GetWork() ([3]string, error) {
 	return res, errors.New("No work available yet, don't panic.")
 }
 
+// WorkPackage returns the work package of a block handed out to external miners:
+// result[0], 32 bytes hex encoded current block header pow-hash
+// result[1], 32 bytes hex encoded seed hash used for DAG
+// result[2], 32 bytes hex encoded boundary condition ("target"), 2^256/difficulty
+// result[3], hex encoded block number
+func WorkPackage(block *types.Block) [4]string {
+	var res [4]string
+
+	res[0] = block.HashNoNonce().Hex()
+	seedHash := ethash.SeedHash(block.NumberU64())
+	res[1] = common.BytesToHash(seedHash).Hex()
+	// Calculate the "target" to be returned to the external miner
+	n := big.NewInt(1)
+	n.Lsh(n, 255)
+	n.Div(n, block.Difficulty())
+	n.Lsh(n, 1)
+	res[2] = common.BytesToHash(n.Bytes()).Hex()
+	res[3] = hexutil.EncodeBig(block.Number())
+
+	return res
+}
+
+// pendingWork retrieves a previously handed out work package by its sealing hash.
+func (a *RemoteAgent) pendingWork(hash common.Hash) *Work {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.work[hash]
+}
+
 // SubmitWork tries to inject a pow solution into the remote agent, returning
 // whether the solution was accepted or not (not can be both a bad pow as well as
 // any other error, like no work pending).
@@ -168,7 +205,7 @@ func (a *RemoteAgent) SubmitWork(nonce types.BlockNonce, mixDigest, hash common.
 // Note, the reason the work and quit channels are passed as parameters is because
 // RemoteAgent.Start() constantly recreates these channels, so the loop code cannot
 // assume data stability in these member fields.
-func (a *RemoteAgent) loop(workCh chan *Work, quitCh chan struct{}) {
+func (a *RemoteAgent) loop(workCh chan *Work, feedCh chan *Work, quitCh chan struct{}) {
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
@@ -176,10 +213,29 @@ func (a *RemoteAgent) loop(workCh chan *Work, quitCh chan struct{}) {
 		select {
 		case <-quitCh:
 			return
-		case work := <-workCh:
+		case work, ok := <-workCh:
+			if !ok {
+				// Stop closes the work channel alongside the quit channel
+				return
+			}
 			a.mu.Lock()
 			a.currentWork = work
+			if work != nil && a.scope.Count() > 0 {
+				a.work[work.Block.HashNoNonce()] = work
+			}
 			a.mu.Unlock()
+
+			if work == nil {
+				continue
+			}
+
+			// Hand the work to the feed without waiting for slow subscribers,
+			// replacing any work they haven't picked up yet
+			select {
+			case <-feedCh:
+			default:
+			}
+			feedCh <- work
 		case <-ticker.C:
 			// cleanup
 			a.mu.Lock()
@@ -200,3 +256,16 @@ func (a *RemoteAgent) loop(workCh chan *Work, quitCh chan struct{}) {
 		}
 	}
 }
+
+// feed delivers new work to the feed subscribers, decoupled from the agent loop
+// so a slow subscriber can't delay accepting new work or submissions.
+func (a *RemoteAgent) feed(feedCh chan *Work, quitCh chan struct{}) {
+	for {
+		select {
+		case work := <-feedCh:
+			a.workFeed.Send(work)
+		case <-quitCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,895 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/consensus/ethash"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/crypto"
+	"github.com/TeamEGEM/go-egem/event"
+	"github.com/TeamEGEM/go-egem/log"
+)
+
+const (
+	// stratumVersion is the protocol version announced to EthereumStratum miners.
+	stratumVersion = "EthereumStratum/1.0.0"
+
+	// stratumExtraNonceSize is the number of leading nonce bytes assigned to each
+	// EthereumStratum session, splitting the nonce space between the miners.
+	stratumExtraNonceSize = 2
+
+	// stratumJobHistory is the number of recent jobs for which shares are still
+	// accepted after new work has been pushed.
+	stratumJobHistory = 8
+
+	// stratumHashrateWindow is the period over which accepted shares are averaged
+	// to estimate the hashrate of a worker.
+	stratumHashrateWindow = time.Minute
+
+	// stratumHashrateInterval is the interval at which worker hashrates are
+	// reported to the remote agent, which expires them after 10 seconds.
+	stratumHashrateInterval = 5 * time.Second
+
+	// stratumReportedHashrateTTL is the time a hashrate reported by the miner
+	// itself takes precedence over the estimate from the accepted shares.
+	stratumReportedHashrateTTL = 10 * time.Second
+
+	// stratumIdleTimeout is the time after which a silent connection is dropped.
+	stratumIdleTimeout = 10 * time.Minute
+
+	// stratumWriteTimeout is the time allowed for writing a message to a miner.
+	stratumWriteTimeout = 10 * time.Second
+
+	// stratumMaxRequestSize is the maximum size of a single request line.
+	stratumMaxRequestSize = 4096
+)
+
+// DefaultShareDifficulty is the default share difficulty of the Stratum server,
+// equal to a difficulty of 1 in EthereumStratum units.
+const DefaultShareDifficulty = 1 << 32
+
+// StratumConfig are the configuration parameters of the Stratum mining server.
+type StratumConfig struct {
+	Addr       string `toml:",omitempty"` // Listening address of the server (empty = disabled)
+	Difficulty uint64 // Default share difficulty in hashes, overridable by miners with a "d=" password
+}
+
+// DefaultStratumConfig contains the default settings of the Stratum server.
+var DefaultStratumConfig = StratumConfig{
+	Difficulty: DefaultShareDifficulty,
+}
+
+var (
+	// stratumMaxTarget is the share target of difficulty one.
+	stratumMaxTarget = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+
+	// stratumDifficultyUnit is the number of hashes of a difficulty of one in the
+	// EthereumStratum protocol.
+	stratumDifficultyUnit = new(big.Float).SetInt64(1 << 32)
+)
+
+// shareVerifier is implemented by consensus engines capable of verifying shares
+// below the block difficulty.
+type shareVerifier interface {
+	VerifyShare(header *types.Header, difficulty *big.Int) (common.Hash, bool, error)
+}
+
+// stratumError is an error reported to a miner.
+type stratumError struct {
+	code    int
+	message string
+}
+
+func (e *stratumError) Error() string { return e.message }
+
+// Errors reported to Stratum miners, using the EthereumStratum error codes.
+var (
+	errStratumUnknown       = &stratumError{20, "Other/Unknown"}
+	errStratumJobNotFound   = &stratumError{21, "Job not found (=stale)"}
+	errStratumDuplicate     = &stratumError{22, "Duplicate share"}
+	errStratumLowDifficulty = &stratumError{23, "Low difficulty share"}
+	errStratumUnauthorized  = &stratumError{24, "Unauthorized worker"}
+	errStratumNotSubscribed = &stratumError{25, "Not subscribed"}
+	errStratumNoWork        = &stratumError{0, "No work available yet"}
+	errStratumBadParams     = &stratumError{-32602, "Invalid params"}
+	errStratumBadMethod     = &stratumError{-32601, "Method not found"}
+	errStratumBadMixDigest  = &stratumError{23, "Invalid mix digest"}
+)
+
+// stratumDialect is the Stratum protocol flavour spoken by a session.
+type stratumDialect int
+
+const (
+	dialectUnknown stratumDialect = iota // No handshake received yet
+	dialectStratum                       // EthereumStratum/1.0.0 (NiceHash)
+	dialectProxy                         // Legacy eth-proxy, wrapping eth_getWork/eth_submitWork
+)
+
+// stratumRequest is a request sent by a miner.
+type stratumRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Worker string          `json:"worker,omitempty"`
+}
+
+// stratumResponse is the reply to a miner's request, or a work push in the
+// eth-proxy dialect.
+type stratumResponse struct {
+	ID      json.RawMessage `json:"id"`
+	Version string          `json:"jsonrpc,omitempty"`
+	Result  interface{}     `json:"result"`
+	Error   interface{}     `json:"error"`
+}
+
+// stratumNotification is a server initiated EthereumStratum message.
+type stratumNotification struct {
+	ID     interface{}   `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+// stratumJob is a sealing task handed out to the miners.
+type stratumJob struct {
+	id         string
+	hash       common.Hash // Sealing hash of the block (excluding nonce and mix digest)
+	seed       common.Hash // Ethash seed hash of the block's epoch
+	number     uint64      // Number of the block being sealed
+	difficulty *big.Int    // Block difficulty, the upper bound of any share difficulty
+
+	shares map[uint64]struct{} // Nonces already submitted, to reject duplicates
+}
+
+// StratumServer is a Stratum mining server, handing out the sealing work of a
+// remote agent to pooled miners over TCP. Both the EthereumStratum/1.0.0 and the
+// legacy eth-proxy dialects are supported.
+type StratumServer struct {
+	config   StratumConfig
+	agent    *RemoteAgent
+	verifier shareVerifier
+
+	listener net.Listener
+	workCh   chan *Work
+	workSub  event.Subscription
+
+	lock      sync.Mutex
+	sessions  map[*stratumSession]struct{}
+	jobs      []*stratumJob // Recent jobs still accepting shares, newest last
+	nextJob   uint64
+	nextNonce uint32
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewStratumServer creates a Stratum server distributing the work of a remote
+// agent. The agent must be registered with the miner to receive work.
+func NewStratumServer(agent *RemoteAgent, config StratumConfig) (*StratumServer, error) {
+	verifier, ok := agent.engine.(shareVerifier)
+	if !ok {
+		return nil, errors.New("consensus engine cannot verify shares")
+	}
+	if config.Difficulty == 0 {
+		config.Difficulty = DefaultShareDifficulty
+	}
+	return &StratumServer{
+		config:   config,
+		agent:    agent,
+		verifier: verifier,
+		sessions: make(map[*stratumSession]struct{}),
+	}, nil
+}
+
+// Start opens the listening socket and starts serving miners.
+func (s *StratumServer) Start() error {
+	listener, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.quit = make(chan struct{})
+	s.workCh = make(chan *Work, 1)
+	s.workSub = s.agent.SubscribeWork(s.workCh)
+
+	s.wg.Add(2)
+	go s.loop()
+	go s.accept()
+
+	log.Info("Stratum server started", "addr", listener.Addr(), "difficulty", s.config.Difficulty)
+	return nil
+}
+
+// Stop closes the listening socket and disconnects all miners.
+func (s *StratumServer) Stop() {
+	s.workSub.Unsubscribe()
+	close(s.quit)
+	s.listener.Close()
+
+	s.lock.Lock()
+	for session := range s.sessions {
+		session.conn.Close()
+	}
+	s.lock.Unlock()
+
+	s.wg.Wait()
+	log.Info("Stratum server stopped", "addr", s.listener.Addr())
+}
+
+// Addr returns the listening address of the server.
+func (s *StratumServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// loop pushes new jobs to the miners whenever the sealing work changes, and
+// periodically reports the worker hashrates to the remote agent.
+func (s *StratumServer) loop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(stratumHashrateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case work := <-s.workCh:
+			if work == nil {
+				continue
+			}
+			s.newJob(work)
+			for _, session := range s.activeSessions() {
+				session.notifyJob()
+			}
+
+		case <-ticker.C:
+			for _, session := range s.activeSessions() {
+				session.reportHashrate()
+			}
+
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// activeSessions returns the currently connected miner sessions.
+func (s *StratumServer) activeSessions() []*stratumSession {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	sessions := make([]*stratumSession, 0, len(s.sessions))
+	for session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// accept serves incoming miner connections until the listener is closed.
+func (s *StratumServer) accept() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.quit:
+			default:
+				log.Error("Stratum listener failed", "err", err)
+			}
+			return
+		}
+		session := &stratumSession{
+			server:     s,
+			conn:       conn,
+			enc:        json.NewEncoder(conn),
+			difficulty: new(big.Int).SetUint64(s.config.Difficulty),
+			started:    time.Now(),
+			notify:     make(chan struct{}, 1),
+			closed:     make(chan struct{}),
+		}
+		s.lock.Lock()
+		s.sessions[session] = struct{}{}
+		s.lock.Unlock()
+
+		s.wg.Add(2)
+		go func() {
+			defer s.wg.Done()
+			session.serve()
+
+			s.lock.Lock()
+			delete(s.sessions, session)
+			s.lock.Unlock()
+		}()
+		go func() {
+			defer s.wg.Done()
+			session.pushLoop()
+		}()
+	}
+}
+
+// newJob creates a job for new sealing work, retiring the oldest job.
+func (s *StratumServer) newJob(work *Work) *stratumJob {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.nextJob++
+	job := &stratumJob{
+		id:         strconv.FormatUint(s.nextJob, 16),
+		hash:       work.Block.HashNoNonce(),
+		seed:       common.BytesToHash(ethash.SeedHash(work.Block.NumberU64())),
+		number:     work.Block.NumberU64(),
+		difficulty: work.Block.Difficulty(),
+		shares:     make(map[uint64]struct{}),
+	}
+	s.jobs = append(s.jobs, job)
+	if len(s.jobs) > stratumJobHistory {
+		s.jobs = s.jobs[len(s.jobs)-stratumJobHistory:]
+	}
+	return job
+}
+
+// currentJob returns the latest job, or nil if no work has been pushed yet.
+func (s *StratumServer) currentJob() *stratumJob {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.jobs) == 0 {
+		return nil
+	}
+	return s.jobs[len(s.jobs)-1]
+}
+
+// findJob returns the recent job matching the given predicate.
+func (s *StratumServer) findJob(match func(*stratumJob) bool) *stratumJob {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for i := len(s.jobs) - 1; i >= 0; i-- {
+		if match(s.jobs[i]) {
+			return s.jobs[i]
+		}
+	}
+	return nil
+}
+
+// allocExtraNonce assigns the nonce prefix of a new EthereumStratum session.
+func (s *StratumServer) allocExtraNonce() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	nonce := s.nextNonce
+	s.nextNonce = (s.nextNonce + 1) % (1 << (8 * stratumExtraNonceSize))
+	return fmt.Sprintf("%0*x", 2*stratumExtraNonceSize, nonce)
+}
+
+// submitShare verifies a share of a job, forwarding it to the remote agent if
+// it seals the block.
+func (s *StratumServer) submitShare(session *stratumSession, job *stratumJob, nonce types.BlockNonce, mixDigest *common.Hash) *stratumError {
+	work := s.agent.pendingWork(job.hash)
+	if work == nil {
+		return errStratumJobNotFound
+	}
+	s.lock.Lock()
+	if _, ok := job.shares[nonce.Uint64()]; ok {
+		s.lock.Unlock()
+		return errStratumDuplicate
+	}
+	job.shares[nonce.Uint64()] = struct{}{}
+	s.lock.Unlock()
+
+	header := work.Block.Header()
+	header.Nonce = nonce
+	if mixDigest != nil {
+		header.MixDigest = *mixDigest
+	}
+	difficulty := session.shareDifficulty(job)
+
+	digest, sealed, err := s.verifier.VerifyShare(header, difficulty)
+	if err != nil {
+		log.Debug("Invalid Stratum share submitted", "worker", session.worker, "job", job.id, "err", err)
+		return errStratumLowDifficulty
+	}
+	if mixDigest != nil && *mixDigest != digest {
+		return errStratumBadMixDigest
+	}
+	session.addShare(difficulty)
+
+	if sealed {
+		if !s.agent.SubmitWork(nonce, digest, job.hash) {
+			return errStratumJobNotFound
+		}
+		log.Info("Stratum share sealed block", "worker", session.worker, "number", job.number, "hash", job.hash)
+	}
+	return nil
+}
+
+// stratumShare is an accepted share, used to estimate the worker hashrate.
+type stratumShare struct {
+	time       time.Time
+	difficulty *big.Int
+}
+
+// stratumSession is the connection of a single Stratum miner.
+type stratumSession struct {
+	server *StratumServer
+	conn   net.Conn
+
+	encLock sync.Mutex
+	enc     *json.Encoder
+
+	notify chan struct{} // Signals that the current job needs pushing to the miner
+	closed chan struct{} // Closed when the connection is torn down
+
+	lock       sync.Mutex
+	dialect    stratumDialect
+	extraNonce string      // Nonce prefix assigned to an EthereumStratum session
+	worker     string      // Name of the authorized worker (empty = unauthorized)
+	id         common.Hash // Identifier of the worker for hashrate reporting
+	difficulty *big.Int    // Share difficulty requested for the worker
+	sentDiff   *big.Int    // Share difficulty last sent to an EthereumStratum miner
+
+	started    time.Time      // Time the session was opened
+	shares     []stratumShare // Shares accepted within the hashrate window
+	reported   uint64         // Hashrate last reported by the miner itself
+	reportedAt time.Time      // Time of the miner's last hashrate report
+}
+
+// serve reads and answers requests until the connection is closed.
+func (s *stratumSession) serve() {
+	defer close(s.closed)
+	defer s.conn.Close()
+
+	scanner := bufio.NewScanner(s.conn)
+	scanner.Buffer(make([]byte, stratumMaxRequestSize), stratumMaxRequestSize)
+	for {
+		s.conn.SetReadDeadline(time.Now().Add(stratumIdleTimeout))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				log.Debug("Stratum connection failed", "addr", s.conn.RemoteAddr(), "err", err)
+			}
+			return
+		}
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var req stratumRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			log.Debug("Malformed Stratum request", "addr", s.conn.RemoteAddr(), "err", err)
+			return
+		}
+		if err := s.handle(&req); err != nil {
+			log.Debug("Stratum connection failed", "addr", s.conn.RemoteAddr(), "err", err)
+			return
+		}
+	}
+}
+
+// handle dispatches a single request to its handler.
+func (s *stratumSession) handle(req *stratumRequest) error {
+	switch req.Method {
+	case "mining.subscribe":
+		return s.handleSubscribe(req)
+	case "mining.extranonce.subscribe":
+		return s.reply(req, true, nil)
+	case "mining.authorize":
+		return s.handleAuthorize(req)
+	case "mining.submit":
+		return s.handleSubmit(req)
+	case "eth_submitLogin":
+		return s.handleLogin(req)
+	case "eth_getWork":
+		return s.handleGetWork(req)
+	case "eth_submitWork":
+		return s.handleSubmitWork(req)
+	case "eth_submitHashrate":
+		return s.handleSubmitHashrate(req)
+	default:
+		return s.reply(req, nil, errStratumBadMethod)
+	}
+}
+
+// handleSubscribe starts an EthereumStratum session, assigning the nonce prefix
+// of the miner.
+func (s *stratumSession) handleSubscribe(req *stratumRequest) error {
+	s.lock.Lock()
+	if s.dialect == dialectUnknown {
+		s.dialect = dialectStratum
+	}
+	subscribed, extraNonce := s.dialect == dialectStratum, s.extraNonce
+	s.lock.Unlock()
+
+	if !subscribed {
+		return s.reply(req, nil, errStratumUnknown)
+	}
+	if extraNonce == "" {
+		extraNonce = s.server.allocExtraNonce()
+
+		s.lock.Lock()
+		s.extraNonce = extraNonce
+		s.lock.Unlock()
+	}
+
+	return s.reply(req, []interface{}{
+		[]string{"mining.notify", extraNonce, stratumVersion},
+		extraNonce,
+	}, nil)
+}
+
+// handleAuthorize authorizes an EthereumStratum worker and sends it the current job.
+func (s *stratumSession) handleAuthorize(req *stratumRequest) error {
+	var params []string
+	if err := json.Unmarshal(req.Params, &params); err != nil || len(params) == 0 {
+		return s.reply(req, nil, errStratumBadParams)
+	}
+	s.lock.Lock()
+	subscribed := s.dialect == dialectStratum
+	s.lock.Unlock()
+	if !subscribed {
+		return s.reply(req, nil, errStratumNotSubscribed)
+	}
+	password := ""
+	if len(params) > 1 {
+		password = params[1]
+	}
+	s.authorize(params[0], password)
+
+	if err := s.reply(req, true, nil); err != nil {
+		return err
+	}
+	s.notifyJob()
+	return nil
+}
+
+// handleSubmit verifies a share submitted by an EthereumStratum worker.
+func (s *stratumSession) handleSubmit(req *stratumRequest) error {
+	var params []string
+	if err := json.Unmarshal(req.Params, &params); err != nil || len(params) < 3 {
+		return s.reply(req, false, errStratumBadParams)
+	}
+	s.lock.Lock()
+	authorized, extraNonce := s.worker != "", s.extraNonce
+	s.lock.Unlock()
+	if !authorized {
+		return s.reply(req, false, errStratumUnauthorized)
+	}
+	job := s.server.findJob(func(job *stratumJob) bool { return job.id == params[1] })
+	if job == nil {
+		return s.reply(req, false, errStratumJobNotFound)
+	}
+	suffix := strings.TrimPrefix(params[2], "0x")
+	if len(extraNonce)+len(suffix) != 16 {
+		return s.reply(req, false, errStratumBadParams)
+	}
+	nonce, err := strconv.ParseUint(extraNonce+suffix, 16, 64)
+	if err != nil {
+		return s.reply(req, false, errStratumBadParams)
+	}
+	if err := s.server.submitShare(s, job, types.EncodeNonce(nonce), nil); err != nil {
+		return s.reply(req, false, err)
+	}
+	return s.reply(req, true, nil)
+}
+
+// handleLogin authorizes an eth-proxy worker.
+func (s *stratumSession) handleLogin(req *stratumRequest) error {
+	var params []string
+	if err := json.Unmarshal(req.Params, &params); err != nil || len(params) == 0 {
+		return s.reply(req, false, errStratumBadParams)
+	}
+	s.lock.Lock()
+	if s.dialect == dialectUnknown {
+		s.dialect = dialectProxy
+	}
+	proxy := s.dialect == dialectProxy
+	s.lock.Unlock()
+	if !proxy {
+		return s.reply(req, false, errStratumUnknown)
+	}
+	login, password := params[0], ""
+	if req.Worker != "" {
+		login += "." + req.Worker
+	}
+	if len(params) > 1 {
+		password = params[1]
+	}
+	s.authorize(login, password)
+
+	return s.reply(req, true, nil)
+}
+
+// handleGetWork returns the current job of an eth-proxy worker.
+func (s *stratumSession) handleGetWork(req *stratumRequest) error {
+	s.lock.Lock()
+	authorized := s.worker != "" && s.dialect == dialectProxy
+	s.lock.Unlock()
+	if !authorized {
+		return s.reply(req, nil, errStratumUnauthorized)
+	}
+	job := s.server.currentJob()
+	if job == nil {
+		return s.reply(req, nil, errStratumNoWork)
+	}
+	return s.reply(req, s.proxyWork(job), nil)
+}
+
+// handleSubmitWork verifies a share submitted by an eth-proxy worker.
+func (s *stratumSession) handleSubmitWork(req *stratumRequest) error {
+	var params []string
+	if err := json.Unmarshal(req.Params, &params); err != nil || len(params) < 3 {
+		return s.reply(req, false, errStratumBadParams)
+	}
+	s.lock.Lock()
+	authorized := s.worker != "" && s.dialect == dialectProxy
+	s.lock.Unlock()
+	if !authorized {
+		return s.reply(req, false, errStratumUnauthorized)
+	}
+	nonce, err := parseStratumUint64(params[0])
+	if err != nil {
+		return s.reply(req, false, errStratumBadParams)
+	}
+	hash, mixDigest := common.HexToHash(params[1]), common.HexToHash(params[2])
+
+	job := s.server.findJob(func(job *stratumJob) bool { return job.hash == hash })
+	if job == nil {
+		return s.reply(req, false, errStratumJobNotFound)
+	}
+	if err := s.server.submitShare(s, job, types.EncodeNonce(nonce), &mixDigest); err != nil {
+		return s.reply(req, false, err)
+	}
+	return s.reply(req, true, nil)
+}
+
+// handleSubmitHashrate records the hashrate reported by the miner itself.
+func (s *stratumSession) handleSubmitHashrate(req *stratumRequest) error {
+	var params []string
+	if err := json.Unmarshal(req.Params, &params); err != nil || len(params) == 0 {
+		return s.reply(req, false, errStratumBadParams)
+	}
+	rate, err := parseStratumUint64(params[0])
+	if err != nil {
+		return s.reply(req, false, errStratumBadParams)
+	}
+	s.lock.Lock()
+	if s.worker == "" {
+		s.lock.Unlock()
+		return s.reply(req, false, errStratumUnauthorized)
+	}
+	s.reported, s.reportedAt = rate, time.Now()
+	id := s.id
+	s.lock.Unlock()
+
+	s.server.agent.SubmitHashrate(id, rate)
+	return s.reply(req, true, nil)
+}
+
+// authorize sets the worker of the session, applying the share difficulty
+// requested through a "d=<difficulty>" password.
+func (s *stratumSession) authorize(worker, password string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.worker = worker
+	s.id = crypto.Keccak256Hash([]byte(worker))
+
+	for _, field := range strings.Split(password, ",") {
+		if !strings.HasPrefix(field, "d=") {
+			continue
+		}
+		if diff, err := strconv.ParseUint(field[2:], 10, 64); err == nil && diff > 0 {
+			s.difficulty = new(big.Int).SetUint64(diff)
+		}
+	}
+	log.Debug("Stratum worker authorized", "worker", worker, "addr", s.conn.RemoteAddr(), "difficulty", s.difficulty)
+}
+
+// shareDifficulty returns the difficulty of the worker's shares of a job, capped
+// at the block difficulty so no block solution is ever rejected.
+func (s *stratumSession) shareDifficulty(job *stratumJob) *big.Int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.difficulty.Cmp(job.difficulty) > 0 {
+		return job.difficulty
+	}
+	return s.difficulty
+}
+
+// notifyJob schedules the current job to be pushed to the miner. It never
+// blocks, so a slow miner can't hold up new work for the others.
+func (s *stratumSession) notifyJob() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pushLoop pushes the current job to the miner whenever notified, until the
+// connection is closed. Jobs superseded before being sent are skipped.
+func (s *stratumSession) pushLoop() {
+	var pushed *stratumJob
+	for {
+		select {
+		case <-s.notify:
+			s.lock.Lock()
+			authorized := s.worker != ""
+			s.lock.Unlock()
+
+			job := s.server.currentJob()
+			if !authorized || job == nil || job == pushed {
+				continue
+			}
+			if err := s.pushJob(job, true); err != nil {
+				log.Debug("Stratum job push failed", "addr", s.conn.RemoteAddr(), "err", err)
+				return
+			}
+			pushed = job
+
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+// pushJob sends a job to an authorized miner, updating the share difficulty of
+// an EthereumStratum miner first if it changed.
+func (s *stratumSession) pushJob(job *stratumJob, clean bool) error {
+	s.lock.Lock()
+	dialect, authorized := s.dialect, s.worker != ""
+	s.lock.Unlock()
+	if !authorized {
+		return nil
+	}
+	difficulty := s.shareDifficulty(job)
+
+	switch dialect {
+	case dialectStratum:
+		s.lock.Lock()
+		changed := s.sentDiff == nil || s.sentDiff.Cmp(difficulty) != 0
+		s.sentDiff = difficulty
+		s.lock.Unlock()
+
+		if changed {
+			diff, _ := new(big.Float).Quo(new(big.Float).SetInt(difficulty), stratumDifficultyUnit).Float64()
+			if err := s.write(&stratumNotification{Method: "mining.set_difficulty", Params: []interface{}{diff}}); err != nil {
+				return err
+			}
+		}
+		return s.write(&stratumNotification{
+			Method: "mining.notify",
+			Params: []interface{}{job.id, hexNoPrefix(job.seed), hexNoPrefix(job.hash), clean},
+		})
+
+	case dialectProxy:
+		return s.write(&stratumResponse{ID: json.RawMessage("0"), Version: "2.0", Result: s.proxyWork(job)})
+	}
+	return nil
+}
+
+// proxyWork returns the eth_getWork package of a job, with the target set to
+// the share difficulty of the worker.
+func (s *stratumSession) proxyWork(job *stratumJob) []string {
+	target := new(big.Int).Div(stratumMaxTarget, s.shareDifficulty(job))
+	return []string{job.hash.Hex(), job.seed.Hex(), common.BigToHash(target).Hex()}
+}
+
+// addShare records an accepted share for the hashrate estimation and reports
+// the updated estimate.
+func (s *stratumSession) addShare(difficulty *big.Int) {
+	s.lock.Lock()
+	s.shares = append(s.shares, stratumShare{time: time.Now(), difficulty: difficulty})
+	s.lock.Unlock()
+
+	s.reportHashrate()
+}
+
+// reportHashrate reports the hashrate of the worker to the remote agent. A rate
+// recently reported by the miner itself takes precedence over the estimate from
+// the accepted shares.
+func (s *stratumSession) reportHashrate() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.worker == "" {
+		return
+	}
+	now := time.Now()
+	if now.Sub(s.reportedAt) < stratumReportedHashrateTTL {
+		s.server.agent.SubmitHashrate(s.id, s.reported)
+		return
+	}
+	// Drop the shares outside the window and average over the rest
+	for len(s.shares) > 0 && now.Sub(s.shares[0].time) > stratumHashrateWindow {
+		s.shares = s.shares[1:]
+	}
+	if len(s.shares) == 0 {
+		return
+	}
+	total := new(big.Int)
+	for _, share := range s.shares {
+		total.Add(total, share.difficulty)
+	}
+	window := stratumHashrateWindow
+	if elapsed := now.Sub(s.started); elapsed < window {
+		window = elapsed
+	}
+	if window < time.Second {
+		window = time.Second
+	}
+	rate := total.Div(total.Mul(total, big.NewInt(int64(time.Second))), big.NewInt(int64(window)))
+	s.server.agent.SubmitHashrate(s.id, rate.Uint64())
+}
+
+// reply answers a request, encoding the error in the format of the dialect.
+func (s *stratumSession) reply(req *stratumRequest, result interface{}, err *stratumError) error {
+	res := &stratumResponse{ID: req.ID, Result: result}
+	if err != nil {
+		s.lock.Lock()
+		dialect := s.dialect
+		s.lock.Unlock()
+
+		if dialect == dialectStratum {
+			res.Error = []interface{}{err.code, err.message, nil}
+		} else {
+			res.Error = map[string]interface{}{"code": err.code, "message": err.message}
+		}
+	}
+	if req.ID == nil {
+		res.ID = json.RawMessage("null")
+	}
+	return s.write(res)
+}
+
+// write sends a single message to the miner.
+func (s *stratumSession) write(msg interface{}) error {
+	s.encLock.Lock()
+	defer s.encLock.Unlock()
+
+	s.conn.SetWriteDeadline(time.Now().Add(stratumWriteTimeout))
+	if err := s.enc.Encode(msg); err != nil {
+		s.conn.Close()
+		return err
+	}
+	return nil
+}
+
+// parseStratumUint64 parses a hex quantity sent by a miner. Unlike hexutil, it
+// accepts the zero padded values sent by most mining software.
+func parseStratumUint64(input string) (uint64, error) {
+	value, ok := new(big.Int).SetString(strings.TrimPrefix(input, "0x"), 16)
+	if !ok || value.Sign() < 0 || value.BitLen() > 64 {
+		return 0, fmt.Errorf("invalid hex quantity %q", input)
+	}
+	return value.Uint64(), nil
+}
+
+// hexNoPrefix returns the hex encoding of a hash without the 0x prefix, as used
+// by the EthereumStratum protocol.
+func hexNoPrefix(hash common.Hash) string {
+	return hash.Hex()[2:]
+}
@@ -0,0 +1,288 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"bufio"
+	"encoding/json"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/consensus/ethash"
+	"github.com/TeamEGEM/go-egem/core/types"
+)
+
+// fakeStratumMiner is a Stratum client driving the server in tests.
+type fakeStratumMiner struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID int
+}
+
+// stratumMessage is any message received from the server.
+type stratumMessage struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params []interface{}   `json:"params"`
+	Result interface{}     `json:"result"`
+	Error  interface{}     `json:"error"`
+}
+
+func dialStratum(t *testing.T, server *StratumServer) *fakeStratumMiner {
+	conn, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect to stratum server: %v", err)
+	}
+	return &fakeStratumMiner{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// request sends a request and waits for its reply.
+func (m *fakeStratumMiner) request(method string, worker string, params ...interface{}) *stratumMessage {
+	m.nextID++
+	req := map[string]interface{}{"id": m.nextID, "method": method, "params": params}
+	if worker != "" {
+		req["worker"] = worker
+	}
+	if err := json.NewEncoder(m.conn).Encode(req); err != nil {
+		m.t.Fatalf("failed to send %s: %v", method, err)
+	}
+	return m.read()
+}
+
+// read waits for the next message from the server.
+func (m *fakeStratumMiner) read() *stratumMessage {
+	m.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := m.reader.ReadBytes('\n')
+	if err != nil {
+		m.t.Fatalf("failed to read message: %v", err)
+	}
+	msg := new(stratumMessage)
+	if err := json.Unmarshal(line, msg); err != nil {
+		m.t.Fatalf("failed to decode message %q: %v", line, err)
+	}
+	return msg
+}
+
+// newStratumTester creates a remote agent with a stratum server on top.
+func newStratumTester(t *testing.T, engine *ethash.Ethash, difficulty uint64) (*RemoteAgent, *StratumServer, chan *Result) {
+	results := make(chan *Result, 4)
+
+	agent := NewRemoteAgent(nil, engine)
+	agent.SetReturnCh(results)
+	agent.Start()
+
+	server, err := NewStratumServer(agent, StratumConfig{Addr: "127.0.0.1:0", Difficulty: difficulty})
+	if err != nil {
+		t.Fatalf("failed to create stratum server: %v", err)
+	}
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start stratum server: %v", err)
+	}
+	return agent, server, results
+}
+
+// pushWork feeds a new block to be sealed into the agent.
+func pushWork(agent *RemoteAgent, number int64) *types.Block {
+	block := types.NewBlockWithHeader(&types.Header{
+		Number:     big.NewInt(number),
+		Difficulty: big.NewInt(1 << 40),
+		Time:       big.NewInt(time.Now().Unix()),
+	})
+	agent.Work() <- &Work{Block: block, createdAt: time.Now()}
+	return block
+}
+
+// Tests the EthereumStratum/1.0.0 handshake, job notifications and share submission.
+func TestStratumEthereumStratum(t *testing.T) {
+	agent, server, results := newStratumTester(t, ethash.NewFaker(), DefaultShareDifficulty)
+	defer agent.Stop()
+	defer server.Stop()
+
+	miner := dialStratum(t, server)
+	defer miner.conn.Close()
+
+	// Authorizing before subscribing must fail
+	if res := miner.request("mining.authorize", "", "rig.1", "x"); res.Error == nil {
+		t.Fatalf("unsubscribed authorization accepted")
+	}
+	res := miner.request("mining.subscribe", "", "fakeminer/1.0", stratumVersion)
+	if res.Error != nil {
+		t.Fatalf("subscription failed: %v", res.Error)
+	}
+	extraNonce := res.Result.([]interface{})[1].(string)
+	if len(extraNonce) != 2*stratumExtraNonceSize {
+		t.Fatalf("extranonce length mismatch: have %d, want %d", len(extraNonce), 2*stratumExtraNonceSize)
+	}
+	if res := miner.request("mining.authorize", "", "rig.1", "d=8589934592"); res.Result != true {
+		t.Fatalf("authorization failed: %v", res.Error)
+	}
+	// Push some work and expect a difficulty update followed by the job
+	block := pushWork(agent, 1)
+
+	msg := miner.read()
+	if msg.Method != "mining.set_difficulty" || msg.Params[0].(float64) != 2 {
+		t.Fatalf("difficulty update mismatch: have %s %v, want mining.set_difficulty [2]", msg.Method, msg.Params)
+	}
+	msg = miner.read()
+	if msg.Method != "mining.notify" {
+		t.Fatalf("job notification mismatch: have %s, want mining.notify", msg.Method)
+	}
+	if hash := msg.Params[2].(string); hash != hexNoPrefix(block.HashNoNonce()) {
+		t.Fatalf("job header hash mismatch: have %s, want %x", hash, block.HashNoNonce())
+	}
+	job := msg.Params[0].(string)
+
+	// Submit a share sealing the block and a duplicate of it
+	if res := miner.request("mining.submit", "", "rig.1", job, "000000000001"); res.Result != true {
+		t.Fatalf("share rejected: %v", res.Error)
+	}
+	select {
+	case result := <-results:
+		if want := extraNonce + "000000000001"; common.Bytes2Hex(result.Block.Header().Nonce[:]) != want {
+			t.Errorf("sealed nonce mismatch: have %x, want %s", result.Block.Header().Nonce, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("sealed block not returned")
+	}
+	if res := miner.request("mining.submit", "", "rig.1", job, "000000000001"); res.Result != false {
+		t.Errorf("duplicate share accepted")
+	}
+	if res := miner.request("mining.submit", "", "rig.1", "unknown", "000000000002"); res.Result != false {
+		t.Errorf("share of unknown job accepted")
+	}
+	// The accepted share should be reported as hashrate
+	if rate := agent.GetHashRate(); rate == 0 {
+		t.Errorf("worker hashrate not reported")
+	}
+}
+
+// Tests the legacy eth-proxy dialect, including work pushes and hashrate reports.
+func TestStratumEthProxy(t *testing.T) {
+	agent, server, results := newStratumTester(t, ethash.NewFaker(), 1<<20)
+	defer agent.Stop()
+	defer server.Stop()
+
+	miner := dialStratum(t, server)
+	defer miner.conn.Close()
+
+	if res := miner.request("eth_getWork", ""); res.Error == nil {
+		t.Fatalf("work handed out before login")
+	}
+	if res := miner.request("eth_submitLogin", "rig1", "0x3fa6576610cac6c68e88ee68de07b104c9524fda"); res.Result != true {
+		t.Fatalf("login failed: %v", res.Error)
+	}
+	if res := miner.request("eth_getWork", ""); res.Error == nil {
+		t.Fatalf("work handed out before any was available")
+	}
+	// Push some work and expect it to be delivered with the share target
+	block := pushWork(agent, 1)
+
+	msg := miner.read()
+	work := msg.Result.([]interface{})
+	if work[0].(string) != block.HashNoNonce().Hex() {
+		t.Fatalf("pushed header hash mismatch: have %s, want %x", work[0], block.HashNoNonce())
+	}
+	target := new(big.Int).Div(stratumMaxTarget, big.NewInt(1<<20))
+	if work[2].(string) != common.BigToHash(target).Hex() {
+		t.Fatalf("pushed target mismatch: have %s, want %x", work[2], target)
+	}
+	if res := miner.request("eth_getWork", ""); res.Result.([]interface{})[0] != work[0] {
+		t.Fatalf("polled work mismatch: have %v, want %v", res.Result, work)
+	}
+	// Submit a solution and a hashrate report
+	if res := miner.request("eth_submitWork", "rig1", "0x0000000000000042", work[0], common.Hash{}.Hex()); res.Result != true {
+		t.Fatalf("share rejected: %v", res.Error)
+	}
+	select {
+	case result := <-results:
+		if result.Block.Nonce() != 0x42 {
+			t.Errorf("sealed nonce mismatch: have %x, want %x", result.Block.Nonce(), 0x42)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("sealed block not returned")
+	}
+	if res := miner.request("eth_submitHashrate", "rig1", "0x500000", common.Hash{}.Hex()); res.Result != true {
+		t.Fatalf("hashrate rejected: %v", res.Error)
+	}
+	if rate := agent.GetHashRate(); rate != 0x500000 {
+		t.Errorf("hashrate mismatch: have %d, want %d", rate, 0x500000)
+	}
+}
+
+// Tests that invalid shares are rejected and not forwarded as sealed blocks.
+func TestStratumInvalidShare(t *testing.T) {
+	agent, server, results := newStratumTester(t, ethash.NewFakeFailer(1), DefaultShareDifficulty)
+	defer agent.Stop()
+	defer server.Stop()
+
+	miner := dialStratum(t, server)
+	defer miner.conn.Close()
+
+	miner.request("eth_submitLogin", "rig1", "0x3fa6576610cac6c68e88ee68de07b104c9524fda")
+	block := pushWork(agent, 1)
+	miner.read()
+
+	if res := miner.request("eth_submitWork", "rig1", "0x0000000000000042", block.HashNoNonce().Hex(), common.Hash{}.Hex()); res.Result != false {
+		t.Fatalf("invalid share accepted")
+	}
+	select {
+	case <-results:
+		t.Fatalf("invalid share returned as sealed block")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// Tests that restarting the agent with a work subscriber attached doesn't leak
+// the nil work read from the closed work channel to the subscribers.
+func TestRemoteAgentRestart(t *testing.T) {
+	agent := NewRemoteAgent(nil, ethash.NewFaker())
+	agent.SetReturnCh(make(chan *Result, 1))
+
+	works := make(chan *Work, 16)
+	sub := agent.SubscribeWork(works)
+	defer sub.Unsubscribe()
+
+	for i := 0; i < 32; i++ {
+		agent.Start()
+		agent.Stop()
+	}
+	agent.Start()
+	defer agent.Stop()
+
+	// Give any stale loops a chance to act on the closed channels
+	time.Sleep(50 * time.Millisecond)
+
+	block := pushWork(agent, 1)
+	select {
+	case work := <-works:
+		if work == nil {
+			t.Fatalf("nil work delivered to subscriber")
+		}
+		if work.Block.Hash() != block.Hash() {
+			t.Fatalf("work mismatch: have %x, want %x", work.Block.Hash(), block.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("work not delivered after restart")
+	}
+	if agent.pendingWork(block.HashNoNonce()) == nil {
+		t.Fatalf("work not registered for submission after restart")
+	}
+}
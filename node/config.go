@@ -26,6 +26,7 @@ import (
 	"strings"
 
 	"github.com/TeamEGEM/go-egem/accounts"
+	"github.com/TeamEGEM/go-egem/accounts/external"
 	"github.com/TeamEGEM/go-egem/accounts/keystore"
 	"github.com/TeamEGEM/go-egem/accounts/usbwallet"
 	"github.com/TeamEGEM/go-egem/common"
@@ -85,6 +86,10 @@ type Config struct {
 	// NoUSB disables hardware wallet monitoring and connectivity.
 	NoUSB bool `toml:",omitempty"`
 
+	// ExternalSigner is the endpoint (IPC path or HTTP URL) of a standalone signer
+	// to forward transaction signing requests to.
+	ExternalSigner string `toml:",omitempty"`
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
@@ -433,5 +438,13 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 			backends = append(backends, trezorhub)
 		}
 	}
+	if conf.ExternalSigner != "" {
+		// Connect to the standalone signer holding the keys outside of the node
+		extapi, err := external.NewExternalBackend(conf.ExternalSigner)
+		if err != nil {
+			return nil, "", fmt.Errorf("error connecting to external signer: %v", err)
+		}
+		backends = append(backends, extapi)
+	}
 	return accounts.NewManager(backends...), ephemeral, nil
 }
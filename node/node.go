@@ -648,6 +648,25 @@ func (n *Node) OpenDatabase(name string, cache, handles int) (ethdb.Database, er
 	return ethdb.NewLDBDatabase(n.config.resolvePath(name), cache, handles)
 }
 
+// OpenDatabaseWithFreezer opens an existing database with the given name (or
+// creates one if no previous can be found) from within the node's instance
+// directory, also attaching an ancient store in the freezer directory. A relative
+// freezer path is resolved within the database directory; an empty one disables
+// the ancient store. If the node is ephemeral, a memory database is returned.
+func (n *Node) OpenDatabaseWithFreezer(name string, cache, handles int, freezer string) (ethdb.Database, error) {
+	if n.config.DataDir == "" {
+		return ethdb.NewMemDatabase()
+	}
+	root := n.config.resolvePath(name)
+	if freezer == "" {
+		return ethdb.NewLDBDatabase(root, cache, handles)
+	}
+	if !filepath.IsAbs(freezer) {
+		freezer = filepath.Join(root, freezer)
+	}
+	return ethdb.NewLDBDatabaseWithFreezer(root, cache, handles, freezer)
+}
+
 // ResolvePath returns the absolute path of a resource in the instance directory.
 func (n *Node) ResolvePath(x string) string {
 	return n.config.resolvePath(x)
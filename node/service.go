@@ -17,6 +17,7 @@
 package node
 
 import (
+	"path/filepath"
 	"reflect"
 
 	"github.com/TeamEGEM/go-egem/accounts"
@@ -50,6 +51,25 @@ func (ctx *ServiceContext) OpenDatabase(name string, cache int, handles int) (et
 	return db, nil
 }
 
+// OpenDatabaseWithFreezer opens an existing database with the given name (or
+// creates one if no previous can be found) from within the node's data directory,
+// also attaching an ancient store in the freezer directory. A relative freezer
+// path is resolved within the database directory; an empty one disables the
+// ancient store. If the node is an ephemeral one, a memory database is returned.
+func (ctx *ServiceContext) OpenDatabaseWithFreezer(name string, cache int, handles int, freezer string) (ethdb.Database, error) {
+	if ctx.config.DataDir == "" {
+		return ethdb.NewMemDatabase()
+	}
+	root := ctx.config.resolvePath(name)
+	if freezer == "" {
+		return ethdb.NewLDBDatabase(root, cache, handles)
+	}
+	if !filepath.IsAbs(freezer) {
+		freezer = filepath.Join(root, freezer)
+	}
+	return ethdb.NewLDBDatabaseWithFreezer(root, cache, handles, freezer)
+}
+
 // ResolvePath resolves a user path into the data directory if that was relative
 // and if the user actually uses persistent storage. It will return an empty string
 // for emphemeral storage and the user's own input for absolute paths.
@@ -127,13 +127,67 @@ type ChainConfig struct {
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
-type EthashConfig struct{}
+type EthashConfig struct {
+	Eras       []RewardEra      `json:"eras,omitempty"`       // Block reward schedule (nil = EGEM mainnet schedule)
+	Difficulty []DifficultyFork `json:"difficulty,omitempty"` // Difficulty algorithm switches (nil = EGEM algorithm)
+}
+
+// Difficulty adjustment algorithms selectable by a DifficultyFork.
+const (
+	DifficultyEGEM  = "egem"  // Fixed step adjustment around DurationLimit
+	DifficultyLWMA  = "lwma"  // Linearly weighted moving average of recent solve times
+	DifficultyASERT = "asert" // Exponential adjustment by the parent's solve time
+)
+
+// DifficultyFork schedules a switch of the difficulty adjustment algorithm.
+type DifficultyFork struct {
+	Block      *big.Int `json:"block"`                // First block whose difficulty is computed by the algorithm
+	Algorithm  string   `json:"algorithm"`            // Name of the difficulty adjustment algorithm
+	TargetTime uint64   `json:"targetTime,omitempty"` // Target block time in seconds (0 = DurationLimit)
+	Window     uint64   `json:"window,omitempty"`     // LWMA averaging window in blocks (0 = default)
+	HalfLife   uint64   `json:"halfLife,omitempty"`   // ASERT half-life in seconds (0 = default)
+}
 
 // String implements the stringer interface, returning the consensus engine details.
 func (c *EthashConfig) String() string {
 	return "ethash"
 }
 
+// RewardEras returns the block reward schedule of the chain, falling back to the
+// EGEM main network schedule if none was configured.
+func (c *EthashConfig) RewardEras() []RewardEra {
+	if c == nil || len(c.Eras) == 0 {
+		return MainnetRewardEras
+	}
+	return c.Eras
+}
+
+// RewardEra returns the reward era in effect at the given block, or nil if the
+// block precedes the first configured era.
+func (c *EthashConfig) RewardEra(num *big.Int) *RewardEra {
+	eras := c.RewardEras()
+	for i := len(eras) - 1; i >= 0; i-- {
+		if isForked(eras[i].Block, num) {
+			return &eras[i]
+		}
+	}
+	return nil
+}
+
+// DifficultyFork returns the difficulty algorithm switch in effect at the given
+// block, or nil if the original EGEM algorithm is still active.
+func (c *EthashConfig) DifficultyFork(num *big.Int) *DifficultyFork {
+	if c == nil {
+		return nil
+	}
+	for i := len(c.Difficulty) - 1; i >= 0; i-- {
+		if isForked(c.Difficulty[i].Block, num) {
+			return &c.Difficulty[i]
+		}
+	}
+	return nil
+}
+
 // CliqueConfig is the consensus engine configs for proof-of-authority based sealing.
 type CliqueConfig struct {
 	Period uint64 `json:"period"` // Number of seconds between blocks to enforce
@@ -246,9 +300,66 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.ConstantinopleBlock, newcfg.ConstantinopleBlock, head) {
 		return newCompatError("Constantinople fork block", c.ConstantinopleBlock, newcfg.ConstantinopleBlock)
 	}
+	if c.Ethash != nil && newcfg.Ethash != nil {
+		if stored, next := rewardEraMismatch(c.Ethash.RewardEras(), newcfg.Ethash.RewardEras()); isForkIncompatible(stored, next, head) {
+			return newCompatError("Ethash reward era", stored, next)
+		}
+		if stored, next := difficultyForkMismatch(c.Ethash.Difficulty, newcfg.Ethash.Difficulty); isForkIncompatible(stored, next, head) {
+			return newCompatError("Ethash difficulty fork", stored, next)
+		}
+	}
 	return nil
 }
 
+// rewardEraMismatch returns the starting blocks of the first eras that differ
+// between two reward schedules, or nils if the schedules are identical.
+func rewardEraMismatch(stored, next []RewardEra) (*big.Int, *big.Int) {
+	for i := 0; i < len(stored) || i < len(next); i++ {
+		switch {
+		case i >= len(stored):
+			return nil, next[i].Block
+		case i >= len(next):
+			return stored[i].Block, nil
+		case !stored[i].equal(&next[i]):
+			if configNumEqual(stored[i].Block, next[i].Block) {
+				// Same activation block but a different policy, report it as
+				// a dropped era so the rewind targets the era start.
+				return stored[i].Block, nil
+			}
+			return stored[i].Block, next[i].Block
+		}
+	}
+	return nil, nil
+}
+
+// difficultyForkMismatch returns the activation blocks of the first difficulty
+// switches that differ between two schedules, or nils if they are identical.
+func difficultyForkMismatch(stored, next []DifficultyFork) (*big.Int, *big.Int) {
+	for i := 0; i < len(stored) || i < len(next); i++ {
+		switch {
+		case i >= len(stored):
+			return nil, next[i].Block
+		case i >= len(next):
+			return stored[i].Block, nil
+		case !stored[i].equal(&next[i]):
+			if configNumEqual(stored[i].Block, next[i].Block) {
+				// Same activation block but a different algorithm, report it
+				// as a dropped switch so the rewind targets the activation.
+				return stored[i].Block, nil
+			}
+			return stored[i].Block, next[i].Block
+		}
+	}
+	return nil, nil
+}
+
+// equal returns whether two difficulty switches select the same algorithm with
+// the same parameters at the same block.
+func (f *DifficultyFork) equal(other *DifficultyFork) bool {
+	return configNumEqual(f.Block, other.Block) && f.Algorithm == other.Algorithm &&
+		f.TargetTime == other.TargetTime && f.Window == other.Window && f.HalfLife == other.HalfLife
+}
+
 // isForkIncompatible returns true if a fork scheduled at s1 cannot be rescheduled to
 // block s2 because head is already past the fork.
 func isForkIncompatible(s1, s2, head *big.Int) bool {
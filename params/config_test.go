@@ -20,6 +20,8 @@ import (
 	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/TeamEGEM/go-egem/common"
 )
 
 func TestCheckCompatible(t *testing.T) {
@@ -70,6 +72,34 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     9,
 			},
 		},
+		{
+			stored:  &ChainConfig{Ethash: new(EthashConfig)},
+			new:     &ChainConfig{Ethash: &EthashConfig{Eras: MainnetRewardEras[:2]}},
+			head:    350000,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{Ethash: new(EthashConfig)},
+			new:    &ChainConfig{Ethash: &EthashConfig{Eras: MainnetRewardEras[:2]}},
+			head:   400000,
+			wantErr: &ConfigCompatError{
+				What:         "Ethash reward era",
+				StoredConfig: big.NewInt(350001),
+				NewConfig:    nil,
+				RewindTo:     350000,
+			},
+		},
+		{
+			stored: &ChainConfig{Ethash: &EthashConfig{Difficulty: []DifficultyFork{{Block: big.NewInt(100), Algorithm: DifficultyLWMA}}}},
+			new:    &ChainConfig{Ethash: &EthashConfig{Difficulty: []DifficultyFork{{Block: big.NewInt(100), Algorithm: DifficultyASERT}}}},
+			head:   150,
+			wantErr: &ConfigCompatError{
+				What:         "Ethash difficulty fork",
+				StoredConfig: big.NewInt(100),
+				NewConfig:    nil,
+				RewindTo:     99,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -79,3 +109,101 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestRewardEra(t *testing.T) {
+	tests := []struct {
+		number uint64
+		want   *big.Int
+	}{
+		{0, big.NewInt(0)},
+		{5000, big.NewInt(0)},
+		{5001, big.NewInt(5001)},
+		{350000, big.NewInt(5001)},
+		{350001, big.NewInt(350001)},
+		{2500000, big.NewInt(350001)},
+		{15000001, big.NewInt(15000001)},
+		{100000000, big.NewInt(15000001)},
+	}
+	var config *EthashConfig
+	for i, tt := range tests {
+		era := config.RewardEra(new(big.Int).SetUint64(tt.number))
+		if era == nil || era.Block.Cmp(tt.want) != 0 {
+			t.Errorf("test %d: era mismatch for block %d: have %v, want %v", i, tt.number, era, tt.want)
+		}
+	}
+	custom := &EthashConfig{Eras: []RewardEra{{Block: big.NewInt(10), BlockReward: big.NewInt(1)}}}
+	if era := custom.RewardEra(big.NewInt(9)); era != nil {
+		t.Errorf("era found before schedule start: %v", era)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var (
+		fund  = DevReward{Address: common.HexToAddress("0x01"), Amount: big.NewInt(Finney)}
+		fund2 = DevReward{Address: common.HexToAddress("0x02"), Amount: big.NewInt(Finney)}
+	)
+	tests := []struct {
+		config *EthashConfig
+		ok     bool
+	}{
+		// The main network schedule and empty configs are valid
+		{&EthashConfig{Eras: MainnetRewardEras}, true},
+		{new(EthashConfig), true},
+		// Decreasing rewards are valid
+		{&EthashConfig{Eras: []RewardEra{
+			{Block: big.NewInt(0), BlockReward: big.NewInt(2 * Ether), DevRewards: []DevReward{fund, fund2}},
+			{Block: big.NewInt(10), BlockReward: big.NewInt(Ether), DevRewards: []DevReward{fund}},
+		}}, true},
+		// Eras not ordered by block height
+		{&EthashConfig{Eras: []RewardEra{
+			{Block: big.NewInt(10), BlockReward: big.NewInt(Ether)},
+			{Block: big.NewInt(10), BlockReward: big.NewInt(Ether)},
+		}}, false},
+		{&EthashConfig{Eras: []RewardEra{
+			{Block: big.NewInt(10), BlockReward: big.NewInt(Ether)},
+			{Block: big.NewInt(5), BlockReward: big.NewInt(Ether)},
+		}}, false},
+		// Dev reward to the zero address
+		{&EthashConfig{Eras: []RewardEra{
+			{Block: big.NewInt(0), BlockReward: big.NewInt(Ether), DevRewards: []DevReward{{Address: common.Address{}, Amount: big.NewInt(Finney)}}},
+		}}, false},
+		// Duplicate dev reward
+		{&EthashConfig{Eras: []RewardEra{
+			{Block: big.NewInt(0), BlockReward: big.NewInt(Ether), DevRewards: []DevReward{fund, fund}},
+		}}, false},
+		// Increasing block reward
+		{&EthashConfig{Eras: []RewardEra{
+			{Block: big.NewInt(0), BlockReward: big.NewInt(Ether)},
+			{Block: big.NewInt(10), BlockReward: big.NewInt(2 * Ether)},
+		}}, false},
+		// Increasing dev-fund payout
+		{&EthashConfig{Eras: []RewardEra{
+			{Block: big.NewInt(0), BlockReward: big.NewInt(Ether), DevRewards: []DevReward{fund}},
+			{Block: big.NewInt(10), BlockReward: big.NewInt(Ether), DevRewards: []DevReward{fund, fund2}},
+		}}, false},
+		// Dev-fund payouts may start after eras without any, but not restart higher
+		{&EthashConfig{Eras: []RewardEra{
+			{Block: big.NewInt(0), BlockReward: big.NewInt(Ether)},
+			{Block: big.NewInt(10), BlockReward: big.NewInt(Ether), DevRewards: []DevReward{fund, fund2}},
+		}}, true},
+		{&EthashConfig{Eras: []RewardEra{
+			{Block: big.NewInt(0), BlockReward: big.NewInt(Ether), DevRewards: []DevReward{fund}},
+			{Block: big.NewInt(10), BlockReward: big.NewInt(Ether)},
+			{Block: big.NewInt(20), BlockReward: big.NewInt(Ether), DevRewards: []DevReward{fund, fund2}},
+		}}, false},
+		// Missing era parameters
+		{&EthashConfig{Eras: []RewardEra{{BlockReward: big.NewInt(Ether)}}}, false},
+		{&EthashConfig{Eras: []RewardEra{{Block: big.NewInt(0)}}}, false},
+		// Difficulty forks
+		{&EthashConfig{Difficulty: []DifficultyFork{{Block: big.NewInt(10), Algorithm: DifficultyLWMA}, {Block: big.NewInt(20), Algorithm: DifficultyASERT}}}, true},
+		{&EthashConfig{Difficulty: []DifficultyFork{{Block: big.NewInt(20), Algorithm: DifficultyLWMA}, {Block: big.NewInt(10), Algorithm: DifficultyASERT}}}, false},
+		{&EthashConfig{Difficulty: []DifficultyFork{{Block: big.NewInt(10), Algorithm: "unknown"}}}, false},
+		{&EthashConfig{Difficulty: []DifficultyFork{{Algorithm: DifficultyLWMA}}}, false},
+	}
+	for i, test := range tests {
+		err := (&ChainConfig{Ethash: test.config}).Validate()
+		if (err == nil) != test.ok {
+			t.Errorf("test %d: validity mismatch: have %v, want ok=%v", i, err, test.ok)
+		}
+	}
+}
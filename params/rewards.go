@@ -0,0 +1,112 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"math/big"
+
+	"github.com/TeamEGEM/go-egem/common"
+)
+
+// RewardEra is a single step of the ethash monetary policy. An era is in effect
+// from its starting block until the starting block of the next era.
+type RewardEra struct {
+	Block       *big.Int    `json:"block"`                // First block the era applies to
+	BlockReward *big.Int    `json:"blockReward"`          // Static reward in wei credited to the miner
+	Uncles      UnclePolicy `json:"uncles"`               // How uncle inclusions are rewarded
+	DevRewards  []DevReward `json:"devRewards,omitempty"` // Fixed payouts credited alongside every block
+}
+
+// UnclePolicy defines how a block including uncles is rewarded.
+type UnclePolicy struct {
+	// InclusionDivisor is the fraction of the running miner reward added as a
+	// bonus for every included uncle (nil = no bonus). The bonus is computed on
+	// the reward accumulated so far, so it compounds with each uncle.
+	InclusionDivisor *big.Int `json:"inclusionDivisor,omitempty"`
+
+	// RewardUncles credits the uncle coinbases with the standard ethash uncle
+	// reward of (uncle + 8 - number) * reward / 8.
+	RewardUncles bool `json:"rewardUncles,omitempty"`
+}
+
+// DevReward is a fixed payout to a development fund address.
+type DevReward struct {
+	Address common.Address `json:"address"` // Recipient of the payout
+	Amount  *big.Int       `json:"amount"`  // Payout in wei per block
+}
+
+// Development fund recipients of the EGEM main network.
+var (
+	// Original dev-fund addresses, paid up to and including block 350000.
+	egemDevFund0 = common.HexToAddress("0x3fa6576610cac6c68e88ee68de07b104c9524fda")
+	egemDevFund1 = common.HexToAddress("0xfc0f0a5F06cB00c9EB435127142ac79ac6F48B94")
+	egemDevFund2 = common.HexToAddress("0x0666bf13ab1902de7dee4f8193c819118d7e21a6")
+	egemDevFund3 = common.HexToAddress("0xcEf0890408b4FC0DC025c8F581c77383529D38B6")
+
+	// Multisig dev-fund addresses, paid from block 350001 onwards.
+	egemDevFund0F = common.HexToAddress("0x1140e31A4A7ae014E55f6c235af027C5CFABCA17") // riddlez
+	egemDevFund1F = common.HexToAddress("0x63e9ceFD428D37430205c0ab8fa2a34A21F911Ac") // beast/tbates
+	egemDevFund2F = common.HexToAddress("0x2025ed239a8dec4de0034a252d5c5e385b73fcd0") // osoese
+	egemDevFund3F = common.HexToAddress("0xe485aA04bb231f331B85BF64614737c6495CC4b3") // jal
+)
+
+// MainnetRewardEras is the reward schedule of the EGEM main network. It is the
+// schedule used by any ethash chain that does not configure its own.
+var MainnetRewardEras = []RewardEra{
+	newEGEMRewardEra(0, 8*Ether, 0),
+	newEGEMRewardEra(5001, 8*Ether, 250*Finney, egemDevFund0, egemDevFund1, egemDevFund2, egemDevFund3),
+	newEGEMRewardEra(350001, 8*Ether, 250*Finney, egemDevFund0F, egemDevFund1F, egemDevFund2F, egemDevFund3F),
+	newEGEMRewardEra(2500001, 4*Ether, 187500*Szabo, egemDevFund0F, egemDevFund1F, egemDevFund2F, egemDevFund3F),
+	newEGEMRewardEra(5000001, 2*Ether, 125*Finney, egemDevFund0F, egemDevFund1F, egemDevFund2F, egemDevFund3F),
+	newEGEMRewardEra(7500001, 1*Ether, 62500*Szabo, egemDevFund0F, egemDevFund1F, egemDevFund2F, egemDevFund3F),
+	newEGEMRewardEra(10000001, 500*Finney, 25*Finney, egemDevFund0F, egemDevFund1F, egemDevFund2F, egemDevFund3F),
+	newEGEMRewardEra(12500001, 250*Finney, 12500*Szabo, egemDevFund0F, egemDevFund1F, egemDevFund2F, egemDevFund3F),
+	newEGEMRewardEra(15000001, 125*Finney, 6250*Szabo, egemDevFund0F, egemDevFund1F, egemDevFund2F, egemDevFund3F),
+}
+
+// newEGEMRewardEra assembles a main network era, paying the same dev reward to
+// each of the given funds and a compounding 1/32 bonus per included uncle.
+func newEGEMRewardEra(block uint64, reward, devReward int64, funds ...common.Address) RewardEra {
+	era := RewardEra{
+		Block:       new(big.Int).SetUint64(block),
+		BlockReward: big.NewInt(reward),
+		Uncles:      UnclePolicy{InclusionDivisor: big.NewInt(32)},
+	}
+	for _, fund := range funds {
+		era.DevRewards = append(era.DevRewards, DevReward{Address: fund, Amount: big.NewInt(devReward)})
+	}
+	return era
+}
+
+// equal returns whether two reward eras describe the same policy.
+func (e *RewardEra) equal(other *RewardEra) bool {
+	if !configNumEqual(e.Block, other.Block) || !configNumEqual(e.BlockReward, other.BlockReward) {
+		return false
+	}
+	if !configNumEqual(e.Uncles.InclusionDivisor, other.Uncles.InclusionDivisor) || e.Uncles.RewardUncles != other.Uncles.RewardUncles {
+		return false
+	}
+	if len(e.DevRewards) != len(other.DevRewards) {
+		return false
+	}
+	for i, dev := range e.DevRewards {
+		if dev.Address != other.DevRewards[i].Address || !configNumEqual(dev.Amount, other.DevRewards[i].Amount) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/TeamEGEM/go-egem/common"
+)
+
+// Validate checks the chain configuration for parameters that are internally
+// inconsistent, such as a reward schedule that is not ordered by block height.
+// Unlike CheckCompatible, it doesn't need a previous configuration to compare to.
+func (c *ChainConfig) Validate() error {
+	if c.Ethash != nil {
+		if err := c.Ethash.Validate(); err != nil {
+			return fmt.Errorf("invalid ethash config: %v", err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the reward schedule and difficulty forks of the ethash config.
+// The eras must be ordered by strictly increasing start blocks, pay dev rewards
+// only to non-zero addresses and never increase the block reward of an era over
+// the previous one. The total dev-fund payout may start in any era, as the main
+// network's did after its launch era, but never increases over the last era
+// paying dev rewards.
+func (c *EthashConfig) Validate() error {
+	var prev, paying *RewardEra
+	for i := range c.Eras {
+		era := &c.Eras[i]
+		if err := era.validate(); err != nil {
+			return fmt.Errorf("reward era %d: %v", i, err)
+		}
+		if prev != nil {
+			if era.Block.Cmp(prev.Block) <= 0 {
+				return fmt.Errorf("reward era %d: start block %v not after era %d start block %v", i, era.Block, i-1, prev.Block)
+			}
+			if era.BlockReward.Cmp(prev.BlockReward) > 0 {
+				return fmt.Errorf("reward era %d: block reward %v increases over era %d reward %v", i, era.BlockReward, i-1, prev.BlockReward)
+			}
+		}
+		if paying != nil {
+			if payout, prevPayout := era.DevPayout(), paying.DevPayout(); payout.Cmp(prevPayout) > 0 {
+				return fmt.Errorf("reward era %d: dev-fund payout %v increases over era at block %v payout %v", i, payout, paying.Block, prevPayout)
+			}
+		}
+		if len(era.DevRewards) > 0 {
+			paying = era
+		}
+		prev = era
+	}
+	for i, fork := range c.Difficulty {
+		if fork.Block == nil {
+			return fmt.Errorf("difficulty fork %d: missing block", i)
+		}
+		if i > 0 && fork.Block.Cmp(c.Difficulty[i-1].Block) <= 0 {
+			return fmt.Errorf("difficulty fork %d: block %v not after fork %d block %v", i, fork.Block, i-1, c.Difficulty[i-1].Block)
+		}
+		switch fork.Algorithm {
+		case DifficultyEGEM, DifficultyLWMA, DifficultyASERT:
+		default:
+			return fmt.Errorf("difficulty fork %d: unknown algorithm %q", i, fork.Algorithm)
+		}
+	}
+	return nil
+}
+
+// validate checks the parameters of a single reward era.
+func (e *RewardEra) validate() error {
+	if e.Block == nil || e.Block.Sign() < 0 {
+		return fmt.Errorf("invalid start block %v", e.Block)
+	}
+	if e.BlockReward == nil || e.BlockReward.Sign() < 0 {
+		return fmt.Errorf("invalid block reward %v", e.BlockReward)
+	}
+	if e.Uncles.InclusionDivisor != nil && e.Uncles.InclusionDivisor.Sign() <= 0 {
+		return fmt.Errorf("invalid uncle inclusion divisor %v", e.Uncles.InclusionDivisor)
+	}
+	seen := make(map[common.Address]bool)
+	for _, dev := range e.DevRewards {
+		if dev.Address == (common.Address{}) {
+			return fmt.Errorf("dev reward to the zero address")
+		}
+		if seen[dev.Address] {
+			return fmt.Errorf("duplicate dev reward to %x", dev.Address)
+		}
+		seen[dev.Address] = true
+
+		if dev.Amount == nil || dev.Amount.Sign() <= 0 {
+			return fmt.Errorf("invalid dev reward %v to %x", dev.Amount, dev.Address)
+		}
+	}
+	return nil
+}
+
+// DevPayout returns the sum of the dev rewards paid with every block of the era.
+func (e *RewardEra) DevPayout() *big.Int {
+	total := new(big.Int)
+	for _, dev := range e.DevRewards {
+		total.Add(total, dev.Amount)
+	}
+	return total
+}
@@ -150,10 +150,15 @@ func (t *httpReadWriteNopCloser) Close() error {
 //
 // Deprecated: Server implements http.Handler
 func NewHTTPServer(cors []string, vhosts []string, srv *Server) *http.Server {
+	return &http.Server{Handler: NewHTTPHandlerStack(srv, cors, vhosts)}
+}
+
+// NewHTTPHandlerStack wraps an HTTP handler with the same CORS and virtual host
+// filtering as the HTTP RPC server.
+func NewHTTPHandlerStack(srv http.Handler, cors []string, vhosts []string) http.Handler {
 	// Wrap the CORS-handler within a host-handler
 	handler := newCorsHandler(srv, cors)
-	handler = newVHostHandler(vhosts, handler)
-	return &http.Server{Handler: handler}
+	return newVHostHandler(vhosts, handler)
 }
 
 // ServeHTTP serves JSON-RPC requests over HTTP.
@@ -194,7 +199,7 @@ func validateRequest(r *http.Request) (int, error) {
 	return 0, nil
 }
 
-func newCorsHandler(srv *Server, allowedOrigins []string) http.Handler {
+func newCorsHandler(srv http.Handler, allowedOrigins []string) http.Handler {
 	// disable CORS support if user has not specified a custom CORS configuration
 	if len(allowedOrigins) == 0 {
 		return srv
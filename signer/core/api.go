@@ -0,0 +1,232 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package core implements the signing service of the standalone external signer.
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/TeamEGEM/go-egem/accounts"
+	"github.com/TeamEGEM/go-egem/accounts/keystore"
+	"github.com/TeamEGEM/go-egem/accounts/usbwallet"
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/crypto"
+	"github.com/TeamEGEM/go-egem/internal/ethapi"
+	"github.com/TeamEGEM/go-egem/log"
+	"github.com/TeamEGEM/go-egem/rlp"
+)
+
+// ErrRequestDenied is returned if the user or the rules deny a request.
+var ErrRequestDenied = errors.New("request denied")
+
+// ExternalAPI defines the external API through which signing requests are made.
+// It is exposed over RPC in the "account" namespace.
+type ExternalAPI interface {
+	// List returns the addresses of the accounts the user allows to be revealed.
+	List(ctx context.Context) ([]common.Address, error)
+
+	// SignTransaction requests the signing of a fully populated transaction.
+	SignTransaction(ctx context.Context, args SendTxArgs) (*ethapi.SignTransactionResult, error)
+
+	// SignData requests the signing of arbitrary data, prefixed according to
+	// personal_sign to prevent it being a valid transaction.
+	SignData(ctx context.Context, addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error)
+}
+
+// SignerUI specifies what methods a UI needs to implement to be able to be used
+// as an approval frontend for the signer.
+type SignerUI interface {
+	// ApproveTx prompts the user for confirmation to sign a transaction.
+	ApproveTx(request *SignTxRequest) (SignTxResponse, error)
+
+	// ApproveSignData prompts the user for confirmation to sign data.
+	ApproveSignData(request *SignDataRequest) (SignDataResponse, error)
+
+	// ApproveListing prompts the user for confirmation to list accounts. The
+	// accounts returned are the ones revealed to the requester.
+	ApproveListing(request *ListRequest) (ListResponse, error)
+
+	// ShowError displays an error message to the user.
+	ShowError(message string)
+
+	// ShowInfo displays an informational message to the user.
+	ShowInfo(message string)
+
+	// OnApprovedTx notifies the UI about a transaction having been successfully
+	// signed. This can be used for bookkeeping, e.g. of spent amounts.
+	OnApprovedTx(tx ethapi.SignTransactionResult)
+
+	// OnSignerStartup is invoked once the signer is up and reachable.
+	OnSignerStartup(info StartupInfo)
+}
+
+// SignerAPI implements ExternalAPI by consulting a SignerUI about every request
+// and signing the approved ones with the wallets of an account manager.
+type SignerAPI struct {
+	chainID *big.Int
+	am      *accounts.Manager
+	ui      SignerUI
+}
+
+// NewAccountManager creates an account manager with the keystore at the given
+// location and, unless disabled, the supported USB hardware wallets.
+func NewAccountManager(ksLocation string, noUSB, lightKDF bool) *accounts.Manager {
+	n, p := keystore.StandardScryptN, keystore.StandardScryptP
+	if lightKDF {
+		n, p = keystore.LightScryptN, keystore.LightScryptP
+	}
+	backends := []accounts.Backend{
+		keystore.NewKeyStore(ksLocation, n, p),
+	}
+	if !noUSB {
+		// Start a USB hub for Ledger hardware wallets
+		if ledgerhub, err := usbwallet.NewLedgerHub(); err != nil {
+			log.Warn(fmt.Sprintf("Failed to start Ledger hub, disabling: %v", err))
+		} else {
+			backends = append(backends, ledgerhub)
+		}
+		// Start a USB hub for Trezor hardware wallets
+		if trezorhub, err := usbwallet.NewTrezorHub(); err != nil {
+			log.Warn(fmt.Sprintf("Failed to start Trezor hub, disabling: %v", err))
+		} else {
+			backends = append(backends, trezorhub)
+		}
+	}
+	return accounts.NewManager(backends...)
+}
+
+// NewSignerAPI creates a new signing service for the given chain, signing with
+// the wallets of am after approval by ui.
+func NewSignerAPI(chainID int64, am *accounts.Manager, ui SignerUI) *SignerAPI {
+	return &SignerAPI{
+		chainID: big.NewInt(chainID),
+		am:      am,
+		ui:      ui,
+	}
+}
+
+// List returns the set of wallet accounts this signer manages, filtered by the
+// UI. Each wallet can potentially contain more than one account.
+func (api *SignerAPI) List(ctx context.Context) ([]common.Address, error) {
+	accs := make([]Account, 0)
+	for _, wallet := range api.am.Wallets() {
+		for _, acc := range wallet.Accounts() {
+			accs = append(accs, Account{Type: "Account", URL: wallet.URL().String(), Address: acc.Address})
+		}
+	}
+	result, err := api.ui.ApproveListing(&ListRequest{Accounts: accs})
+	if err != nil {
+		return nil, err
+	}
+	if result.Accounts == nil {
+		return nil, ErrRequestDenied
+	}
+	addrs := make([]common.Address, 0, len(result.Accounts))
+	for _, acc := range result.Accounts {
+		addrs = append(addrs, acc.Address)
+	}
+	return addrs, nil
+}
+
+// SignTransaction signs the given transaction and returns it both as a JSON
+// object and in its RLP encoded form, if the UI approves it.
+func (api *SignerAPI) SignTransaction(ctx context.Context, args SendTxArgs) (*ethapi.SignTransactionResult, error) {
+	if err := args.validate(); err != nil {
+		return nil, err
+	}
+	result, err := api.ui.ApproveTx(&SignTxRequest{Transaction: args})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Approved {
+		return nil, ErrRequestDenied
+	}
+	// Look up the wallet containing the requested signer and sign the transaction
+	account := accounts.Account{Address: args.From}
+	wallet, err := api.am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	// Hardware wallets have the user confirm on the device instead of taking a
+	// passphrase, so sign without one there
+	var signed *types.Transaction
+	switch wallet.URL().Scheme {
+	case usbwallet.LedgerScheme, usbwallet.TrezorScheme:
+		signed, err = wallet.SignTx(account, args.toTransaction(), api.chainID)
+	default:
+		signed, err = wallet.SignTxWithPassphrase(account, result.Password, args.toTransaction(), api.chainID)
+	}
+	if err != nil {
+		api.ui.ShowError(err.Error())
+		return nil, err
+	}
+	data, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		return nil, err
+	}
+	response := ethapi.SignTransactionResult{Raw: data, Tx: signed}
+
+	// Finally, send the signed tx to the UI
+	api.ui.OnApprovedTx(response)
+	return &response, nil
+}
+
+// SignData signs the hash of the provided data, after prefixing it with the
+// personal_sign preamble, if the UI approves it.
+//
+// Note, the produced signature conforms to the secp256k1 curve R, S and V values,
+// where the V value will be 27 or 28 for legacy reasons.
+func (api *SignerAPI) SignData(ctx context.Context, addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+	hash, msg := SignHash(data)
+
+	result, err := api.ui.ApproveSignData(&SignDataRequest{Address: addr, Rawdata: data, Message: msg, Hash: hash})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Approved {
+		return nil, ErrRequestDenied
+	}
+	// Look up the wallet containing the requested signer and sign the hash
+	account := accounts.Account{Address: addr}
+	wallet, err := api.am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := wallet.SignHashWithPassphrase(account, result.Password, hash)
+	if err != nil {
+		api.ui.ShowError(err.Error())
+		return nil, err
+	}
+	signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	return signature, nil
+}
+
+// SignHash is a helper function that calculates a hash for the given message
+// that can be safely used to calculate a signature from, along with the message
+// that was hashed.
+//
+// The hash is calculated as keccak256("\x19Ethereum Signed Message:\n"${message
+// length}${message}). This gives context to the signed message and prevents signing of transactions.
+func SignHash(data []byte) ([]byte, string) {
+	msg := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(data), data)
+	return crypto.Keccak256([]byte(msg)), msg
+}
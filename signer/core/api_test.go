@@ -0,0 +1,250 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ethereum "github.com/TeamEGEM/go-egem"
+	"github.com/TeamEGEM/go-egem/accounts"
+	"github.com/TeamEGEM/go-egem/accounts/keystore"
+	"github.com/TeamEGEM/go-egem/accounts/usbwallet"
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/core/types"
+	"github.com/TeamEGEM/go-egem/crypto"
+	"github.com/TeamEGEM/go-egem/event"
+	"github.com/TeamEGEM/go-egem/internal/ethapi"
+	"github.com/TeamEGEM/go-egem/rlp"
+)
+
+// headlessUI is a SignerUI answering every request with a preset verdict.
+type headlessUI struct {
+	approve  bool
+	password string
+	signed   []ethapi.SignTransactionResult
+}
+
+func (ui *headlessUI) ApproveTx(request *SignTxRequest) (SignTxResponse, error) {
+	return SignTxResponse{Approved: ui.approve, Password: ui.password}, nil
+}
+
+func (ui *headlessUI) ApproveSignData(request *SignDataRequest) (SignDataResponse, error) {
+	return SignDataResponse{Approved: ui.approve, Password: ui.password}, nil
+}
+
+func (ui *headlessUI) ApproveListing(request *ListRequest) (ListResponse, error) {
+	if !ui.approve {
+		return ListResponse{}, nil
+	}
+	return ListResponse{Accounts: request.Accounts}, nil
+}
+
+func (ui *headlessUI) ShowError(message string) {}
+func (ui *headlessUI) ShowInfo(message string)  {}
+func (ui *headlessUI) OnApprovedTx(tx ethapi.SignTransactionResult) {
+	ui.signed = append(ui.signed, tx)
+}
+func (ui *headlessUI) OnSignerStartup(info StartupInfo) {}
+
+// setup creates a signer with a single account protected by the password "pass".
+func setup(t *testing.T) (*SignerAPI, *headlessUI, common.Address, func()) {
+	dir, err := ioutil.TempDir("", "signer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Create the account up front, as the manager tracks new ones asynchronously
+	ks := keystore.NewKeyStore(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	am := NewAccountManager(filepath.Join(dir, "keystore"), true, true)
+	ui := &headlessUI{approve: true, password: "pass"}
+	return NewSignerAPI(1987, am, ui), ui, account.Address, func() { am.Close(); os.RemoveAll(dir) }
+}
+
+func TestList(t *testing.T) {
+	api, ui, addr, teardown := setup(t)
+	defer teardown()
+
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list accounts: %v", err)
+	}
+	if len(list) != 1 || list[0] != addr {
+		t.Errorf("account list mismatch: have %x, want [%x]", list, addr)
+	}
+	ui.approve = false
+	if _, err := api.List(context.Background()); err != ErrRequestDenied {
+		t.Errorf("denied listing error mismatch: have %v, want %v", err, ErrRequestDenied)
+	}
+}
+
+func TestSignTransaction(t *testing.T) {
+	api, ui, addr, teardown := setup(t)
+	defer teardown()
+
+	to := common.HexToAddress("0x0000000000000000000000000000000000000042")
+	args := SendTxArgs{
+		From:     addr,
+		To:       &to,
+		Gas:      21000,
+		GasPrice: hexutil.Big(*big.NewInt(1)),
+		Value:    hexutil.Big(*big.NewInt(1000)),
+		Nonce:    7,
+	}
+	res, err := api.SignTransaction(context.Background(), args)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	var tx types.Transaction
+	if err := rlp.DecodeBytes(res.Raw, &tx); err != nil {
+		t.Fatalf("failed to decode signed transaction: %v", err)
+	}
+	from, err := types.Sender(types.NewEIP155Signer(big.NewInt(1987)), &tx)
+	if err != nil {
+		t.Fatalf("failed to recover sender: %v", err)
+	}
+	if from != addr || tx.Nonce() != 7 || *tx.To() != to || tx.Value().Int64() != 1000 {
+		t.Errorf("signed transaction mismatch: from %x nonce %d to %x value %v", from, tx.Nonce(), tx.To(), tx.Value())
+	}
+	if len(ui.signed) != 1 || ui.signed[0].Tx.Hash() != tx.Hash() {
+		t.Errorf("UI not notified of signed transaction")
+	}
+	// Wrong passwords and denials must both fail the request
+	ui.password = "wrong"
+	if _, err := api.SignTransaction(context.Background(), args); err != keystore.ErrDecrypt {
+		t.Errorf("wrong password error mismatch: have %v, want %v", err, keystore.ErrDecrypt)
+	}
+	ui.approve = false
+	if _, err := api.SignTransaction(context.Background(), args); err != ErrRequestDenied {
+		t.Errorf("denied transaction error mismatch: have %v, want %v", err, ErrRequestDenied)
+	}
+	// Contract creations without code must be rejected before reaching the UI
+	args.To = nil
+	if _, err := api.SignTransaction(context.Background(), args); err == nil || !strings.Contains(err.Error(), "contract creation") {
+		t.Errorf("empty contract creation error mismatch: have %v", err)
+	}
+}
+
+// hardwareWallet is a stub Ledger wallet signing with a local key. Like the real
+// devices, it doesn't support passphrases.
+type hardwareWallet struct {
+	key *ecdsa.PrivateKey
+}
+
+func (w *hardwareWallet) URL() accounts.URL {
+	return accounts.URL{Scheme: usbwallet.LedgerScheme, Path: "stub"}
+}
+func (w *hardwareWallet) Status() (string, error)      { return "Online", nil }
+func (w *hardwareWallet) Open(passphrase string) error { return nil }
+func (w *hardwareWallet) Close() error                 { return nil }
+func (w *hardwareWallet) Accounts() []accounts.Account {
+	return []accounts.Account{{Address: crypto.PubkeyToAddress(w.key.PublicKey), URL: w.URL()}}
+}
+func (w *hardwareWallet) Contains(account accounts.Account) bool {
+	return account.Address == crypto.PubkeyToAddress(w.key.PublicKey)
+}
+func (w *hardwareWallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+func (w *hardwareWallet) SelfDerive(base accounts.DerivationPath, chain ethereum.ChainStateReader) {}
+func (w *hardwareWallet) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+func (w *hardwareWallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.NewEIP155Signer(chainID), w.key)
+}
+func (w *hardwareWallet) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+func (w *hardwareWallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// hardwareBackend is an account backend holding a single stub hardware wallet.
+type hardwareBackend struct {
+	wallet accounts.Wallet
+	feed   event.Feed
+}
+
+func (b *hardwareBackend) Wallets() []accounts.Wallet { return []accounts.Wallet{b.wallet} }
+func (b *hardwareBackend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return b.feed.Subscribe(sink)
+}
+
+// Tests that transactions from hardware wallets are signed without a passphrase.
+func TestSignTransactionHardwareWallet(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	am := accounts.NewManager(&hardwareBackend{wallet: &hardwareWallet{key: key}})
+	defer am.Close()
+	api := NewSignerAPI(1987, am, &headlessUI{approve: true})
+
+	to := common.HexToAddress("0x0000000000000000000000000000000000000042")
+	res, err := api.SignTransaction(context.Background(), SendTxArgs{
+		From:     addr,
+		To:       &to,
+		Gas:      21000,
+		GasPrice: hexutil.Big(*big.NewInt(1)),
+		Value:    hexutil.Big(*big.NewInt(1000)),
+	})
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	from, err := types.Sender(types.NewEIP155Signer(big.NewInt(1987)), res.Tx)
+	if err != nil || from != addr {
+		t.Errorf("sender mismatch: have %x, %v; want %x", from, err, addr)
+	}
+}
+
+func TestSignData(t *testing.T) {
+	api, ui, addr, teardown := setup(t)
+	defer teardown()
+
+	data := hexutil.Bytes("Hello EGEM")
+	signature, err := api.SignData(context.Background(), addr, data)
+	if err != nil {
+		t.Fatalf("failed to sign data: %v", err)
+	}
+	if signature[64] != 27 && signature[64] != 28 {
+		t.Errorf("signature V mismatch: have %d, want 27 or 28", signature[64])
+	}
+	signature[64] -= 27
+
+	hash, _ := SignHash(data)
+	pubkey, err := crypto.SigToPub(hash, signature)
+	if err != nil {
+		t.Fatalf("failed to recover public key: %v", err)
+	}
+	if signer := crypto.PubkeyToAddress(*pubkey); signer != addr {
+		t.Errorf("signer mismatch: have %x, want %x", signer, addr)
+	}
+	ui.approve = false
+	if _, err := api.SignData(context.Background(), addr, data); err != ErrRequestDenied {
+		t.Errorf("denied signing error mismatch: have %v, want %v", err, ErrRequestDenied)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"strings"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/internal/ethapi"
+	"github.com/TeamEGEM/go-egem/log"
+)
+
+// AuditLogger is an ExternalAPI wrapper recording every request made to the
+// wrapped API, along with its outcome, into a log file.
+type AuditLogger struct {
+	log log.Logger
+	api ExternalAPI
+}
+
+// NewAuditLogger creates an audit log appending to the file at path, which
+// wraps and forwards all requests to api.
+func NewAuditLogger(path string, api ExternalAPI) (*AuditLogger, error) {
+	l := log.New("api", "signer")
+	handler, err := log.FileHandler(path, log.LogfmtFormat())
+	if err != nil {
+		return nil, err
+	}
+	l.SetHandler(handler)
+	l.Info("Configured", "audit log", path)
+	return &AuditLogger{l, api}, nil
+}
+
+// List implements ExternalAPI, logging the request and the revealed accounts.
+func (l *AuditLogger) List(ctx context.Context) ([]common.Address, error) {
+	l.log.Info("List", "type", "request")
+	res, err := l.api.List(ctx)
+
+	addrs := make([]string, len(res))
+	for i, addr := range res {
+		addrs[i] = addr.Hex()
+	}
+	l.log.Info("List", "type", "response", "accounts", strings.Join(addrs, ","), "error", err)
+	return res, err
+}
+
+// SignTransaction implements ExternalAPI, logging the request and the signed
+// transaction.
+func (l *AuditLogger) SignTransaction(ctx context.Context, args SendTxArgs) (*ethapi.SignTransactionResult, error) {
+	l.log.Info("SignTransaction", "type", "request", "from", args.From, "to", args.To, "value", args.Value.String(),
+		"gas", args.Gas, "gasprice", args.GasPrice.String(), "nonce", args.Nonce, "data", hexutil.Bytes(args.input()))
+
+	res, err := l.api.SignTransaction(ctx, args)
+	if res != nil {
+		l.log.Info("SignTransaction", "type", "response", "hash", res.Tx.Hash(), "raw", res.Raw, "error", err)
+	} else {
+		l.log.Info("SignTransaction", "type", "response", "error", err)
+	}
+	return res, err
+}
+
+// SignData implements ExternalAPI, logging the request and the signature.
+func (l *AuditLogger) SignData(ctx context.Context, addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+	l.log.Info("SignData", "type", "request", "addr", addr, "data", data)
+	res, err := l.api.SignData(ctx, addr, data)
+	l.log.Info("SignData", "type", "response", "signature", res, "error", err)
+	return res, err
+}
@@ -0,0 +1,146 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/core/types"
+)
+
+// SendTxArgs represents a fully populated transaction to be signed by the signer.
+// As opposed to the node's own variant, no defaults are filled in, the requester
+// is expected to provide every field.
+type SendTxArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      hexutil.Uint64  `json:"gas"`
+	GasPrice hexutil.Big     `json:"gasPrice"`
+	Value    hexutil.Big     `json:"value"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	// We accept "data" and "input" for backwards-compatibility reasons. "input" is the
+	// newer name and should be preferred by clients.
+	Data  *hexutil.Bytes `json:"data"`
+	Input *hexutil.Bytes `json:"input"`
+}
+
+// String implements fmt.Stringer, returning a human readable summary of the
+// transaction to be presented for approval.
+func (args SendTxArgs) String() string {
+	to := "<contract creation>"
+	if args.To != nil {
+		to = args.To.Hex()
+	}
+	b := new(bytes.Buffer)
+	fmt.Fprintf(b, "from:     %s\n", args.From.Hex())
+	fmt.Fprintf(b, "to:       %s\n", to)
+	fmt.Fprintf(b, "value:    %v wei\n", args.Value.ToInt())
+	fmt.Fprintf(b, "gas:      %d\n", uint64(args.Gas))
+	fmt.Fprintf(b, "gasprice: %v wei\n", args.GasPrice.ToInt())
+	fmt.Fprintf(b, "nonce:    %d\n", uint64(args.Nonce))
+	if data := args.input(); len(data) > 0 {
+		fmt.Fprintf(b, "data:     %#x\n", data)
+	}
+	return b.String()
+}
+
+// validate checks that the arguments describe a transaction which can be signed.
+func (args *SendTxArgs) validate() error {
+	if args.Data != nil && args.Input != nil && !bytes.Equal(*args.Data, *args.Input) {
+		return errors.New(`both "data" and "input" are set and not equal`)
+	}
+	if args.To == nil && len(args.input()) == 0 {
+		return errors.New("contract creation without any data provided")
+	}
+	return nil
+}
+
+// input returns the call data of the transaction, whichever field it was
+// supplied in.
+func (args *SendTxArgs) input() []byte {
+	if args.Data != nil {
+		return *args.Data
+	}
+	if args.Input != nil {
+		return *args.Input
+	}
+	return nil
+}
+
+// toTransaction converts the arguments into an unsigned transaction.
+func (args *SendTxArgs) toTransaction() *types.Transaction {
+	if args.To == nil {
+		return types.NewContractCreation(uint64(args.Nonce), (*big.Int)(&args.Value), uint64(args.Gas), (*big.Int)(&args.GasPrice), args.input())
+	}
+	return types.NewTransaction(uint64(args.Nonce), *args.To, (*big.Int)(&args.Value), uint64(args.Gas), (*big.Int)(&args.GasPrice), args.input())
+}
+
+// Account is an account managed by the signer, as presented to the user when
+// listing is requested.
+type Account struct {
+	Type    string         `json:"type"`
+	URL     string         `json:"url"`
+	Address common.Address `json:"address"`
+}
+
+// SignTxRequest contains info about a transaction to sign.
+type SignTxRequest struct {
+	Transaction SendTxArgs `json:"transaction"`
+}
+
+// SignTxResponse is the UI's verdict on a transaction signing request, along
+// with the password needed to unlock the account if approved.
+type SignTxResponse struct {
+	Approved bool   `json:"approved"`
+	Password string `json:"password"`
+}
+
+// SignDataRequest contains info about data to sign. The Message is the text
+// form of Rawdata and Hash is what will actually be signed.
+type SignDataRequest struct {
+	Address common.Address `json:"address"`
+	Rawdata hexutil.Bytes  `json:"raw_data"`
+	Message string         `json:"message"`
+	Hash    hexutil.Bytes  `json:"hash"`
+}
+
+// SignDataResponse is the UI's verdict on a data signing request.
+type SignDataResponse struct {
+	Approved bool   `json:"approved"`
+	Password string `json:"password"`
+}
+
+// ListRequest contains the accounts a listing request would reveal.
+type ListRequest struct {
+	Accounts []Account `json:"accounts"`
+}
+
+// ListResponse contains the subset of accounts the UI allows to be revealed.
+type ListResponse struct {
+	Accounts []Account `json:"accounts"`
+}
+
+// StartupInfo is shown to the UI when the signer has started up, detailing the
+// endpoints it can be reached on.
+type StartupInfo struct {
+	Info map[string]interface{} `json:"info"`
+}
@@ -0,0 +1,239 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package rules implements a scriptable SignerUI, deciding requests according
+// to a javascript rule file before falling back to another UI.
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/internal/ethapi"
+	"github.com/TeamEGEM/go-egem/internal/jsre"
+	"github.com/TeamEGEM/go-egem/log"
+	"github.com/TeamEGEM/go-egem/signer/core"
+	"github.com/TeamEGEM/go-egem/signer/storage"
+	"github.com/robertkrimen/otto"
+)
+
+var (
+	// errUnknownVerdict is returned if a rule returns something other than
+	// "Approve" or "Reject", which defers the request to the next UI.
+	errUnknownVerdict = errors.New("no verdict")
+
+	// errNoCredentials is returned if a request is approved by the rules, but
+	// there is no password to unlock the signing account with.
+	errNoCredentials = errors.New("no credentials for account")
+)
+
+// helpersJS are the utility functions available to the rules, besides the
+// bignumber.js library.
+const helpersJS = `
+function toBigNumber(value) {
+	if (typeof value === "string" && value.indexOf("0x") === 0) {
+		return new BigNumber(value.slice(2), 16);
+	}
+	return new BigNumber(value);
+}
+`
+
+// rulesetUI is a SignerUI which evaluates requests with javascript rules. The
+// rules may approve or reject a request, any other outcome passes the request
+// on to the next UI.
+//
+// A rule is a global function named after the SignerUI method it implements,
+// i.e. ApproveTx, ApproveSignData or ApproveListing, which is given the request
+// as a JSON object and returns "Approve" or "Reject". Quantities are passed as
+// hex strings, which the toBigNumber helper converts into bignumber.js values.
+type rulesetUI struct {
+	next        core.SignerUI   // UI to call for requests the rules don't decide on
+	jsRules     string          // Javascript source of the rules
+	credentials storage.Storage // Passwords to unlock accounts with on approval, keyed by address
+}
+
+// NewRuleEvaluator creates a rule based SignerUI, deferring to next for any
+// request not decided by the rules. Accounts are unlocked for approved requests
+// with the passwords in credentials, stored under the hex addresses.
+func NewRuleEvaluator(next core.SignerUI, credentials storage.Storage) *rulesetUI {
+	return &rulesetUI{
+		next:        next,
+		credentials: credentials,
+	}
+}
+
+// Init loads the javascript rules, making sure they can be evaluated.
+func (r *rulesetUI) Init(javascriptRules string) error {
+	if _, err := r.newVM(javascriptRules); err != nil {
+		return err
+	}
+	r.jsRules = javascriptRules
+	return nil
+}
+
+// newVM creates a fresh javascript environment with the rules loaded. Every
+// evaluation happens in a new environment, so rules can't keep state between
+// requests.
+func (r *rulesetUI) newVM(rules string) (*otto.Otto, error) {
+	vm := otto.New()
+
+	console, err := vm.Object("({})")
+	if err != nil {
+		return nil, err
+	}
+	console.Set("log", consoleOutput)
+	vm.Set("console", console)
+
+	if _, err := vm.Run(jsre.BigNumber_JS); err != nil {
+		return nil, fmt.Errorf("bignumber.js: %v", err)
+	}
+	if _, err := vm.Run(helpersJS); err != nil {
+		return nil, fmt.Errorf("helpers: %v", err)
+	}
+	if _, err := vm.Run(rules); err != nil {
+		return nil, fmt.Errorf("rules: %v", err)
+	}
+	return vm, nil
+}
+
+// consoleOutput is an override for the console.log function, sending the output
+// of the rules to the logger.
+func consoleOutput(call otto.FunctionCall) otto.Value {
+	var output []string
+	for _, argument := range call.ArgumentList {
+		output = append(output, argument.String())
+	}
+	log.Info("Rule output", "msg", strings.Join(output, " "))
+	return otto.UndefinedValue()
+}
+
+// checkApproval invokes the named rule with the request, returning whether the
+// request was approved or rejected. An error means the rules didn't decide.
+func (r *rulesetUI) checkApproval(jsfunc string, request interface{}) (bool, error) {
+	vm, err := r.newVM(r.jsRules)
+	if err != nil {
+		return false, err
+	}
+	if fn, _ := vm.Get(jsfunc); !fn.IsFunction() {
+		return false, errUnknownVerdict
+	}
+	blob, err := json.Marshal(request)
+	if err != nil {
+		return false, err
+	}
+	arg, err := vm.Call("JSON.parse", nil, string(blob))
+	if err != nil {
+		return false, err
+	}
+	result, err := vm.Call(jsfunc, nil, arg)
+	if err != nil {
+		return false, err
+	}
+	switch verdict, _ := result.ToString(); verdict {
+	case "Approve":
+		return true, nil
+	case "Reject":
+		return false, nil
+	default:
+		return false, errUnknownVerdict
+	}
+}
+
+// ApproveTx implements core.SignerUI, deciding with the ApproveTx rule.
+func (r *rulesetUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	approved, err := r.checkApproval("ApproveTx", request)
+	if err == nil && approved {
+		var password string
+		if password, err = r.lookupPassword(request.Transaction.From); err == nil {
+			return core.SignTxResponse{Approved: true, Password: password}, nil
+		}
+	}
+	if err != nil {
+		log.Info("Rules did not decide transaction, passing on", "err", err)
+		return r.next.ApproveTx(request)
+	}
+	log.Info("Transaction rejected by rules", "from", request.Transaction.From)
+	return core.SignTxResponse{Approved: false}, nil
+}
+
+// ApproveSignData implements core.SignerUI, deciding with the ApproveSignData rule.
+func (r *rulesetUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
+	approved, err := r.checkApproval("ApproveSignData", request)
+	if err == nil && approved {
+		var password string
+		if password, err = r.lookupPassword(request.Address); err == nil {
+			return core.SignDataResponse{Approved: true, Password: password}, nil
+		}
+	}
+	if err != nil {
+		log.Info("Rules did not decide data signing, passing on", "err", err)
+		return r.next.ApproveSignData(request)
+	}
+	log.Info("Data signing rejected by rules", "addr", request.Address)
+	return core.SignDataResponse{Approved: false}, nil
+}
+
+// ApproveListing implements core.SignerUI, deciding with the ApproveListing rule.
+// Approval reveals all accounts.
+func (r *rulesetUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
+	approved, err := r.checkApproval("ApproveListing", request)
+	if err != nil {
+		log.Info("Rules did not decide listing, passing on", "err", err)
+		return r.next.ApproveListing(request)
+	}
+	if approved {
+		return core.ListResponse{Accounts: request.Accounts}, nil
+	}
+	return core.ListResponse{}, nil
+}
+
+// lookupPassword retrieves the password of an account to sign with.
+func (r *rulesetUI) lookupPassword(address common.Address) (string, error) {
+	password, err := r.credentials.Get(address.Hex())
+	switch {
+	case err == storage.ErrNotFound:
+		return "", errNoCredentials
+	case err != nil:
+		log.Warn("Failed to read account credentials", "address", address, "err", err)
+		return "", errNoCredentials
+	}
+	return password, nil
+}
+
+// ShowError implements core.SignerUI, forwarding to the next UI.
+func (r *rulesetUI) ShowError(message string) {
+	log.Error(message)
+	r.next.ShowError(message)
+}
+
+// ShowInfo implements core.SignerUI, forwarding to the next UI.
+func (r *rulesetUI) ShowInfo(message string) {
+	log.Info(message)
+	r.next.ShowInfo(message)
+}
+
+// OnApprovedTx implements core.SignerUI, forwarding to the next UI.
+func (r *rulesetUI) OnApprovedTx(tx ethapi.SignTransactionResult) {
+	r.next.OnApprovedTx(tx)
+}
+
+// OnSignerStartup implements core.SignerUI, forwarding to the next UI.
+func (r *rulesetUI) OnSignerStartup(info core.StartupInfo) {
+	r.next.OnSignerStartup(info)
+}
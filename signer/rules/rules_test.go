@@ -0,0 +1,177 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rules
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/TeamEGEM/go-egem/common"
+	"github.com/TeamEGEM/go-egem/common/hexutil"
+	"github.com/TeamEGEM/go-egem/internal/ethapi"
+	"github.com/TeamEGEM/go-egem/signer/core"
+	"github.com/TeamEGEM/go-egem/signer/storage"
+)
+
+// alwaysDenyUI is the UI behind the rules, recording whether it was consulted.
+type alwaysDenyUI struct {
+	consulted int
+}
+
+func (ui *alwaysDenyUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	ui.consulted++
+	return core.SignTxResponse{Approved: false}, nil
+}
+
+func (ui *alwaysDenyUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
+	ui.consulted++
+	return core.SignDataResponse{Approved: false}, nil
+}
+
+func (ui *alwaysDenyUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
+	ui.consulted++
+	return core.ListResponse{}, nil
+}
+
+func (ui *alwaysDenyUI) ShowError(message string)                     {}
+func (ui *alwaysDenyUI) ShowInfo(message string)                      {}
+func (ui *alwaysDenyUI) OnApprovedTx(tx ethapi.SignTransactionResult) {}
+func (ui *alwaysDenyUI) OnSignerStartup(info core.StartupInfo)        {}
+
+var (
+	sender    = common.HexToAddress("0x000000000000000000000000000000000000dead")
+	whitelist = common.HexToAddress("0x000000000000000000000000000000000000beef")
+	stranger  = common.HexToAddress("0x000000000000000000000000000000000000cafe")
+)
+
+// whitelistRules approves transfers of up to 1 ether to the whitelisted
+// address, rejects contract creations and leaves everything else to the user.
+const whitelistRules = `
+var limits = {"0x000000000000000000000000000000000000beef": new BigNumber("1e18")};
+
+function ApproveTx(req) {
+	var tx = req.transaction;
+	if (tx.to === null) {
+		return "Reject";
+	}
+	var limit = limits[tx.to.toLowerCase()];
+	if (limit !== undefined && toBigNumber(tx.value).lessThanOrEqualTo(limit)) {
+		console.log("approving transfer to", tx.to);
+		return "Approve";
+	}
+}
+
+function ApproveListing(req) {
+	return req.accounts.length > 0 ? "Approve" : "Reject";
+}
+`
+
+func tx(to *common.Address, value *big.Int) *core.SignTxRequest {
+	return &core.SignTxRequest{Transaction: core.SendTxArgs{
+		From:  sender,
+		To:    to,
+		Value: hexutil.Big(*value),
+	}}
+}
+
+func TestApproveTx(t *testing.T) {
+	next := new(alwaysDenyUI)
+	credentials := storage.NewEphemeralStorage()
+	credentials.Put(sender.Hex(), "secret")
+
+	r := NewRuleEvaluator(next, credentials)
+	if err := r.Init(whitelistRules); err != nil {
+		t.Fatalf("failed to load rules: %v", err)
+	}
+	ether := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+	tests := []struct {
+		request   *core.SignTxRequest
+		approved  bool
+		consulted int
+	}{
+		{tx(&whitelist, big.NewInt(1)), true, 0},                           // small transfer to whitelist
+		{tx(&whitelist, ether), true, 0},                                   // transfer at the limit
+		{tx(&whitelist, new(big.Int).Add(ether, big.NewInt(1))), false, 1}, // over the limit goes to the user
+		{tx(&stranger, big.NewInt(1)), false, 1},                           // unknown recipient goes to the user
+		{tx(nil, big.NewInt(0)), false, 0},                                 // contract creation rejected outright
+	}
+	for i, tt := range tests {
+		next.consulted = 0
+		res, err := r.ApproveTx(tt.request)
+		if err != nil {
+			t.Errorf("test %d: approval failed: %v", i, err)
+			continue
+		}
+		if res.Approved != tt.approved {
+			t.Errorf("test %d: approval mismatch: have %v, want %v", i, res.Approved, tt.approved)
+		}
+		if res.Approved && res.Password != "secret" {
+			t.Errorf("test %d: password mismatch: have %q, want %q", i, res.Password, "secret")
+		}
+		if next.consulted != tt.consulted {
+			t.Errorf("test %d: next UI consultations mismatch: have %d, want %d", i, next.consulted, tt.consulted)
+		}
+	}
+}
+
+func TestApproveWithoutCredentials(t *testing.T) {
+	next := new(alwaysDenyUI)
+	r := NewRuleEvaluator(next, storage.NewEphemeralStorage())
+	if err := r.Init(whitelistRules); err != nil {
+		t.Fatalf("failed to load rules: %v", err)
+	}
+	// Approved transactions can't be signed without a password, ask the user
+	res, err := r.ApproveTx(tx(&whitelist, big.NewInt(1)))
+	if err != nil {
+		t.Fatalf("approval failed: %v", err)
+	}
+	if res.Approved || next.consulted != 1 {
+		t.Errorf("request not passed on: approved %v, consulted %d", res.Approved, next.consulted)
+	}
+}
+
+func TestMissingRules(t *testing.T) {
+	next := new(alwaysDenyUI)
+	r := NewRuleEvaluator(next, storage.NewEphemeralStorage())
+	if err := r.Init(whitelistRules); err != nil {
+		t.Fatalf("failed to load rules: %v", err)
+	}
+	// There is no rule for data signing, it must be left to the user
+	if _, err := r.ApproveSignData(&core.SignDataRequest{Address: sender}); err != nil {
+		t.Fatalf("approval failed: %v", err)
+	}
+	if next.consulted != 1 {
+		t.Errorf("request not passed on")
+	}
+	// Listing is decided by the rules
+	next.consulted = 0
+	res, err := r.ApproveListing(&core.ListRequest{Accounts: []core.Account{{Address: sender}}})
+	if err != nil {
+		t.Fatalf("listing failed: %v", err)
+	}
+	if len(res.Accounts) != 1 || next.consulted != 0 {
+		t.Errorf("listing not approved by rules: accounts %v, consulted %d", res.Accounts, next.consulted)
+	}
+}
+
+func TestInvalidRules(t *testing.T) {
+	r := NewRuleEvaluator(new(alwaysDenyUI), storage.NewEphemeralStorage())
+	if err := r.Init("function ApproveTx(req) { return "); err == nil {
+		t.Errorf("invalid rules accepted")
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// storedCredential is an encrypted value along with the nonce it was sealed
+// with.
+type storedCredential struct {
+	IV         []byte `json:"iv"`
+	CipherText []byte `json:"c"`
+}
+
+// AESEncryptedStorage is a Storage keeping its values in a JSON file, each one
+// sealed with AES-GCM. The key of an entry is authenticated along with its value,
+// so entries moved to a different key in the file fail to decrypt.
+type AESEncryptedStorage struct {
+	filename string     // File the encrypted values are stored in
+	key      []byte     // AES key to seal the values with
+	lock     sync.Mutex // Lock serializing the read-modify-write cycles of the file
+}
+
+// NewAESEncryptedStorage creates a store backed by the given file, sealing the
+// values with the key, which must be 16, 24 or 32 bytes long.
+func NewAESEncryptedStorage(filename string, key []byte) *AESEncryptedStorage {
+	return &AESEncryptedStorage{
+		filename: filename,
+		key:      key,
+	}
+}
+
+// Put implements Storage, encrypting the value into the backing file.
+func (s *AESEncryptedStorage) Put(key, value string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	creds, err := s.readStorage()
+	if err != nil {
+		return err
+	}
+	iv, ciphertext, err := encrypt(s.key, []byte(value), []byte(key))
+	if err != nil {
+		return err
+	}
+	creds[key] = storedCredential{IV: iv, CipherText: ciphertext}
+	return s.writeStorage(creds)
+}
+
+// Get implements Storage, decrypting the value from the backing file.
+func (s *AESEncryptedStorage) Get(key string) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	creds, err := s.readStorage()
+	if err != nil {
+		return "", err
+	}
+	cred, ok := creds[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	value, err := decrypt(s.key, cred.IV, cred.CipherText, []byte(key))
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// readStorage loads the encrypted values from the backing file, which doesn't
+// need to exist yet.
+func (s *AESEncryptedStorage) readStorage() (map[string]storedCredential, error) {
+	creds := make(map[string]storedCredential)
+
+	blob, err := ioutil.ReadFile(s.filename)
+	if os.IsNotExist(err) {
+		return creds, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(blob, &creds); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// writeStorage replaces the backing file with the given encrypted values,
+// readable only by the current user.
+func (s *AESEncryptedStorage) writeStorage(creds map[string]storedCredential) error {
+	blob, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.filename, blob, 0600)
+}
+
+// encrypt seals the plaintext with AES-GCM under a random nonce, authenticating
+// the additional data along with it.
+func encrypt(key []byte, plaintext []byte, additionalData []byte) ([]byte, []byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, aesgcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, aesgcm.Seal(nil, nonce, plaintext, additionalData), nil
+}
+
+// decrypt opens a ciphertext sealed by encrypt.
+func decrypt(key []byte, nonce []byte, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return aesgcm.Open(nil, nonce, ciphertext, additionalData)
+}
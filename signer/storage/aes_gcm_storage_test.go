@@ -0,0 +1,98 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TeamEGEM/go-egem/crypto"
+)
+
+// newTestStorage creates an encrypted store in a temporary directory.
+func newTestStorage(t *testing.T, key []byte) (*AESEncryptedStorage, func()) {
+	dir, err := ioutil.TempDir("", "signer-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewAESEncryptedStorage(filepath.Join(dir, "credentials.json"), key), func() { os.RemoveAll(dir) }
+}
+
+// Tests that values survive a round trip through the encrypted file, and can't
+// be read with a different key.
+func TestAESEncryptedStorage(t *testing.T) {
+	key := crypto.Keccak256([]byte("key"))
+	store, teardown := newTestStorage(t, key)
+	defer teardown()
+
+	if _, err := store.Get("missing"); err != ErrNotFound {
+		t.Fatalf("missing key error mismatch: have %v, want %v", err, ErrNotFound)
+	}
+	if err := store.Put("alice", "secret"); err != nil {
+		t.Fatalf("failed to store value: %v", err)
+	}
+	if err := store.Put("bob", "hidden"); err != nil {
+		t.Fatalf("failed to store value: %v", err)
+	}
+	// Reopen the file and check the values, also making sure they aren't in plain
+	reopened := NewAESEncryptedStorage(store.filename, key)
+	for key, want := range map[string]string{"alice": "secret", "bob": "hidden"} {
+		if value, err := reopened.Get(key); err != nil || value != want {
+			t.Errorf("value %s mismatch: have %q, %v; want %q", key, value, err, want)
+		}
+	}
+	blob, err := ioutil.ReadFile(store.filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var creds map[string]storedCredential
+	if err := json.Unmarshal(blob, &creds); err != nil {
+		t.Fatal(err)
+	}
+	if string(creds["alice"].CipherText) == "secret" {
+		t.Errorf("value stored in plain")
+	}
+	// A different key must not decrypt anything
+	other := NewAESEncryptedStorage(store.filename, crypto.Keccak256([]byte("other")))
+	if _, err := other.Get("alice"); err == nil {
+		t.Errorf("value decrypted with the wrong key")
+	}
+}
+
+// Tests that values moved to a different key in the file fail to decrypt.
+func TestAESEncryptedStorageSwap(t *testing.T) {
+	store, teardown := newTestStorage(t, crypto.Keccak256([]byte("key")))
+	defer teardown()
+
+	if err := store.Put("alice", "secret"); err != nil {
+		t.Fatalf("failed to store value: %v", err)
+	}
+	creds, err := store.readStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds["bob"] = creds["alice"]
+	if err := store.writeStorage(creds); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("bob"); err == nil {
+		t.Errorf("swapped value decrypted")
+	}
+}
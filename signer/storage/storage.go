@@ -0,0 +1,68 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package storage implements the stores the signer keeps its secrets in.
+package storage
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNotFound is returned if no value is stored under the requested key.
+var ErrNotFound = errors.New("not found")
+
+// Storage is a key-value store of secrets.
+type Storage interface {
+	// Put stores the value under the key, replacing any previous one.
+	Put(key, value string) error
+
+	// Get retrieves the value stored under the key, or ErrNotFound if there is
+	// none.
+	Get(key string) (string, error)
+}
+
+// EphemeralStorage is an in-memory Storage, losing its contents on exit.
+type EphemeralStorage struct {
+	data map[string]string
+	lock sync.RWMutex
+}
+
+// NewEphemeralStorage creates an empty in-memory store.
+func NewEphemeralStorage() *EphemeralStorage {
+	return &EphemeralStorage{data: make(map[string]string)}
+}
+
+// Put implements Storage, storing the value in memory.
+func (s *EphemeralStorage) Put(key, value string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.data[key] = value
+	return nil
+}
+
+// Get implements Storage, retrieving the value from memory.
+func (s *EphemeralStorage) Get(key string) (string, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	value, ok := s.data[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
@@ -56,7 +56,10 @@ func (test *DifficultyTest) Run(config *params.ChainConfig) error {
 		UncleHash:  test.UncleHash,
 	}
 
-	actual := ethash.CalcDifficulty(config, test.CurrentTimestamp.Uint64(), parent)
+	actual, err := ethash.CalcDifficulty(config, test.CurrentTimestamp.Uint64(), parent)
+	if err != nil {
+		return err
+	}
 	exp := test.CurrentDifficulty
 
 	if actual.Cmp(exp) != 0 {
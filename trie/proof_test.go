@@ -20,6 +20,7 @@ import (
 	"bytes"
 	crand "crypto/rand"
 	mrand "math/rand"
+	"sort"
 	"testing"
 	"time"
 
@@ -88,6 +89,153 @@ func TestVerifyBadProof(t *testing.T) {
 	}
 }
 
+// sortedEntries returns the key-value pairs of a trie sorted by key.
+func sortedEntries(vals map[string]*kv) []*kv {
+	entries := make([]*kv, 0, len(vals))
+	for _, kv := range vals {
+		entries = append(entries, kv)
+	}
+	sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].k, entries[j].k) < 0 })
+	return entries
+}
+
+// proveRange collects the proofs of the first key and the last entry of a range.
+func proveRange(t *testing.T, trie *Trie, first []byte, last *kv) *ethdb.MemDatabase {
+	proof, _ := ethdb.NewMemDatabase()
+	if err := trie.Prove(first, 0, proof); err != nil {
+		t.Fatalf("failed to prove first key %x: %v", first, err)
+	}
+	if last != nil {
+		if err := trie.Prove(last.k, 0, proof); err != nil {
+			t.Fatalf("failed to prove last key %x: %v", last.k, err)
+		}
+	}
+	return proof
+}
+
+// rangeData splits a range of entries into its keys and values.
+func rangeData(entries []*kv) ([][]byte, [][]byte) {
+	var keys, vals [][]byte
+	for _, entry := range entries {
+		keys = append(keys, entry.k)
+		vals = append(vals, entry.v)
+	}
+	return keys, vals
+}
+
+// Tests that random ranges of a trie can be proven with the proofs of their edge
+// keys, whether the first key exists in the trie or not.
+func TestRangeProof(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	entries := sortedEntries(vals)
+	root := trie.Hash()
+
+	for i := 0; i < 200; i++ {
+		start := mrand.Intn(len(entries))
+		end := start + 1 + mrand.Intn(len(entries)-start)
+
+		first := entries[start].k
+		if i%2 == 1 && first[len(first)-1] > 0 {
+			// Prove the range from a key missing from the trie
+			first = common.CopyBytes(first)
+			first[len(first)-1]--
+			if start > 0 && bytes.Compare(first, entries[start-1].k) <= 0 {
+				first = entries[start].k
+			}
+		}
+		keys, values := rangeData(entries[start:end])
+		more, err := VerifyRangeProof(root, first, keys, values, proveRange(t, trie, first, entries[end-1]))
+		if err != nil {
+			t.Fatalf("range %d-%d: failed to verify: %v", start, end, err)
+		}
+		if more != (end < len(entries)) {
+			t.Fatalf("range %d-%d: more entries mismatch: have %v, want %v", start, end, more, end < len(entries))
+		}
+	}
+}
+
+// Tests that tampered ranges fail verification.
+func TestBadRangeProof(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	entries := sortedEntries(vals)
+	root := trie.Hash()
+
+	for i := 0; i < 200; i++ {
+		start := mrand.Intn(len(entries) - 2)
+		end := start + 3 + mrand.Intn(len(entries)-start-2)
+		if end > len(entries) {
+			end = len(entries)
+		}
+		keys, values := rangeData(entries[start:end])
+		proof := proveRange(t, trie, keys[0], entries[end-1])
+
+		index := 1 + mrand.Intn(len(keys)-2)
+		switch mrand.Intn(3) {
+		case 0:
+			// Drop an entry from the middle of the range
+			keys = append(keys[:index:index], keys[index+1:]...)
+			values = append(values[:index:index], values[index+1:]...)
+		case 1:
+			// Modify the value of an entry
+			values = append([][]byte{}, values...)
+			values[index] = randBytes(20)
+		case 2:
+			// Replace the key of an entry by one in between its neighbours
+			keys = append([][]byte{}, keys...)
+			keys[index] = common.CopyBytes(keys[index])
+			keys[index][len(keys[index])-1]++
+			if bytes.Compare(keys[index], keys[index+1]) >= 0 {
+				continue
+			}
+		}
+		if _, err := VerifyRangeProof(root, keys[0], keys, values, proof); err == nil {
+			t.Fatalf("range %d-%d: tampered range verified", start, end)
+		}
+	}
+}
+
+// Tests the special cases of range proofs: entire tries without proofs, empty
+// ranges and single entries.
+func TestRangeProofSpecialCases(t *testing.T) {
+	trie, vals := randomTrie(512)
+	entries := sortedEntries(vals)
+	root := trie.Hash()
+
+	// The entire trie must verify without a proof, but not a part of it
+	keys, values := rangeData(entries)
+	if more, err := VerifyRangeProof(root, nil, keys, values, nil); err != nil || more {
+		t.Fatalf("entire trie: have %v, %v; want false, nil", more, err)
+	}
+	if _, err := VerifyRangeProof(root, nil, keys[1:], values[1:], nil); err == nil {
+		t.Fatalf("partial trie verified without proof")
+	}
+	// An empty range is only valid past the last entry
+	last := common.CopyBytes(entries[len(entries)-1].k)
+	last[len(last)-1]++
+	if more, err := VerifyRangeProof(root, last, nil, nil, proveRange(t, trie, last, nil)); err != nil || more {
+		t.Fatalf("empty range past the end: have %v, %v; want false, nil", more, err)
+	}
+	first := entries[100].k
+	if _, err := VerifyRangeProof(root, first, nil, nil, proveRange(t, trie, first, nil)); err == nil {
+		t.Fatalf("empty range with entries verified")
+	}
+	// A single entry is proven by its own proof
+	keys, values = rangeData(entries[100:101])
+	if more, err := VerifyRangeProof(root, first, keys, values, proveRange(t, trie, first, nil)); err != nil || !more {
+		t.Fatalf("single entry: have %v, %v; want true, nil", more, err)
+	}
+	keys, values = rangeData(entries[len(entries)-1:])
+	if more, err := VerifyRangeProof(root, keys[0], keys, values, proveRange(t, trie, keys[0], nil)); err != nil || more {
+		t.Fatalf("last entry: have %v, %v; want false, nil", more, err)
+	}
+	// Ranges which aren't sorted are rejected
+	keys, values = rangeData(entries[100:103])
+	keys[1], keys[2] = keys[2], keys[1]
+	if _, err := VerifyRangeProof(root, keys[0], keys, values, proveRange(t, trie, keys[0], entries[102])); err == nil {
+		t.Fatalf("unsorted range verified")
+	}
+}
+
 // mutateByte changes one byte in b.
 func mutateByte(b []byte) {
 	for r := mrand.Intn(len(b)); ; {